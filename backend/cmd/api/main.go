@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/handlers"
+	"recetario-backend/internal/realtime"
+	"recetario-backend/internal/repository"
+	"recetario-backend/internal/routes"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+func main() {
+	// Cargar configuración
+	config.LoadConfig()
+
+	// ✅ DEBUG: Verificar que las variables se carguen correctamente
+	log.Println("========================================")
+	log.Println("📋 CONFIGURACIÓN CARGADA")
+	log.Println("========================================")
+	log.Println("SUPABASE_URL:", config.AppConfig.SupabaseURL)
+	log.Println("SUPABASE_STORAGE_URL:", config.AppConfig.SupabaseStorageURL)
+	log.Println("PORT:", config.AppConfig.Port)
+	log.Println("========================================")
+
+	// Validar que las variables críticas existan
+	if config.AppConfig.SupabaseStorageURL == "" {
+		log.Fatal("❌ ERROR: SUPABASE_STORAGE_URL está vacío en el .env")
+	}
+	if config.AppConfig.SupabaseServiceKey == "" {
+		log.Fatal("❌ ERROR: SUPABASE_SERVICE_KEY está vacío en el .env")
+	}
+
+	// ==================== DEPENDENCY INJECTION ====================
+
+	// 1. Inicializar cliente Supabase REST API
+	supabaseClient := repository.NewSupabaseClient()
+
+	// 2. Inicializar Portafolio Repository con REST API (SIN SQL)
+	portafolioRepo := repository.NewPortafolioRepository(supabaseClient)
+	categoriaRepo := repository.NewCategoriaRepository(supabaseClient)
+	log.Println("✅ Repositorios inicializados con REST API")
+
+	// Hub en memoria para el stream SSE de notificaciones
+	notificationHub := realtime.NewHub()
+
+	// 3. Repositories
+	authRepo := repository.NewAuthRepository(supabaseClient)
+	usuarioRepo := repository.NewUsuarioRepository(supabaseClient)
+	cicloRepo := repository.NewCicloRepository(supabaseClient)
+	cursoRepo := repository.NewCursoRepository(supabaseClient)
+	matriculaRepo := repository.NewMatriculaRepository(supabaseClient)
+	temaRepo := repository.NewTemaRepository(supabaseClient)
+	materialRepo := repository.NewMaterialRepository(supabaseClient)
+	tareaRepo := repository.NewTareaRepository(supabaseClient)
+	entregaRepo := repository.NewEntregaRepository(supabaseClient)
+	notificationRepo := repository.NewNotificationRepository(supabaseClient, notificationHub) // ✅ NUEVO
+	dashboardRepo := repository.NewDashboardRepository(supabaseClient)                        // ✅ DASHBOARD
+	calendarioRepo := repository.NewCalendarioRepository(supabaseClient)
+	calificacionesRepo := repository.NewCalificacionesRepository(supabaseClient)
+	rubricaRepo := repository.NewRubricaRepository(supabaseClient)
+	recalificacionRepo := repository.NewRecalificacionRepository(supabaseClient)
+	prerrequisitoRepo := repository.NewPrerrequisitoRepository(supabaseClient)
+	asistenciaRepo := repository.NewAsistenciaRepository(supabaseClient)
+	cuestionarioRepo := repository.NewCuestionarioRepository(supabaseClient)
+	grupoRepo := repository.NewGrupoRepository(supabaseClient)
+
+	// 4. Services
+	// Email (SMTP o en memoria si no está configurado)
+	emailService := services.NewEmailService(services.NewEmailSender())
+
+	authService := services.NewAuthService(authRepo, usuarioRepo)
+	adminService := services.NewAdminService(authRepo, usuarioRepo, emailService)
+	cicloService := services.NewCicloService(cicloRepo, cursoRepo, matriculaRepo, calificacionesRepo)
+
+	// Storage Service
+	storageService := services.NewStorageService(
+		config.AppConfig.SupabaseStorageURL,
+		config.AppConfig.SupabaseServiceKey,
+		"archivos", // nombre del bucket
+	)
+	// ✅ NUEVO: Firebase Service
+	// ✅ NUEVO: Firebase Service con fallback seguro
+	firebaseService, err := services.NewFirebaseService()
+	if err != nil {
+		log.Printf("⚠️ Firebase no disponible: %v", err)
+		firebaseService = nil // Explícitamente nil
+	}
+
+	// ✅ NUEVO: Notification Service (funciona CON o SIN Firebase)
+	notificationService := services.NewNotificationService(
+		notificationRepo,
+		firebaseService, // Puede ser nil
+		usuarioRepo,
+		portafolioRepo,
+		matriculaRepo,
+		cursoRepo,
+		emailService,
+	)
+	matriculaService := services.NewMatriculaService(matriculaRepo, usuarioRepo, cursoRepo, cicloRepo, prerrequisitoRepo, notificationService)
+	cursoService := services.NewCursoService(cursoRepo, cicloRepo, usuarioRepo, temaRepo,
+		materialRepo, tareaRepo, rubricaRepo, calificacionesRepo, cuestionarioRepo, storageService, matriculaService)
+	materialService := services.NewMaterialService(materialRepo, storageService)
+	temaService := services.NewTemaService(temaRepo, tareaRepo, entregaRepo, materialRepo, cursoRepo, matriculaRepo, notificationService)
+	calificacionesService := services.NewCalificacionesService(calificacionesRepo, cursoRepo, matriculaRepo, tareaRepo, entregaRepo)
+	rubricaService := services.NewRubricaService(rubricaRepo, tareaRepo, cursoRepo)
+	tareaService := services.NewTareaService(tareaRepo, entregaRepo, cursoRepo, notificationService, calificacionesService, rubricaService, temaService)
+	cuestionarioService := services.NewCuestionarioService(cuestionarioRepo, tareaRepo, entregaRepo, cursoRepo, matriculaRepo, tareaService, temaService)
+	entregaService := services.NewEntregaService(entregaRepo, tareaRepo, grupoRepo, cursoRepo, storageService, temaService)
+	grupoService := services.NewGrupoService(grupoRepo, cursoRepo, matriculaRepo)
+	recalificacionService := services.NewRecalificacionService(recalificacionRepo, entregaRepo, tareaRepo, cursoRepo, tareaService, notificationService)
+	categoriaService := services.NewCategoriaService(categoriaRepo)
+	portafolioService := services.NewPortafolioService(portafolioRepo, storageService)
+	progresoService := services.NewProgresoService(materialRepo, tareaRepo, entregaRepo, matriculaRepo, cursoRepo)
+	historialService := services.NewHistorialAcademicoService(matriculaRepo, usuarioRepo)
+	asistenciaService := services.NewAsistenciaService(asistenciaRepo, cursoRepo, cicloRepo, matriculaRepo, storageService)
+	dashboardService := services.NewDashboardService(dashboardRepo) // ✅ DASHBOARD
+	calendarioService := services.NewCalendarioService(calendarioRepo, usuarioRepo, cursoRepo, cicloRepo, tareaRepo)
+
+	// Recordatorios de tareas por vencer (revisión cada hora)
+	jobsCtx, cancelarJobs := context.WithCancel(context.Background())
+	defer cancelarJobs()
+	recordatorioService := services.NewRecordatorioService(tareaRepo, entregaRepo, matriculaRepo, notificationRepo, notificationService)
+	recordatorioService.Iniciar(jobsCtx, time.Hour)
+
+	// Avisos de temas que llegan a su fecha de desbloqueo
+	temaService.IniciarAvisosDesbloqueo(jobsCtx, 15*time.Minute)
+
+	// Cierre y calificación de cuestionarios cuyo tiempo terminó
+	cuestionarioService.IniciarCierreIntentos(jobsCtx, time.Minute)
+
+	// Limpieza de notificaciones leídas antiguas (revisión diaria)
+	retencion := time.Duration(config.AppConfig.RetencionNotificacionesDias) * 24 * time.Hour
+	notificationService.IniciarRetencion(jobsCtx, 24*time.Hour, retencion)
+
+	// 5. Handlers
+	authHandler := handlers.NewAuthHandler(authService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	cicloHandler := handlers.NewCicloHandler(cicloService)
+	cursoHandler := handlers.NewCursoHandler(cursoService)
+	matriculaHandler := handlers.NewMatriculaHandler(matriculaService)
+	temaHandler := handlers.NewTemaHandler(temaService)
+	materialHandler := handlers.NewMaterialHandler(materialService, storageService)
+	tareaHandler := handlers.NewTareaHandler(tareaService, entregaService)
+	entregaHandler := handlers.NewEntregaHandler(entregaService, tareaService, storageService)
+	categoriaHandler := handlers.NewCategoriaHandler(categoriaService)
+	portafolioHandler := handlers.NewPortafolioHandler(portafolioService, storageService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, notificationHub)
+	usuarioHandler := handlers.NewUsuarioHandler(adminService, notificationService)
+	horarioHandler := handlers.NewHorarioHandler(cursoService)         // ✅ HORARIO
+	dashboardHandler := handlers.NewDashboardHandler(dashboardService) // ✅ DASHBOARD
+	calendarioHandler := handlers.NewCalendarioHandler(calendarioService)
+	calificacionesHandler := handlers.NewCalificacionesHandler(calificacionesService)
+	rubricaHandler := handlers.NewRubricaHandler(rubricaService)
+	recalificacionHandler := handlers.NewRecalificacionHandler(recalificacionService)
+	asistenciaHandler := handlers.NewAsistenciaHandler(asistenciaService)
+	progresoHandler := handlers.NewProgresoHandler(progresoService)
+	historialHandler := handlers.NewHistorialHandler(historialService)
+	cuestionarioHandler := handlers.NewCuestionarioHandler(cuestionarioService)
+	grupoHandler := handlers.NewGrupoHandler(grupoService)
+
+	// ==================== FIBER SETUP ====================
+
+	// Crear app Fiber
+	app := fiber.New(fiber.Config{
+		AppName:      "Sistema de Recetas API",
+		ErrorHandler: customErrorHandler,
+	})
+
+	// Middlewares globales
+	app.Use(recover.New())
+	app.Use(logger.New(logger.Config{
+		Format: "[${time}] ${status} - ${method} ${path}\n",
+	}))
+	app.Use(cors.New(cors.Config{
+		AllowOrigins: "*",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, ngrok-skip-browser-warning, User-Agent",
+		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS, PATCH",
+	}))
+
+	// Health check
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"status":  "ok",
+			"service": "Sistema de Recetas API",
+			"version": "1.0.0",
+		})
+	})
+
+	// Configurar rutas
+	routes.SetupRoutes(
+		app,
+		authHandler,
+		adminHandler,
+		cicloHandler,
+		cursoHandler,
+		matriculaHandler,
+		temaHandler,
+		materialHandler,
+		tareaHandler,
+		entregaHandler,
+		categoriaHandler,
+		portafolioHandler,
+		notificationHandler,
+		usuarioHandler,
+		horarioHandler,   // ✅ HORARIO
+		dashboardHandler, // ✅ DASHBOARD
+		calendarioHandler,
+		calificacionesHandler,
+		rubricaHandler,
+		recalificacionHandler,
+		asistenciaHandler,
+		progresoHandler,
+		historialHandler,
+		cuestionarioHandler,
+		grupoHandler,
+	)
+
+	// Graceful shutdown
+	go func() {
+		sigint := make(chan os.Signal, 1)
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+		<-sigint
+		log.Println("🛑 Apagando servidor...")
+		cancelarJobs()
+		notificationHub.Cerrar() // Cerrar streams abiertos para no bloquear el apagado
+		app.Shutdown()
+	}()
+
+	// Iniciar servidor
+	port := config.AppConfig.Port
+	log.Printf("🚀 Servidor corriendo en http://localhost:%s", port)
+
+	if err := app.Listen("0.0.0.0:" + port); err != nil {
+		log.Fatal("❌ Error al iniciar servidor:", err)
+	}
+}
+
+// Manejo de errores personalizado
+func customErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+
+	if e, ok := err.(*fiber.Error); ok {
+		code = e.Code
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"error":   true,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,69 @@
+// migrar-horarios convierte el texto libre de cursos.horario en sesiones
+// estructuradas. Ejecutar después de la migración 003.
+//
+//	go run ./cmd/migrar-horarios            # aplica los cambios
+//	go run ./cmd/migrar-horarios -dry-run   # solo muestra lo que haría
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+	"recetario-backend/internal/services"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "no guarda cambios, solo muestra el resultado")
+	flag.Parse()
+
+	config.LoadConfig()
+
+	cursoRepo := repository.NewCursoRepository(repository.NewSupabaseClient())
+
+	respBody, err := cursoRepo.GetAllCursos()
+	if err != nil {
+		log.Fatalf("❌ Error obteniendo cursos: %v", err)
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil {
+		log.Fatalf("❌ Error parseando cursos: %v", err)
+	}
+
+	migrados, sinHorario, fallidos := 0, 0, 0
+	for _, curso := range cursos {
+		if len(curso.Sesiones) > 0 {
+			continue
+		}
+		if curso.Horario == "" {
+			sinHorario++
+			continue
+		}
+
+		sesiones, err := services.ParsearHorarioLegacy(curso.Horario)
+		if err != nil {
+			log.Printf("⚠️ %s (%s): %v", curso.Nombre, curso.ID, err)
+			fallidos++
+			continue
+		}
+
+		log.Printf("✅ %s: %q → %s", curso.Nombre, curso.Horario, services.FormatearHorario(sesiones))
+		if *dryRun {
+			migrados++
+			continue
+		}
+
+		if err := cursoRepo.UpdateCurso(curso.ID, map[string]interface{}{"sesiones": sesiones}); err != nil {
+			log.Printf("❌ Error actualizando %s: %v", curso.ID, err)
+			fallidos++
+			continue
+		}
+		migrados++
+	}
+
+	log.Printf("📋 Cursos: %d convertidos, %d sin horario, %d por revisar manualmente", migrados, sinHorario, fallidos)
+}
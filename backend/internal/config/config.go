@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +17,17 @@ type Config struct {
 	SupabasePassword   string
 	Port               string
 	JWTSecret          string
+	ZonaHoraria        string
+
+	// Días que se conservan las notificaciones leídas (0 = sin límite)
+	RetencionNotificacionesDias int
+
+	// Email (SMTP)
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUsuario   string
+	SMTPPassword  string
+	SMTPRemitente string
 }
 
 var AppConfig *Config
@@ -33,7 +46,26 @@ func LoadConfig() {
 		SupabasePassword:   getEnv("SUPABASE_DB_PASSWORD", ""),
 		Port:               getEnv("PORT", "8080"),
 		JWTSecret:          getEnv("JWT_SECRET", "default-secret"),
+		ZonaHoraria:        getEnv("ZONA_HORARIA", "America/Lima"),
+
+		RetencionNotificacionesDias: getEnvInt("NOTIFICACIONES_RETENCION_DIAS", 90),
+
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnv("SMTP_PORT", "587"),
+		SMTPUsuario:   getEnv("SMTP_USUARIO", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+		SMTPRemitente: getEnv("SMTP_REMITENTE", ""),
+	}
+}
+
+// Ubicacion devuelve la zona horaria de la institución (UTC si no es válida)
+func (c *Config) Ubicacion() *time.Location {
+	loc, err := time.LoadLocation(c.ZonaHoraria)
+	if err != nil {
+		log.Printf("⚠️ Zona horaria inválida '%s', usando UTC", c.ZonaHoraria)
+		return time.UTC
 	}
+	return loc
 }
 
 func getEnv(key, defaultValue string) string {
@@ -43,3 +75,11 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// identidad devuelve el usuario y rol que dejó el middleware de autenticación
+func identidad(c *fiber.Ctx) (string, string, bool) {
+	usuarioID, ok := c.Locals("user_id").(string)
+	if !ok || usuarioID == "" {
+		return "", "", false
+	}
+	rol, _ := c.Locals("user_role").(string)
+	return usuarioID, rol, true
+}
+
+// estadoPorError traduce los errores de acceso compartidos a códigos HTTP
+func estadoPorError(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, services.ErrSinAccesoCurso), errors.Is(err, services.ErrSinPermiso),
+		errors.Is(err, services.ErrTemaBloqueado):
+		return 403
+	case errors.Is(err, services.ErrCursoNoEncontrado), errors.Is(err, services.ErrSesionNoEncontrada),
+		errors.Is(err, services.ErrEstudianteNoEncontrado):
+		return 404
+	case errors.Is(err, services.ErrCicloCerrado):
+		return 409
+	default:
+		return porDefecto
+	}
+}
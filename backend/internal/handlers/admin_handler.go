@@ -122,6 +122,37 @@ func (h *AdminHandler) EliminarUsuario(c *fiber.Ctx) error {
 	})
 }
 
+func (h *AdminHandler) RestablecerPassword(c *fiber.Ctx) error {
+	userID := c.Params("id")
+
+	if userID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "ID de usuario requerido",
+		})
+	}
+
+	temporal, err := h.adminService.RestablecerPassword(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// El correo falló: se entrega la contraseña al administrador
+	if temporal != "" {
+		return c.JSON(fiber.Map{
+			"message":           "Contraseña restablecida, pero no se pudo enviar el correo",
+			"email_enviado":     false,
+			"password_temporal": temporal,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Contraseña restablecida y enviada por correo",
+		"email_enviado": true,
+	})
+}
+
 func (h *AdminHandler) ObtenerEstadisticas(c *fiber.Ctx) error {
 	stats, err := h.adminService.ObtenerEstadisticas()
 	if err != nil {
@@ -147,3 +178,37 @@ func (h *AdminHandler) GetDocentes(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/json")
 	return c.Send(respBody)
 }
+
+// ==================== IMPORTACIÓN MASIVA DE USUARIOS ====================
+
+// POST /api/admin/usuarios/importar?confirmar=true&formato=xlsx
+// Sin confirmar devuelve la vista previa; con formato=xlsx, el archivo de resultados
+func (h *AdminHandler) ImportarUsuarios(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Archivo no proporcionado"})
+	}
+	contenido, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al abrir archivo"})
+	}
+	defer contenido.Close()
+
+	resultado, err := h.adminService.ImportarUsuarios(file.Filename, contenido, c.QueryBool("confirmar", false))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("formato") != "xlsx" {
+		return c.JSON(resultado)
+	}
+
+	excelBuffer, err := services.GenerarReporteImportacionUsuarios(resultado)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", "attachment; filename=\"Resultado_Importacion_Usuarios.xlsx\"")
+	return c.Send(excelBuffer.Bytes())
+}
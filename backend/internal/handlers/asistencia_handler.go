@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AsistenciaHandler struct {
+	service *services.AsistenciaService
+}
+
+func NewAsistenciaHandler(service *services.AsistenciaService) *AsistenciaHandler {
+	return &AsistenciaHandler{service: service}
+}
+
+// GET /api/cursos/:id/sesiones
+func (h *AsistenciaHandler) ListarSesiones(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	sesiones, err := h.service.ListarSesiones(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(sesiones)
+}
+
+// POST /api/cursos/:id/sesiones
+func (h *AsistenciaHandler) CrearSesion(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.CrearSesionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	sesion, err := h.service.CrearSesion(c.Context(), c.Params("id"), usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(sesion)
+}
+
+// POST /api/cursos/:id/sesiones/generar
+// Crea las sesiones del ciclo a partir del horario semanal del curso
+func (h *AsistenciaHandler) GenerarSesiones(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	resultado, err := h.service.GenerarSesiones(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(resultado)
+}
+
+// DELETE /api/sesiones/:id
+func (h *AsistenciaHandler) EliminarSesion(c *fiber.Ctx) error {
+	sesionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.EliminarSesion(c.Context(), sesionID, usuarioID, rol); err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Sesión eliminada"})
+}
+
+// GET /api/sesiones/:id/asistencia
+func (h *AsistenciaHandler) ObtenerLista(c *fiber.Ctx) error {
+	sesionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	lista, err := h.service.ObtenerLista(c.Context(), sesionID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(lista)
+}
+
+// PUT /api/sesiones/:id/asistencia
+func (h *AsistenciaHandler) MarcarAsistencia(c *fiber.Ctx) error {
+	sesionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.MarcarAsistenciaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	lista, err := h.service.MarcarAsistencia(c.Context(), sesionID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(lista)
+}
+
+// POST /api/sesiones/:id/asistencia/:matricula_id/justificacion (campo "file")
+func (h *AsistenciaHandler) SubirJustificacion(c *fiber.Ctx) error {
+	sesionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Archivo no proporcionado"})
+	}
+	fileContent, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al abrir archivo"})
+	}
+	defer fileContent.Close()
+
+	fila, err := h.service.SubirJustificacion(c.Context(), sesionID, c.Params("matricula_id"), usuarioID, rol, fileContent, file)
+	if err != nil {
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fila)
+}
+
+// GET /api/cursos/:id/asistencia
+func (h *AsistenciaHandler) ResumenCurso(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	reporte, err := h.service.ResumenCurso(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(reporte)
+}
+
+// GET /api/cursos/:id/asistencia/mia (estudiante)
+func (h *AsistenciaHandler) MiAsistencia(c *fiber.Ctx) error {
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	resumen, err := h.service.MiAsistencia(c.Context(), c.Params("id"), usuarioID)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(resumen)
+}
+
+// GET /api/cursos/:id/asistencia/export
+func (h *AsistenciaHandler) ExportarExcel(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	excelBuffer, nombreCurso, err := h.service.ExportarAsistenciaExcel(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename := "Asistencia_" + nombreCurso + ".xlsx"
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+
+	return c.Send(excelBuffer.Bytes())
+}
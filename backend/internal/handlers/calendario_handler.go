@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarioHandler struct {
+	service *services.CalendarioService
+}
+
+func NewCalendarioHandler(service *services.CalendarioService) *CalendarioHandler {
+	return &CalendarioHandler{service: service}
+}
+
+// ObtenerEnlace devuelve la URL del feed .ics del usuario autenticado
+func (h *CalendarioHandler) ObtenerEnlace(c *fiber.Ctx) error {
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	token, err := h.service.ObtenerToken(c.Context(), usuarioID.String())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al obtener enlace de calendario"})
+	}
+
+	return c.JSON(enlacesCalendario(c, token))
+}
+
+// RegenerarEnlace invalida el enlace anterior (por ejemplo si se compartió por error)
+func (h *CalendarioHandler) RegenerarEnlace(c *fiber.Ctx) error {
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	token, err := h.service.RegenerarToken(c.Context(), usuarioID.String())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al regenerar enlace de calendario"})
+	}
+
+	return c.JSON(enlacesCalendario(c, token))
+}
+
+// Feed sirve el .ics; el token de la URL es la única autenticación
+func (h *CalendarioHandler) Feed(c *fiber.Ctx) error {
+	ics, err := h.service.GenerarFeed(c.Context(), c.Params("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrTokenCalendarioInvalido) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Error al generar calendario"})
+	}
+
+	c.Set("Content-Type", "text/calendar; charset=utf-8")
+	c.Set("Content-Disposition", `inline; filename="horario.ics"`)
+	c.Set("Cache-Control", "private, max-age=900")
+	return c.Send(ics)
+}
+
+func enlacesCalendario(c *fiber.Ctx, token string) fiber.Map {
+	url := c.BaseURL() + "/api/calendario/" + token + ".ics"
+	webcal := "webcal://" + strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+
+	return fiber.Map{
+		"token":  token,
+		"url":    url,
+		"webcal": webcal,
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalificacionesHandler struct {
+	service *services.CalificacionesService
+}
+
+func NewCalificacionesHandler(service *services.CalificacionesService) *CalificacionesHandler {
+	return &CalificacionesHandler{service: service}
+}
+
+// ObtenerLibro devuelve la matriz de notas del curso (docente o administrador)
+func (h *CalificacionesHandler) ObtenerLibro(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	libro, err := h.service.ObtenerLibro(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(libro)
+}
+
+// ObtenerMiFila devuelve solo las notas del estudiante autenticado
+func (h *CalificacionesHandler) ObtenerMiFila(c *fiber.Ctx) error {
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	libro, err := h.service.ObtenerMiFila(c.Context(), c.Params("id"), usuarioID)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"curso_id": libro.CursoID,
+		"tareas":   libro.Tareas,
+		"fila":     libro.Filas[0],
+		"escala":   libro.Esquema.Escala,
+	})
+}
+
+// ObtenerEsquema devuelve la configuración de pesos del curso
+func (h *CalificacionesHandler) ObtenerEsquema(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	esquema, err := h.service.ObtenerEsquema(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(esquema)
+}
+
+// GuardarEsquema configura los pesos y recalcula las notas finales
+func (h *CalificacionesHandler) GuardarEsquema(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.GuardarEsquemaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	esquema, err := h.service.GuardarEsquema(c.Context(), c.Params("id"), usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Esquema de calificación guardado",
+		"esquema": esquema,
+	})
+}
+
+// Recalcular vuelve a calcular la nota final de todas las matrículas activas
+func (h *CalificacionesHandler) Recalcular(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	actualizadas, err := h.service.RecalcularCursoDocente(c.Context(), c.Params("id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Notas finales recalculadas",
+		"actualizadas": actualizadas,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
 
@@ -171,3 +172,29 @@ func (h *CicloHandler) ObtenerCicloActivo(c *fiber.Ctx) error {
 
 	return c.JSON(ciclo)
 }
+
+// ==================== CIERRE DE CICLO ====================
+
+// POST /api/admin/ciclos/:id/cerrar
+// Sin "confirmar" devuelve la vista previa del cierre
+func (h *CicloHandler) CerrarCiclo(c *fiber.Ctx) error {
+	req := new(models.CierreCicloRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Datos inválidos",
+		})
+	}
+
+	resultado, err := h.cicloService.CerrarCiclo(c.Params("id"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCicloCerrado):
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrCicloNoEncontrado):
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(resultado)
+}
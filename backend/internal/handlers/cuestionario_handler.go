@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"errors"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CuestionarioHandler struct {
+	service *services.CuestionarioService
+}
+
+func NewCuestionarioHandler(service *services.CuestionarioService) *CuestionarioHandler {
+	return &CuestionarioHandler{service: service}
+}
+
+func estadoCuestionario(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, services.ErrPreguntaNoEncontrada),
+		errors.Is(err, services.ErrCuestionarioNoEncontrado),
+		errors.Is(err, services.ErrIntentoNoEncontrado):
+		return 404
+	case errors.Is(err, services.ErrCuestionarioConIntentos),
+		errors.Is(err, services.ErrIntentoEnviado),
+		errors.Is(err, services.ErrIntentoAbierto),
+		errors.Is(err, services.ErrTiempoAgotado):
+		return 409
+	case errors.Is(err, services.ErrPreguntaInvalida):
+		return 400
+	default:
+		return estadoPorError(err, porDefecto)
+	}
+}
+
+// ==================== BANCO DE PREGUNTAS ====================
+
+// GET /api/cursos/:id/preguntas
+func (h *CuestionarioHandler) ListarPreguntas(c *fiber.Ctx) error {
+	cursoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	preguntas, err := h.service.ListarPreguntas(c.Context(), cursoID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(preguntas)
+}
+
+// POST /api/cursos/:id/preguntas
+func (h *CuestionarioHandler) CrearPregunta(c *fiber.Ctx) error {
+	cursoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.PreguntaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	pregunta, err := h.service.CrearPregunta(c.Context(), cursoID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(pregunta)
+}
+
+// PUT /api/preguntas/:id
+func (h *CuestionarioHandler) ActualizarPregunta(c *fiber.Ctx) error {
+	preguntaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.PreguntaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	if err := h.service.ActualizarPregunta(c.Context(), preguntaID, usuarioID, rol, &req); err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Pregunta actualizada exitosamente"})
+}
+
+// DELETE /api/preguntas/:id
+func (h *CuestionarioHandler) EliminarPregunta(c *fiber.Ctx) error {
+	preguntaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.EliminarPregunta(c.Context(), preguntaID, usuarioID, rol); err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Pregunta eliminada exitosamente"})
+}
+
+// ==================== CUESTIONARIO DE TAREA ====================
+
+// GET /api/tareas/:id/cuestionario
+func (h *CuestionarioHandler) ObtenerCuestionario(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	cuestionario, err := h.service.ObtenerCuestionario(c.Context(), tareaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(cuestionario)
+}
+
+// PUT /api/tareas/:id/cuestionario
+func (h *CuestionarioHandler) ConfigurarCuestionario(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.ConfigurarCuestionarioRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	cuestionario, err := h.service.ConfigurarCuestionario(c.Context(), tareaID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(cuestionario)
+}
+
+// DELETE /api/tareas/:id/cuestionario
+func (h *CuestionarioHandler) QuitarCuestionario(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.QuitarCuestionario(c.Context(), tareaID, usuarioID, rol); err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Cuestionario quitado de la tarea"})
+}
+
+// ==================== INTENTOS ====================
+
+// POST /api/tareas/:id/cuestionario/iniciar (estudiante)
+func (h *CuestionarioHandler) IniciarIntento(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	intento, err := h.service.IniciarIntento(c.Context(), tareaID, usuarioID)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intento)
+}
+
+// PUT /api/tareas/:id/cuestionario/respuestas (estudiante, guardado parcial)
+func (h *CuestionarioHandler) GuardarRespuestas(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.ResponderCuestionarioRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	intento, err := h.service.GuardarRespuestas(c.Context(), tareaID, usuarioID, req.Respuestas)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intento)
+}
+
+// POST /api/tareas/:id/cuestionario/enviar (estudiante)
+func (h *CuestionarioHandler) EnviarIntento(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	// Sin cuerpo se envía lo último guardado
+	var req models.ResponderCuestionarioRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+		}
+	}
+
+	intento, err := h.service.EnviarIntento(c.Context(), tareaID, usuarioID, req.Respuestas)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intento)
+}
+
+// GET /api/tareas/:id/cuestionario/intentos (docente)
+func (h *CuestionarioHandler) ListarIntentos(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	intentos, err := h.service.ListarIntentos(c.Context(), tareaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intentos)
+}
+
+// GET /api/intentos/:id
+func (h *CuestionarioHandler) ObtenerIntento(c *fiber.Ctx) error {
+	intentoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	intento, err := h.service.ObtenerIntento(c.Context(), intentoID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intento)
+}
+
+// PUT /api/intentos/:id/revision (docente)
+func (h *CuestionarioHandler) RevisarIntento(c *fiber.Ctx) error {
+	intentoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.RevisarIntentoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	intento, err := h.service.RevisarIntento(c.Context(), intentoID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoCuestionario(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(intento)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
 
@@ -32,6 +33,14 @@ func (h *CursoHandler) CrearCurso(c *fiber.Ctx) error {
 
 	cursoID, err := h.cursoService.CrearCurso(req)
 	if err != nil {
+		var conflicto *services.ConflictoHorarioError
+		if errors.As(err, &conflicto) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":      err.Error(),
+				"conflictos": conflicto.Conflictos,
+			})
+		}
+
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -105,6 +114,14 @@ func (h *CursoHandler) ActualizarCurso(c *fiber.Ctx) error {
 	}
 
 	if err := h.cursoService.ActualizarCurso(cursoID, req); err != nil {
+		var conflicto *services.ConflictoHorarioError
+		if errors.As(err, &conflicto) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":      err.Error(),
+				"conflictos": conflicto.Conflictos,
+			})
+		}
+
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -194,3 +211,41 @@ func (h *CursoHandler) ListarCursosPorEstudiante(c *fiber.Ctx) error {
 
 	return c.JSON(cursos)
 }
+
+// ==================== CLONAR CURSO ====================
+
+// POST /api/admin/cursos/:id/clonar
+// Copia el curso con sus temas, materiales y tareas al ciclo indicado
+func (h *CursoHandler) ClonarCurso(c *fiber.Ctx) error {
+	cursoID := c.Params("id")
+
+	req := new(models.ClonarCursoRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Datos inválidos",
+		})
+	}
+
+	resultado, err := h.cursoService.ClonarCurso(cursoID, req)
+	if err != nil {
+		var conflicto *services.ConflictoHorarioError
+		if errors.As(err, &conflicto) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":      err.Error(),
+				"conflictos": conflicto.Conflictos,
+			})
+		}
+		if errors.Is(err, services.ErrCursoNoEncontrado) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":   "Curso clonado exitosamente",
+		"resultado": resultado,
+	})
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -27,6 +30,19 @@ func NewEntregaHandler(
 	}
 }
 
+func estadoEntrega(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, services.ErrVersionNoEncontrada):
+		return 404
+	case errors.Is(err, services.ErrEntregaCerrada),
+		errors.Is(err, services.ErrReenvioNoPermitido),
+		errors.Is(err, services.ErrReenviosAgotados):
+		return 409
+	default:
+		return estadoPorError(err, porDefecto)
+	}
+}
+
 // POST /api/entregas (estudiante entrega tarea)
 func (h *EntregaHandler) CrearEntrega(c *fiber.Ctx) error {
 	var req models.CreateEntregaRequest
@@ -53,7 +69,7 @@ func (h *EntregaHandler) CrearEntrega(c *fiber.Ctx) error {
 	entrega, err := h.entregaService.CrearEntrega(c.Context(), estudianteID, &req)
 	if err != nil {
 		log.Printf("❌ ERROR creando entrega: %v", err)
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(estadoEntrega(err, 400)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	log.Printf("✅ Entrega creada exitosamente: %s", entrega.ID)
@@ -72,6 +88,15 @@ func (h *EntregaHandler) SubirArchivoEntrega(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Archivo no proporcionado"})
 	}
 
+	// Validar dueño y política de reenvío antes de subir al Storage
+	estudianteID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "No autenticado"})
+	}
+	if err := h.entregaService.ValidarCambio(c.Context(), entregaID, estudianteID); err != nil {
+		return c.Status(estadoEntrega(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	folder := "entregas/" + entregaID.String()
 
 	// Abrir el archivo
@@ -98,8 +123,8 @@ func (h *EntregaHandler) SubirArchivoEntrega(c *fiber.Ctx) error {
 		TamanoMB:      &tamanoMB,
 	}
 
-	if err := h.entregaService.AgregarArchivo(c.Context(), entregaID, archivo); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.entregaService.AgregarArchivo(c.Context(), entregaID, estudianteID, archivo); err != nil {
+		return c.Status(estadoEntrega(err, 500)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	log.Printf("✅ Archivo subido: %s", file.Filename)
@@ -167,18 +192,58 @@ func (h *EntregaHandler) CalificarEntrega(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
 	}
 
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
 	var req models.CalificarEntregaRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if err := h.tareaService.CalificarEntrega(c.Context(), entregaID, &req); err != nil {
+	if err := h.tareaService.CalificarEntrega(c.Context(), entregaID, usuarioID, &req); err != nil {
+		if errors.Is(err, services.ErrEvaluacionInvalida) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrCicloCerrado) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{"message": "Entrega calificada exitosamente"})
 }
 
+// PUT /api/entregas/:id/ajuste (docente ajusta la nota de un integrante del grupo)
+func (h *EntregaHandler) AjustarCalificacionIndividual(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+	rol, _ := c.Locals("user_role").(string)
+
+	var req models.AjusteIndividualRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	entrega, err := h.tareaService.AjustarCalificacionIndividual(c.Context(), entregaID, usuarioID, rol, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrEvaluacionInvalida) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(entrega)
+}
+
 // GET /api/entregas/:id
 func (h *EntregaHandler) ObtenerEntregaPorID(c *fiber.Ctx) error {
 	entregaID, err := uuid.Parse(c.Params("id"))
@@ -186,7 +251,9 @@ func (h *EntregaHandler) ObtenerEntregaPorID(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
 	}
 
-	entrega, err := h.entregaService.ObtenerEntregaPorID(c.Context(), entregaID)
+	rol, _ := c.Locals("user_role").(string)
+
+	entrega, err := h.entregaService.ObtenerEntregaPorID(c.Context(), entregaID, rol)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Entrega no encontrada"})
 	}
@@ -217,8 +284,8 @@ func (h *EntregaHandler) EditarEntrega(c *fiber.Ctx) error {
 		return c.Status(403).JSON(fiber.Map{"error": "No tienes permiso para editar esta entrega"})
 	}
 
-	if err := h.entregaService.EditarEntrega(c.Context(), entregaID, &req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	if err := h.entregaService.EditarEntrega(c.Context(), entregaID, estudianteID, &req); err != nil {
+		return c.Status(estadoEntrega(err, 400)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	log.Printf("✅ Entrega editada: %s", entregaID)
@@ -246,12 +313,18 @@ func (h *EntregaHandler) EliminarEntrega(c *fiber.Ctx) error {
 		return c.Status(403).JSON(fiber.Map{"error": "No tienes permiso para eliminar esta entrega"})
 	}
 
-	// ✅ MEJORADO: Obtener archivos ANTES de eliminar la entrega
-	archivos, err := h.entregaService.ObtenerArchivosPorEntregaID(c.Context(), entregaID)
+	// ✅ MEJORADO: Obtener archivos ANTES de eliminar la entrega, incluidos
+	// los que solo quedan en versiones anteriores
+	archivos, err := h.entregaService.ArchivosAlmacenados(c.Context(), entregaID)
 	if err != nil {
 		log.Printf("⚠️ No se pudieron obtener archivos: %v", err)
 	}
 
+	// Eliminar entrega (esto eliminará en cascada los registros de archivos y las versiones)
+	if err := h.entregaService.EliminarEntrega(c.Context(), entregaID); err != nil {
+		return c.Status(estadoEntrega(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// ✅ Eliminar archivos del Storage
 	for _, archivo := range archivos {
 		if err := h.storageService.DeleteFile(archivo.URLArchivo); err != nil {
@@ -261,45 +334,94 @@ func (h *EntregaHandler) EliminarEntrega(c *fiber.Ctx) error {
 		}
 	}
 
-	// Eliminar entrega (esto eliminará en cascada los registros de archivos)
-	if err := h.entregaService.EliminarEntrega(c.Context(), entregaID); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
-	}
-
 	log.Printf("✅ Entrega eliminada exitosamente: %s", entregaID)
 	return c.Status(204).JSON(fiber.Map{"message": "Entrega eliminada exitosamente"})
 }
 
-// ✅ NUEVO: DELETE /api/entregas/archivos/:archivoId (eliminar archivo individual)
+// ✅ NUEVO: DELETE /api/entregas/archivos/:archivoId (eliminar archivo individual).
+// El archivo sale de la entrega pero se conserva en el Storage porque las
+// versiones anteriores lo siguen referenciando.
 func (h *EntregaHandler) EliminarArchivoEntrega(c *fiber.Ctx) error {
 	archivoID, err := uuid.Parse(c.Params("archivoId"))
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
 	}
 
-	log.Printf("🗑️ Eliminando archivo individual: %s", archivoID)
+	estudianteID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "No autenticado"})
+	}
+
+	log.Printf("🗑️ Quitando archivo de la entrega: %s", archivoID)
+
+	if err := h.entregaService.EliminarArchivo(c.Context(), archivoID, estudianteID); err != nil {
+		log.Printf("❌ Error al quitar archivo: %v", err)
+		return c.Status(estadoEntrega(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	log.Printf("✅ Archivo quitado de la entrega: %s", archivoID)
+	return c.Status(204).SendString("")
+}
 
-	// Obtener info del archivo
-	archivo, err := h.entregaService.ObtenerArchivoPorID(c.Context(), archivoID)
+// GET /api/entregas/:id/versiones
+func (h *EntregaHandler) ListarVersiones(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		log.Printf("❌ Error al obtener archivo: %v", err)
-		return c.Status(404).JSON(fiber.Map{"error": "Archivo no encontrado"})
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
 	}
 
-	// Eliminar del Storage
-	if err := h.storageService.DeleteFile(archivo.URLArchivo); err != nil {
-		log.Printf("⚠️ Advertencia: No se pudo eliminar del Storage: %v", err)
-		// Continuar aunque falle el Storage
-	} else {
-		log.Printf("✅ Archivo eliminado del Storage: %s", archivo.NombreArchivo)
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
 	}
+	rol, _ := c.Locals("user_role").(string)
 
-	// Eliminar registro de la base de datos
-	if err := h.entregaService.EliminarArchivo(c.Context(), archivoID); err != nil {
-		log.Printf("❌ Error al eliminar registro: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Error al eliminar archivo"})
+	historial, err := h.entregaService.ListarVersiones(c.Context(), entregaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoEntrega(err, 500)).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	log.Printf("✅ Archivo eliminado exitosamente: %s", archivoID)
-	return c.Status(204).SendString("")
+	return c.JSON(historial)
+}
+
+// GET /api/entregas/:id/versiones/diferencias?desde=1&hasta=3
+func (h *EntregaHandler) CompararVersiones(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+
+	usuarioID, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+	rol, _ := c.Locals("user_role").(string)
+
+	desde, err := numeroVersion(c.Query("desde"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "desde inválido"})
+	}
+	hasta, err := numeroVersion(c.Query("hasta"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "hasta inválido"})
+	}
+
+	diferencia, err := h.entregaService.CompararVersiones(c.Context(), entregaID, usuarioID, rol, desde, hasta)
+	if err != nil {
+		return c.Status(estadoEntrega(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(diferencia)
+}
+
+// numeroVersion lee un número de versión opcional de la query
+func numeroVersion(valor string) (*int, error) {
+	if valor == "" {
+		return nil, nil
+	}
+	numero, err := strconv.Atoi(valor)
+	if err != nil || numero < 0 {
+		return nil, fmt.Errorf("número de versión inválido")
+	}
+	return &numero, nil
 }
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type GrupoHandler struct {
+	service *services.GrupoService
+}
+
+func NewGrupoHandler(service *services.GrupoService) *GrupoHandler {
+	return &GrupoHandler{service: service}
+}
+
+func estadoGrupo(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, services.ErrGrupoNoEncontrado):
+		return 404
+	case errors.Is(err, services.ErrGrupoLleno),
+		errors.Is(err, services.ErrYaEnGrupo),
+		errors.Is(err, services.ErrGrupoConEntregas):
+		return 409
+	case errors.Is(err, services.ErrGrupoInvalido):
+		return 400
+	default:
+		return estadoPorError(err, porDefecto)
+	}
+}
+
+// GET /api/cursos/:id/grupos
+func (h *GrupoHandler) ListarGrupos(c *fiber.Ctx) error {
+	cursoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	grupos, err := h.service.ListarGrupos(c.Context(), cursoID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupos)
+}
+
+// GET /api/cursos/:id/grupos/mio
+func (h *GrupoHandler) MiGrupo(c *fiber.Ctx) error {
+	cursoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	grupo, err := h.service.MiGrupo(c.Context(), cursoID, usuarioID)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupo)
+}
+
+// POST /api/cursos/:id/grupos
+func (h *GrupoHandler) CrearGrupo(c *fiber.Ctx) error {
+	cursoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.GrupoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	grupo, err := h.service.CrearGrupo(c.Context(), cursoID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(grupo)
+}
+
+// PUT /api/grupos/:id
+func (h *GrupoHandler) ActualizarGrupo(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.GrupoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	grupo, err := h.service.ActualizarGrupo(c.Context(), grupoID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupo)
+}
+
+// DELETE /api/grupos/:id
+func (h *GrupoHandler) EliminarGrupo(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.EliminarGrupo(c.Context(), grupoID, usuarioID, rol); err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Grupo eliminado"})
+}
+
+// POST /api/grupos/:id/integrantes
+func (h *GrupoHandler) AgregarIntegrante(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.AgregarIntegranteRequest
+	if err := c.BodyParser(&req); err != nil || req.EstudianteID == uuid.Nil {
+		return c.Status(400).JSON(fiber.Map{"error": "estudiante_id es requerido"})
+	}
+
+	grupo, err := h.service.AgregarIntegrante(c.Context(), grupoID, req.EstudianteID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupo)
+}
+
+// DELETE /api/grupos/:id/integrantes/:estudiante_id
+func (h *GrupoHandler) QuitarIntegrante(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	estudianteID, err := uuid.Parse(c.Params("estudiante_id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de estudiante inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	grupo, err := h.service.QuitarIntegrante(c.Context(), grupoID, estudianteID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupo)
+}
+
+// POST /api/grupos/:id/unirse
+func (h *GrupoHandler) Unirse(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	grupo, err := h.service.Unirse(c.Context(), grupoID, usuarioID)
+	if err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(grupo)
+}
+
+// DELETE /api/grupos/:id/unirse
+func (h *GrupoHandler) Salir(c *fiber.Ctx) error {
+	grupoID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.Salir(c.Context(), grupoID, usuarioID); err != nil {
+		return c.Status(estadoGrupo(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Saliste del grupo"})
+}
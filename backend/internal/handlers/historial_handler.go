@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HistorialHandler struct {
+	service *services.HistorialAcademicoService
+}
+
+func NewHistorialHandler(service *services.HistorialAcademicoService) *HistorialHandler {
+	return &HistorialHandler{service: service}
+}
+
+// GET /api/estudiantes/:estudiante_id/historial?formato=json|xlsx|pdf
+func (h *HistorialHandler) ObtenerHistorial(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	formato := c.Query("formato", "json")
+	if formato != "json" && formato != "xlsx" && formato != "pdf" {
+		return c.Status(400).JSON(fiber.Map{"error": "formato inválido: usa json, xlsx o pdf"})
+	}
+
+	historial, err := h.service.ObtenerHistorial(c.Params("estudiante_id"), usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	nombreArchivo := "Historial_" + historial.Codigo
+	if historial.Codigo == "" {
+		nombreArchivo = "Historial_" + historial.EstudianteID
+	}
+
+	switch formato {
+	case "xlsx":
+		buf, err := services.ExportarHistorialExcel(historial)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Set("Content-Disposition", "attachment; filename=\""+nombreArchivo+".xlsx\"")
+		return c.Send(buf.Bytes())
+	case "pdf":
+		buf := services.ExportarHistorialPDF(historial)
+		c.Set("Content-Type", "application/pdf")
+		c.Set("Content-Disposition", "attachment; filename=\""+nombreArchivo+".pdf\"")
+		return c.Send(buf.Bytes())
+	}
+
+	return c.JSON(historial)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -45,6 +46,7 @@ func (h *HorarioHandler) ObtenerHorarioDocente(c *fiber.Ctx) error {
 			"nivel":          curso.Nivel,
 			"seccion":        curso.Seccion,
 			"horario":        curso.Horario,
+			"sesiones":       sesionesParaGrilla(&curso),
 			"activo":         curso.Activo,
 			"docente_nombre": nil, // El docente no necesita ver su propio nombre
 		}
@@ -97,6 +99,7 @@ func (h *HorarioHandler) ObtenerHorarioEstudiante(c *fiber.Ctx) error {
 			"nivel":          curso.Nivel,
 			"seccion":        curso.Seccion,
 			"horario":        curso.Horario,
+			"sesiones":       sesionesParaGrilla(&curso),
 			"activo":         curso.Activo,
 			"docente_nombre": docenteNombre, // ✅ Nombre del docente o nil
 		}
@@ -105,3 +108,13 @@ func (h *HorarioHandler) ObtenerHorarioEstudiante(c *fiber.Ctx) error {
 
 	return c.JSON(horario)
 }
+
+// sesionesParaGrilla devuelve las sesiones estructuradas del curso;
+// nunca nil para que el cliente siempre reciba una lista
+func sesionesParaGrilla(curso *models.Curso) []models.SesionHorario {
+	sesiones := services.SesionesDeCurso(curso)
+	if sesiones == nil {
+		return []models.SesionHorario{}
+	}
+	return sesiones
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // ✅ MatriculaHandler con dependency injection
@@ -30,8 +32,42 @@ func (h *MatriculaHandler) CrearMatricula(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.OmitirPrerrequisitos {
+		usuarioID, ok := autorizarOmision(c)
+		if !ok {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Solo un administrador puede omitir prerrequisitos",
+			})
+		}
+		req.OmitidoPor = usuarioID
+	}
+
 	matricula, err := h.matriculaService.CrearMatricula(req)
 	if err != nil {
+		var pendientes *services.PrerrequisitosPendientesError
+		if errors.As(err, &pendientes) {
+			return c.Status(422).JSON(fiber.Map{
+				"error":                 err.Error(),
+				"requisitos_pendientes": pendientes.Pendientes,
+			})
+		}
+
+		var enEspera *services.EnListaEsperaError
+		if errors.As(err, &enEspera) {
+			return c.Status(202).JSON(fiber.Map{
+				"message":      err.Error(),
+				"lista_espera": enEspera.Entrada,
+			})
+		}
+
+		var conflicto *services.ConflictoHorarioError
+		if errors.As(err, &conflicto) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":      err.Error(),
+				"conflictos": conflicto.Conflictos,
+			})
+		}
+
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -58,6 +94,16 @@ func (h *MatriculaHandler) CrearMatriculaMasiva(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.OmitirPrerrequisitos {
+		usuarioID, ok := autorizarOmision(c)
+		if !ok {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Solo un administrador puede omitir prerrequisitos",
+			})
+		}
+		req.OmitidoPor = usuarioID
+	}
+
 	matriculas, errores, err := h.matriculaService.CrearMatriculaMasiva(req)
 
 	if err != nil {
@@ -152,7 +198,7 @@ func (h *MatriculaHandler) ListarEstudiantesDisponibles(c *fiber.Ctx) error {
 		})
 	}
 
-	estudiantes, err := h.matriculaService.ListarEstudiantesDisponibles(cursoID, cicloID)
+	estudiantes, err := h.matriculaService.ListarEstudiantesDisponibles(cursoID, cicloID, c.QueryBool("solo_elegibles", false))
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": err.Error(),
@@ -179,6 +225,11 @@ func (h *MatriculaHandler) ActualizarMatricula(c *fiber.Ctx) error {
 	}
 
 	if err := h.matriculaService.ActualizarMatricula(matriculaID, req); err != nil {
+		if errors.Is(err, services.ErrCursoLleno) || errors.Is(err, services.ErrCicloCerrado) {
+			return c.Status(409).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -221,3 +272,120 @@ func (h *MatriculaHandler) ListarTodasLasMatriculas(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/json")
 	return c.Send(matriculas)
 }
+
+// POST /api/admin/matriculas/importar?ciclo_id=...&formato=xlsx
+// Sin ciclo_id se usa el ciclo activo; con formato=xlsx devuelve el archivo de resultados
+func (h *MatriculaHandler) ImportarMatriculas(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Archivo no proporcionado"})
+	}
+	contenido, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al abrir archivo"})
+	}
+	defer contenido.Close()
+
+	resultado, err := h.matriculaService.ImportarMatriculas(file.Filename, contenido, c.Query("ciclo_id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("formato") != "xlsx" {
+		return c.JSON(resultado)
+	}
+
+	excelBuffer, err := services.GenerarReporteImportacionMatriculas(resultado)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", "attachment; filename=\"Resultado_Importacion_Matriculas.xlsx\"")
+	return c.Send(excelBuffer.Bytes())
+}
+
+// ==================== LISTA DE ESPERA ====================
+
+// GET /api/admin/cursos/:id/lista-espera
+func (h *MatriculaHandler) ListarListaEspera(c *fiber.Ctx) error {
+	lista, err := h.matriculaService.ListarListaEspera(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(lista)
+}
+
+// POST /api/admin/cursos/:id/lista-espera/promover
+// Llena los cupos libres (por ejemplo, después de aumentar la capacidad)
+func (h *MatriculaHandler) PromoverListaEspera(c *fiber.Ctx) error {
+	promovidas, err := h.matriculaService.PromoverListaEsperaDeCurso(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"promovidas": promovidas,
+		"total":      len(promovidas),
+	})
+}
+
+// DELETE /api/admin/lista-espera/:id
+func (h *MatriculaHandler) QuitarDeListaEspera(c *fiber.Ctx) error {
+	if err := h.matriculaService.QuitarDeListaEspera(c.Params("id")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(204).Send(nil)
+}
+
+// ==================== PRERREQUISITOS ====================
+
+// autorizarOmision devuelve el administrador que autoriza omitir prerrequisitos
+func autorizarOmision(c *fiber.Ctx) (string, bool) {
+	usuarioID, rol, ok := identidad(c)
+	if !ok || rol != "administrador" {
+		return "", false
+	}
+	return usuarioID, true
+}
+
+// GET /api/admin/cursos/:id/prerrequisitos
+func (h *MatriculaHandler) ListarPrerrequisitos(c *fiber.Ctx) error {
+	prerrequisitos, err := h.matriculaService.ListarPrerrequisitos(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(prerrequisitos)
+}
+
+// POST /api/admin/cursos/:id/prerrequisitos
+func (h *MatriculaHandler) AgregarPrerrequisito(c *fiber.Ctx) error {
+	req := new(models.CrearPrerrequisitoRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	prerrequisito, err := h.matriculaService.AgregarPrerrequisito(c.Params("id"), req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(prerrequisito)
+}
+
+// DELETE /api/admin/prerrequisitos/:id
+func (h *MatriculaHandler) EliminarPrerrequisito(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+
+	if err := h.matriculaService.EliminarPrerrequisito(id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(204).Send(nil)
+}
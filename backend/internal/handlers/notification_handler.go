@@ -1,18 +1,32 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"recetario-backend/internal/middleware"
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/realtime"
 	"recetario-backend/internal/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+// Intervalo de heartbeat del stream SSE
+const intervaloHeartbeat = 25 * time.Second
+
 type NotificationHandler struct {
 	service *services.NotificationService
+	hub     *realtime.Hub
 }
 
-func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
-	return &NotificationHandler{service: service}
+func NewNotificationHandler(service *services.NotificationService, hub *realtime.Hub) *NotificationHandler {
+	return &NotificationHandler{service: service, hub: hub}
 }
 
 // Compartir receta
@@ -122,7 +136,19 @@ func (h *NotificationHandler) MarcarComoLeida(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.service.MarcarComoLeida(notificacionID)
+	uid, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	err = h.service.MarcarComoLeida(uid, notificacionID)
+	if errors.Is(err, services.ErrNotificacionNoEncontrada) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Error marcando notificación: " + err.Error(),
@@ -240,3 +266,426 @@ func (h *NotificationHandler) ContarNoLeidas(c *fiber.Ctx) error {
 		"no_leidas": count,
 	})
 }
+
+// Desactivar dispositivo FCM (p.ej. al cerrar sesión)
+func (h *NotificationHandler) DesactivarDispositivo(c *fiber.Ctx) error {
+	var req RegistrarDispositivoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Datos inválidos",
+		})
+	}
+
+	if req.FCMToken == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "fcm_token es requerido",
+		})
+	}
+
+	usuarioID, ok := c.Locals("user_id").(string)
+	if !ok || usuarioID == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Usuario no autenticado",
+		})
+	}
+
+	uid, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	if err := h.service.DesactivarDispositivo(uid, req.FCMToken); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Error desactivando dispositivo: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Dispositivo desactivado",
+	})
+}
+
+// Anuncio a todo un curso
+type AnuncioCursoRequest struct {
+	Titulo  string `json:"titulo"`
+	Mensaje string `json:"mensaje"`
+}
+
+func (h *NotificationHandler) EnviarAnuncioCurso(c *fiber.Ctx) error {
+	cursoID := c.Params("id")
+
+	var req AnuncioCursoRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Datos inválidos",
+		})
+	}
+
+	if req.Titulo == "" || req.Mensaje == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "titulo y mensaje son requeridos",
+		})
+	}
+
+	usuarioID, ok := c.Locals("user_id").(string)
+	if !ok || usuarioID == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Usuario no autenticado",
+		})
+	}
+
+	uid, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	// El administrador puede anunciar en cualquier curso
+	if rol, _ := c.Locals("user_role").(string); rol != "administrador" {
+		if err := h.service.ValidarDocenteDelCurso(cursoID, usuarioID); err != nil {
+			return c.Status(403).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	messageID, err := h.service.EnviarAnuncioCurso(cursoID, uid, req.Titulo, req.Mensaje)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Error enviando anuncio: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Anuncio enviado al curso",
+		"message_id": messageID,
+	})
+}
+
+// Ticket de un solo uso para abrir el stream desde EventSource, que no
+// permite enviar el header Authorization
+func (h *NotificationHandler) EmitirTicketStream(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+	email, _ := c.Locals("user_email").(string)
+
+	ticket, expira, err := h.hub.EmitirTicket(usuarioID, email, rol)
+	if err != nil {
+		return c.Status(503).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"ticket":    ticket,
+		"expira_at": expira,
+	})
+}
+
+// AutenticarStream acepta el header Authorization o un ?ticket= emitido por
+// EmitirTicketStream. El JWT nunca viaja en la URL.
+func (h *NotificationHandler) AutenticarStream(c *fiber.Ctx) error {
+	if c.Get("Authorization") != "" {
+		return middleware.AuthRequired(c)
+	}
+
+	datos, ok := h.hub.CanjearTicket(c.Query("ticket"))
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Ticket de stream inválido o expirado"})
+	}
+	c.Locals("user_id", datos.UsuarioID)
+	c.Locals("user_email", datos.Email)
+	c.Locals("user_role", datos.Rol)
+
+	return c.Next()
+}
+
+// Stream SSE de notificaciones en tiempo real
+func (h *NotificationHandler) Stream(c *fiber.Ctx) error {
+	usuarioID, ok := c.Locals("user_id").(string)
+	if !ok || usuarioID == "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   true,
+			"message": "Usuario no autenticado",
+		})
+	}
+
+	uid, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	// EventSource envía Last-Event-ID al reconectar; se acepta también por query
+	ultimoIDStr := c.Get("Last-Event-ID")
+	if ultimoIDStr == "" {
+		ultimoIDStr = c.Query("last_event_id")
+	}
+	ultimoID, _ := strconv.ParseUint(ultimoIDStr, 10, 64)
+
+	noLeidas, err := h.service.ContarNoLeidas(uid)
+	if err != nil {
+		noLeidas = -1
+	}
+
+	sub, pendientes := h.hub.Suscribir(usuarioID, ultimoID)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Cancelar(sub)
+
+		fmt.Fprintf(w, "retry: 5000\n\n")
+
+		for _, ev := range pendientes {
+			escribirEventoSSE(w, ev)
+		}
+
+		if noLeidas >= 0 {
+			fmt.Fprintf(w, "event: %s\ndata: {\"count\":%d}\n\n", realtime.EventoNoLeidas, noLeidas)
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(intervaloHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case ev, abierto := <-sub.Eventos:
+				if !abierto {
+					return
+				}
+				escribirEventoSSE(w, ev)
+			case <-heartbeat.C:
+				fmt.Fprintf(w, ": heartbeat\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				log.Printf("📴 Stream cerrado para usuario %s", usuarioID)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func escribirEventoSSE(w *bufio.Writer, ev realtime.Evento) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Tipo, ev.Datos)
+}
+
+// Bandeja paginada por cursor
+// GET /api/notificaciones?limite=20&cursor=...&tipo=...&leida=true|false&archivadas=true
+func (h *NotificationHandler) ListarBandeja(c *fiber.Ctx) error {
+	uid, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	var leida *bool
+	if v := c.Query("leida"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "leida debe ser true o false",
+			})
+		}
+		leida = &b
+	}
+
+	pagina, err := h.service.ListarBandeja(
+		uid,
+		c.Query("tipo"),
+		leida,
+		c.QueryBool("archivadas", false),
+		c.QueryInt("limite", 0),
+		c.Query("cursor"),
+	)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(pagina)
+}
+
+// Archivar una notificación
+func (h *NotificationHandler) Archivar(c *fiber.Ctx) error {
+	return h.archivarUna(c, true)
+}
+
+// Desarchivar una notificación
+func (h *NotificationHandler) Desarchivar(c *fiber.Ctx) error {
+	return h.archivarUna(c, false)
+}
+
+func (h *NotificationHandler) archivarUna(c *fiber.Ctx, archivada bool) error {
+	notificacionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "ID de notificación inválido",
+		})
+	}
+
+	uid, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	n, err := h.service.Archivar(uid, []uuid.UUID{notificacionID}, archivada)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Error actualizando notificación: " + err.Error(),
+		})
+	}
+	if n == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"error": services.ErrNotificacionNoEncontrada.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notificación actualizada",
+	})
+}
+
+// Eliminar una notificación
+func (h *NotificationHandler) Eliminar(c *fiber.Ctx) error {
+	notificacionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "ID de notificación inválido",
+		})
+	}
+
+	uid, err := usuarioAutenticado(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "Usuario no autenticado",
+		})
+	}
+
+	n, err := h.service.Eliminar(uid, []uuid.UUID{notificacionID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Error eliminando notificación: " + err.Error(),
+		})
+	}
+	if n == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"error": services.ErrNotificacionNoEncontrada.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notificación eliminada",
+	})
+}
+
+// Archivar varias notificaciones
+func (h *NotificationHandler) ArchivarVarias(c *fiber.Ctx) error {
+	uid, ids, err := parsearAccionNotificaciones(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	n, err := h.service.Archivar(uid, ids, true)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Notificaciones archivadas",
+		"actualizadas": n,
+	})
+}
+
+// Eliminar varias notificaciones
+func (h *NotificationHandler) EliminarVarias(c *fiber.Ctx) error {
+	uid, ids, err := parsearAccionNotificaciones(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	n, err := h.service.Eliminar(uid, ids)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Notificaciones eliminadas",
+		"eliminadas": n,
+	})
+}
+
+// Solo se tocan las notificaciones del usuario: los IDs ajenos se ignoran
+func parsearAccionNotificaciones(c *fiber.Ctx) (uuid.UUID, []uuid.UUID, error) {
+	uid, err := usuarioAutenticado(c)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	var req models.AccionNotificacionesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("datos inválidos")
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return uuid.Nil, nil, fmt.Errorf("ID de notificación inválido: %s", id)
+		}
+		ids = append(ids, parsed)
+	}
+
+	return uid, ids, nil
+}
+
+func usuarioAutenticado(c *fiber.Ctx) (uuid.UUID, error) {
+	usuarioID, ok := c.Locals("user_id").(string)
+	if !ok || usuarioID == "" {
+		return uuid.Nil, fmt.Errorf("usuario no autenticado")
+	}
+	return uuid.Parse(usuarioID)
+}
+
+// Métricas de entrega de notificaciones push
+func (h *NotificationHandler) ObtenerMetricasPush(c *fiber.Ctx) error {
+	metricas := h.service.ObtenerMetricasPush()
+	if metricas == nil {
+		return c.JSON(fiber.Map{
+			"firebase_disponible": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"firebase_disponible": true,
+		"metricas":            metricas,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestObtenerMetricasPushConClienteFalso(t *testing.T) {
+	fake := services.NewFakeMessagingClient()
+	fake.MarcarTokenInvalido("muerto")
+	firebase := services.NewFirebaseServiceWithClient(fake)
+	if _, err := firebase.EnviarNotificacionMultiple([]string{"vivo", "muerto"}, "Hola", "Mensaje", nil); err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+
+	handler := NewNotificationHandler(services.NewNotificationService(nil, firebase, nil, nil, nil, nil, nil), nil)
+	app := fiber.New()
+	app.Get("/metricas", handler.ObtenerMetricasPush)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metricas", nil))
+	if err != nil {
+		t.Fatalf("error en la petición: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, se esperaba 200", resp.StatusCode)
+	}
+
+	var cuerpo struct {
+		FirebaseDisponible bool                  `json:"firebase_disponible"`
+		Metricas           services.MetricasPush `json:"metricas"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&cuerpo); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+	if !cuerpo.FirebaseDisponible {
+		t.Fatal("se esperaba firebase_disponible = true")
+	}
+	m := cuerpo.Metricas
+	if m.Enviados != 2 || m.Exitosos != 1 || m.Fallidos != 1 || m.TokensInvalidos != 1 || m.TasaExito != 50 {
+		t.Fatalf("métricas inesperadas: %+v", m)
+	}
+}
+
+func TestObtenerMetricasPushSinFirebase(t *testing.T) {
+	handler := NewNotificationHandler(services.NewNotificationService(nil, nil, nil, nil, nil, nil, nil), nil)
+	app := fiber.New()
+	app.Get("/metricas", handler.ObtenerMetricasPush)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metricas", nil))
+	if err != nil {
+		t.Fatalf("error en la petición: %v", err)
+	}
+
+	var cuerpo map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&cuerpo); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+	if cuerpo["firebase_disponible"] != false {
+		t.Fatalf("se esperaba firebase_disponible = false, se obtuvo %v", cuerpo["firebase_disponible"])
+	}
+	if _, ok := cuerpo["metricas"]; ok {
+		t.Fatal("sin Firebase no debería haber métricas")
+	}
+}
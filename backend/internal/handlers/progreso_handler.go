@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProgresoHandler struct {
+	service *services.ProgresoService
+}
+
+func NewProgresoHandler(service *services.ProgresoService) *ProgresoHandler {
+	return &ProgresoHandler{service: service}
+}
+
+// GET /api/cursos/:id/progreso?solo_rezagados=true
+func (h *ProgresoHandler) ObtenerProgresoCurso(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	progreso, err := h.service.ObtenerProgresoCurso(c.Context(), c.Params("id"), usuarioID, rol, c.QueryBool("solo_rezagados", false))
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(progreso)
+}
+
+// GET /api/cursos/:id/progreso/mio (estudiante)
+func (h *ProgresoHandler) ObtenerMiProgreso(c *fiber.Ctx) error {
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	progreso, err := h.service.ObtenerMiProgreso(c.Context(), c.Params("id"), usuarioID)
+	if err != nil {
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(progreso)
+}
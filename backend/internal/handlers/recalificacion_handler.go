@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RecalificacionHandler struct {
+	service *services.RecalificacionService
+}
+
+func NewRecalificacionHandler(service *services.RecalificacionService) *RecalificacionHandler {
+	return &RecalificacionHandler{service: service}
+}
+
+func estadoRecalificacion(err error, porDefecto int) int {
+	switch {
+	case errors.Is(err, services.ErrSolicitudNoEncontrada):
+		return 404
+	case errors.Is(err, services.ErrSolicitudResuelta), errors.Is(err, services.ErrSolicitudDuplicada):
+		return 409
+	case errors.Is(err, services.ErrEvaluacionInvalida):
+		return 400
+	}
+	return estadoPorError(err, porDefecto)
+}
+
+// GET /api/entregas/:id/historial
+func (h *RecalificacionHandler) ObtenerHistorial(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	historial, err := h.service.ObtenerHistorial(c.Context(), entregaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoRecalificacion(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(historial)
+}
+
+// POST /api/entregas/:id/recalificaciones (estudiante)
+func (h *RecalificacionHandler) Solicitar(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.SolicitarRecalificacionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	solicitud, err := h.service.Solicitar(c.Context(), entregaID, usuarioID, &req)
+	if err != nil {
+		return c.Status(estadoRecalificacion(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(solicitud)
+}
+
+// GET /api/entregas/:id/recalificaciones
+func (h *RecalificacionHandler) Listar(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	solicitudes, err := h.service.ListarSolicitudes(c.Context(), entregaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoRecalificacion(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(solicitudes)
+}
+
+// PUT /api/entregas/:id/recalificaciones/:solicitudId (docente acepta o rechaza)
+func (h *RecalificacionHandler) Resolver(c *fiber.Ctx) error {
+	entregaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	solicitudID, err := uuid.Parse(c.Params("solicitudId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de solicitud inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.ResolverRecalificacionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	solicitud, err := h.service.Resolver(c.Context(), entregaID, solicitudID, usuarioID, rol, &req)
+	if err != nil {
+		return c.Status(estadoRecalificacion(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(solicitud)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RubricaHandler struct {
+	service *services.RubricaService
+}
+
+func NewRubricaHandler(service *services.RubricaService) *RubricaHandler {
+	return &RubricaHandler{service: service}
+}
+
+func estadoRubrica(err error, porDefecto int) int {
+	if errors.Is(err, services.ErrRubricaNoEncontrada) {
+		return 404
+	}
+	return estadoPorError(err, porDefecto)
+}
+
+// POST /api/rubricas
+func (h *RubricaHandler) CrearRubrica(c *fiber.Ctx) error {
+	usuarioID, _, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.RubricaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	rubrica, err := h.service.CrearRubrica(c.Context(), usuarioID, &req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(rubrica)
+}
+
+// GET /api/rubricas
+func (h *RubricaHandler) ListarRubricas(c *fiber.Ctx) error {
+	rubricas, err := h.service.ListarRubricas(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(rubricas)
+}
+
+// GET /api/rubricas/:id
+func (h *RubricaHandler) ObtenerRubrica(c *fiber.Ctx) error {
+	rubricaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+
+	rubrica, err := h.service.ObtenerRubrica(c.Context(), rubricaID)
+	if err != nil {
+		return c.Status(estadoRubrica(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(rubrica)
+}
+
+// PUT /api/rubricas/:id
+func (h *RubricaHandler) ActualizarRubrica(c *fiber.Ctx) error {
+	rubricaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.RubricaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Datos inválidos"})
+	}
+
+	if err := h.service.ActualizarRubrica(c.Context(), rubricaID, usuarioID, rol, &req); err != nil {
+		return c.Status(estadoRubrica(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Rúbrica actualizada exitosamente"})
+}
+
+// DELETE /api/rubricas/:id
+func (h *RubricaHandler) EliminarRubrica(c *fiber.Ctx) error {
+	rubricaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.EliminarRubrica(c.Context(), rubricaID, usuarioID, rol); err != nil {
+		return c.Status(estadoRubrica(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Rúbrica eliminada exitosamente"})
+}
+
+// GET /api/tareas/:id/rubrica (los estudiantes ven los criterios antes de entregar)
+func (h *RubricaHandler) ObtenerRubricaDeTarea(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+
+	rubrica, err := h.service.ObtenerRubricaDeTarea(c.Context(), tareaID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if rubrica == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "La tarea no tiene rúbrica"})
+	}
+
+	return c.JSON(rubrica)
+}
+
+// PUT /api/tareas/:id/rubrica
+func (h *RubricaHandler) AsignarRubrica(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	var req models.AsignarRubricaRequest
+	if err := c.BodyParser(&req); err != nil || req.RubricaID == uuid.Nil {
+		return c.Status(400).JSON(fiber.Map{"error": "rubrica_id es requerido"})
+	}
+
+	rubrica, err := h.service.AsignarRubrica(c.Context(), tareaID, req.RubricaID, usuarioID, rol)
+	if err != nil {
+		return c.Status(estadoRubrica(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(rubrica)
+}
+
+// DELETE /api/tareas/:id/rubrica
+func (h *RubricaHandler) QuitarRubrica(c *fiber.Ctx) error {
+	tareaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	if err := h.service.QuitarRubrica(c.Context(), tareaID, usuarioID, rol); err != nil {
+		return c.Status(estadoRubrica(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Rúbrica quitada de la tarea"})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/services"
 
@@ -87,9 +89,14 @@ func (h *TareaHandler) ObtenerTarea(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "ID de tarea inválido"})
 	}
 
-	tarea, err := h.tareaService.GetTareaByID(c.Context(), tareaUUID)
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	tarea, err := h.tareaService.GetTareaByID(c.Context(), tareaUUID, usuarioID, rol)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(tarea)
@@ -137,3 +144,87 @@ func (h *TareaHandler) EliminarTarea(c *fiber.Ctx) error {
 
 	return c.Status(204).Send(nil)
 }
+
+// POST /api/tareas/:id/calificaciones/publicar
+func (h *TareaHandler) PublicarCalificaciones(c *fiber.Ctx) error {
+	tareaUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de tarea inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	resumen, err := h.tareaService.PublicarCalificaciones(c.Context(), tareaUUID, usuarioID, rol)
+	if err != nil {
+		if errors.Is(err, services.ErrCalificacionesYaPublicadas) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(resumen)
+}
+
+// GET /api/tareas/:id/calificaciones/plantilla
+func (h *TareaHandler) DescargarPlantillaCalificaciones(c *fiber.Ctx) error {
+	tareaUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de tarea inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	excelBuffer, nombreTarea, err := h.tareaService.GenerarPlantillaCalificaciones(c.Context(), tareaUUID, usuarioID, rol)
+	if err != nil {
+		if errors.Is(err, services.ErrImportacionGrupal) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename := "Calificaciones_" + nombreTarea + ".xlsx"
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+
+	return c.Send(excelBuffer.Bytes())
+}
+
+// POST /api/tareas/:id/calificaciones/importar?confirmar=true&sobrescribir=true
+// Sin confirmar devuelve la vista previa de la planilla (campo "file")
+func (h *TareaHandler) ImportarCalificaciones(c *fiber.Ctx) error {
+	tareaUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de tarea inválido"})
+	}
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Archivo no proporcionado"})
+	}
+	contenido, err := file.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Error al abrir archivo"})
+	}
+	defer contenido.Close()
+
+	confirmar := c.QueryBool("confirmar", false)
+	sobrescribir := c.QueryBool("sobrescribir", false)
+
+	resultado, err := h.tareaService.ImportarCalificaciones(c.Context(), tareaUUID, usuarioID, rol, file.Filename, contenido, confirmar, sobrescribir)
+	if err != nil {
+		if errors.Is(err, services.ErrImportacionConErrores) {
+			return c.Status(422).JSON(fiber.Map{"error": err.Error(), "resultado": resultado})
+		}
+		return c.Status(estadoPorError(err, 400)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(resultado)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt" // ✅ AGREGAR ESTA LÍNEA
 	"recetario-backend/internal/services"
 
@@ -29,13 +30,14 @@ func (h *TemaHandler) ListarTemasPorCurso(c *fiber.Ctx) error {
 
 	// ✅ EXTRAER USER_ID DEL MIDDLEWARE DE AUTENTICACIÓN
 	userID := c.Locals("user_id") // Esto viene del middleware auth
+	rol, _ := c.Locals("user_role").(string)
 
 	fmt.Printf("🔍 BACKEND: user_id del contexto: %v\n", userID)
 
-	temas, err := h.temaService.GetTemasByCursoID(cursoID, userID)
+	temas, err := h.temaService.GetTemasByCursoID(cursoID, userID, rol)
 	if err != nil {
 		fmt.Printf("❌ BACKEND: Error: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	fmt.Printf("✅ BACKEND: Devolviendo %d temas\n", len(temas))
@@ -101,10 +103,39 @@ func (h *TemaHandler) ObtenerTema(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "ID de tema requerido"})
 	}
 
-	tema, err := h.temaService.GetTemaByID(temaID)
+	usuarioID, rol, _ := identidad(c)
+
+	tema, err := h.temaService.GetTemaByID(temaID, usuarioID, rol)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, services.ErrTemaNoEncontrado) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(tema)
 }
+
+// RequerirDesbloqueo corta el acceso del estudiante al contenido de un tema
+// que aún no se desbloquea (rutas /api/temas/:id/...)
+func (h *TemaHandler) RequerirDesbloqueo(c *fiber.Ctx) error {
+	usuarioID, rol, ok := identidad(c)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Usuario no autenticado"})
+	}
+	if rol != "estudiante" {
+		return c.Next()
+	}
+
+	if err := h.temaService.VerificarDesbloqueo(c.Context(), c.Params("id"), usuarioID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTemaBloqueado):
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrTemaNoEncontrado):
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(estadoPorError(err, 500)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estados de asistencia que registra el docente
+const (
+	AsistenciaPresente    = "presente"
+	AsistenciaTarde       = "tarde"
+	AsistenciaAusente     = "ausente"
+	AsistenciaJustificado = "justificado"
+)
+
+// Origen de una sesión de clase
+const (
+	SesionDesdeHorario = "horario"
+	SesionManual       = "manual"
+)
+
+// SesionClase es una clase dictada en una fecha concreta
+type SesionClase struct {
+	ID         uuid.UUID  `json:"id"`
+	CursoID    uuid.UUID  `json:"curso_id"`
+	Fecha      string     `json:"fecha"`       // "2024-03-04"
+	HoraInicio string     `json:"hora_inicio"` // "HH:MM"
+	HoraFin    *string    `json:"hora_fin,omitempty"`
+	Aula       *string    `json:"aula,omitempty"`
+	Tema       *string    `json:"tema,omitempty"`
+	Origen     string     `json:"origen"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Para docente
+	Registradas int `json:"registradas"`
+}
+
+// CrearSesionRequest crea una sesión fuera del horario (recuperación, práctica extra)
+type CrearSesionRequest struct {
+	Fecha      string  `json:"fecha"`
+	HoraInicio string  `json:"hora_inicio"`
+	HoraFin    *string `json:"hora_fin"`
+	Aula       *string `json:"aula"`
+	Tema       *string `json:"tema"`
+}
+
+// ResultadoGeneracionSesiones resume las sesiones creadas desde el horario
+type ResultadoGeneracionSesiones struct {
+	Creadas  int           `json:"creadas"`
+	Omitidas int           `json:"omitidas"` // ya existían
+	Sesiones []SesionClase `json:"sesiones"`
+}
+
+// Asistencia es la marca de una matrícula en una sesión
+type Asistencia struct {
+	ID               uuid.UUID  `json:"id"`
+	SesionID         uuid.UUID  `json:"sesion_id"`
+	MatriculaID      string     `json:"matricula_id"`
+	Estado           string     `json:"estado"`
+	Observacion      *string    `json:"observacion,omitempty"`
+	JustificacionURL *string    `json:"justificacion_url,omitempty"`
+	RegistradoPor    *uuid.UUID `json:"registrado_por,omitempty"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// MarcaAsistencia es el estado de un estudiante al pasar lista
+type MarcaAsistencia struct {
+	MatriculaID string  `json:"matricula_id"`
+	Estado      string  `json:"estado"`
+	Observacion *string `json:"observacion"`
+}
+
+// MarcarAsistenciaRequest registra la asistencia de varios estudiantes.
+// EstadoPorDefecto se aplica a quienes aún no tienen marca en la sesión.
+type MarcarAsistenciaRequest struct {
+	EstadoPorDefecto string            `json:"estado_por_defecto"`
+	Marcas           []MarcaAsistencia `json:"marcas"`
+}
+
+// FilaAsistencia es un estudiante en la lista de una sesión
+type FilaAsistencia struct {
+	MatriculaID      string  `json:"matricula_id"`
+	EstudianteID     string  `json:"estudiante_id"`
+	Codigo           string  `json:"codigo,omitempty"`
+	Nombre           string  `json:"nombre"`
+	Estado           *string `json:"estado"` // nil = sin registrar
+	Observacion      *string `json:"observacion,omitempty"`
+	JustificacionURL *string `json:"justificacion_url,omitempty"`
+}
+
+// ListaAsistencia es la sesión con la lista de estudiantes
+type ListaAsistencia struct {
+	Sesion SesionClase      `json:"sesion"`
+	Filas  []FilaAsistencia `json:"filas"`
+}
+
+// ResumenAsistencia acumula las marcas de una matrícula en el curso.
+// Porcentaje = (presente + tarde + justificado) / registradas.
+type ResumenAsistencia struct {
+	MatriculaID  string   `json:"matricula_id"`
+	EstudianteID string   `json:"estudiante_id"`
+	Codigo       string   `json:"codigo,omitempty"`
+	Nombre       string   `json:"nombre"`
+	Presente     int      `json:"presente"`
+	Tarde        int      `json:"tarde"`
+	Ausente      int      `json:"ausente"`
+	Justificado  int      `json:"justificado"`
+	Registradas  int      `json:"registradas"`
+	Porcentaje   *float64 `json:"porcentaje"` // nil sin marcas
+
+	// Para estudiante: sus marcas por sesión
+	Marcas []Asistencia `json:"marcas,omitempty"`
+}
+
+// ReporteAsistencia es el resumen de asistencia de todo el curso
+type ReporteAsistencia struct {
+	CursoID   string              `json:"curso_id"`
+	Sesiones  int                 `json:"sesiones"`
+	Resumenes []ResumenAsistencia `json:"resumenes"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Modos de ponderación del esquema de calificación
+const (
+	ModoPesoPorTipo  = "tipo"
+	ModoPesoPorTarea = "tarea"
+)
+
+// Tratamiento de las tareas vencidas sin entrega
+const (
+	FaltantesCero    = "cero"
+	FaltantesExcluir = "excluir"
+)
+
+// EsquemaCalificacion define cómo se calcula la nota final de un curso
+type EsquemaCalificacion struct {
+	CursoID         string             `json:"curso_id"`
+	Modo            string             `json:"modo"`             // tipo o tarea
+	Pesos           map[string]float64 `json:"pesos"`            // por tipo o por tarea_id
+	EliminarMenores map[string]int     `json:"eliminar_menores"` // notas más bajas descartadas por tipo
+	Faltantes       string             `json:"faltantes"`        // cero o excluir
+	Escala          float64            `json:"escala"`           // nota máxima (vigesimal = 20)
+	NotaAprobatoria float64            `json:"nota_aprobatoria"`
+	UpdatedAt       *time.Time         `json:"updated_at,omitempty"`
+}
+
+// GuardarEsquemaRequest representa los datos para configurar el esquema
+type GuardarEsquemaRequest struct {
+	Modo            string             `json:"modo"`
+	Pesos           map[string]float64 `json:"pesos"`
+	EliminarMenores map[string]int     `json:"eliminar_menores"`
+	Faltantes       string             `json:"faltantes"`
+	Escala          *float64           `json:"escala,omitempty"`
+	NotaAprobatoria *float64           `json:"nota_aprobatoria,omitempty"`
+}
+
+// ColumnaLibro es una tarea del libro de calificaciones
+type ColumnaLibro struct {
+	TareaID       uuid.UUID `json:"tarea_id"`
+	Titulo        string    `json:"titulo"`
+	Tipo          string    `json:"tipo"`
+	PuntajeMaximo float64   `json:"puntaje_maximo"`
+	FechaLimite   time.Time `json:"fecha_limite"`
+	Peso          float64   `json:"peso"`
+	Publicada     bool      `json:"publicada"` // notas visibles para los estudiantes
+}
+
+// CeldaLibro es la nota de un estudiante en una tarea
+type CeldaLibro struct {
+	TareaID      uuid.UUID  `json:"tarea_id"`
+	EntregaID    *uuid.UUID `json:"entrega_id,omitempty"`
+	Calificacion *float64   `json:"calificacion"`
+	Porcentaje   *float64   `json:"porcentaje"` // 0-100 respecto al puntaje máximo
+	Estado       string     `json:"estado"`     // calificada, por_calificar, sin_entrega, pendiente
+	Descartada   bool       `json:"descartada"` // eliminada por la regla de notas más bajas
+}
+
+// FilaLibro es la fila de un estudiante
+type FilaLibro struct {
+	MatriculaID   string              `json:"matricula_id"`
+	EstudianteID  string              `json:"estudiante_id"`
+	Nombre        string              `json:"nombre"`
+	Codigo        string              `json:"codigo,omitempty"`
+	Estado        string              `json:"estado"`
+	Celdas        []CeldaLibro        `json:"celdas"`
+	PromedioTipo  map[string]*float64 `json:"promedio_por_tipo"` // 0-100
+	NotaCalculada *float64            `json:"nota_calculada"`
+	NotaFinal     *float64            `json:"nota_final"`
+}
+
+// LibroCalificaciones es la matriz completa de un curso
+type LibroCalificaciones struct {
+	CursoID string              `json:"curso_id"`
+	Esquema EsquemaCalificacion `json:"esquema"`
+	Tareas  []ColumnaLibro      `json:"tareas"`
+	Filas   []FilaLibro         `json:"filas"`
+}
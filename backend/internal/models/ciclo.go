@@ -4,13 +4,14 @@ import "time"
 
 // Ciclo representa un ciclo académico (2024-I, 2024-II, etc.)
 type Ciclo struct {
-	ID              string    `json:"id"`
-	Nombre          string    `json:"nombre"`           // "2024-I", "2024-II"
-	FechaInicio     string    `json:"fecha_inicio"`     // "2024-03-01"
-	FechaFin        string    `json:"fecha_fin"`        // "2024-06-15"
-	DuracionSemanas int       `json:"duracion_semanas"` // 16
-	Activo          bool      `json:"activo"`           // true/false
-	CreatedAt       time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	Nombre          string     `json:"nombre"`           // "2024-I", "2024-II"
+	FechaInicio     string     `json:"fecha_inicio"`     // "2024-03-01"
+	FechaFin        string     `json:"fecha_fin"`        // "2024-06-15"
+	DuracionSemanas int        `json:"duracion_semanas"` // 16
+	Activo          bool       `json:"activo"`           // true/false
+	CerradoAt       *time.Time `json:"cerrado_at"`       // notas bloqueadas desde el cierre
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // CrearCicloRequest representa los datos para crear un ciclo
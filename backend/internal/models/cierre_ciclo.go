@@ -0,0 +1,84 @@
+package models
+
+// Pasos del cierre de ciclo, en el orden en que se ejecutan
+const (
+	PasoFinalizarMatriculas = "finalizar_matriculas"
+	PasoBloquearNotas       = "bloquear_notas"
+	PasoPromoverEstudiantes = "promover_estudiantes"
+	PasoArchivarCursos      = "archivar_cursos"
+	PasoActivarCiclo        = "activar_ciclo"
+)
+
+// Estado final de una matrícula al cerrar el ciclo
+const (
+	EstadoMatriculaAprobado    = "aprobado"
+	EstadoMatriculaDesaprobado = "desaprobado"
+)
+
+// Estados de un paso del cierre
+const (
+	PasoPendiente  = "pendiente" // vista previa
+	PasoCompletado = "completado"
+)
+
+// CierreCicloRequest indica el ciclo que se activa al cerrar el actual.
+// Sin confirmar solo se devuelve la vista previa.
+type CierreCicloRequest struct {
+	CicloSiguienteID string `json:"ciclo_siguiente_id"`
+	Confirmar        bool   `json:"confirmar"`
+}
+
+// PasoCierre es el resultado de un paso del cierre
+type PasoCierre struct {
+	Paso      string `json:"paso"`
+	Estado    string `json:"estado"`
+	Afectados int    `json:"afectados"`
+	Detalle   string `json:"detalle"`
+}
+
+// CierreCurso resume las notas finales de un curso del ciclo
+type CierreCurso struct {
+	CursoID         string  `json:"curso_id"`
+	Codigo          string  `json:"codigo,omitempty"`
+	Nombre          string  `json:"nombre"`
+	NotaAprobatoria float64 `json:"nota_aprobatoria"`
+	Aprobados       int     `json:"aprobados"`
+	Desaprobados    int     `json:"desaprobados"`
+	SinNota         int     `json:"sin_nota"` // cuentan como desaprobados
+}
+
+// CierreMatricula es el estado final que recibe una matrícula activa
+type CierreMatricula struct {
+	MatriculaID  string   `json:"matricula_id"`
+	EstudianteID string   `json:"estudiante_id"`
+	Estudiante   string   `json:"estudiante"`
+	CursoID      string   `json:"curso_id"`
+	Curso        string   `json:"curso"`
+	NotaFinal    *float64 `json:"nota_final"`
+	EstadoNuevo  string   `json:"estado_nuevo"`
+}
+
+// PromocionEstudiante indica si el estudiante pasa al siguiente ciclo
+type PromocionEstudiante struct {
+	EstudianteID  string `json:"estudiante_id"`
+	Codigo        string `json:"codigo_estudiante"`
+	Nombre        string `json:"nombre"`
+	CicloAnterior int    `json:"ciclo_anterior"`
+	CicloNuevo    int    `json:"ciclo_nuevo"`
+	Promovido     bool   `json:"promovido"`
+	Motivo        string `json:"motivo,omitempty"`
+}
+
+// ResultadoCierreCiclo es la vista previa o el reporte del cierre
+type ResultadoCierreCiclo struct {
+	CicloID          string                `json:"ciclo_id"`
+	Ciclo            string                `json:"ciclo"`
+	CicloSiguienteID string                `json:"ciclo_siguiente_id"`
+	CicloSiguiente   string                `json:"ciclo_siguiente"`
+	Confirmado       bool                  `json:"confirmado"`
+	Pasos            []PasoCierre          `json:"pasos"`
+	Cursos           []CierreCurso         `json:"cursos"`
+	Matriculas       []CierreMatricula     `json:"matriculas"`
+	Estudiantes      []PromocionEstudiante `json:"estudiantes"`
+	Advertencias     []string              `json:"advertencias"`
+}
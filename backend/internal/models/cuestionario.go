@@ -0,0 +1,155 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de pregunta del banco
+const (
+	PreguntaOpcionMultiple = "opcion_multiple"
+	PreguntaVerdaderoFalso = "verdadero_falso"
+	PreguntaRespuestaCorta = "respuesta_corta"
+	PreguntaEmparejamiento = "emparejamiento"
+)
+
+// OpcionPregunta es una alternativa de una pregunta de opción múltiple
+type OpcionPregunta struct {
+	ID       string `json:"id"`
+	Texto    string `json:"texto"`
+	Correcta bool   `json:"correcta,omitempty"`
+}
+
+// ParEmparejamiento une un elemento de la izquierda con su pareja
+type ParEmparejamiento struct {
+	ID        string `json:"id"`
+	Izquierda string `json:"izquierda"`
+	Derecha   string `json:"derecha,omitempty"`
+}
+
+// Pregunta del banco de un curso. Solo se usan los campos de su tipo.
+type Pregunta struct {
+	ID                  uuid.UUID           `json:"id"`
+	CursoID             uuid.UUID           `json:"curso_id"`
+	Tipo                string              `json:"tipo"`
+	Enunciado           string              `json:"enunciado"`
+	Opciones            []OpcionPregunta    `json:"opciones,omitempty"`
+	RespuestaVerdadera  *bool               `json:"respuesta_verdadera,omitempty"`
+	RespuestasAceptadas []string            `json:"respuestas_aceptadas,omitempty"`
+	Pares               []ParEmparejamiento `json:"pares,omitempty"`
+	Puntaje             float64             `json:"puntaje"`
+	CreadoPor           *uuid.UUID          `json:"creado_por,omitempty"`
+	Activo              bool                `json:"activo"`
+	CreatedAt           time.Time           `json:"created_at"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+}
+
+// PreguntaRequest representa los datos para crear o editar una pregunta
+type PreguntaRequest struct {
+	Tipo                string              `json:"tipo"`
+	Enunciado           string              `json:"enunciado"`
+	Opciones            []OpcionPregunta    `json:"opciones"`
+	RespuestaVerdadera  *bool               `json:"respuesta_verdadera"`
+	RespuestasAceptadas []string            `json:"respuestas_aceptadas"`
+	Pares               []ParEmparejamiento `json:"pares"`
+	Puntaje             float64             `json:"puntaje"`
+}
+
+// Cuestionario de una tarea con la copia de sus preguntas
+type Cuestionario struct {
+	TareaID             uuid.UUID  `json:"tarea_id"`
+	TiempoLimiteMinutos int        `json:"tiempo_limite_minutos"` // 0 = sin límite
+	OrdenAleatorio      bool       `json:"orden_aleatorio"`
+	Preguntas           []Pregunta `json:"preguntas,omitempty"` // solo para el docente
+	ConfiguradoAt       time.Time  `json:"configurado_at"`
+
+	PuntajeTotal      float64 `json:"puntaje_total"`
+	CantidadPreguntas int     `json:"cantidad_preguntas"`
+
+	// Para estudiante
+	MiIntento *IntentoCuestionario `json:"mi_intento,omitempty"`
+}
+
+// ConfigurarCuestionarioRequest elige las preguntas del banco para la tarea
+type ConfigurarCuestionarioRequest struct {
+	PreguntaIDs         []uuid.UUID `json:"pregunta_ids"`
+	TiempoLimiteMinutos int         `json:"tiempo_limite_minutos"`
+	OrdenAleatorio      bool        `json:"orden_aleatorio"`
+}
+
+// RespuestaPregunta es lo que responde el estudiante. Solo se usa el campo
+// del tipo de la pregunta.
+type RespuestaPregunta struct {
+	PreguntaID string            `json:"pregunta_id"`
+	Opciones   []string          `json:"opciones,omitempty"` // IDs de las opciones marcadas
+	Valor      *bool             `json:"valor,omitempty"`    // verdadero / falso
+	Texto      string            `json:"texto,omitempty"`    // respuesta corta
+	Pares      map[string]string `json:"pares,omitempty"`    // ID del par -> texto de la derecha elegido
+}
+
+// ResponderCuestionarioRequest guarda o envía las respuestas del intento
+type ResponderCuestionarioRequest struct {
+	Respuestas []RespuestaPregunta `json:"respuestas"`
+}
+
+// ResultadoPregunta es la corrección de una respuesta
+type ResultadoPregunta struct {
+	PreguntaID    string  `json:"pregunta_id"`
+	Puntaje       float64 `json:"puntaje"`
+	PuntajeMaximo float64 `json:"puntaje_maximo"`
+	Correcta      bool    `json:"correcta"`
+
+	// Respuesta corta que no coincide con ninguna aceptada
+	RequiereRevision bool   `json:"requiere_revision,omitempty"`
+	Ajustado         bool   `json:"ajustado,omitempty"` // puntaje fijado por el docente
+	Comentario       string `json:"comentario,omitempty"`
+}
+
+// IntentoCuestionario es la rendición de un estudiante
+type IntentoCuestionario struct {
+	ID              uuid.UUID           `json:"id"`
+	TareaID         uuid.UUID           `json:"tarea_id"`
+	EstudianteID    uuid.UUID           `json:"estudiante_id"`
+	EntregaID       *uuid.UUID          `json:"entrega_id"`
+	Orden           []string            `json:"orden"`
+	Respuestas      []RespuestaPregunta `json:"respuestas"`
+	Resultado       []ResultadoPregunta `json:"resultado,omitempty"`
+	PuntajeObtenido *float64            `json:"puntaje_obtenido"`
+	IniciadoAt      time.Time           `json:"iniciado_at"`
+	VenceAt         time.Time           `json:"vence_at"`
+	EnviadoAt       *time.Time          `json:"enviado_at"`
+	RevisadoPor     *uuid.UUID          `json:"revisado_por,omitempty"`
+	RevisadoAt      *time.Time          `json:"revisado_at,omitempty"`
+
+	// Para el estudiante mientras responde
+	Preguntas         []PreguntaCuestionario `json:"preguntas,omitempty"`
+	SegundosRestantes *int                   `json:"segundos_restantes,omitempty"`
+
+	// Para el docente
+	Estudiante        *EstudianteInfo `json:"estudiante,omitempty"`
+	PendientesRevisar int             `json:"pendientes_revisar,omitempty"`
+}
+
+// PreguntaCuestionario es la pregunta como la ve el estudiante, sin respuestas
+type PreguntaCuestionario struct {
+	ID        string              `json:"id"`
+	Tipo      string              `json:"tipo"`
+	Enunciado string              `json:"enunciado"`
+	Puntaje   float64             `json:"puntaje"`
+	Opciones  []OpcionPregunta    `json:"opciones,omitempty"`
+	Pares     []ParEmparejamiento `json:"pares,omitempty"`    // solo la izquierda
+	Derechas  []string            `json:"derechas,omitempty"` // parejas a elegir, mezcladas
+}
+
+// AjustePregunta fija a mano el puntaje de una respuesta
+type AjustePregunta struct {
+	PreguntaID string  `json:"pregunta_id"`
+	Puntaje    float64 `json:"puntaje"`
+	Comentario string  `json:"comentario"`
+}
+
+// RevisarIntentoRequest son los ajustes del docente sobre un intento enviado
+type RevisarIntentoRequest struct {
+	Ajustes []AjustePregunta `json:"ajustes"`
+}
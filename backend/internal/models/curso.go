@@ -4,18 +4,25 @@ import "time"
 
 // Curso representa una materia/curso académico
 type Curso struct {
-	ID          string    `json:"id"`
-	Nombre      string    `json:"nombre"`
-	Descripcion string    `json:"descripcion,omitempty"`
-	DocenteID   string    `json:"docente_id"`
-	CicloID     string    `json:"ciclo_id"`
-	Nivel       int       `json:"nivel,omitempty"`
-	Seccion     string    `json:"seccion,omitempty"`
-	Creditos    int       `json:"creditos"`
-	Horario     string    `json:"horario,omitempty"`
-	Activo      bool      `json:"activo"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string          `json:"id"`
+	Codigo      string          `json:"codigo,omitempty"` // mismo código en todos los ciclos
+	Nombre      string          `json:"nombre"`
+	Descripcion string          `json:"descripcion,omitempty"`
+	DocenteID   string          `json:"docente_id"`
+	CicloID     string          `json:"ciclo_id"`
+	Nivel       int             `json:"nivel,omitempty"`
+	Seccion     string          `json:"seccion,omitempty"`
+	Creditos    int             `json:"creditos"`
+	Capacidad   *int            `json:"capacidad"` // nil = sin límite
+	Horario     string          `json:"horario,omitempty"`
+	Sesiones    []SesionHorario `json:"sesiones"`
+	Activo      bool            `json:"activo"`
+	ArchivadoAt *time.Time      `json:"archivado_at,omitempty"` // al cerrar su ciclo
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Los temas se abren en orden al ver los materiales obligatorios del anterior
+	DesbloqueoSecuencial bool `json:"desbloqueo_secuencial"`
 
 	// Relaciones (opcionales, para cuando se incluyan en la query)
 	Docente *Docente `json:"docentes,omitempty"`
@@ -24,25 +31,71 @@ type Curso struct {
 
 // CrearCursoRequest representa los datos para crear un curso
 type CrearCursoRequest struct {
-	Nombre      string `json:"nombre" validate:"required"`
-	Descripcion string `json:"descripcion"`
-	DocenteID   string `json:"docente_id" validate:"required"`
-	CicloID     string `json:"ciclo_id" validate:"required"`
-	Nivel       int    `json:"nivel" validate:"required,min=1,max=10"`
-	Seccion     string `json:"seccion"`
-	Creditos    int    `json:"creditos" validate:"required,min=1,max=10"`
-	Horario     string `json:"horario"`
+	Codigo      string          `json:"codigo"`
+	Nombre      string          `json:"nombre" validate:"required"`
+	Descripcion string          `json:"descripcion"`
+	DocenteID   string          `json:"docente_id" validate:"required"`
+	CicloID     string          `json:"ciclo_id" validate:"required"`
+	Nivel       int             `json:"nivel" validate:"required,min=1,max=10"`
+	Seccion     string          `json:"seccion"`
+	Creditos    int             `json:"creditos" validate:"required,min=1,max=10"`
+	Capacidad   *int            `json:"capacidad,omitempty"`
+	Horario     string          `json:"horario"`
+	Sesiones    []SesionHorario `json:"sesiones"`
+
+	DesbloqueoSecuencial bool `json:"desbloqueo_secuencial"`
 }
 
 // ActualizarCursoRequest representa los datos para actualizar un curso
 type ActualizarCursoRequest struct {
-	Nombre      *string `json:"nombre,omitempty"`
-	Descripcion *string `json:"descripcion,omitempty"`
-	DocenteID   *string `json:"docente_id,omitempty"`
-	CicloID     *string `json:"ciclo_id,omitempty"`
-	Nivel       *int    `json:"nivel,omitempty"`
-	Seccion     *string `json:"seccion,omitempty"`
-	Creditos    *int    `json:"creditos,omitempty"`
-	Horario     *string `json:"horario,omitempty"`
-	Activo      *bool   `json:"activo,omitempty"`
+	Codigo      *string          `json:"codigo,omitempty"`
+	Nombre      *string          `json:"nombre,omitempty"`
+	Descripcion *string          `json:"descripcion,omitempty"`
+	DocenteID   *string          `json:"docente_id,omitempty"`
+	CicloID     *string          `json:"ciclo_id,omitempty"`
+	Nivel       *int             `json:"nivel,omitempty"`
+	Seccion     *string          `json:"seccion,omitempty"`
+	Creditos    *int             `json:"creditos,omitempty"`
+	Capacidad   *int             `json:"capacidad,omitempty"` // 0 = quitar el límite
+	Horario     *string          `json:"horario,omitempty"`
+	Sesiones    *[]SesionHorario `json:"sesiones,omitempty"`
+	Activo      *bool            `json:"activo,omitempty"`
+
+	DesbloqueoSecuencial *bool `json:"desbloqueo_secuencial,omitempty"`
+}
+
+// SesionHorario es un bloque semanal de clase.
+// DiaSemana va de 1 (lunes) a 7 (domingo) y las horas usan formato "HH:MM".
+type SesionHorario struct {
+	DiaSemana  int    `json:"dia_semana"`
+	HoraInicio string `json:"hora_inicio"`
+	HoraFin    string `json:"hora_fin"`
+	Aula       string `json:"aula,omitempty"`
+}
+
+// ClonarCursoRequest copia un curso con sus temas, materiales y tareas a otro ciclo
+type ClonarCursoRequest struct {
+	CicloID          string           `json:"ciclo_id"`
+	DocenteID        string           `json:"docente_id,omitempty"` // vacío = mismo docente
+	Seccion          *string          `json:"seccion,omitempty"`
+	Sesiones         *[]SesionHorario `json:"sesiones,omitempty"` // nil = mismo horario
+	DuplicarArchivos bool             `json:"duplicar_archivos"`  // false = comparte los archivos del original
+}
+
+// ResultadoClonacion resume lo copiado al clonar un curso
+type ResultadoClonacion struct {
+	CursoID            string   `json:"curso_id"`
+	CursoOrigenID      string   `json:"curso_origen_id"`
+	CicloID            string   `json:"ciclo_id"`
+	DocenteID          string   `json:"docente_id"`
+	DesfaseDias        int      `json:"desfase_dias"` // días sumados a las fechas de las tareas
+	Temas              int      `json:"temas"`
+	Materiales         int      `json:"materiales"`
+	ArchivosDuplicados int      `json:"archivos_duplicados"`
+	Tareas             int      `json:"tareas"`
+	Rubricas           int      `json:"rubricas"`
+	Preguntas          int      `json:"preguntas"`
+	Cuestionarios      int      `json:"cuestionarios"`
+	EsquemaCopiado     bool     `json:"esquema_copiado"`
+	Advertencias       []string `json:"advertencias"`
 }
@@ -61,7 +61,8 @@ type MatriculasPorCurso struct {
 	CursoID       string  `json:"curso_id"`
 	CursoNombre   string  `json:"curso_nombre"`
 	Matriculados  int     `json:"matriculados"`
-	Capacidad     int     `json:"capacidad"`
+	Capacidad     int     `json:"capacidad"` // 0 = sin límite
+	EnEspera      int     `json:"en_espera"`
 	Porcentaje    float64 `json:"porcentaje"`
 	DocenteNombre string  `json:"docente_nombre"`
 	Seccion       string  `json:"seccion"`
@@ -7,24 +7,38 @@ import (
 )
 
 type Entrega struct {
-	ID                   uuid.UUID `json:"id,omitempty" db:"id"` // ✅ Agregado omitempty
-	TareaID              uuid.UUID `json:"tarea_id" db:"tarea_id"`
-	EstudianteID         uuid.UUID `json:"estudiante_id" db:"estudiante_id"`
-	Titulo               string    `json:"titulo" db:"titulo"`
-	Descripcion          *string   `json:"descripcion,omitempty" db:"descripcion"`
-	FechaEntrega         time.Time `json:"fecha_entrega" db:"fecha_entrega"`
-	DiasRetraso          int       `json:"dias_retraso" db:"dias_retraso"`
-	PenalizacionAplicada float64   `json:"penalizacion_aplicada" db:"penalizacion_aplicada"`
-	Calificacion         *float64  `json:"calificacion,omitempty" db:"calificacion"`
-	ComentarioDocente    *string   `json:"comentario_docente,omitempty" db:"comentario_docente"`
-	Estado               string    `json:"estado" db:"estado"` // pendiente, evaluada, rechazada
-	EntregaTardia        bool      `json:"entrega_tardia" db:"entrega_tardia"`
-	CreatedAt            time.Time `json:"created_at,omitempty" db:"created_at"` // ✅ Agregado omitempty
+	ID                   uuid.UUID          `json:"id,omitempty" db:"id"` // ✅ Agregado omitempty
+	TareaID              uuid.UUID          `json:"tarea_id" db:"tarea_id"`
+	EstudianteID         uuid.UUID          `json:"estudiante_id" db:"estudiante_id"`
+	Titulo               string             `json:"titulo" db:"titulo"`
+	Descripcion          *string            `json:"descripcion,omitempty" db:"descripcion"`
+	FechaEntrega         time.Time          `json:"fecha_entrega" db:"fecha_entrega"`
+	DiasRetraso          int                `json:"dias_retraso" db:"dias_retraso"`
+	PenalizacionAplicada float64            `json:"penalizacion_aplicada" db:"penalizacion_aplicada"`
+	Calificacion         *float64           `json:"calificacion,omitempty" db:"calificacion"`
+	ComentarioDocente    *string            `json:"comentario_docente,omitempty" db:"comentario_docente"`
+	Estado               string             `json:"estado" db:"estado"` // pendiente, evaluada, rechazada
+	EntregaTardia        bool               `json:"entrega_tardia" db:"entrega_tardia"`
+	RubricaEvaluacion    []CriterioEvaluado `json:"rubrica_evaluacion,omitempty" db:"rubrica_evaluacion"`
+	CreatedAt            time.Time          `json:"created_at,omitempty" db:"created_at"` // ✅ Agregado omitempty
+
+	// Entrega grupal: misma fila por integrante; la nota es la del grupo más el ajuste
+	GrupoID            *uuid.UUID `json:"grupo_id,omitempty" db:"grupo_id"`
+	CalificacionGrupal *float64   `json:"calificacion_grupal,omitempty" db:"calificacion_grupal"`
+	AjusteIndividual   float64    `json:"ajuste_individual,omitempty" db:"ajuste_individual"`
+	MotivoAjuste       *string    `json:"motivo_ajuste,omitempty" db:"motivo_ajuste"`
 
 	// Relaciones
 	Archivos   []ArchivoEntrega `json:"archivos,omitempty"`
 	Estudiante *EstudianteInfo  `json:"estudiante,omitempty"`
 	Tarea      *Tarea           `json:"tarea,omitempty"`
+	Grupo      *Grupo           `json:"grupo,omitempty"`
+
+	// Para el docente: lo que había entregado el estudiante a la fecha límite
+	VersionAlCierre *VersionEntrega `json:"version_al_cierre,omitempty"`
+
+	// Reemplaza al estudiante mientras la tarea se califica de forma anónima
+	Alias string `json:"alias,omitempty"`
 }
 
 type ArchivoEntrega struct {
@@ -46,6 +60,15 @@ type CreateEntregaRequest struct {
 type CalificarEntregaRequest struct {
 	Calificacion      float64 `json:"calificacion" binding:"required,min=0"`
 	ComentarioDocente string  `json:"comentario_docente" binding:"required"`
+
+	// Nivel por criterio si la tarea tiene rúbrica; la calificación se calcula
+	Rubrica []EvaluarCriterioRequest `json:"rubrica,omitempty"`
+}
+
+// AjusteIndividualRequest suma (o resta) puntos a un integrante sobre la nota del grupo
+type AjusteIndividualRequest struct {
+	Ajuste float64 `json:"ajuste"`
+	Motivo string  `json:"motivo"`
 }
 
 // EstudianteInfo contiene información básica del estudiante para entregas
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Grupo de trabajo de un curso para las tareas grupales
+type Grupo struct {
+	ID              uuid.UUID  `json:"id"`
+	CursoID         uuid.UUID  `json:"curso_id"`
+	Nombre          string     `json:"nombre"`
+	MaxIntegrantes  *int       `json:"max_integrantes"` // nil = sin límite
+	Autoinscripcion bool       `json:"autoinscripcion"`
+	CreadoPor       *uuid.UUID `json:"creado_por,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	Integrantes []IntegranteGrupo `json:"integrantes"`
+	Lleno       bool              `json:"lleno"`
+}
+
+// IntegranteGrupo es un estudiante inscrito en un grupo
+type IntegranteGrupo struct {
+	GrupoID      uuid.UUID       `json:"grupo_id"`
+	CursoID      uuid.UUID       `json:"curso_id"`
+	EstudianteID uuid.UUID       `json:"estudiante_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Estudiante   *EstudianteInfo `json:"estudiante,omitempty"`
+}
+
+// GrupoRequest representa los datos para crear o editar un grupo. Los
+// integrantes iniciales solo se usan al crear.
+type GrupoRequest struct {
+	Nombre          string      `json:"nombre"`
+	MaxIntegrantes  *int        `json:"max_integrantes"`
+	Autoinscripcion bool        `json:"autoinscripcion"`
+	EstudianteIDs   []uuid.UUID `json:"estudiante_ids,omitempty"`
+}
+
+// AgregarIntegranteRequest inscribe a un estudiante en un grupo (docente)
+type AgregarIntegranteRequest struct {
+	EstudianteID uuid.UUID `json:"estudiante_id"`
+}
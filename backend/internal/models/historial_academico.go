@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// HistorialAcademico reúne las matrículas del estudiante en todos los ciclos.
+// Los promedios se ponderan por créditos y solo cuentan los cursos con nota
+// final que no fueron retirados.
+type HistorialAcademico struct {
+	EstudianteID string    `json:"estudiante_id"`
+	Codigo       string    `json:"codigo,omitempty"`
+	Nombre       string    `json:"nombre"`
+	GeneradoAt   time.Time `json:"generado_at"`
+
+	Ciclos []CicloHistorial `json:"ciclos"`
+
+	// Acumulado de todos los ciclos
+	CreditosMatriculados int      `json:"creditos_matriculados"`
+	CreditosAprobados    int      `json:"creditos_aprobados"`
+	CreditosComputados   int      `json:"creditos_computados"` // con nota, base del promedio
+	PromedioPonderado    *float64 `json:"promedio_ponderado"`  // nil sin notas
+}
+
+// CicloHistorial son los cursos de un ciclo con su promedio ponderado
+type CicloHistorial struct {
+	CicloID     string           `json:"ciclo_id"`
+	Nombre      string           `json:"nombre"`
+	FechaInicio string           `json:"fecha_inicio,omitempty"`
+	FechaFin    string           `json:"fecha_fin,omitempty"`
+	Cursos      []CursoHistorial `json:"cursos"`
+
+	CreditosMatriculados int      `json:"creditos_matriculados"`
+	CreditosAprobados    int      `json:"creditos_aprobados"`
+	CreditosComputados   int      `json:"creditos_computados"`
+	PromedioPonderado    *float64 `json:"promedio_ponderado"`
+}
+
+// CursoHistorial es una matrícula dentro del historial
+type CursoHistorial struct {
+	MatriculaID string   `json:"matricula_id"`
+	CursoID     string   `json:"curso_id"`
+	Codigo      string   `json:"codigo,omitempty"`
+	Nombre      string   `json:"nombre"`
+	Creditos    int      `json:"creditos"`
+	NotaFinal   *float64 `json:"nota_final"`
+	Estado      string   `json:"estado"`
+}
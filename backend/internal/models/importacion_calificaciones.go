@@ -0,0 +1,49 @@
+package models
+
+import "github.com/google/uuid"
+
+// Estado de cada fila al importar calificaciones
+const (
+	FilaValida     = "valida"
+	FilaConflicto  = "conflicto"   // la entrega ya tiene otra nota
+	FilaError      = "error"       // no se puede aplicar
+	FilaSinCambios = "sin_cambios" // misma nota y comentario
+	FilaOmitida    = "omitida"     // sin calificación en la planilla
+)
+
+// FilaImportacionCalificacion es el resultado de validar una fila de la planilla
+type FilaImportacionCalificacion struct {
+	Fila               int        `json:"fila"`
+	EntregaID          *uuid.UUID `json:"entrega_id,omitempty"`
+	Codigo             string     `json:"codigo,omitempty"`
+	Estudiante         string     `json:"estudiante,omitempty"`
+	Calificacion       *float64   `json:"calificacion,omitempty"`
+	Penalizacion       float64    `json:"penalizacion"`
+	CalificacionFinal  *float64   `json:"calificacion_final,omitempty"`
+	CalificacionActual *float64   `json:"calificacion_actual,omitempty"`
+	Comentario         string     `json:"comentario,omitempty"`
+	Estado             string     `json:"estado"`
+	Errores            []string   `json:"errores,omitempty"`
+}
+
+// ResultadoImportacionCalificaciones es la vista previa o el resultado de aplicar
+type ResultadoImportacionCalificaciones struct {
+	TareaID    uuid.UUID                     `json:"tarea_id"`
+	Confirmada bool                          `json:"confirmada"` // false = vista previa
+	Total      int                           `json:"total"`
+	Validas    int                           `json:"validas"`
+	Conflictos int                           `json:"conflictos"`
+	ConErrores int                           `json:"con_errores"`
+	SinCambios int                           `json:"sin_cambios"`
+	Omitidas   int                           `json:"omitidas"`
+	Aplicadas  int                           `json:"aplicadas"`
+	Filas      []FilaImportacionCalificacion `json:"filas"`
+}
+
+// CalificacionImportada es una nota del lote que se aplica en la BD
+type CalificacionImportada struct {
+	EntregaID            uuid.UUID `json:"entrega_id"`
+	Calificacion         float64   `json:"calificacion"`
+	Comentario           string    `json:"comentario"`
+	CalificacionEsperada *float64  `json:"calificacion_esperada"`
+}
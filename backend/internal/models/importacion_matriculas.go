@@ -0,0 +1,31 @@
+package models
+
+// Estados propios de la importación de matrículas
+const (
+	FilaYaMatriculado = "ya_matriculado" // la importación es idempotente
+	FilaEnEspera      = "en_espera"      // curso lleno: quedó en la lista de espera
+)
+
+// FilaImportacionMatricula es el resultado de una fila de la importación de matrículas
+type FilaImportacionMatricula struct {
+	Fila             int      `json:"fila"`
+	CodigoEstudiante string   `json:"codigo_estudiante"`
+	Estudiante       string   `json:"estudiante,omitempty"`
+	Curso            string   `json:"curso"`
+	Seccion          string   `json:"seccion,omitempty"`
+	CursoID          string   `json:"curso_id,omitempty"`
+	Estado           string   `json:"estado"` // creada, ya_matriculado, en_espera o error
+	MatriculaID      string   `json:"matricula_id,omitempty"`
+	Errores          []string `json:"errores,omitempty"`
+}
+
+// ResultadoImportacionMatriculas resume la importación de matrículas de un ciclo
+type ResultadoImportacionMatriculas struct {
+	CicloID        string                     `json:"ciclo_id"`
+	Total          int                        `json:"total"`
+	Matriculadas   int                        `json:"matriculadas"`
+	YaMatriculadas int                        `json:"ya_matriculadas"`
+	EnEspera       int                        `json:"en_espera"`
+	ConErrores     int                        `json:"con_errores"`
+	Filas          []FilaImportacionMatricula `json:"filas"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// Estados propios de la importación de usuarios (además de FilaValida y FilaError)
+const (
+	FilaDuplicada = "duplicada" // email o código ya registrados o repetidos en el archivo
+	FilaCreada    = "creada"
+)
+
+// FilaImportacionUsuario es el resultado de validar (o crear) una fila
+type FilaImportacionUsuario struct {
+	Fila         int      `json:"fila"`
+	Nombre       string   `json:"nombre"`
+	Email        string   `json:"email"`
+	Codigo       string   `json:"codigo"`
+	Rol          string   `json:"rol"`
+	Ciclo        int      `json:"ciclo,omitempty"`
+	Seccion      string   `json:"seccion,omitempty"`
+	Especialidad string   `json:"especialidad,omitempty"`
+	Estado       string   `json:"estado"`
+	UsuarioID    string   `json:"usuario_id,omitempty"`
+	Errores      []string `json:"errores,omitempty"`
+}
+
+// ResultadoImportacionUsuarios resume la vista previa o la creación
+type ResultadoImportacionUsuarios struct {
+	Confirmada bool                     `json:"confirmada"` // false = vista previa
+	Total      int                      `json:"total"`
+	Validas    int                      `json:"validas"`
+	Duplicadas int                      `json:"duplicadas"`
+	ConErrores int                      `json:"con_errores"`
+	Creadas    int                      `json:"creadas"`
+	Filas      []FilaImportacionUsuario `json:"filas"`
+}
@@ -16,6 +16,7 @@ type Material struct {
 	DuracionMinutos *int      `json:"duracion_minutos,omitempty" db:"duracion_minutos"`
 	Descripcion     *string   `json:"descripcion,omitempty" db:"descripcion"`
 	Orden           int       `json:"orden" db:"orden"`
+	Obligatorio     bool      `json:"obligatorio" db:"obligatorio"` // cuenta para el desbloqueo secuencial
 	Activo          bool      `json:"activo" db:"activo"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 
@@ -36,6 +37,7 @@ type CreateMaterialRequest struct {
 	DuracionMinutos *int      `json:"duracion_minutos"`
 	Descripcion     *string   `json:"descripcion"`
 	Orden           int       `json:"orden" binding:"required,min=1"`
+	Obligatorio     *bool     `json:"obligatorio,omitempty"` // nil = obligatorio
 }
 
 type MaterialVisto struct {
@@ -54,4 +56,5 @@ type UpdateMaterialRequest struct {
 	Descripcion *string  `json:"descripcion,omitempty"`
 	TamanoMB    *float64 `json:"tamano_mb,omitempty"`
 	Orden       *int     `json:"orden,omitempty"`
+	Obligatorio *bool    `json:"obligatorio,omitempty"`
 }
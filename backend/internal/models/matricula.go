@@ -12,7 +12,10 @@ type Matricula struct {
 	NotaFinal      *float64   `json:"nota_final,omitempty"`
 	Observaciones  *string    `json:"observaciones,omitempty"`   // ✅ NUEVO
 	FechaMatricula *time.Time `json:"fecha_matricula,omitempty"` // ✅ NUEVO
-	CreatedAt      time.Time  `json:"created_at"`
+	// Matrícula autorizada sin cumplir prerrequisitos
+	RequisitosOmitidosPor *string   `json:"requisitos_omitidos_por,omitempty"`
+	MotivoOmision         *string   `json:"motivo_omision,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
 
 	// ✅ CAMBIAR JSON TAGS A PLURAL
 	Estudiante *EstudianteDetalle `json:"estudiantes,omitempty"`
@@ -33,6 +36,7 @@ type EstudianteDetalle struct {
 // CursoDetalle - Datos del curso con docente
 type CursoDetalle struct {
 	ID        string          `json:"id"`
+	Codigo    string          `json:"codigo,omitempty"`
 	Nombre    string          `json:"nombre"`
 	Nivel     *int            `json:"nivel"`
 	Seccion   *string         `json:"seccion,omitempty"`
@@ -69,6 +73,11 @@ type CrearMatriculaRequest struct {
 	CicloID       string  `json:"ciclo_id"`
 	Estado        *string `json:"estado,omitempty"`        // ✅ NUEVO (opcional)
 	Observaciones *string `json:"observaciones,omitempty"` // ✅ NUEVO (opcional)
+
+	// Solo administradores: matricular aunque falten prerrequisitos (motivo obligatorio)
+	OmitirPrerrequisitos bool    `json:"omitir_prerrequisitos,omitempty"`
+	MotivoOmision        *string `json:"motivo_omision,omitempty"`
+	OmitidoPor           string  `json:"-"` // lo completa el handler con el usuario autenticado
 }
 
 // MatriculaMasivaRequest - Request para matricular múltiples estudiantes
@@ -78,6 +87,10 @@ type MatriculaMasivaRequest struct {
 	CicloID        string   `json:"ciclo_id"`
 	Estado         *string  `json:"estado,omitempty"`        // ✅ NUEVO (opcional, se aplica a todos)
 	Observaciones  *string  `json:"observaciones,omitempty"` // ✅ NUEVO (opcional, se aplica a todos)
+
+	OmitirPrerrequisitos bool    `json:"omitir_prerrequisitos,omitempty"`
+	MotivoOmision        *string `json:"motivo_omision,omitempty"`
+	OmitidoPor           string  `json:"-"`
 }
 
 // ActualizarMatriculaRequest - Request para actualizar matrícula
@@ -86,3 +99,26 @@ type ActualizarMatriculaRequest struct {
 	NotaFinal     *float64 `json:"nota_final,omitempty"`
 	Observaciones *string  `json:"observaciones,omitempty"` // ✅ NUEVO
 }
+
+// ListaEspera es un estudiante esperando cupo en un curso lleno (FIFO por CreatedAt)
+type ListaEspera struct {
+	ID            string    `json:"id"`
+	CursoID       string    `json:"curso_id"`
+	CicloID       string    `json:"ciclo_id"`
+	EstudianteID  string    `json:"estudiante_id"`
+	Observaciones *string   `json:"observaciones,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	Posicion      int       `json:"posicion"`
+
+	Usuario *UsuarioDetalle `json:"usuarios,omitempty"`
+}
+
+// ResultadoMatriculaConCupo es la respuesta de matricular_con_cupo:
+// o se creó la matrícula o el estudiante quedó en la lista de espera.
+// Promovidos son los que ya esperaban y ocuparon los cupos libres antes.
+type ResultadoMatriculaConCupo struct {
+	Matricula   *Matricula   `json:"matricula,omitempty"`
+	ListaEspera *ListaEspera `json:"lista_espera,omitempty"`
+	Posicion    int          `json:"posicion,omitempty"`
+	Promovidos  []Matricula  `json:"promovidos,omitempty"`
+}
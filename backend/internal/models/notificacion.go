@@ -15,6 +15,7 @@ type Notificacion struct {
 	RecetaID     *uuid.UUID `json:"receta_id,omitempty" db:"receta_id"`
 	EnviadoPorID *uuid.UUID `json:"enviado_por_id,omitempty" db:"enviado_por_id"`
 	Leida        bool       `json:"leida" db:"leida"`
+	Archivada    bool       `json:"archivada" db:"archivada"`
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 }
 
@@ -23,3 +24,26 @@ type NotificacionConInfo struct {
 	NombreEnviador *string `json:"nombre_enviador,omitempty"`
 	TituloReceta   *string `json:"titulo_receta,omitempty"`
 }
+
+// FiltroNotificaciones - Filtros y cursor para listar la bandeja
+type FiltroNotificaciones struct {
+	Tipo      string
+	Leida     *bool
+	Archivada bool
+	Limite    int
+
+	// Cursor: solo notificaciones anteriores a (AntesDe, AntesDeID)
+	AntesDe   *time.Time
+	AntesDeID *uuid.UUID
+}
+
+// PaginaNotificaciones - Página de la bandeja con cursor a la siguiente
+type PaginaNotificaciones struct {
+	Notificaciones  []NotificacionConInfo `json:"notificaciones"`
+	SiguienteCursor *string               `json:"siguiente_cursor"`
+}
+
+// AccionNotificacionesRequest - IDs para archivar/eliminar en bloque
+type AccionNotificacionesRequest struct {
+	IDs []string `json:"ids"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prerrequisito: para matricularse en CursoCodigo hay que haber aprobado
+// RequisitoCodigo (en un ciclo anterior) con al menos NotaMinima
+type Prerrequisito struct {
+	ID              uuid.UUID `json:"id"`
+	CursoCodigo     string    `json:"curso_codigo"`
+	RequisitoCodigo string    `json:"requisito_codigo"`
+	NotaMinima      float64   `json:"nota_minima"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CrearPrerrequisitoRequest agrega un prerrequisito al curso de la URL
+type CrearPrerrequisitoRequest struct {
+	RequisitoCodigo string   `json:"requisito_codigo"`
+	NotaMinima      *float64 `json:"nota_minima,omitempty"` // por defecto 10.5
+}
+
+// RequisitoPendiente es un prerrequisito que el estudiante aún no cumple
+type RequisitoPendiente struct {
+	RequisitoCodigo string   `json:"requisito_codigo"`
+	NotaMinima      float64  `json:"nota_minima"`
+	MejorNota       *float64 `json:"mejor_nota,omitempty"` // nil = nunca lo llevó o no tiene nota
+}
+
+// NotaHistorica es una nota final del estudiante en un curso identificado por código
+type NotaHistorica struct {
+	EstudianteID string   `json:"estudiante_id"`
+	CicloID      string   `json:"ciclo_id"`
+	NotaFinal    *float64 `json:"nota_final"`
+	Curso        struct {
+		Codigo string `json:"codigo"`
+	} `json:"cursos"`
+}
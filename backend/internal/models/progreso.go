@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProgresoEstudiante resume el avance de un estudiante en el curso.
+// Solo cuentan los materiales activos y las tareas activas ya publicadas.
+type ProgresoEstudiante struct {
+	MatriculaID  string `json:"matricula_id"`
+	EstudianteID string `json:"estudiante_id"`
+	Codigo       string `json:"codigo,omitempty"`
+	Nombre       string `json:"nombre"`
+
+	MaterialesVistos     int      `json:"materiales_vistos"`
+	MaterialesTotal      int      `json:"materiales_total"`
+	PorcentajeMateriales *float64 `json:"porcentaje_materiales"` // nil sin materiales
+
+	TareasAsignadas       int      `json:"tareas_asignadas"`
+	TareasVencidas        int      `json:"tareas_vencidas"`
+	TareasEntregadas      int      `json:"tareas_entregadas"`
+	TareasCalificadas     int      `json:"tareas_calificadas"`
+	EntregasATiempo       int      `json:"entregas_a_tiempo"`
+	PendientesVencidas    int      `json:"pendientes_vencidas"`    // vencidas sin entregar
+	PorcentajeEntregas    *float64 `json:"porcentaje_entregas"`    // entregadas / asignadas
+	PorcentajePuntualidad *float64 `json:"porcentaje_puntualidad"` // a tiempo / entregadas
+
+	Rezagado bool     `json:"rezagado"`
+	Alertas  []string `json:"alertas"`
+
+	// Para estudiante: tareas publicadas que aún no entrega
+	Pendientes []TareaPendiente `json:"pendientes,omitempty"`
+}
+
+// TareaPendiente es una tarea publicada sin entrega del estudiante
+type TareaPendiente struct {
+	TareaID     uuid.UUID `json:"tarea_id"`
+	Titulo      string    `json:"titulo"`
+	FechaLimite time.Time `json:"fecha_limite"`
+	Vencida     bool      `json:"vencida"`
+}
+
+// ProgresoCurso es la matriz de avance de todos los estudiantes del curso
+type ProgresoCurso struct {
+	CursoID            string   `json:"curso_id"`
+	MaterialesTotal    int      `json:"materiales_total"`
+	TareasAsignadas    int      `json:"tareas_asignadas"`
+	PromedioMateriales *float64 `json:"promedio_materiales"` // % medio de materiales vistos
+	PromedioEntregas   *float64 `json:"promedio_entregas"`   // % medio de tareas entregadas
+	Rezagados          int      `json:"rezagados"`
+
+	Estudiantes []ProgresoEstudiante `json:"estudiantes"`
+	// Vistas por material (CantidadVistos / TotalEstudiantes)
+	Materiales []Material `json:"materiales"`
+}
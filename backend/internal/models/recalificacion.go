@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Origen de un cambio de calificación
+const (
+	OrigenCalificacion   = "calificacion"
+	OrigenRecalificacion = "recalificacion"
+	OrigenImportacion    = "importacion"
+	OrigenCuestionario   = "cuestionario"
+)
+
+// Estados de una solicitud de recalificación
+const (
+	RecalificacionPendiente = "pendiente"
+	RecalificacionAceptada  = "aceptada"
+	RecalificacionRechazada = "rechazada"
+)
+
+// NuevaCalificacion son los datos que se guardan al calificar una entrega
+type NuevaCalificacion struct {
+	Calificacion  float64
+	Comentario    string
+	Rubrica       []CriterioEvaluado
+	CalificadoPor *uuid.UUID
+	Origen        string
+
+	// Nota del grupo de la que sale Calificacion (solo entregas grupales)
+	CalificacionGrupal *float64
+}
+
+// CambioCalificacion es una entrada del historial de una entrega (solo lectura)
+type CambioCalificacion struct {
+	ID                   uuid.UUID  `json:"id"`
+	EntregaID            uuid.UUID  `json:"entrega_id"`
+	CambiadoPor          *uuid.UUID `json:"cambiado_por"`
+	Origen               string     `json:"origen"`
+	CalificacionAnterior *float64   `json:"calificacion_anterior"`
+	CalificacionNueva    *float64   `json:"calificacion_nueva"`
+	ComentarioAnterior   *string    `json:"comentario_anterior"`
+	ComentarioNuevo      *string    `json:"comentario_nuevo"`
+	CreatedAt            time.Time  `json:"created_at"`
+
+	// Relaciones
+	Usuario *struct {
+		NombreCompleto string `json:"nombre_completo"`
+	} `json:"usuario,omitempty"`
+}
+
+// SolicitudRecalificacion es el reclamo de un estudiante sobre su nota
+type SolicitudRecalificacion struct {
+	ID                   uuid.UUID  `json:"id"`
+	EntregaID            uuid.UUID  `json:"entrega_id"`
+	EstudianteID         uuid.UUID  `json:"estudiante_id"`
+	Justificacion        string     `json:"justificacion"`
+	Estado               string     `json:"estado"`
+	CalificacionAnterior *float64   `json:"calificacion_anterior"`
+	CalificacionNueva    *float64   `json:"calificacion_nueva"`
+	Respuesta            *string    `json:"respuesta"`
+	ResueltaPor          *uuid.UUID `json:"resuelta_por"`
+	CreatedAt            time.Time  `json:"created_at"`
+	ResueltaAt           *time.Time `json:"resuelta_at"`
+}
+
+type SolicitarRecalificacionRequest struct {
+	Justificacion string `json:"justificacion" binding:"required"`
+}
+
+// ResolverRecalificacionRequest acepta (con la nueva nota o niveles de
+// rúbrica) o rechaza la solicitud; la respuesta es obligatoria al rechazar
+type ResolverRecalificacionRequest struct {
+	Aceptar      bool                     `json:"aceptar"`
+	Respuesta    string                   `json:"respuesta"`
+	Calificacion *float64                 `json:"calificacion"`
+	Rubrica      []EvaluarCriterioRequest `json:"rubrica,omitempty"`
+}
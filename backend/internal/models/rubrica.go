@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NivelRubrica es un nivel de desempeño de un criterio
+type NivelRubrica struct {
+	ID          string  `json:"id"`
+	Nombre      string  `json:"nombre"`
+	Descripcion string  `json:"descripcion,omitempty"`
+	Puntaje     float64 `json:"puntaje"`
+}
+
+// CriterioRubrica es un aspecto evaluado (presentación, técnica, higiene...)
+type CriterioRubrica struct {
+	ID          string         `json:"id"`
+	Nombre      string         `json:"nombre"`
+	Descripcion string         `json:"descripcion,omitempty"`
+	Niveles     []NivelRubrica `json:"niveles"`
+}
+
+// Rubrica es una plantilla reutilizable
+type Rubrica struct {
+	ID          uuid.UUID         `json:"id"`
+	Nombre      string            `json:"nombre"`
+	Descripcion *string           `json:"descripcion,omitempty"`
+	CreadoPor   *uuid.UUID        `json:"creado_por,omitempty"`
+	Criterios   []CriterioRubrica `json:"criterios"`
+	Activo      bool              `json:"activo"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	PuntajeTotal float64 `json:"puntaje_total"`
+}
+
+// RubricaRequest representa los datos para crear o editar una rúbrica
+type RubricaRequest struct {
+	Nombre      string            `json:"nombre"`
+	Descripcion *string           `json:"descripcion"`
+	Criterios   []CriterioRubrica `json:"criterios"`
+}
+
+// RubricaTarea es la copia de la rúbrica asignada a una tarea
+type RubricaTarea struct {
+	TareaID    uuid.UUID         `json:"tarea_id"`
+	RubricaID  *uuid.UUID        `json:"rubrica_id,omitempty"`
+	Nombre     string            `json:"nombre"`
+	Criterios  []CriterioRubrica `json:"criterios"`
+	AsignadaAt time.Time         `json:"asignada_at"`
+
+	PuntajeTotal float64 `json:"puntaje_total"`
+}
+
+// AsignarRubricaRequest asocia una plantilla a una tarea
+type AsignarRubricaRequest struct {
+	RubricaID uuid.UUID `json:"rubrica_id"`
+}
+
+// CriterioEvaluado guarda el nivel elegido en un criterio al calificar
+type CriterioEvaluado struct {
+	CriterioID    string  `json:"criterio_id"`
+	Criterio      string  `json:"criterio"`
+	NivelID       string  `json:"nivel_id"`
+	Nivel         string  `json:"nivel"`
+	Puntaje       float64 `json:"puntaje"`
+	PuntajeMaximo float64 `json:"puntaje_maximo"`
+	Comentario    string  `json:"comentario,omitempty"`
+}
+
+// EvaluarCriterioRequest es el nivel elegido por el docente para un criterio
+type EvaluarCriterioRequest struct {
+	CriterioID string `json:"criterio_id"`
+	NivelID    string `json:"nivel_id"`
+	Comentario string `json:"comentario"`
+}
@@ -23,6 +23,17 @@ type Tarea struct {
 	Activo               bool       `json:"activo" db:"activo"`
 	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
 
+	// Las notas se muestran al estudiante recién al publicarlas
+	CalificacionesPublicadasAt *time.Time `json:"calificaciones_publicadas_at" db:"calificaciones_publicadas_at"`
+	CalificacionAnonima        bool       `json:"calificacion_anonima" db:"calificacion_anonima"`
+
+	// Una sola entrega por grupo que cuenta para todos sus integrantes
+	EntregaGrupal bool `json:"entrega_grupal" db:"entrega_grupal"`
+
+	// Política de reenvío: cada envío después del primero es un reenvío
+	PermiteReenvio bool `json:"permite_reenvio" db:"permite_reenvio"`
+	MaxReenvios    *int `json:"max_reenvios" db:"max_reenvios"` // nil = sin límite
+
 	// Stats para docente
 	TotalEntregas        int `json:"total_entregas,omitempty"`
 	EntregasSinCalificar int `json:"entregas_sin_calificar,omitempty"`
@@ -47,4 +58,18 @@ type CreateTareaRequest struct {
 	PenalizacionPorDia   float64    `json:"penalizacion_por_dia"`
 	DiasTolerancia       int        `json:"dias_tolerancia"`
 	Tipo                 string     `json:"tipo" binding:"required,oneof=practica evaluacion proyecto"`
+	CalificacionAnonima  bool       `json:"calificacion_anonima"`
+
+	EntregaGrupal bool `json:"entrega_grupal"`
+
+	PermiteReenvio *bool `json:"permite_reenvio,omitempty"` // nil = sin cambios (por defecto sí)
+	MaxReenvios    *int  `json:"max_reenvios"`
+}
+
+// PublicacionCalificaciones resume la publicación de notas de una tarea
+type PublicacionCalificaciones struct {
+	TareaID      uuid.UUID `json:"tarea_id"`
+	PublicadasAt time.Time `json:"publicadas_at"`
+	Calificadas  int       `json:"calificadas"`
+	SinCalificar int       `json:"sin_calificar"`
 }
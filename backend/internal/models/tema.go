@@ -17,17 +17,33 @@ type Tema struct {
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 
+	// Avisar a los estudiantes al llegar la fecha de desbloqueo
+	NotificarDesbloqueo    bool       `json:"notificar_desbloqueo" db:"notificar_desbloqueo"`
+	DesbloqueoNotificadoAt *time.Time `json:"desbloqueo_notificado_at,omitempty" db:"desbloqueo_notificado_at"`
+
+	// Para estudiante: un tema bloqueado solo muestra sus datos generales
+	Bloqueado     bool   `json:"bloqueado"`
+	MotivoBloqueo string `json:"motivo_bloqueo,omitempty"`
+
 	// Relaciones
 	Materiales []Material `json:"materiales,omitempty"`
 	Tareas     []Tarea    `json:"tareas,omitempty"`
 }
 
+// Motivos por los que un tema sigue bloqueado para el estudiante
+const (
+	BloqueoPorFecha     = "fecha"
+	BloqueoPorSecuencia = "secuencia"
+)
+
 type CreateTemaRequest struct {
 	CursoID         uuid.UUID  `json:"curso_id" binding:"required"`
 	Titulo          string     `json:"titulo" binding:"required"`
 	Descripcion     *string    `json:"descripcion"`
 	Orden           int        `json:"orden" binding:"required,min=1"`
 	FechaDesbloqueo *time.Time `json:"fecha_desbloqueo"`
+
+	NotificarDesbloqueo bool `json:"notificar_desbloqueo"`
 }
 
 type UpdateTemaRequest struct {
@@ -36,4 +52,6 @@ type UpdateTemaRequest struct {
 	Orden           *int       `json:"orden" binding:"omitempty,min=1"`
 	FechaDesbloqueo *time.Time `json:"fecha_desbloqueo"`
 	Activo          *bool      `json:"activo"`
+
+	NotificarDesbloqueo *bool `json:"notificar_desbloqueo"`
 }
@@ -18,6 +18,10 @@ type Usuario struct {
 
 	// ✅ NUEVO: Relación con estudiantes (para cuando se incluya en la query)
 	Estudiante *Estudiante `json:"estudiantes,omitempty"`
+
+	// Solo en ListarEstudiantesDisponibles: prerrequisitos que no cumple para el curso
+	RequisitosPendientes []RequisitoPendiente `json:"requisitos_pendientes,omitempty"`
+	Elegible             *bool                `json:"elegible,omitempty"`
 }
 
 // ✅ Métodos útiles (opcionales)
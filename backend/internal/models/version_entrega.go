@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Motivos por los que se guarda una versión de la entrega
+const (
+	MotivoVersionEntrega          = "entrega"
+	MotivoVersionEdicion          = "edicion"
+	MotivoVersionArchivoAgregado  = "archivo_agregado"
+	MotivoVersionArchivoEliminado = "archivo_eliminado"
+)
+
+// VersionEntrega es la foto inmutable de una entrega al guardarla
+type VersionEntrega struct {
+	ID          uuid.UUID        `json:"id"`
+	EntregaID   uuid.UUID        `json:"entrega_id"`
+	Numero      int              `json:"numero"`
+	Envio       int              `json:"envio"` // 1 = envío original; 2 en adelante, reenvíos
+	Motivo      string           `json:"motivo"`
+	Titulo      string           `json:"titulo"`
+	Descripcion *string          `json:"descripcion,omitempty"`
+	Archivos    []ArchivoEntrega `json:"archivos"`
+	CreadoPor   *uuid.UUID       `json:"creado_por,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+
+	// Calculados respecto de la fecha límite de la tarea
+	Tardia   bool `json:"tardia"`
+	AlCierre bool `json:"al_cierre"`
+}
+
+// HistorialVersiones son todas las versiones de una entrega, de la más
+// antigua a la más reciente
+type HistorialVersiones struct {
+	EntregaID         uuid.UUID        `json:"entrega_id"`
+	Versiones         []VersionEntrega `json:"versiones"`
+	VersionAlCierre   *int             `json:"version_al_cierre"` // número de la versión vigente a la fecha límite
+	ReenviosUsados    int              `json:"reenvios_usados"`
+	ReenviosRestantes *int             `json:"reenvios_restantes"` // nil = sin límite
+	PermiteReenvio    bool             `json:"permite_reenvio"`
+}
+
+// LineaDiferencia es una línea de la descripción al comparar dos versiones
+type LineaDiferencia struct {
+	Tipo  string `json:"tipo"` // igual, agregada, eliminada
+	Texto string `json:"texto"`
+}
+
+// CambioTexto es un campo de una sola línea que cambió entre versiones
+type CambioTexto struct {
+	Antes   string `json:"antes"`
+	Despues string `json:"despues"`
+}
+
+// DiferenciaVersiones resume qué cambió de una versión a otra
+type DiferenciaVersiones struct {
+	Desde int `json:"desde"`
+	Hasta int `json:"hasta"`
+
+	Titulo             *CambioTexto      `json:"titulo,omitempty"` // nil si no cambió
+	DescripcionCambio  bool              `json:"descripcion_cambio"`
+	Descripcion        []LineaDiferencia `json:"descripcion"`
+	ArchivosAgregados  []ArchivoEntrega  `json:"archivos_agregados"`
+	ArchivosEliminados []ArchivoEntrega  `json:"archivos_eliminados"`
+	ArchivosSinCambios []ArchivoEntrega  `json:"archivos_sin_cambios"`
+}
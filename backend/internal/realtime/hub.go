@@ -0,0 +1,207 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Tipos de evento enviados por el stream
+const (
+	EventoNotificacion = "notificacion"
+	EventoNoLeidas     = "no_leidas"
+)
+
+const (
+	historialPorUsuario = 100 // eventos guardados por usuario para replay
+	bufferSuscripcion   = 32  // eventos pendientes antes de cortar a un cliente lento
+
+	// Sin conexiones abiertas, el historial de un usuario se conserva este
+	// tiempo para que pueda reconectarse y recuperar lo que se perdió
+	retencionHistorial = 5 * time.Minute
+	intervaloLimpieza  = time.Minute
+)
+
+// Evento es un mensaje publicado a un usuario
+type Evento struct {
+	ID    uint64
+	Tipo  string
+	Datos []byte // JSON
+}
+
+// Suscripcion es una conexión abierta de un usuario (un dispositivo/pestaña)
+type Suscripcion struct {
+	UsuarioID string
+	Eventos   chan Evento
+
+	cerrada bool
+}
+
+// Hub reparte eventos en memoria a todas las conexiones de cada usuario.
+// Es seguro usar un *Hub nil: todas las operaciones se ignoran.
+type Hub struct {
+	mu             sync.Mutex
+	ultimoID       uint64
+	suscriptores   map[string]map[*Suscripcion]struct{}
+	historial      map[string][]Evento
+	ultimoUso      map[string]time.Time // última conexión o evento de cada usuario
+	ultimaLimpieza time.Time
+	tickets        map[string]TicketStream
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		suscriptores: make(map[string]map[*Suscripcion]struct{}),
+		historial:    make(map[string][]Evento),
+		ultimoUso:    make(map[string]time.Time),
+		tickets:      make(map[string]TicketStream),
+	}
+}
+
+// Suscribir abre una conexión para el usuario y devuelve los eventos
+// posteriores a ultimoID que todavía están en el historial.
+func (h *Hub) Suscribir(usuarioID string, ultimoID uint64) (*Suscripcion, []Evento) {
+	sub := &Suscripcion{
+		UsuarioID: usuarioID,
+		Eventos:   make(chan Evento, bufferSuscripcion),
+	}
+	if h == nil {
+		close(sub.Eventos)
+		return sub, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.limpiarHistorial(time.Now())
+	if h.suscriptores[usuarioID] == nil {
+		h.suscriptores[usuarioID] = make(map[*Suscripcion]struct{})
+	}
+	h.suscriptores[usuarioID][sub] = struct{}{}
+
+	// Un ID mayor al actual viene de antes de un reinicio: no hay nada que reenviar
+	var pendientes []Evento
+	if ultimoID > 0 && ultimoID <= h.ultimoID {
+		for _, ev := range h.historial[usuarioID] {
+			if ev.ID > ultimoID {
+				pendientes = append(pendientes, ev)
+			}
+		}
+	}
+
+	log.Printf("📡 Stream abierto para usuario %s (%d conexiones)", usuarioID, len(h.suscriptores[usuarioID]))
+	return sub, pendientes
+}
+
+// Cancelar cierra la conexión y la quita del hub
+func (h *Hub) Cancelar(sub *Suscripcion) {
+	if h == nil || sub == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.quitar(sub)
+}
+
+// Publicar envía un evento a todas las conexiones del usuario
+func (h *Hub) Publicar(usuarioID, tipo string, datos interface{}) {
+	if h == nil {
+		return
+	}
+
+	payload, err := json.Marshal(datos)
+	if err != nil {
+		log.Printf("❌ Error serializando evento %s: %v", tipo, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ahora := time.Now()
+	h.limpiarHistorial(ahora)
+
+	h.ultimoID++
+	ev := Evento{ID: h.ultimoID, Tipo: tipo, Datos: payload}
+	h.ultimoUso[usuarioID] = ahora
+
+	hist := append(h.historial[usuarioID], ev)
+	if len(hist) > historialPorUsuario {
+		hist = hist[len(hist)-historialPorUsuario:]
+	}
+	h.historial[usuarioID] = hist
+
+	for sub := range h.suscriptores[usuarioID] {
+		select {
+		case sub.Eventos <- ev:
+		default:
+			// Cliente lento: se corta y al reconectar recupera con Last-Event-ID
+			log.Printf("⚠️ Stream de usuario %s saturado, cerrando conexión", usuarioID)
+			h.quitar(sub)
+		}
+	}
+}
+
+// Cerrar termina todas las conexiones (al apagar el servidor)
+func (h *Hub) Cerrar() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subs := range h.suscriptores {
+		for sub := range subs {
+			h.quitar(sub)
+		}
+	}
+}
+
+// Conexiones abiertas de un usuario
+func (h *Hub) Conexiones(usuarioID string) int {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.suscriptores[usuarioID])
+}
+
+// quitar asume que h.mu está tomado
+func (h *Hub) quitar(sub *Suscripcion) {
+	if sub.cerrada {
+		return
+	}
+	sub.cerrada = true
+	close(sub.Eventos)
+
+	subs := h.suscriptores[sub.UsuarioID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.suscriptores, sub.UsuarioID)
+		h.ultimoUso[sub.UsuarioID] = time.Now()
+	}
+}
+
+// limpiarHistorial descarta el historial de los usuarios sin conexiones
+// abiertas cuya última actividad pasó la retención. Asume que h.mu está tomado.
+func (h *Hub) limpiarHistorial(ahora time.Time) {
+	if ahora.Sub(h.ultimaLimpieza) < intervaloLimpieza {
+		return
+	}
+	h.ultimaLimpieza = ahora
+
+	for usuarioID, uso := range h.ultimoUso {
+		if len(h.suscriptores[usuarioID]) > 0 || ahora.Sub(uso) < retencionHistorial {
+			continue
+		}
+		delete(h.historial, usuarioID)
+		delete(h.ultimoUso, usuarioID)
+	}
+}
@@ -0,0 +1,62 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanjearTicketEsDeUnSoloUso(t *testing.T) {
+	h := NewHub()
+
+	ticket, _, err := h.EmitirTicket("u1", "u1@test.com", "estudiante")
+	if err != nil {
+		t.Fatalf("EmitirTicket: %v", err)
+	}
+
+	datos, ok := h.CanjearTicket(ticket)
+	if !ok || datos.UsuarioID != "u1" || datos.Rol != "estudiante" {
+		t.Fatalf("primer canje = %+v, %v", datos, ok)
+	}
+	if _, ok := h.CanjearTicket(ticket); ok {
+		t.Fatal("el ticket se pudo canjear dos veces")
+	}
+}
+
+func TestCanjearTicketVencido(t *testing.T) {
+	h := NewHub()
+
+	ticket, _, _ := h.EmitirTicket("u1", "", "estudiante")
+	h.tickets[ticket] = TicketStream{UsuarioID: "u1", Expira: time.Now().Add(-time.Second)}
+
+	if _, ok := h.CanjearTicket(ticket); ok {
+		t.Fatal("se aceptó un ticket vencido")
+	}
+	if _, ok := h.CanjearTicket("inexistente"); ok {
+		t.Fatal("se aceptó un ticket inexistente")
+	}
+}
+
+func TestLimpiarHistorialDescartaUsuariosInactivos(t *testing.T) {
+	h := NewHub()
+
+	h.Publicar("inactivo", EventoNoLeidas, 1)
+	sub, _ := h.Suscribir("conectado", 0)
+	defer h.Cancelar(sub)
+	h.Publicar("conectado", EventoNoLeidas, 1)
+
+	// Simula que pasó la retención desde la última actividad
+	antes := time.Now().Add(-2 * retencionHistorial)
+	h.mu.Lock()
+	h.ultimoUso["inactivo"] = antes
+	h.ultimoUso["conectado"] = antes
+	h.ultimaLimpieza = time.Time{}
+	h.limpiarHistorial(time.Now())
+	h.mu.Unlock()
+
+	if _, ok := h.historial["inactivo"]; ok {
+		t.Error("no se descartó el historial del usuario sin conexiones")
+	}
+	if _, ok := h.historial["conectado"]; !ok {
+		t.Error("se descartó el historial de un usuario con conexiones abiertas")
+	}
+}
@@ -0,0 +1,71 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// vigenciaTicket es el tiempo que tiene el cliente para abrir el stream
+const vigenciaTicket = time.Minute
+
+// TicketStream identifica al usuario que abre el stream. EventSource no
+// permite enviar headers, así que en lugar del JWT el cliente pide un ticket
+// de un solo uso y corta duración y lo manda como ?ticket=.
+type TicketStream struct {
+	UsuarioID string
+	Email     string
+	Rol       string
+	Expira    time.Time
+}
+
+// EmitirTicket crea un ticket para el usuario ya autenticado
+func (h *Hub) EmitirTicket(usuarioID, email, rol string) (string, time.Time, error) {
+	if h == nil {
+		return "", time.Time{}, errors.New("stream de notificaciones no disponible")
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := hex.EncodeToString(bytes)
+	expira := time.Now().Add(vigenciaTicket)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Los tickets que nadie usó se descartan al emitir uno nuevo
+	ahora := time.Now()
+	for t, datos := range h.tickets {
+		if ahora.After(datos.Expira) {
+			delete(h.tickets, t)
+		}
+	}
+	h.tickets[ticket] = TicketStream{UsuarioID: usuarioID, Email: email, Rol: rol, Expira: expira}
+
+	return ticket, expira, nil
+}
+
+// CanjearTicket devuelve los datos del ticket y lo invalida. Un ticket
+// vencido o ya usado no sirve.
+func (h *Hub) CanjearTicket(ticket string) (TicketStream, bool) {
+	if h == nil || ticket == "" {
+		return TicketStream{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	datos, ok := h.tickets[ticket]
+	if !ok {
+		return TicketStream{}, false
+	}
+	delete(h.tickets, ticket)
+
+	if time.Now().After(datos.Expira) {
+		return TicketStream{}, false
+	}
+	return datos, true
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type AsistenciaRepository struct {
+	client *SupabaseClient
+}
+
+func NewAsistenciaRepository(client *SupabaseClient) *AsistenciaRepository {
+	return &AsistenciaRepository{client: client}
+}
+
+// ==================== SESIONES ====================
+
+// Crear una sesión; falla si ya existe otra en la misma fecha y hora
+func (r *AsistenciaRepository) CrearSesion(ctx context.Context, data map[string]interface{}) (*models.SesionClase, error) {
+	url := fmt.Sprintf("%s/rest/v1/sesiones_clase", config.AppConfig.SupabaseURL)
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear sesión: %w", err)
+	}
+
+	var sesiones []models.SesionClase
+	if err := json.Unmarshal(respBody, &sesiones); err != nil {
+		return nil, err
+	}
+	if len(sesiones) == 0 {
+		return nil, fmt.Errorf("no se pudo crear la sesión")
+	}
+
+	return &sesiones[0], nil
+}
+
+// Crear varias sesiones ignorando las que ya existen; devuelve solo las nuevas
+func (r *AsistenciaRepository) CrearSesionesSiNoExisten(ctx context.Context, data []map[string]interface{}) ([]models.SesionClase, error) {
+	url := fmt.Sprintf("%s/rest/v1/sesiones_clase?on_conflict=curso_id,fecha,hora_inicio",
+		config.AppConfig.SupabaseURL)
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=ignore-duplicates,return=representation"
+
+	respBody, err := r.client.DoRequest("POST", url, data, headers)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar sesiones: %w", err)
+	}
+
+	var sesiones []models.SesionClase
+	if err := json.Unmarshal(respBody, &sesiones); err != nil {
+		return nil, err
+	}
+
+	return sesiones, nil
+}
+
+// Obtener sesión por ID
+func (r *AsistenciaRepository) GetSesionByID(ctx context.Context, sesionID uuid.UUID) (*models.SesionClase, error) {
+	url := fmt.Sprintf("%s/rest/v1/sesiones_clase?id=eq.%s", config.AppConfig.SupabaseURL, sesionID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener sesión: %w", err)
+	}
+
+	var sesiones []models.SesionClase
+	if err := json.Unmarshal(respBody, &sesiones); err != nil {
+		return nil, err
+	}
+	if len(sesiones) == 0 {
+		return nil, fmt.Errorf("sesión no encontrada")
+	}
+
+	return &sesiones[0], nil
+}
+
+// Listar sesiones del curso en orden cronológico
+func (r *AsistenciaRepository) GetSesionesByCurso(ctx context.Context, cursoID string) ([]models.SesionClase, error) {
+	url := fmt.Sprintf("%s/rest/v1/sesiones_clase?curso_id=eq.%s&order=fecha.asc,hora_inicio.asc",
+		config.AppConfig.SupabaseURL, cursoID)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener sesiones: %w", err)
+	}
+
+	var sesiones []models.SesionClase
+	if err := json.Unmarshal(respBody, &sesiones); err != nil {
+		return nil, err
+	}
+
+	return sesiones, nil
+}
+
+// Eliminar sesión (sus marcas se eliminan en cascada)
+func (r *AsistenciaRepository) DeleteSesion(ctx context.Context, sesionID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/sesiones_clase?id=eq.%s", config.AppConfig.SupabaseURL, sesionID.String())
+
+	if _, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al eliminar sesión: %w", err)
+	}
+
+	return nil
+}
+
+// ==================== MARCAS ====================
+
+// Listar las marcas de una sesión
+func (r *AsistenciaRepository) GetBySesion(ctx context.Context, sesionID uuid.UUID) ([]models.Asistencia, error) {
+	url := fmt.Sprintf("%s/rest/v1/asistencias?sesion_id=eq.%s", config.AppConfig.SupabaseURL, sesionID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener asistencia: %w", err)
+	}
+
+	var asistencias []models.Asistencia
+	if err := json.Unmarshal(respBody, &asistencias); err != nil {
+		return nil, err
+	}
+
+	return asistencias, nil
+}
+
+// Listar todas las marcas de las sesiones del curso
+func (r *AsistenciaRepository) GetByCurso(ctx context.Context, cursoID string) ([]models.Asistencia, error) {
+	url := fmt.Sprintf("%s/rest/v1/asistencias?select=*,sesiones_clase!inner(curso_id)&sesiones_clase.curso_id=eq.%s",
+		config.AppConfig.SupabaseURL, cursoID)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener asistencia del curso: %w", err)
+	}
+
+	var asistencias []models.Asistencia
+	if err := json.Unmarshal(respBody, &asistencias); err != nil {
+		return nil, err
+	}
+
+	return asistencias, nil
+}
+
+// Crear o reemplazar marcas. Todas las filas deben traer las mismas columnas;
+// las columnas que no se envían conservan su valor.
+func (r *AsistenciaRepository) Guardar(ctx context.Context, marcas []map[string]interface{}) error {
+	url := fmt.Sprintf("%s/rest/v1/asistencias?on_conflict=sesion_id,matricula_id", config.AppConfig.SupabaseURL)
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=merge-duplicates"
+
+	if _, err := r.client.DoRequest("POST", url, marcas, headers); err != nil {
+		return fmt.Errorf("error al registrar asistencia: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"recetario-backend/internal/config"
+	"time"
+)
+
+type CalendarioRepository struct {
+	client *SupabaseClient
+}
+
+func NewCalendarioRepository(client *SupabaseClient) *CalendarioRepository {
+	return &CalendarioRepository{client: client}
+}
+
+type calendarioToken struct {
+	UsuarioID string `json:"usuario_id"`
+	Token     string `json:"token"`
+}
+
+// Obtener el token de calendario del usuario ("" si todavía no tiene)
+func (r *CalendarioRepository) GetTokenByUsuario(ctx context.Context, usuarioID string) (string, error) {
+	url := fmt.Sprintf("%s/rest/v1/calendario_tokens?usuario_id=eq.%s&select=usuario_id,token",
+		config.AppConfig.SupabaseURL, usuarioID)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return "", fmt.Errorf("error al obtener token de calendario: %w", err)
+	}
+
+	var tokens []calendarioToken
+	if err := json.Unmarshal(respBody, &tokens); err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	return tokens[0].Token, nil
+}
+
+// Obtener el usuario dueño de un token ("" si no existe)
+func (r *CalendarioRepository) GetUsuarioByToken(ctx context.Context, token string) (string, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/calendario_tokens?token=eq.%s&select=usuario_id,token",
+		config.AppConfig.SupabaseURL, url.QueryEscape(token))
+
+	respBody, err := r.client.DoRequest("GET", endpoint, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return "", fmt.Errorf("error al validar token de calendario: %w", err)
+	}
+
+	var tokens []calendarioToken
+	if err := json.Unmarshal(respBody, &tokens); err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	return tokens[0].UsuarioID, nil
+}
+
+// Guardar (o reemplazar) el token de calendario del usuario
+func (r *CalendarioRepository) GuardarToken(ctx context.Context, usuarioID, token string) error {
+	url := fmt.Sprintf("%s/rest/v1/calendario_tokens?on_conflict=usuario_id", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"usuario_id": usuarioID,
+		"token":      token,
+		"created_at": time.Now().UTC(),
+	}
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=merge-duplicates"
+
+	if _, err := r.client.DoRequest("POST", url, data, headers); err != nil {
+		return fmt.Errorf("error al guardar token de calendario: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"time"
+)
+
+type CalificacionesRepository struct {
+	client *SupabaseClient
+}
+
+func NewCalificacionesRepository(client *SupabaseClient) *CalificacionesRepository {
+	return &CalificacionesRepository{client: client}
+}
+
+// Obtener el esquema de calificación del curso (nil si no fue configurado)
+func (r *CalificacionesRepository) GetEsquema(ctx context.Context, cursoID string) (*models.EsquemaCalificacion, error) {
+	url := fmt.Sprintf("%s/rest/v1/esquemas_calificacion?curso_id=eq.%s",
+		config.AppConfig.SupabaseURL, cursoID)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener esquema de calificación: %w", err)
+	}
+
+	var esquemas []models.EsquemaCalificacion
+	if err := json.Unmarshal(respBody, &esquemas); err != nil {
+		return nil, err
+	}
+	if len(esquemas) == 0 {
+		return nil, nil
+	}
+
+	return &esquemas[0], nil
+}
+
+// Crear o reemplazar el esquema de calificación del curso
+func (r *CalificacionesRepository) GuardarEsquema(ctx context.Context, esquema *models.EsquemaCalificacion) error {
+	url := fmt.Sprintf("%s/rest/v1/esquemas_calificacion?on_conflict=curso_id", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"curso_id":         esquema.CursoID,
+		"modo":             esquema.Modo,
+		"pesos":            esquema.Pesos,
+		"eliminar_menores": esquema.EliminarMenores,
+		"faltantes":        esquema.Faltantes,
+		"escala":           esquema.Escala,
+		"nota_aprobatoria": esquema.NotaAprobatoria,
+		"updated_at":       time.Now().UTC(),
+	}
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=merge-duplicates"
+
+	if _, err := r.client.DoRequest("POST", url, data, headers); err != nil {
+		return fmt.Errorf("error al guardar esquema de calificación: %w", err)
+	}
+
+	return nil
+}
+
+// Guardar la nota final calculada de una matrícula
+func (r *CalificacionesRepository) ActualizarNotaFinal(ctx context.Context, matriculaID string, nota *float64) error {
+	url := fmt.Sprintf("%s/rest/v1/matriculas?id=eq.%s", config.AppConfig.SupabaseURL, matriculaID)
+
+	data := map[string]interface{}{
+		"nota_final":        nota,
+		"nota_calculada_at": time.Now().UTC(),
+	}
+
+	if _, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al actualizar nota final: %w", err)
+	}
+
+	return nil
+}
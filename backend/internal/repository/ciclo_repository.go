@@ -81,3 +81,12 @@ func (r *cicloRepository) CicloTieneCursos(cicloID string) (bool, error) {
 	// Si hay algo más, significa que sí hay cursos
 	return string(result) != "[]", nil
 }
+
+// CerrarCiclo aplica el cierre del ciclo en una sola transacción
+func (r *cicloRepository) CerrarCiclo(data map[string]interface{}) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/rpc/cerrar_ciclo"
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("POST", url, data, headers)
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CuestionarioRepository struct {
+	client *SupabaseClient
+}
+
+func NewCuestionarioRepository(client *SupabaseClient) *CuestionarioRepository {
+	return &CuestionarioRepository{client: client}
+}
+
+// ==================== BANCO DE PREGUNTAS ====================
+
+// Crear pregunta
+func (r *CuestionarioRepository) CrearPregunta(ctx context.Context, data map[string]interface{}) (*models.Pregunta, error) {
+	url := fmt.Sprintf("%s/rest/v1/preguntas", config.AppConfig.SupabaseURL)
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear pregunta: %w", err)
+	}
+
+	var preguntas []models.Pregunta
+	if err := json.Unmarshal(respBody, &preguntas); err != nil {
+		return nil, err
+	}
+	if len(preguntas) == 0 {
+		return nil, fmt.Errorf("no se pudo crear la pregunta")
+	}
+
+	return &preguntas[0], nil
+}
+
+// Obtener pregunta por ID (nil si no existe)
+func (r *CuestionarioRepository) GetPregunta(ctx context.Context, preguntaID uuid.UUID) (*models.Pregunta, error) {
+	url := fmt.Sprintf("%s/rest/v1/preguntas?id=eq.%s", config.AppConfig.SupabaseURL, preguntaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pregunta: %w", err)
+	}
+
+	var preguntas []models.Pregunta
+	if err := json.Unmarshal(respBody, &preguntas); err != nil {
+		return nil, err
+	}
+	if len(preguntas) == 0 {
+		return nil, nil
+	}
+
+	return &preguntas[0], nil
+}
+
+// Preguntas activas del banco de un curso
+func (r *CuestionarioRepository) GetPreguntasByCurso(ctx context.Context, cursoID uuid.UUID) ([]models.Pregunta, error) {
+	url := fmt.Sprintf("%s/rest/v1/preguntas?curso_id=eq.%s&activo=eq.true&order=created_at.asc",
+		config.AppConfig.SupabaseURL, cursoID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener preguntas: %w", err)
+	}
+
+	var preguntas []models.Pregunta
+	if err := json.Unmarshal(respBody, &preguntas); err != nil {
+		return nil, err
+	}
+
+	return preguntas, nil
+}
+
+// Preguntas por IDs (para armar un cuestionario)
+func (r *CuestionarioRepository) GetPreguntasByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Pregunta, error) {
+	if len(ids) == 0 {
+		return []models.Pregunta{}, nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/preguntas?id=in.(%s)", config.AppConfig.SupabaseURL, unirIDs(ids))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener preguntas: %w", err)
+	}
+
+	var preguntas []models.Pregunta
+	if err := json.Unmarshal(respBody, &preguntas); err != nil {
+		return nil, err
+	}
+
+	return preguntas, nil
+}
+
+// Actualizar pregunta
+func (r *CuestionarioRepository) UpdatePregunta(ctx context.Context, preguntaID uuid.UUID, data map[string]interface{}) error {
+	url := fmt.Sprintf("%s/rest/v1/preguntas?id=eq.%s", config.AppConfig.SupabaseURL, preguntaID.String())
+
+	data["updated_at"] = time.Now().UTC()
+
+	if _, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al actualizar pregunta: %w", err)
+	}
+
+	return nil
+}
+
+// ==================== CUESTIONARIO DE TAREA ====================
+
+// Obtener el cuestionario de una tarea (nil si no tiene)
+func (r *CuestionarioRepository) GetDeTarea(ctx context.Context, tareaID uuid.UUID) (*models.Cuestionario, error) {
+	url := fmt.Sprintf("%s/rest/v1/tarea_cuestionarios?tarea_id=eq.%s", config.AppConfig.SupabaseURL, tareaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cuestionario: %w", err)
+	}
+
+	var cuestionarios []models.Cuestionario
+	if err := json.Unmarshal(respBody, &cuestionarios); err != nil {
+		return nil, err
+	}
+	if len(cuestionarios) == 0 {
+		return nil, nil
+	}
+
+	return &cuestionarios[0], nil
+}
+
+// Guardar (o reemplazar) el cuestionario de una tarea
+func (r *CuestionarioRepository) Guardar(ctx context.Context, c *models.Cuestionario) error {
+	url := fmt.Sprintf("%s/rest/v1/tarea_cuestionarios?on_conflict=tarea_id", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"tarea_id":              c.TareaID.String(),
+		"tiempo_limite_minutos": c.TiempoLimiteMinutos,
+		"orden_aleatorio":       c.OrdenAleatorio,
+		"preguntas":             c.Preguntas,
+		"configurado_at":        time.Now().UTC(),
+	}
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=merge-duplicates"
+
+	if _, err := r.client.DoRequest("POST", url, data, headers); err != nil {
+		return fmt.Errorf("error al guardar cuestionario: %w", err)
+	}
+
+	return nil
+}
+
+// Quitar el cuestionario de una tarea
+func (r *CuestionarioRepository) Quitar(ctx context.Context, tareaID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/tarea_cuestionarios?tarea_id=eq.%s", config.AppConfig.SupabaseURL, tareaID.String())
+
+	if _, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al quitar cuestionario: %w", err)
+	}
+
+	return nil
+}
+
+// ==================== INTENTOS ====================
+
+// Crear el intento si el estudiante aún no tiene uno; si ya existe se
+// devuelve el existente (el índice único evita intentos duplicados)
+func (r *CuestionarioRepository) CrearIntentoSiNoExiste(ctx context.Context, data map[string]interface{}) (*models.IntentoCuestionario, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?on_conflict=tarea_id,estudiante_id", config.AppConfig.SupabaseURL)
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=ignore-duplicates,return=representation"
+
+	if _, err := r.client.DoRequest("POST", url, data, headers); err != nil {
+		return nil, fmt.Errorf("error al iniciar cuestionario: %w", err)
+	}
+
+	tareaID, _ := data["tarea_id"].(string)
+	estudianteID, _ := data["estudiante_id"].(string)
+	intento, err := r.getIntento(fmt.Sprintf("tarea_id=eq.%s&estudiante_id=eq.%s", tareaID, estudianteID))
+	if err != nil {
+		return nil, err
+	}
+	if intento == nil {
+		return nil, fmt.Errorf("no se pudo iniciar el cuestionario")
+	}
+
+	return intento, nil
+}
+
+// Intento de un estudiante en una tarea (nil si no existe)
+func (r *CuestionarioRepository) GetIntento(ctx context.Context, tareaID, estudianteID uuid.UUID) (*models.IntentoCuestionario, error) {
+	return r.getIntento(fmt.Sprintf("tarea_id=eq.%s&estudiante_id=eq.%s", tareaID.String(), estudianteID.String()))
+}
+
+// Intento por ID (nil si no existe)
+func (r *CuestionarioRepository) GetIntentoByID(ctx context.Context, intentoID uuid.UUID) (*models.IntentoCuestionario, error) {
+	return r.getIntento("id=eq." + intentoID.String())
+}
+
+func (r *CuestionarioRepository) getIntento(filtro string) (*models.IntentoCuestionario, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?%s", config.AppConfig.SupabaseURL, filtro)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener intento: %w", err)
+	}
+
+	var intentos []models.IntentoCuestionario
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return nil, err
+	}
+	if len(intentos) == 0 {
+		return nil, nil
+	}
+
+	return &intentos[0], nil
+}
+
+// Intentos de una tarea con los datos del estudiante
+func (r *CuestionarioRepository) GetIntentosByTarea(ctx context.Context, tareaID uuid.UUID) ([]models.IntentoCuestionario, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?tarea_id=eq.%s&select=*,estudiante:estudiantes!estudiante_id(usuario_id,codigo_estudiante,seccion,usuario:usuarios!usuario_id(nombre_completo,email,avatar_url))&order=iniciado_at.asc",
+		config.AppConfig.SupabaseURL, tareaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener intentos: %w", err)
+	}
+
+	var intentos []models.IntentoCuestionario
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return nil, err
+	}
+
+	return intentos, nil
+}
+
+// Indica si algún estudiante ya inició el cuestionario de la tarea
+func (r *CuestionarioRepository) TieneIntentos(ctx context.Context, tareaID uuid.UUID) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?tarea_id=eq.%s&select=id&limit=1",
+		config.AppConfig.SupabaseURL, tareaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return false, fmt.Errorf("error al obtener intentos: %w", err)
+	}
+
+	var intentos []map[string]interface{}
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return false, err
+	}
+
+	return len(intentos) > 0, nil
+}
+
+// Intentos sin enviar cuyo tiempo venció antes de 'hasta'
+func (r *CuestionarioRepository) GetIntentosVencidos(ctx context.Context, hasta time.Time) ([]models.IntentoCuestionario, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?enviado_at=is.null&vence_at=lt.%s&order=vence_at.asc",
+		config.AppConfig.SupabaseURL, hasta.UTC().Format(time.RFC3339))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener intentos vencidos: %w", err)
+	}
+
+	var intentos []models.IntentoCuestionario
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return nil, err
+	}
+
+	return intentos, nil
+}
+
+// Guardar las respuestas de un intento que sigue abierto. Devuelve false si
+// el intento ya fue enviado.
+func (r *CuestionarioRepository) GuardarRespuestas(ctx context.Context, intentoID uuid.UUID, respuestas []models.RespuestaPregunta) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?id=eq.%s&enviado_at=is.null",
+		config.AppConfig.SupabaseURL, intentoID.String())
+
+	data := map[string]interface{}{"respuestas": respuestas}
+
+	respBody, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return false, fmt.Errorf("error al guardar respuestas: %w", err)
+	}
+
+	var intentos []models.IntentoCuestionario
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return false, err
+	}
+
+	return len(intentos) > 0, nil
+}
+
+// Cerrar el intento con su corrección. Solo uno de los que intenten cerrarlo
+// a la vez lo consigue (devuelve true).
+func (r *CuestionarioRepository) Finalizar(ctx context.Context, intento *models.IntentoCuestionario) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?id=eq.%s&enviado_at=is.null",
+		config.AppConfig.SupabaseURL, intento.ID.String())
+
+	data := map[string]interface{}{
+		"entrega_id":       intento.EntregaID,
+		"respuestas":       intento.Respuestas,
+		"resultado":        intento.Resultado,
+		"puntaje_obtenido": intento.PuntajeObtenido,
+		"enviado_at":       intento.EnviadoAt,
+	}
+
+	respBody, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return false, fmt.Errorf("error al enviar cuestionario: %w", err)
+	}
+
+	var intentos []models.IntentoCuestionario
+	if err := json.Unmarshal(respBody, &intentos); err != nil {
+		return false, err
+	}
+
+	return len(intentos) > 0, nil
+}
+
+// Guardar la revisión del docente sobre un intento enviado
+func (r *CuestionarioRepository) GuardarRevision(ctx context.Context, intento *models.IntentoCuestionario) error {
+	url := fmt.Sprintf("%s/rest/v1/intentos_cuestionario?id=eq.%s", config.AppConfig.SupabaseURL, intento.ID.String())
+
+	data := map[string]interface{}{
+		"resultado":        intento.Resultado,
+		"puntaje_obtenido": intento.PuntajeObtenido,
+		"revisado_por":     intento.RevisadoPor,
+		"revisado_at":      intento.RevisadoAt,
+	}
+
+	if _, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al guardar revisión: %w", err)
+	}
+
+	return nil
+}
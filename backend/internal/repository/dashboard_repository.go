@@ -276,7 +276,7 @@ func (r *DashboardRepository) GetEstudiantesPorSeccion(cicloID string) ([]byte,
 
 // GetMatriculasPorCurso obtiene matrículas agrupadas por curso
 func (r *DashboardRepository) GetMatriculasPorCurso(cicloID string) ([]byte, error) {
-	url := config.AppConfig.SupabaseURL + "/rest/v1/cursos?select=id,nombre,seccion,creditos,docente_id,docentes(usuarios(nombre_completo)),matriculas(id)"
+	url := config.AppConfig.SupabaseURL + "/rest/v1/cursos?select=id,nombre,seccion,creditos,capacidad,docente_id,docentes(usuarios(nombre_completo)),matriculas(id,estado),lista_espera(id)"
 
 	if cicloID != "" {
 		url += "&ciclo_id=eq." + cicloID
@@ -24,17 +24,7 @@ func (r *EntregaRepository) Create(ctx context.Context, entrega *models.Entrega)
 	url := fmt.Sprintf("%s/rest/v1/entregas", config.AppConfig.SupabaseURL)
 
 	// ✅ NO enviar el campo 'id', dejar que Supabase lo genere automáticamente
-	insertData := map[string]interface{}{
-		"tarea_id":              entrega.TareaID,
-		"estudiante_id":         entrega.EstudianteID,
-		"titulo":                entrega.Titulo,
-		"descripcion":           entrega.Descripcion,
-		"fecha_entrega":         entrega.FechaEntrega,
-		"entrega_tardia":        entrega.EntregaTardia,
-		"dias_retraso":          entrega.DiasRetraso,
-		"penalizacion_aplicada": entrega.PenalizacionAplicada,
-		"estado":                "entregada",
-	}
+	insertData := datosEntrega(entrega)
 
 	log.Printf("🔍 DEBUG - Insertando entrega SIN campo 'id'")
 
@@ -57,6 +47,43 @@ func (r *EntregaRepository) Create(ctx context.Context, entrega *models.Entrega)
 	return &entregas[0], nil
 }
 
+// CreateGrupal crea en una sola petición la fila de cada integrante del grupo
+func (r *EntregaRepository) CreateGrupal(ctx context.Context, entregas []models.Entrega) ([]models.Entrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/entregas", config.AppConfig.SupabaseURL)
+
+	filas := make([]map[string]interface{}, 0, len(entregas))
+	for i := range entregas {
+		filas = append(filas, datosEntrega(&entregas[i]))
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, filas, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear entrega grupal: %w", err)
+	}
+
+	var creadas []models.Entrega
+	if err := json.Unmarshal(respBody, &creadas); err != nil {
+		return nil, err
+	}
+
+	return creadas, nil
+}
+
+func datosEntrega(entrega *models.Entrega) map[string]interface{} {
+	return map[string]interface{}{
+		"tarea_id":              entrega.TareaID,
+		"estudiante_id":         entrega.EstudianteID,
+		"titulo":                entrega.Titulo,
+		"descripcion":           entrega.Descripcion,
+		"fecha_entrega":         entrega.FechaEntrega,
+		"entrega_tardia":        entrega.EntregaTardia,
+		"dias_retraso":          entrega.DiasRetraso,
+		"penalizacion_aplicada": entrega.PenalizacionAplicada,
+		"estado":                "entregada",
+		"grupo_id":              entrega.GrupoID,
+	}
+}
+
 // Obtener entrega por ID
 func (r *EntregaRepository) GetByID(ctx context.Context, entregaID uuid.UUID) (*models.Entrega, error) {
 	url := fmt.Sprintf("%s/rest/v1/entregas?id=eq.%s",
@@ -97,6 +124,28 @@ func (r *EntregaRepository) GetByTareaID(ctx context.Context, tareaID uuid.UUID)
 	return entregas, nil
 }
 
+// Obtener entregas de varias tareas (libro de calificaciones)
+func (r *EntregaRepository) GetByTareaIDs(ctx context.Context, tareaIDs []uuid.UUID) ([]models.Entrega, error) {
+	if len(tareaIDs) == 0 {
+		return []models.Entrega{}, nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/entregas?tarea_id=in.(%s)&order=fecha_entrega.desc",
+		config.AppConfig.SupabaseURL, unirIDs(tareaIDs))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener entregas: %w", err)
+	}
+
+	var entregas []models.Entrega
+	if err := json.Unmarshal(respBody, &entregas); err != nil {
+		return nil, err
+	}
+
+	return entregas, nil
+}
+
 // Obtener entrega por tarea y estudiante
 func (r *EntregaRepository) GetByTareaAndEstudiante(ctx context.Context, tareaID, estudianteID uuid.UUID) (*models.Entrega, error) {
 	url := fmt.Sprintf("%s/rest/v1/entregas?tarea_id=eq.%s&estudiante_id=eq.%s&select=*,estudiante:estudiantes!estudiante_id(usuario_id,codigo_estudiante,seccion,usuario:usuarios!usuario_id(nombre_completo,email,avatar_url))&order=fecha_entrega.desc",
@@ -119,6 +168,24 @@ func (r *EntregaRepository) GetByTareaAndEstudiante(ctx context.Context, tareaID
 	return &entregas[0], nil
 }
 
+// Filas de la entrega de un grupo, una por integrante
+func (r *EntregaRepository) GetByTareaAndGrupo(ctx context.Context, tareaID, grupoID uuid.UUID) ([]models.Entrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/entregas?tarea_id=eq.%s&grupo_id=eq.%s",
+		config.AppConfig.SupabaseURL, tareaID.String(), grupoID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener entrega del grupo: %w", err)
+	}
+
+	var entregas []models.Entrega
+	if err := json.Unmarshal(respBody, &entregas); err != nil {
+		return nil, err
+	}
+
+	return entregas, nil
+}
+
 // Agregar archivo a entrega
 func (r *EntregaRepository) AddArchivo(ctx context.Context, archivo *models.ArchivoEntrega) error {
 	url := fmt.Sprintf("%s/rest/v1/archivos_entrega", config.AppConfig.SupabaseURL)
@@ -158,6 +225,24 @@ func (r *EntregaRepository) GetArchivosByEntregaID(ctx context.Context, entregaI
 	return archivos, nil
 }
 
+// Archivos subidos por cualquier integrante a la entrega del grupo
+func (r *EntregaRepository) GetArchivosDeGrupo(ctx context.Context, tareaID, grupoID uuid.UUID) ([]models.ArchivoEntrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/archivos_entrega?select=*,entregas!inner(tarea_id,grupo_id)&entregas.tarea_id=eq.%s&entregas.grupo_id=eq.%s&order=uploaded_at.asc",
+		config.AppConfig.SupabaseURL, tareaID.String(), grupoID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener archivos: %w", err)
+	}
+
+	var archivos []models.ArchivoEntrega
+	if err := json.Unmarshal(respBody, &archivos); err != nil {
+		return nil, err
+	}
+
+	return archivos, nil
+}
+
 // ✅ NUEVO: Obtener archivo por ID
 func (r *EntregaRepository) GetArchivoByID(ctx context.Context, archivoID uuid.UUID) (*models.ArchivoEntrega, error) {
 	url := fmt.Sprintf("%s/rest/v1/archivos_entrega?id=eq.%s",
@@ -194,15 +279,20 @@ func (r *EntregaRepository) DeleteArchivo(ctx context.Context, archivoID uuid.UU
 	return nil
 }
 
-// Calificar entrega
-func (r *EntregaRepository) Calificar(ctx context.Context, entregaID uuid.UUID, calificacion float64, comentario string) error {
+// Calificar entrega guardando autor, origen y el nivel obtenido en cada
+// criterio de la rúbrica. El historial lo registra un trigger de la BD.
+func (r *EntregaRepository) GuardarCalificacion(ctx context.Context, entregaID uuid.UUID, c *models.NuevaCalificacion) error {
 	url := fmt.Sprintf("%s/rest/v1/entregas?id=eq.%s",
 		config.AppConfig.SupabaseURL, entregaID.String())
 
 	data := map[string]interface{}{
-		"calificacion":       calificacion,
-		"comentario_docente": comentario,
-		"estado":             "evaluada",
+		"calificacion":        c.Calificacion,
+		"comentario_docente":  c.Comentario,
+		"estado":              "evaluada",
+		"rubrica_evaluacion":  c.Rubrica,
+		"calificado_por":      c.CalificadoPor,
+		"origen_calificacion": c.Origen,
+		"calificacion_grupal": c.CalificacionGrupal,
 	}
 
 	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
@@ -213,6 +303,103 @@ func (r *EntregaRepository) Calificar(ctx context.Context, entregaID uuid.UUID,
 	return nil
 }
 
+// Guardar el ajuste individual de un integrante y recalcular su nota en una
+// sola transacción. Devuelve la entrega actualizada.
+func (r *EntregaRepository) AjustarCalificacionIndividual(ctx context.Context, entregaID uuid.UUID, ajuste float64, motivo *string, calificadoPor uuid.UUID) (*models.Entrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/rpc/ajustar_calificacion_individual", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"p_entrega_id":     entregaID,
+		"p_ajuste":         ajuste,
+		"p_motivo":         motivo,
+		"p_calificado_por": calificadoPor,
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar ajuste: %w", err)
+	}
+
+	var entregas []models.Entrega
+	if err := json.Unmarshal(respBody, &entregas); err != nil {
+		return nil, err
+	}
+	if len(entregas) == 0 {
+		return nil, fmt.Errorf("entrega no encontrada")
+	}
+
+	return &entregas[0], nil
+}
+
+// Calificar a todos los integrantes de un grupo en una sola transacción.
+// Devuelve las entregas con la nota de cada integrante.
+func (r *EntregaRepository) CalificarGrupo(ctx context.Context, tareaID, grupoID uuid.UUID, c *models.NuevaCalificacion) ([]models.Entrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/rpc/calificar_grupo", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"p_tarea_id":            tareaID,
+		"p_grupo_id":            grupoID,
+		"p_calificacion_grupal": c.Calificacion,
+		"p_comentario":          c.Comentario,
+		"p_rubrica":             c.Rubrica,
+		"p_calificado_por":      c.CalificadoPor,
+		"p_origen":              c.Origen,
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al calificar al grupo: %w", err)
+	}
+
+	var entregas []models.Entrega
+	if err := json.Unmarshal(respBody, &entregas); err != nil {
+		return nil, err
+	}
+
+	return entregas, nil
+}
+
+// Aplicar un lote de calificaciones en una sola transacción (todo o nada)
+func (r *EntregaRepository) ImportarCalificaciones(ctx context.Context, tareaID, calificadoPor uuid.UUID, lote []models.CalificacionImportada) (int, error) {
+	url := fmt.Sprintf("%s/rest/v1/rpc/importar_calificaciones", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"p_tarea_id":       tareaID,
+		"p_calificado_por": calificadoPor,
+		"p_filas":          lote,
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return 0, fmt.Errorf("error al importar calificaciones: %w", err)
+	}
+
+	var aplicadas int
+	if err := json.Unmarshal(respBody, &aplicadas); err != nil {
+		return 0, err
+	}
+
+	return aplicadas, nil
+}
+
+// Historial de cambios de calificación de una entrega (más antiguo primero)
+func (r *EntregaRepository) GetHistorial(ctx context.Context, entregaID uuid.UUID) ([]models.CambioCalificacion, error) {
+	url := fmt.Sprintf("%s/rest/v1/historial_calificaciones?entrega_id=eq.%s&select=*,usuario:usuarios!cambiado_por(nombre_completo)&order=created_at.asc",
+		config.AppConfig.SupabaseURL, entregaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener historial de calificaciones: %w", err)
+	}
+
+	var historial []models.CambioCalificacion
+	if err := json.Unmarshal(respBody, &historial); err != nil {
+		return nil, err
+	}
+
+	return historial, nil
+}
+
 // Actualizar entrega
 func (r *EntregaRepository) Update(ctx context.Context, entregaID uuid.UUID, req *models.CreateEntregaRequest) error {
 	url := fmt.Sprintf("%s/rest/v1/entregas?id=eq.%s",
@@ -231,6 +418,37 @@ func (r *EntregaRepository) Update(ctx context.Context, entregaID uuid.UUID, req
 	return nil
 }
 
+// Actualizar la entrega de un grupo (todas sus filas)
+func (r *EntregaRepository) UpdateGrupo(ctx context.Context, tareaID, grupoID uuid.UUID, req *models.CreateEntregaRequest) error {
+	url := fmt.Sprintf("%s/rest/v1/entregas?tarea_id=eq.%s&grupo_id=eq.%s",
+		config.AppConfig.SupabaseURL, tareaID.String(), grupoID.String())
+
+	data := map[string]interface{}{
+		"titulo":      req.Titulo,
+		"descripcion": req.Descripcion,
+	}
+
+	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al actualizar entrega: %w", err)
+	}
+
+	return nil
+}
+
+// Eliminar la entrega de un grupo (todas sus filas)
+func (r *EntregaRepository) DeleteGrupo(ctx context.Context, tareaID, grupoID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/entregas?tarea_id=eq.%s&grupo_id=eq.%s",
+		config.AppConfig.SupabaseURL, tareaID.String(), grupoID.String())
+
+	_, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al eliminar entrega: %w", err)
+	}
+
+	return nil
+}
+
 // Eliminar entrega
 func (r *EntregaRepository) Delete(ctx context.Context, entregaID uuid.UUID) error {
 	url := fmt.Sprintf("%s/rest/v1/entregas?id=eq.%s",
@@ -327,3 +545,96 @@ func (r *EntregaRepository) GetEstadisticasByTareaID(ctx context.Context, tareaI
 func (r *EntregaRepository) GetMiEntrega(ctx context.Context, tareaID, estudianteID uuid.UUID) (*models.Entrega, error) {
 	return r.GetByTareaAndEstudiante(ctx, tareaID, estudianteID)
 }
+
+// ==================== VERSIONES ====================
+
+// Guardar una versión por entrega (en las grupales, una por integrante). La BD
+// asigna el número de versión y rechaza cualquier modificación posterior.
+func (r *EntregaRepository) CrearVersiones(ctx context.Context, versiones []models.VersionEntrega) error {
+	if len(versiones) == 0 {
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/versiones_entrega", config.AppConfig.SupabaseURL)
+
+	filas := make([]map[string]interface{}, 0, len(versiones))
+	for _, v := range versiones {
+		filas = append(filas, map[string]interface{}{
+			"entrega_id":  v.EntregaID,
+			"envio":       v.Envio,
+			"motivo":      v.Motivo,
+			"titulo":      v.Titulo,
+			"descripcion": v.Descripcion,
+			"archivos":    v.Archivos,
+			"creado_por":  v.CreadoPor,
+		})
+	}
+
+	_, err := r.client.DoRequest("POST", url, filas, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al guardar versión de la entrega: %w", err)
+	}
+
+	return nil
+}
+
+// Versiones de una entrega, de la más antigua a la más reciente
+func (r *EntregaRepository) GetVersiones(ctx context.Context, entregaID uuid.UUID) ([]models.VersionEntrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/versiones_entrega?entrega_id=eq.%s&order=numero.asc",
+		config.AppConfig.SupabaseURL, entregaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener versiones: %w", err)
+	}
+
+	var versiones []models.VersionEntrega
+	if err := json.Unmarshal(respBody, &versiones); err != nil {
+		return nil, err
+	}
+
+	return versiones, nil
+}
+
+// Última versión de una entrega (nil si no tiene)
+func (r *EntregaRepository) GetUltimaVersion(ctx context.Context, entregaID uuid.UUID) (*models.VersionEntrega, error) {
+	url := fmt.Sprintf("%s/rest/v1/versiones_entrega?entrega_id=eq.%s&order=numero.desc&limit=1",
+		config.AppConfig.SupabaseURL, entregaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener versión: %w", err)
+	}
+
+	var versiones []models.VersionEntrega
+	if err := json.Unmarshal(respBody, &versiones); err != nil {
+		return nil, err
+	}
+	if len(versiones) == 0 {
+		return nil, nil
+	}
+
+	return &versiones[0], nil
+}
+
+// Versiones de varias entregas (vista del docente)
+func (r *EntregaRepository) GetVersionesByEntregaIDs(ctx context.Context, entregaIDs []uuid.UUID) ([]models.VersionEntrega, error) {
+	if len(entregaIDs) == 0 {
+		return []models.VersionEntrega{}, nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/versiones_entrega?entrega_id=in.(%s)&order=numero.asc",
+		config.AppConfig.SupabaseURL, unirIDs(entregaIDs))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener versiones: %w", err)
+	}
+
+	var versiones []models.VersionEntrega
+	if err := json.Unmarshal(respBody, &versiones); err != nil {
+		return nil, err
+	}
+
+	return versiones, nil
+}
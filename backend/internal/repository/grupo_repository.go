@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// selectGrupo trae el grupo con sus integrantes y los datos de cada estudiante
+const selectGrupo = "*,integrantes:grupo_integrantes(*,estudiante:estudiantes!estudiante_id(usuario_id,codigo_estudiante,seccion,usuario:usuarios!usuario_id(nombre_completo,email,avatar_url)))"
+
+type GrupoRepository struct {
+	client *SupabaseClient
+}
+
+func NewGrupoRepository(client *SupabaseClient) *GrupoRepository {
+	return &GrupoRepository{client: client}
+}
+
+// ==================== GRUPOS ====================
+
+// Crear grupo
+func (r *GrupoRepository) Create(ctx context.Context, data map[string]interface{}) (*models.Grupo, error) {
+	url := fmt.Sprintf("%s/rest/v1/grupos", config.AppConfig.SupabaseURL)
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear grupo: %w", err)
+	}
+
+	var grupos []models.Grupo
+	if err := json.Unmarshal(respBody, &grupos); err != nil {
+		return nil, err
+	}
+	if len(grupos) == 0 {
+		return nil, fmt.Errorf("no se pudo crear el grupo")
+	}
+
+	return &grupos[0], nil
+}
+
+// Obtener grupo con sus integrantes (nil si no existe)
+func (r *GrupoRepository) GetByID(ctx context.Context, grupoID uuid.UUID) (*models.Grupo, error) {
+	url := fmt.Sprintf("%s/rest/v1/grupos?id=eq.%s&select=%s",
+		config.AppConfig.SupabaseURL, grupoID.String(), selectGrupo)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener grupo: %w", err)
+	}
+
+	var grupos []models.Grupo
+	if err := json.Unmarshal(respBody, &grupos); err != nil {
+		return nil, err
+	}
+	if len(grupos) == 0 {
+		return nil, nil
+	}
+
+	return &grupos[0], nil
+}
+
+// Grupos de un curso con sus integrantes
+func (r *GrupoRepository) GetByCurso(ctx context.Context, cursoID uuid.UUID) ([]models.Grupo, error) {
+	url := fmt.Sprintf("%s/rest/v1/grupos?curso_id=eq.%s&select=%s&order=nombre.asc",
+		config.AppConfig.SupabaseURL, cursoID.String(), selectGrupo)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener grupos: %w", err)
+	}
+
+	var grupos []models.Grupo
+	if err := json.Unmarshal(respBody, &grupos); err != nil {
+		return nil, err
+	}
+
+	return grupos, nil
+}
+
+// Grupo del estudiante en el curso (nil si no pertenece a ninguno)
+func (r *GrupoRepository) GetDeEstudiante(ctx context.Context, cursoID, estudianteID uuid.UUID) (*models.Grupo, error) {
+	url := fmt.Sprintf("%s/rest/v1/grupo_integrantes?curso_id=eq.%s&estudiante_id=eq.%s&select=grupo_id",
+		config.AppConfig.SupabaseURL, cursoID.String(), estudianteID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener grupo del estudiante: %w", err)
+	}
+
+	var filas []struct {
+		GrupoID uuid.UUID `json:"grupo_id"`
+	}
+	if err := json.Unmarshal(respBody, &filas); err != nil {
+		return nil, err
+	}
+	if len(filas) == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(ctx, filas[0].GrupoID)
+}
+
+// Actualizar grupo
+func (r *GrupoRepository) Update(ctx context.Context, grupoID uuid.UUID, data map[string]interface{}) error {
+	url := fmt.Sprintf("%s/rest/v1/grupos?id=eq.%s", config.AppConfig.SupabaseURL, grupoID.String())
+
+	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al actualizar grupo: %w", err)
+	}
+
+	return nil
+}
+
+// Eliminar grupo (los integrantes se eliminan en cascada)
+func (r *GrupoRepository) Delete(ctx context.Context, grupoID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/grupos?id=eq.%s", config.AppConfig.SupabaseURL, grupoID.String())
+
+	_, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al eliminar grupo: %w", err)
+	}
+
+	return nil
+}
+
+// TieneEntregas indica si el grupo ya entregó alguna tarea
+func (r *GrupoRepository) TieneEntregas(ctx context.Context, grupoID uuid.UUID) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/entregas?grupo_id=eq.%s&select=id&limit=1",
+		config.AppConfig.SupabaseURL, grupoID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return false, fmt.Errorf("error al obtener entregas del grupo: %w", err)
+	}
+
+	var filas []struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &filas); err != nil {
+		return false, err
+	}
+
+	return len(filas) > 0, nil
+}
+
+// ==================== INTEGRANTES ====================
+
+// Inscribir estudiante. Un trigger rechaza el grupo lleno con 'grupo_lleno'.
+func (r *GrupoRepository) AgregarIntegrante(ctx context.Context, grupoID, cursoID, estudianteID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/grupo_integrantes", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"grupo_id":      grupoID,
+		"curso_id":      cursoID,
+		"estudiante_id": estudianteID,
+	}
+
+	_, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al agregar integrante: %w", err)
+	}
+
+	return nil
+}
+
+// Quitar estudiante del grupo
+func (r *GrupoRepository) QuitarIntegrante(ctx context.Context, grupoID, estudianteID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/grupo_integrantes?grupo_id=eq.%s&estudiante_id=eq.%s",
+		config.AppConfig.SupabaseURL, grupoID.String(), estudianteID.String())
+
+	_, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al quitar integrante: %w", err)
+	}
+
+	return nil
+}
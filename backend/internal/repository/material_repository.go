@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	neturl "net/url"
 	"recetario-backend/internal/config"
 	"recetario-backend/internal/models"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -101,6 +103,24 @@ func (r *MaterialRepository) GetByTemaID(ctx context.Context, temaID uuid.UUID)
 	return materiales, nil
 }
 
+// Contar materiales que usan el archivo (un curso clonado puede compartirlo)
+func (r *MaterialRepository) ContarPorURL(ctx context.Context, urlArchivo string) (int, error) {
+	url := fmt.Sprintf("%s/rest/v1/materiales?url_archivo=eq.%s&select=id",
+		config.AppConfig.SupabaseURL, neturl.QueryEscape(urlArchivo))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return 0, fmt.Errorf("error al contar materiales: %w", err)
+	}
+
+	var ids []map[string]interface{}
+	if err := json.Unmarshal(respBody, &ids); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
 // Marcar material como visto
 func (r *MaterialRepository) MarcarComoVisto(ctx context.Context, materialID, estudianteID uuid.UUID) error {
 	url := fmt.Sprintf("%s/rest/v1/material_visto", config.AppConfig.SupabaseURL)
@@ -118,6 +138,80 @@ func (r *MaterialRepository) MarcarComoVisto(ctx context.Context, materialID, es
 	return nil
 }
 
+// IDs de los materiales indicados que el estudiante ya vio
+func (r *MaterialRepository) GetVistosPorEstudiante(ctx context.Context, estudianteID uuid.UUID, materialIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	vistos := make(map[uuid.UUID]bool)
+	if len(materialIDs) == 0 {
+		return vistos, nil
+	}
+
+	ids := make([]string, len(materialIDs))
+	for i, id := range materialIDs {
+		ids[i] = id.String()
+	}
+	url := fmt.Sprintf("%s/rest/v1/material_visto?estudiante_id=eq.%s&material_id=in.(%s)&select=material_id",
+		config.AppConfig.SupabaseURL, estudianteID.String(), strings.Join(ids, ","))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener materiales vistos: %w", err)
+	}
+
+	var filas []models.MaterialVisto
+	if err := json.Unmarshal(respBody, &filas); err != nil {
+		return nil, err
+	}
+	for _, f := range filas {
+		vistos[f.MaterialID] = true
+	}
+
+	return vistos, nil
+}
+
+// Listar los materiales de todos los temas del curso
+func (r *MaterialRepository) GetByCursoID(ctx context.Context, cursoID string) ([]models.Material, error) {
+	url := fmt.Sprintf("%s/rest/v1/materiales?select=*,temas!inner(curso_id)&temas.curso_id=eq.%s&order=orden.asc",
+		config.AppConfig.SupabaseURL, cursoID)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener materiales del curso: %w", err)
+	}
+
+	var materiales []models.Material
+	if err := json.Unmarshal(respBody, &materiales); err != nil {
+		return nil, err
+	}
+
+	return materiales, nil
+}
+
+// Listar las vistas registradas de los materiales indicados
+func (r *MaterialRepository) GetVistosPorMateriales(ctx context.Context, materialIDs []uuid.UUID) ([]models.MaterialVisto, error) {
+	if len(materialIDs) == 0 {
+		return []models.MaterialVisto{}, nil
+	}
+
+	ids := make([]string, len(materialIDs))
+	for i, id := range materialIDs {
+		ids[i] = id.String()
+	}
+	url := fmt.Sprintf("%s/rest/v1/material_visto?material_id=in.(%s)&select=material_id,estudiante_id",
+		config.AppConfig.SupabaseURL, strings.Join(ids, ","))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener materiales vistos: %w", err)
+	}
+
+	var vistos []models.MaterialVisto
+	if err := json.Unmarshal(respBody, &vistos); err != nil {
+		return nil, err
+	}
+
+	return vistos, nil
+}
+
 // Eliminar material
 func (r *MaterialRepository) Delete(ctx context.Context, materialID uuid.UUID) error {
 	url := fmt.Sprintf("%s/rest/v1/materiales?id=eq.%s",
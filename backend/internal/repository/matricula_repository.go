@@ -23,6 +23,14 @@ func (r *matriculaRepository) CreateMatricula(data map[string]interface{}) ([]by
 	return r.client.DoRequest("POST", url, data, headers)
 }
 
+func (r *matriculaRepository) GetMatriculaByID(matriculaID string) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/matriculas?id=eq." + matriculaID
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
 func (r *matriculaRepository) GetMatriculasByCurso(cursoID string) ([]byte, error) {
 	// JOIN: matriculas -> estudiantes -> usuarios
 	// ✅ AGREGADO: observaciones, fecha_matricula
@@ -34,10 +42,34 @@ func (r *matriculaRepository) GetMatriculasByCurso(cursoID string) ([]byte, erro
 	return r.client.DoRequest("GET", url, nil, headers)
 }
 
+// GetMatriculasByCiclo trae las matrículas del ciclo con el ciclo actual del estudiante
+func (r *matriculaRepository) GetMatriculasByCiclo(cicloID string) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/matriculas?ciclo_id=eq." + cicloID +
+		"&select=id,estudiante_id,curso_id,ciclo_id,estado,nota_final,created_at," +
+		"estudiantes(usuario_id,codigo_estudiante,ciclo_actual,usuarios(nombre_completo))," +
+		"cursos(id,codigo,nombre,creditos,docente_id)"
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
+// GetHistorialEstudiante trae todas las matrículas del estudiante con el curso y el ciclo
+func (r *matriculaRepository) GetHistorialEstudiante(estudianteID string) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/matriculas?estudiante_id=eq." + estudianteID +
+		"&select=id,estudiante_id,curso_id,ciclo_id,estado,nota_final,created_at," +
+		"cursos(id,codigo,nombre,creditos),ciclos(id,nombre,fecha_inicio,fecha_fin)" +
+		"&order=created_at.asc"
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
 func (r *matriculaRepository) GetMatriculasByEstudiante(estudianteID string) ([]byte, error) {
 	// ✅ El * ya incluye observaciones y fecha_matricula automáticamente
 	url := config.AppConfig.SupabaseURL + "/rest/v1/matriculas?estudiante_id=eq." + estudianteID +
-		"&select=*,cursos(nombre),ciclos(nombre)"
+		"&select=*,cursos(nombre,codigo),ciclos(nombre)"
 
 	headers := r.client.GetAuthHeaders()
 
@@ -71,6 +103,50 @@ func (r *matriculaRepository) DeleteMatricula(matriculaID string) error {
 	return err
 }
 
+// ==================== CUPOS Y LISTA DE ESPERA ====================
+
+// MatricularConCupo matricula o encola en la lista de espera en una sola transacción
+func (r *matriculaRepository) MatricularConCupo(data map[string]interface{}) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/rpc/matricular_con_cupo"
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("POST", url, data, headers)
+}
+
+// PromoverListaEspera matricula a los primeros de la lista mientras haya cupo
+func (r *matriculaRepository) PromoverListaEspera(cursoID, cicloID string) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/rpc/promover_lista_espera"
+
+	data := map[string]interface{}{
+		"p_curso_id": cursoID,
+		"p_ciclo_id": cicloID,
+	}
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("POST", url, data, headers)
+}
+
+func (r *matriculaRepository) GetListaEspera(cursoID, cicloID string) ([]byte, error) {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/lista_espera?curso_id=eq." + cursoID +
+		"&ciclo_id=eq." + cicloID +
+		"&select=*,usuarios(nombre_completo,email,codigo)&order=created_at.asc,id.asc"
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
+func (r *matriculaRepository) DeleteListaEspera(id string) error {
+	url := config.AppConfig.SupabaseURL + "/rest/v1/lista_espera?id=eq." + id
+
+	headers := r.client.GetAuthHeaders()
+
+	_, err := r.client.DoRequest("DELETE", url, nil, headers)
+	return err
+}
+
 func (r *matriculaRepository) GetAllMatriculas() ([]byte, error) {
 	// Query CON datos anidados
 	// ✅ El * ya incluye observaciones y fecha_matricula automáticamente
@@ -3,20 +3,24 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	neturl "net/url"
+	"strings"
 	"time"
 
 	"recetario-backend/internal/config"
 	"recetario-backend/internal/models"
+	"recetario-backend/internal/realtime"
 
 	"github.com/google/uuid"
 )
 
 type NotificationRepository struct {
 	client *SupabaseClient
+	hub    *realtime.Hub // puede ser nil
 }
 
-func NewNotificationRepository(client *SupabaseClient) *NotificationRepository {
-	return &NotificationRepository{client: client}
+func NewNotificationRepository(client *SupabaseClient, hub *realtime.Hub) *NotificationRepository {
+	return &NotificationRepository{client: client, hub: hub}
 }
 
 // Crear notificación
@@ -48,19 +52,54 @@ func (r *NotificationRepository) CrearNotificacion(notif *models.Notificacion) e
 	if len(result) > 0 {
 		*notif = result[0]
 	}
+
+	// Avisar en tiempo real a los dispositivos conectados
+	r.hub.Publicar(notif.UsuarioID.String(), realtime.EventoNotificacion, notif)
+	r.publicarNoLeidas(notif.UsuarioID)
 	return nil
 }
 
-// Obtener notificaciones de un usuario
+// Publicar el contador de no leídas actualizado
+func (r *NotificationRepository) publicarNoLeidas(usuarioID uuid.UUID) {
+	if r.hub == nil || r.hub.Conexiones(usuarioID.String()) == 0 {
+		return
+	}
+
+	count, err := r.ContarNoLeidas(usuarioID)
+	if err != nil {
+		return
+	}
+	r.hub.Publicar(usuarioID.String(), realtime.EventoNoLeidas, map[string]int{"count": count})
+}
+
 // Obtener notificaciones de un usuario con información del remitente y receta
 func (r *NotificationRepository) ObtenerNotificacionesPorUsuario(usuarioID uuid.UUID) ([]models.NotificacionConInfo, error) {
+	return r.ListarNotificaciones(usuarioID, &models.FiltroNotificaciones{Limite: 50})
+}
+
+// Listar notificaciones con filtros, ordenadas de la más reciente a la más antigua
+func (r *NotificationRepository) ListarNotificaciones(usuarioID uuid.UUID, filtro *models.FiltroNotificaciones) ([]models.NotificacionConInfo, error) {
 	// ✅ CORREGIDO: Agregar select con JOIN para traer nombre del remitente y título de receta
 	url := fmt.Sprintf(
-		"%s/rest/v1/notificaciones?usuario_id=eq.%s&select=*,enviador:usuarios!enviado_por_id(nombre_completo),receta:portafolio!receta_id(titulo)&order=created_at.desc&limit=50",
+		"%s/rest/v1/notificaciones?usuario_id=eq.%s&archivada=eq.%t&select=*,enviador:usuarios!enviado_por_id(nombre_completo),receta:portafolio!receta_id(titulo)&order=created_at.desc,id.desc&limit=%d",
 		config.AppConfig.SupabaseURL,
 		usuarioID.String(),
+		filtro.Archivada,
+		filtro.Limite,
 	)
 
+	if filtro.Tipo != "" {
+		url += "&tipo=eq." + neturl.QueryEscape(filtro.Tipo)
+	}
+	if filtro.Leida != nil {
+		url += fmt.Sprintf("&leida=eq.%t", *filtro.Leida)
+	}
+	if filtro.AntesDe != nil && filtro.AntesDeID != nil {
+		ts := filtro.AntesDe.UTC().Format(time.RFC3339Nano)
+		cursor := fmt.Sprintf(`(created_at.lt."%s",and(created_at.eq."%s",id.lt.%s))`, ts, ts, filtro.AntesDeID.String())
+		url += "&or=" + neturl.QueryEscape(cursor)
+	}
+
 	resp, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
 	if err != nil {
 		return nil, err
@@ -76,6 +115,7 @@ func (r *NotificationRepository) ObtenerNotificacionesPorUsuario(usuarioID uuid.
 		RecetaID     *string                `json:"receta_id"`
 		EnviadoPorID *string                `json:"enviado_por_id"`
 		Leida        bool                   `json:"leida"`
+		Archivada    bool                   `json:"archivada"`
 		CreatedAt    string                 `json:"created_at"`
 		Enviador     map[string]interface{} `json:"enviador"`
 		Receta       map[string]interface{} `json:"receta"`
@@ -105,6 +145,7 @@ func (r *NotificationRepository) ObtenerNotificacionesPorUsuario(usuarioID uuid.
 		notif.Titulo = raw.Titulo
 		notif.Mensaje = raw.Mensaje
 		notif.Leida = raw.Leida
+		notif.Archivada = raw.Archivada
 
 		// Parsear RecetaID
 		if raw.RecetaID != nil {
@@ -145,14 +186,73 @@ func (r *NotificationRepository) ObtenerNotificacionesPorUsuario(usuarioID uuid.
 	return notificaciones, nil
 }
 
-// Marcar notificación como leída
-func (r *NotificationRepository) MarcarComoLeida(notificacionID uuid.UUID) error {
-	url := fmt.Sprintf("%s/rest/v1/notificaciones?id=eq.%s",
-		config.AppConfig.SupabaseURL, notificacionID.String())
+// Marcar notificación como leída (solo si pertenece al usuario)
+func (r *NotificationRepository) MarcarComoLeida(usuarioID, notificacionID uuid.UUID) (int, error) {
+	return r.actualizarPropias(usuarioID, []uuid.UUID{notificacionID}, map[string]interface{}{"leida": true})
+}
 
-	data := map[string]interface{}{"leida": true}
-	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
-	return err
+// Archivar o desarchivar notificaciones del usuario
+func (r *NotificationRepository) ActualizarArchivada(usuarioID uuid.UUID, ids []uuid.UUID, archivada bool) (int, error) {
+	return r.actualizarPropias(usuarioID, ids, map[string]interface{}{"archivada": archivada})
+}
+
+// Eliminar notificaciones del usuario
+func (r *NotificationRepository) EliminarNotificaciones(usuarioID uuid.UUID, ids []uuid.UUID) (int, error) {
+	url := fmt.Sprintf("%s/rest/v1/notificaciones?usuario_id=eq.%s&id=in.(%s)&select=id",
+		config.AppConfig.SupabaseURL, usuarioID.String(), unirIDs(ids))
+
+	resp, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return 0, err
+	}
+
+	var eliminadas []map[string]interface{}
+	json.Unmarshal(resp, &eliminadas)
+	if len(eliminadas) > 0 {
+		r.publicarNoLeidas(usuarioID)
+	}
+	return len(eliminadas), nil
+}
+
+// PATCH restringido a notificaciones del usuario; devuelve cuántas se modificaron
+func (r *NotificationRepository) actualizarPropias(usuarioID uuid.UUID, ids []uuid.UUID, data map[string]interface{}) (int, error) {
+	url := fmt.Sprintf("%s/rest/v1/notificaciones?usuario_id=eq.%s&id=in.(%s)&select=id",
+		config.AppConfig.SupabaseURL, usuarioID.String(), unirIDs(ids))
+
+	resp, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return 0, err
+	}
+
+	var actualizadas []map[string]interface{}
+	json.Unmarshal(resp, &actualizadas)
+	if len(actualizadas) > 0 {
+		r.publicarNoLeidas(usuarioID)
+	}
+	return len(actualizadas), nil
+}
+
+// Eliminar notificaciones leídas creadas antes de la fecha indicada
+func (r *NotificationRepository) PurgarLeidasAntesDe(fecha time.Time) (int, error) {
+	url := fmt.Sprintf("%s/rest/v1/notificaciones?leida=eq.true&created_at=lt.%s&select=id",
+		config.AppConfig.SupabaseURL, fecha.UTC().Format(time.RFC3339))
+
+	resp, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return 0, err
+	}
+
+	var eliminadas []map[string]interface{}
+	json.Unmarshal(resp, &eliminadas)
+	return len(eliminadas), nil
+}
+
+func unirIDs(ids []uuid.UUID) string {
+	partes := make([]string, len(ids))
+	for i, id := range ids {
+		partes[i] = id.String()
+	}
+	return strings.Join(partes, ",")
 }
 
 // Marcar todas las notificaciones de un usuario como leídas
@@ -162,12 +262,17 @@ func (r *NotificationRepository) MarcarTodasComoLeidas(usuarioID uuid.UUID) erro
 
 	data := map[string]interface{}{"leida": true}
 	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
-	return err
+	if err != nil {
+		return err
+	}
+
+	r.publicarNoLeidas(usuarioID)
+	return nil
 }
 
 // Contar notificaciones no leídas
 func (r *NotificationRepository) ContarNoLeidas(usuarioID uuid.UUID) (int, error) {
-	url := fmt.Sprintf("%s/rest/v1/notificaciones?usuario_id=eq.%s&leida=eq.false&select=id",
+	url := fmt.Sprintf("%s/rest/v1/notificaciones?usuario_id=eq.%s&leida=eq.false&archivada=eq.false&select=id",
 		config.AppConfig.SupabaseURL, usuarioID.String())
 
 	resp, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
@@ -248,3 +353,27 @@ func (r *NotificationRepository) DesactivarDispositivo(fcmToken string) error {
 	_, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders())
 	return err
 }
+
+// Registrar que se envió el recordatorio de una tarea a un estudiante.
+// Devuelve false si ya estaba registrado.
+func (r *NotificationRepository) RegistrarRecordatorio(tareaID, estudianteID uuid.UUID) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/recordatorios_entrega?on_conflict=tarea_id,estudiante_id",
+		config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"tarea_id":      tareaID.String(),
+		"estudiante_id": estudianteID.String(),
+	}
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=ignore-duplicates,return=representation"
+
+	resp, err := r.client.DoRequest("POST", url, data, headers)
+	if err != nil {
+		return false, err
+	}
+
+	var insertados []map[string]interface{}
+	json.Unmarshal(resp, &insertados)
+	return len(insertados) > 0, nil
+}
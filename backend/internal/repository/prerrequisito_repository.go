@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type PrerrequisitoRepository struct {
+	client *SupabaseClient
+}
+
+func NewPrerrequisitoRepository(client *SupabaseClient) *PrerrequisitoRepository {
+	return &PrerrequisitoRepository{client: client}
+}
+
+// Prerrequisitos de un curso (por código)
+func (r *PrerrequisitoRepository) GetByCursoCodigo(ctx context.Context, codigo string) ([]models.Prerrequisito, error) {
+	url := fmt.Sprintf("%s/rest/v1/prerrequisitos?curso_codigo=eq.%s&order=requisito_codigo.asc",
+		config.AppConfig.SupabaseURL, neturl.QueryEscape(codigo))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener prerrequisitos: %w", err)
+	}
+
+	var prerrequisitos []models.Prerrequisito
+	if err := json.Unmarshal(respBody, &prerrequisitos); err != nil {
+		return nil, err
+	}
+
+	return prerrequisitos, nil
+}
+
+// Crear prerrequisito
+func (r *PrerrequisitoRepository) Create(ctx context.Context, p *models.Prerrequisito) (*models.Prerrequisito, error) {
+	url := fmt.Sprintf("%s/rest/v1/prerrequisitos", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"curso_codigo":     p.CursoCodigo,
+		"requisito_codigo": p.RequisitoCodigo,
+		"nota_minima":      p.NotaMinima,
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear prerrequisito: %w", err)
+	}
+
+	var creados []models.Prerrequisito
+	if err := json.Unmarshal(respBody, &creados); err != nil {
+		return nil, err
+	}
+	if len(creados) == 0 {
+		return nil, fmt.Errorf("no se pudo crear el prerrequisito")
+	}
+
+	return &creados[0], nil
+}
+
+// Eliminar prerrequisito
+func (r *PrerrequisitoRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/prerrequisitos?id=eq.%s", config.AppConfig.SupabaseURL, id.String())
+
+	_, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return fmt.Errorf("error al eliminar prerrequisito: %w", err)
+	}
+	return nil
+}
+
+// Notas finales registradas en los cursos con esos códigos. Con estudianteID
+// vacío devuelve las de todos los estudiantes.
+func (r *PrerrequisitoRepository) GetNotasPorCodigo(ctx context.Context, codigos []string, estudianteID string) ([]models.NotaHistorica, error) {
+	if len(codigos) == 0 {
+		return nil, nil
+	}
+
+	url := fmt.Sprintf("%s/rest/v1/matriculas?select=estudiante_id,ciclo_id,nota_final,cursos!inner(codigo)&nota_final=not.is.null&cursos.codigo=in.%s",
+		config.AppConfig.SupabaseURL, neturl.QueryEscape("("+listaEntreComillas(codigos)+")"))
+	if estudianteID != "" {
+		url += "&estudiante_id=eq." + estudianteID
+	}
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener historial de notas: %w", err)
+	}
+
+	var notas []models.NotaHistorica
+	if err := json.Unmarshal(respBody, &notas); err != nil {
+		return nil, err
+	}
+
+	return notas, nil
+}
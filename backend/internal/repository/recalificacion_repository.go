@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RecalificacionRepository struct {
+	client *SupabaseClient
+}
+
+func NewRecalificacionRepository(client *SupabaseClient) *RecalificacionRepository {
+	return &RecalificacionRepository{client: client}
+}
+
+// Crear solicitud de recalificación (pendiente)
+func (r *RecalificacionRepository) Create(ctx context.Context, s *models.SolicitudRecalificacion) (*models.SolicitudRecalificacion, error) {
+	url := fmt.Sprintf("%s/rest/v1/solicitudes_recalificacion", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"entrega_id":            s.EntregaID,
+		"estudiante_id":         s.EstudianteID,
+		"justificacion":         s.Justificacion,
+		"estado":                models.RecalificacionPendiente,
+		"calificacion_anterior": s.CalificacionAnterior,
+	}
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear solicitud de recalificación: %w", err)
+	}
+
+	var solicitudes []models.SolicitudRecalificacion
+	if err := json.Unmarshal(respBody, &solicitudes); err != nil {
+		return nil, err
+	}
+	if len(solicitudes) == 0 {
+		return nil, fmt.Errorf("no se pudo crear la solicitud de recalificación")
+	}
+
+	return &solicitudes[0], nil
+}
+
+// Obtener solicitud por ID
+func (r *RecalificacionRepository) GetByID(ctx context.Context, solicitudID uuid.UUID) (*models.SolicitudRecalificacion, error) {
+	url := fmt.Sprintf("%s/rest/v1/solicitudes_recalificacion?id=eq.%s",
+		config.AppConfig.SupabaseURL, solicitudID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener solicitud de recalificación: %w", err)
+	}
+
+	var solicitudes []models.SolicitudRecalificacion
+	if err := json.Unmarshal(respBody, &solicitudes); err != nil {
+		return nil, err
+	}
+	if len(solicitudes) == 0 {
+		return nil, fmt.Errorf("solicitud de recalificación no encontrada")
+	}
+
+	return &solicitudes[0], nil
+}
+
+// Listar solicitudes de una entrega (más reciente primero)
+func (r *RecalificacionRepository) GetByEntregaID(ctx context.Context, entregaID uuid.UUID) ([]models.SolicitudRecalificacion, error) {
+	url := fmt.Sprintf("%s/rest/v1/solicitudes_recalificacion?entrega_id=eq.%s&order=created_at.desc",
+		config.AppConfig.SupabaseURL, entregaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener solicitudes de recalificación: %w", err)
+	}
+
+	var solicitudes []models.SolicitudRecalificacion
+	if err := json.Unmarshal(respBody, &solicitudes); err != nil {
+		return nil, err
+	}
+
+	return solicitudes, nil
+}
+
+// Resolver una solicitud pendiente. Devuelve false si ya había sido resuelta.
+func (r *RecalificacionRepository) Resolver(ctx context.Context, solicitudID uuid.UUID, estado string, calificacionNueva *float64, respuesta string, resueltaPor uuid.UUID) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/solicitudes_recalificacion?id=eq.%s&estado=eq.%s",
+		config.AppConfig.SupabaseURL, solicitudID.String(), models.RecalificacionPendiente)
+
+	data := map[string]interface{}{
+		"estado":             estado,
+		"calificacion_nueva": calificacionNueva,
+		"respuesta":          respuesta,
+		"resuelta_por":       resueltaPor,
+		"resuelta_at":        time.Now().UTC(),
+	}
+
+	respBody, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return false, fmt.Errorf("error al resolver solicitud de recalificación: %w", err)
+	}
+
+	var solicitudes []models.SolicitudRecalificacion
+	if err := json.Unmarshal(respBody, &solicitudes); err != nil {
+		return false, err
+	}
+
+	return len(solicitudes) > 0, nil
+}
+
+// Devolver una solicitud a pendiente (si falló aplicar la nueva nota)
+func (r *RecalificacionRepository) Reabrir(ctx context.Context, solicitudID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/solicitudes_recalificacion?id=eq.%s",
+		config.AppConfig.SupabaseURL, solicitudID.String())
+
+	data := map[string]interface{}{
+		"estado":             models.RecalificacionPendiente,
+		"calificacion_nueva": nil,
+		"respuesta":          nil,
+		"resuelta_por":       nil,
+		"resuelta_at":        nil,
+	}
+
+	if _, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al reabrir solicitud de recalificación: %w", err)
+	}
+
+	return nil
+}
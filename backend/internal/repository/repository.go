@@ -34,6 +34,10 @@ type UsuarioRepository interface {
 
 	// 🆕 AGREGAR ESTE MÉTODO
 	GetUsuariosRelacionadosPorCurso(userID string, userRol string) ([]byte, error)
+
+	// Usuarios existentes con alguno de los emails o códigos (importación masiva)
+	GetUsuariosPorEmailOCodigo(emails, codigos []string) ([]byte, error)
+	GetEstudiantesPorCodigo(codigos []string) ([]byte, error)
 }
 
 // ==================== CICLO REPOSITORY ====================
@@ -45,6 +49,7 @@ type CicloRepository interface {
 	UpdateCiclo(cicloID string, data map[string]interface{}) error
 	DeleteCiclo(cicloID string) error
 	CicloTieneCursos(cicloID string) (bool, error) // 👈 AGREGAR ESTA LÍNEA
+	CerrarCiclo(data map[string]interface{}) ([]byte, error)
 }
 
 // ==================== CURSO REPOSITORY ====================
@@ -64,9 +69,16 @@ type CursoRepository interface {
 type MatriculaRepository interface {
 	CreateMatricula(data map[string]interface{}) ([]byte, error)
 	GetAllMatriculas() ([]byte, error)
+	GetMatriculaByID(matriculaID string) ([]byte, error)
 	GetMatriculasByCurso(cursoID string) ([]byte, error)
 	GetMatriculasByEstudiante(estudianteID string) ([]byte, error)
 	CheckMatriculaExists(estudianteID, cursoID, cicloID string) ([]byte, error)
 	UpdateMatricula(matriculaID string, data map[string]interface{}) error
 	DeleteMatricula(matriculaID string) error
+	MatricularConCupo(data map[string]interface{}) ([]byte, error)
+	PromoverListaEspera(cursoID, cicloID string) ([]byte, error)
+	GetListaEspera(cursoID, cicloID string) ([]byte, error)
+	DeleteListaEspera(id string) error
+	GetMatriculasByCiclo(cicloID string) ([]byte, error)
+	GetHistorialEstudiante(estudianteID string) ([]byte, error)
 }
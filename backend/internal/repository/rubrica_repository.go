@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RubricaRepository struct {
+	client *SupabaseClient
+}
+
+func NewRubricaRepository(client *SupabaseClient) *RubricaRepository {
+	return &RubricaRepository{client: client}
+}
+
+// ==================== PLANTILLAS ====================
+
+// Crear rúbrica
+func (r *RubricaRepository) Create(ctx context.Context, data map[string]interface{}) (*models.Rubrica, error) {
+	url := fmt.Sprintf("%s/rest/v1/rubricas", config.AppConfig.SupabaseURL)
+
+	respBody, err := r.client.DoRequest("POST", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return nil, fmt.Errorf("error al crear rúbrica: %w", err)
+	}
+
+	var rubricas []models.Rubrica
+	if err := json.Unmarshal(respBody, &rubricas); err != nil {
+		return nil, err
+	}
+	if len(rubricas) == 0 {
+		return nil, fmt.Errorf("no se pudo crear la rúbrica")
+	}
+
+	return &rubricas[0], nil
+}
+
+// Obtener rúbrica por ID
+func (r *RubricaRepository) GetByID(ctx context.Context, rubricaID uuid.UUID) (*models.Rubrica, error) {
+	url := fmt.Sprintf("%s/rest/v1/rubricas?id=eq.%s", config.AppConfig.SupabaseURL, rubricaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener rúbrica: %w", err)
+	}
+
+	var rubricas []models.Rubrica
+	if err := json.Unmarshal(respBody, &rubricas); err != nil {
+		return nil, err
+	}
+	if len(rubricas) == 0 {
+		return nil, fmt.Errorf("rúbrica no encontrada")
+	}
+
+	return &rubricas[0], nil
+}
+
+// Listar rúbricas activas (todas las plantillas se comparten entre docentes)
+func (r *RubricaRepository) ListActivas(ctx context.Context) ([]models.Rubrica, error) {
+	url := fmt.Sprintf("%s/rest/v1/rubricas?activo=eq.true&order=nombre.asc", config.AppConfig.SupabaseURL)
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener rúbricas: %w", err)
+	}
+
+	var rubricas []models.Rubrica
+	if err := json.Unmarshal(respBody, &rubricas); err != nil {
+		return nil, err
+	}
+
+	return rubricas, nil
+}
+
+// Actualizar rúbrica
+func (r *RubricaRepository) Update(ctx context.Context, rubricaID uuid.UUID, data map[string]interface{}) error {
+	url := fmt.Sprintf("%s/rest/v1/rubricas?id=eq.%s", config.AppConfig.SupabaseURL, rubricaID.String())
+
+	data["updated_at"] = time.Now().UTC()
+
+	if _, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al actualizar rúbrica: %w", err)
+	}
+
+	return nil
+}
+
+// ==================== RÚBRICA DE TAREA ====================
+
+// Obtener la rúbrica asignada a una tarea (nil si no tiene)
+func (r *RubricaRepository) GetDeTarea(ctx context.Context, tareaID uuid.UUID) (*models.RubricaTarea, error) {
+	url := fmt.Sprintf("%s/rest/v1/tarea_rubricas?tarea_id=eq.%s", config.AppConfig.SupabaseURL, tareaID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener rúbrica de la tarea: %w", err)
+	}
+
+	var rubricas []models.RubricaTarea
+	if err := json.Unmarshal(respBody, &rubricas); err != nil {
+		return nil, err
+	}
+	if len(rubricas) == 0 {
+		return nil, nil
+	}
+
+	return &rubricas[0], nil
+}
+
+// Asignar (o reemplazar) la rúbrica de una tarea
+func (r *RubricaRepository) AsignarATarea(ctx context.Context, rubrica *models.RubricaTarea) error {
+	url := fmt.Sprintf("%s/rest/v1/tarea_rubricas?on_conflict=tarea_id", config.AppConfig.SupabaseURL)
+
+	data := map[string]interface{}{
+		"tarea_id":    rubrica.TareaID.String(),
+		"rubrica_id":  rubrica.RubricaID,
+		"nombre":      rubrica.Nombre,
+		"criterios":   rubrica.Criterios,
+		"asignada_at": time.Now().UTC(),
+	}
+
+	headers := r.client.GetAuthHeaders()
+	headers["Prefer"] = "resolution=merge-duplicates"
+
+	if _, err := r.client.DoRequest("POST", url, data, headers); err != nil {
+		return fmt.Errorf("error al asignar rúbrica: %w", err)
+	}
+
+	return nil
+}
+
+// Quitar la rúbrica de una tarea
+func (r *RubricaRepository) QuitarDeTarea(ctx context.Context, tareaID uuid.UUID) error {
+	url := fmt.Sprintf("%s/rest/v1/tarea_rubricas?tarea_id=eq.%s", config.AppConfig.SupabaseURL, tareaID.String())
+
+	if _, err := r.client.DoRequest("DELETE", url, nil, r.client.GetAuthHeaders()); err != nil {
+		return fmt.Errorf("error al quitar rúbrica: %w", err)
+	}
+
+	return nil
+}
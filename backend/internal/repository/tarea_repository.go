@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"recetario-backend/internal/config"
 	"recetario-backend/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -79,6 +80,42 @@ func (r *TareaRepository) GetByTemaID(ctx context.Context, temaID uuid.UUID) ([]
 	return tareas, nil
 }
 
+// Listar tareas por curso
+func (r *TareaRepository) GetByCursoID(ctx context.Context, cursoID uuid.UUID) ([]models.Tarea, error) {
+	url := fmt.Sprintf("%s/rest/v1/tareas?curso_id=eq.%s&order=fecha_limite.asc",
+		config.AppConfig.SupabaseURL, cursoID.String())
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tareas: %w", err)
+	}
+
+	var tareas []models.Tarea
+	if err := json.Unmarshal(respBody, &tareas); err != nil {
+		return nil, err
+	}
+
+	return tareas, nil
+}
+
+// Listar tareas activas cuya fecha límite cae en (desde, hasta]
+func (r *TareaRepository) GetProximasAVencer(ctx context.Context, desde, hasta time.Time) ([]models.Tarea, error) {
+	url := fmt.Sprintf("%s/rest/v1/tareas?activo=eq.true&and=(fecha_limite.gt.%s,fecha_limite.lte.%s)&order=fecha_limite.asc",
+		config.AppConfig.SupabaseURL, desde.UTC().Format(time.RFC3339), hasta.UTC().Format(time.RFC3339))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tareas: %w", err)
+	}
+
+	var tareas []models.Tarea
+	if err := json.Unmarshal(respBody, &tareas); err != nil {
+		return nil, err
+	}
+
+	return tareas, nil
+}
+
 // Actualizar tarea
 func (r *TareaRepository) Update(ctx context.Context, tareaID uuid.UUID, req *models.CreateTareaRequest) error {
 	url := fmt.Sprintf("%s/rest/v1/tareas?id=eq.%s",
@@ -92,6 +129,29 @@ func (r *TareaRepository) Update(ctx context.Context, tareaID uuid.UUID, req *mo
 	return nil
 }
 
+// Publicar las calificaciones de la tarea. Solo actualiza si aún no estaban
+// publicadas; devuelve false si otra petición se adelantó.
+func (r *TareaRepository) PublicarCalificaciones(ctx context.Context, tareaID uuid.UUID, fecha time.Time) (bool, error) {
+	url := fmt.Sprintf("%s/rest/v1/tareas?id=eq.%s&calificaciones_publicadas_at=is.null",
+		config.AppConfig.SupabaseURL, tareaID.String())
+
+	data := map[string]interface{}{
+		"calificaciones_publicadas_at": fecha.UTC().Format(time.RFC3339),
+	}
+
+	respBody, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return false, fmt.Errorf("error al publicar calificaciones: %w", err)
+	}
+
+	var tareas []models.Tarea
+	if err := json.Unmarshal(respBody, &tareas); err != nil {
+		return false, err
+	}
+
+	return len(tareas) > 0, nil
+}
+
 // Eliminar tarea
 func (r *TareaRepository) Delete(ctx context.Context, tareaID uuid.UUID) error {
 	url := fmt.Sprintf("%s/rest/v1/tareas?id=eq.%s",
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"time"
 )
 
 type TemaRepository struct {
@@ -93,3 +96,43 @@ func (r *TemaRepository) GetMaterialesByTemaID(temaID string) ([]byte, error) {
 
 	return r.client.DoRequest("GET", url, nil, headers)
 }
+
+// Temas activos con aviso pendiente cuya fecha de desbloqueo ya pasó
+func (r *TemaRepository) GetDesbloqueosPorNotificar(hasta time.Time) ([]models.Tema, error) {
+	url := fmt.Sprintf("%s/rest/v1/temas?notificar_desbloqueo=is.true&desbloqueo_notificado_at=is.null"+
+		"&activo=is.true&fecha_desbloqueo=lte.%s&order=fecha_desbloqueo.asc",
+		config.AppConfig.SupabaseURL, hasta.UTC().Format(time.RFC3339))
+
+	respBody, err := r.client.DoRequest("GET", url, nil, r.client.GetAuthHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener temas por desbloquear: %w", err)
+	}
+
+	var temas []models.Tema
+	if err := json.Unmarshal(respBody, &temas); err != nil {
+		return nil, err
+	}
+
+	return temas, nil
+}
+
+// Marcar el aviso de desbloqueo como enviado. Devuelve false si otra
+// ejecución ya lo había marcado.
+func (r *TemaRepository) MarcarDesbloqueoNotificado(temaID string) (bool, error) {
+	url := config.AppConfig.SupabaseURL +
+		"/rest/v1/temas?id=eq." + temaID + "&desbloqueo_notificado_at=is.null"
+
+	data := map[string]interface{}{"desbloqueo_notificado_at": time.Now().UTC()}
+
+	respBody, err := r.client.DoRequest("PATCH", url, data, r.client.GetAuthHeadersWithPrefer())
+	if err != nil {
+		return false, fmt.Errorf("error al marcar aviso de desbloqueo: %w", err)
+	}
+
+	var temas []models.Tema
+	if err := json.Unmarshal(respBody, &temas); err != nil {
+		return false, err
+	}
+
+	return len(temas) > 0, nil
+}
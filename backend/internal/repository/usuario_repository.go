@@ -3,6 +3,7 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
+	neturl "net/url"
 	"recetario-backend/internal/config"
 	"strings"
 )
@@ -417,6 +418,52 @@ func (r *usuarioRepository) getUsuariosParaDocente(userID string, headers map[st
 		nil, headers)
 }
 
+// ==================== IMPORTACIÓN MASIVA ====================
+
+func (r *usuarioRepository) GetUsuariosPorEmailOCodigo(emails, codigos []string) ([]byte, error) {
+	var filtros []string
+	if len(emails) > 0 {
+		filtros = append(filtros, "email.in.("+listaEntreComillas(emails)+")")
+	}
+	if len(codigos) > 0 {
+		filtros = append(filtros, "codigo.in.("+listaEntreComillas(codigos)+")")
+	}
+	if len(filtros) == 0 {
+		return []byte("[]"), nil
+	}
+
+	url := config.AppConfig.SupabaseURL + "/rest/v1/usuarios?select=id,email,codigo" +
+		"&or=" + neturl.QueryEscape("("+strings.Join(filtros, ",")+")")
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
+// GetEstudiantesPorCodigo resuelve códigos de estudiante a su usuario
+func (r *usuarioRepository) GetEstudiantesPorCodigo(codigos []string) ([]byte, error) {
+	if len(codigos) == 0 {
+		return []byte("[]"), nil
+	}
+
+	url := config.AppConfig.SupabaseURL + "/rest/v1/estudiantes?select=usuario_id,codigo_estudiante," +
+		"usuarios!inner(nombre_completo,activo)" +
+		"&codigo_estudiante=in." + neturl.QueryEscape("("+listaEntreComillas(codigos)+")")
+
+	headers := r.client.GetAuthHeaders()
+
+	return r.client.DoRequest("GET", url, nil, headers)
+}
+
+// listaEntreComillas arma la lista de un filtro in.(...) de PostgREST
+func listaEntreComillas(valores []string) string {
+	partes := make([]string, len(valores))
+	for i, v := range valores {
+		partes[i] = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return strings.Join(partes, ",")
+}
+
 // ==================== ✅ OBTENER TODOS LOS DOCENTES (CORREGIDO) ====================
 
 func (r *usuarioRepository) GetDocentes() ([]byte, error) {
@@ -24,6 +24,15 @@ func SetupRoutes(
 	usuarioHandler *handlers.UsuarioHandler,
 	horarioHandler *handlers.HorarioHandler, // ✅ HORARIO
 	dashboardHandler *handlers.DashboardHandler, // ✅ DASHBOARD
+	calendarioHandler *handlers.CalendarioHandler,
+	calificacionesHandler *handlers.CalificacionesHandler,
+	rubricaHandler *handlers.RubricaHandler,
+	recalificacionHandler *handlers.RecalificacionHandler,
+	asistenciaHandler *handlers.AsistenciaHandler,
+	progresoHandler *handlers.ProgresoHandler,
+	historialHandler *handlers.HistorialHandler,
+	cuestionarioHandler *handlers.CuestionarioHandler,
+	grupoHandler *handlers.GrupoHandler,
 ) {
 	api := app.Group("/api")
 
@@ -50,12 +59,15 @@ func SetupRoutes(
 
 	// ✅ DASHBOARD - NUEVA RUTA
 	admin.Get("/dashboard/stats", dashboardHandler.ObtenerEstadisticas)
+	admin.Get("/notificaciones/metricas", middleware.RequireRole("administrador"), notificationHandler.ObtenerMetricasPush)
 
 	admin.Post("/crear-usuario", adminHandler.CrearUsuario)
+	admin.Post("/usuarios/importar", middleware.RequireRole("administrador"), adminHandler.ImportarUsuarios)
 	admin.Get("/usuarios", adminHandler.ListarUsuarios)
 	admin.Get("/usuarios/:id", adminHandler.ObtenerUsuarioPorID)
 	admin.Put("/usuarios/:id", adminHandler.EditarUsuario)
 	admin.Delete("/usuarios/:id", adminHandler.EliminarUsuario)
+	admin.Post("/usuarios/:id/restablecer-password", middleware.RequireRole("administrador"), adminHandler.RestablecerPassword)
 
 	// ✅ DOCENTES - NUEVA RUTA
 	admin.Get("/docentes", adminHandler.GetDocentes)
@@ -68,6 +80,7 @@ func SetupRoutes(
 	admin.Delete("/ciclos/:id", cicloHandler.EliminarCiclo)
 	admin.Post("/ciclos/:id/activar", cicloHandler.ActivarCiclo)
 	admin.Post("/ciclos/:id/desactivar", cicloHandler.DesactivarCiclo)
+	admin.Post("/ciclos/:id/cerrar", middleware.RequireRole("administrador"), cicloHandler.CerrarCiclo)
 
 	admin.Post("/cursos", cursoHandler.CrearCurso)
 	admin.Get("/cursos", cursoHandler.ListarCursos)
@@ -76,10 +89,18 @@ func SetupRoutes(
 	admin.Delete("/cursos/:id", cursoHandler.EliminarCurso)
 	admin.Post("/cursos/:id/activar", cursoHandler.ActivarCurso)
 	admin.Post("/cursos/:id/desactivar", cursoHandler.DesactivarCurso)
+	admin.Post("/cursos/:id/clonar", middleware.RequireRole("administrador"), cursoHandler.ClonarCurso)
+	admin.Get("/cursos/:id/lista-espera", middleware.RequireRole("administrador"), matriculaHandler.ListarListaEspera)
+	admin.Post("/cursos/:id/lista-espera/promover", middleware.RequireRole("administrador"), matriculaHandler.PromoverListaEspera)
+	admin.Delete("/lista-espera/:id", middleware.RequireRole("administrador"), matriculaHandler.QuitarDeListaEspera)
+	admin.Get("/cursos/:id/prerrequisitos", middleware.RequireRole("administrador"), matriculaHandler.ListarPrerrequisitos)
+	admin.Post("/cursos/:id/prerrequisitos", middleware.RequireRole("administrador"), matriculaHandler.AgregarPrerrequisito)
+	admin.Delete("/prerrequisitos/:id", middleware.RequireRole("administrador"), matriculaHandler.EliminarPrerrequisito)
 
 	admin.Get("/matriculas", matriculaHandler.ListarTodasLasMatriculas)
 	admin.Post("/matriculas", matriculaHandler.CrearMatricula)
 	admin.Post("/matriculas/masiva", matriculaHandler.CrearMatriculaMasiva)
+	admin.Post("/matriculas/importar", middleware.RequireRole("administrador"), matriculaHandler.ImportarMatriculas)
 	admin.Get("/matriculas/curso/:curso_id", matriculaHandler.ListarMatriculasPorCurso)
 	admin.Get("/matriculas/estudiante/:estudiante_id", matriculaHandler.ListarMatriculasPorEstudiante)
 	admin.Get("/matriculas/disponibles", matriculaHandler.ListarEstudiantesDisponibles)
@@ -96,12 +117,52 @@ func SetupRoutes(
 	estudiantes.Use(middleware.AuthRequired)
 
 	estudiantes.Get("/:estudiante_id/cursos", cursoHandler.ListarCursosPorEstudiante)
+	// Historial académico (administrador o el propio estudiante)
+	estudiantes.Get("/:estudiante_id/historial", middleware.RequireRole("administrador", "estudiante"), historialHandler.ObtenerHistorial)
 
 	// ==================== CURSOS ====================
 	cursos := api.Group("/cursos")
 	cursos.Use(middleware.AuthRequired)
 
 	cursos.Get("/:id/temas", temaHandler.ListarTemasPorCurso)
+	cursos.Post("/:id/anuncios", middleware.RequireRole("docente", "administrador"), notificationHandler.EnviarAnuncioCurso)
+
+	// Libro de calificaciones
+	cursos.Get("/:id/calificaciones", middleware.RequireRole("docente", "administrador"), calificacionesHandler.ObtenerLibro)
+	cursos.Get("/:id/calificaciones/mias", middleware.RequireRole("estudiante"), calificacionesHandler.ObtenerMiFila)
+	cursos.Get("/:id/calificaciones/esquema", middleware.RequireRole("docente", "administrador"), calificacionesHandler.ObtenerEsquema)
+	cursos.Put("/:id/calificaciones/esquema", middleware.RequireRole("docente", "administrador"), calificacionesHandler.GuardarEsquema)
+	cursos.Post("/:id/calificaciones/recalcular", middleware.RequireRole("docente", "administrador"), calificacionesHandler.Recalcular)
+
+	// Progreso de los estudiantes
+	cursos.Get("/:id/progreso", middleware.RequireRole("docente", "administrador"), progresoHandler.ObtenerProgresoCurso)
+	cursos.Get("/:id/progreso/mio", middleware.RequireRole("estudiante"), progresoHandler.ObtenerMiProgreso)
+
+	// Banco de preguntas para cuestionarios
+	cursos.Get("/:id/preguntas", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.ListarPreguntas)
+	cursos.Post("/:id/preguntas", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.CrearPregunta)
+
+	// Grupos para tareas grupales
+	cursos.Get("/:id/grupos", grupoHandler.ListarGrupos)
+	cursos.Get("/:id/grupos/mio", middleware.RequireRole("estudiante"), grupoHandler.MiGrupo)
+	cursos.Post("/:id/grupos", middleware.RequireRole("docente", "administrador"), grupoHandler.CrearGrupo)
+
+	// Sesiones de clase y asistencia
+	cursos.Get("/:id/sesiones", middleware.RequireRole("docente", "administrador"), asistenciaHandler.ListarSesiones)
+	cursos.Post("/:id/sesiones", middleware.RequireRole("docente", "administrador"), asistenciaHandler.CrearSesion)
+	cursos.Post("/:id/sesiones/generar", middleware.RequireRole("docente", "administrador"), asistenciaHandler.GenerarSesiones)
+	cursos.Get("/:id/asistencia", middleware.RequireRole("docente", "administrador"), asistenciaHandler.ResumenCurso)
+	cursos.Get("/:id/asistencia/mia", middleware.RequireRole("estudiante"), asistenciaHandler.MiAsistencia)
+	cursos.Get("/:id/asistencia/export", middleware.RequireRole("docente", "administrador"), asistenciaHandler.ExportarExcel)
+
+	// ==================== SESIONES DE CLASE ====================
+	sesiones := api.Group("/sesiones")
+	sesiones.Use(middleware.AuthRequired)
+
+	sesiones.Delete("/:id", middleware.RequireRole("docente", "administrador"), asistenciaHandler.EliminarSesion)
+	sesiones.Get("/:id/asistencia", middleware.RequireRole("docente", "administrador"), asistenciaHandler.ObtenerLista)
+	sesiones.Put("/:id/asistencia", middleware.RequireRole("docente", "administrador"), asistenciaHandler.MarcarAsistencia)
+	sesiones.Post("/:id/asistencia/:matricula_id/justificacion", asistenciaHandler.SubirJustificacion)
 
 	// ==================== ✅ HORARIO ====================
 	horario := api.Group("/horario")
@@ -110,6 +171,16 @@ func SetupRoutes(
 	horario.Get("/docente/:docente_id", horarioHandler.ObtenerHorarioDocente)
 	horario.Get("/estudiante/:estudiante_id", horarioHandler.ObtenerHorarioEstudiante) // ✅ NUEVA LÍNEA
 
+	// ==================== CALENDARIO (iCal) ====================
+	// Feed .ics autenticado por el token de la URL (antes del grupo protegido)
+	api.Get("/calendario/:token.ics", calendarioHandler.Feed)
+
+	calendario := api.Group("/calendario")
+	calendario.Use(middleware.AuthRequired)
+
+	calendario.Get("/enlace", calendarioHandler.ObtenerEnlace)
+	calendario.Post("/enlace/regenerar", calendarioHandler.RegenerarEnlace)
+
 	// ==================== TEMAS ====================
 	temas := api.Group("/temas")
 	temas.Use(middleware.AuthRequired)
@@ -120,8 +191,8 @@ func SetupRoutes(
 	temas.Put("/:id", temaHandler.ActualizarTema)
 	temas.Delete("/:id", temaHandler.EliminarTema)
 
-	temas.Get("/:id/materiales", materialHandler.ListarMaterialesPorTema)
-	temas.Get("/:id/tareas", tareaHandler.ListarTareasPorTema)
+	temas.Get("/:id/materiales", temaHandler.RequerirDesbloqueo, materialHandler.ListarMaterialesPorTema)
+	temas.Get("/:id/tareas", temaHandler.RequerirDesbloqueo, tareaHandler.ListarTareasPorTema)
 
 	// ==================== MATERIALES ====================
 	materiales := api.Group("/materiales")
@@ -144,6 +215,56 @@ func SetupRoutes(
 
 	tareas.Get("/:id/entregas", tareaHandler.GetEntregasPorTarea)
 	tareas.Get("/:id/mi-entrega", entregaHandler.ObtenerMiEntrega)
+	tareas.Post("/:id/calificaciones/publicar", middleware.RequireRole("docente", "administrador"), tareaHandler.PublicarCalificaciones)
+	tareas.Get("/:id/calificaciones/plantilla", middleware.RequireRole("docente", "administrador"), tareaHandler.DescargarPlantillaCalificaciones)
+	tareas.Post("/:id/calificaciones/importar", middleware.RequireRole("docente", "administrador"), tareaHandler.ImportarCalificaciones)
+
+	tareas.Get("/:id/rubrica", rubricaHandler.ObtenerRubricaDeTarea)
+	tareas.Put("/:id/rubrica", middleware.RequireRole("docente", "administrador"), rubricaHandler.AsignarRubrica)
+	tareas.Delete("/:id/rubrica", middleware.RequireRole("docente", "administrador"), rubricaHandler.QuitarRubrica)
+
+	// Cuestionario con corrección automática
+	tareas.Get("/:id/cuestionario", cuestionarioHandler.ObtenerCuestionario)
+	tareas.Put("/:id/cuestionario", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.ConfigurarCuestionario)
+	tareas.Delete("/:id/cuestionario", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.QuitarCuestionario)
+	tareas.Post("/:id/cuestionario/iniciar", middleware.RequireRole("estudiante"), cuestionarioHandler.IniciarIntento)
+	tareas.Put("/:id/cuestionario/respuestas", middleware.RequireRole("estudiante"), cuestionarioHandler.GuardarRespuestas)
+	tareas.Post("/:id/cuestionario/enviar", middleware.RequireRole("estudiante"), cuestionarioHandler.EnviarIntento)
+	tareas.Get("/:id/cuestionario/intentos", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.ListarIntentos)
+
+	// ==================== CUESTIONARIOS ====================
+	preguntas := api.Group("/preguntas")
+	preguntas.Use(middleware.AuthRequired, middleware.RequireRole("docente", "administrador"))
+
+	preguntas.Put("/:id", cuestionarioHandler.ActualizarPregunta)
+	preguntas.Delete("/:id", cuestionarioHandler.EliminarPregunta)
+
+	intentos := api.Group("/intentos")
+	intentos.Use(middleware.AuthRequired)
+
+	intentos.Get("/:id", cuestionarioHandler.ObtenerIntento)
+	intentos.Put("/:id/revision", middleware.RequireRole("docente", "administrador"), cuestionarioHandler.RevisarIntento)
+
+	// ==================== GRUPOS ====================
+	grupos := api.Group("/grupos")
+	grupos.Use(middleware.AuthRequired)
+
+	grupos.Put("/:id", middleware.RequireRole("docente", "administrador"), grupoHandler.ActualizarGrupo)
+	grupos.Delete("/:id", middleware.RequireRole("docente", "administrador"), grupoHandler.EliminarGrupo)
+	grupos.Post("/:id/integrantes", middleware.RequireRole("docente", "administrador"), grupoHandler.AgregarIntegrante)
+	grupos.Delete("/:id/integrantes/:estudiante_id", middleware.RequireRole("docente", "administrador"), grupoHandler.QuitarIntegrante)
+	grupos.Post("/:id/unirse", middleware.RequireRole("estudiante"), grupoHandler.Unirse)
+	grupos.Delete("/:id/unirse", middleware.RequireRole("estudiante"), grupoHandler.Salir)
+
+	// ==================== RÚBRICAS ====================
+	rubricas := api.Group("/rubricas")
+	rubricas.Use(middleware.AuthRequired, middleware.RequireRole("docente", "administrador"))
+
+	rubricas.Post("/", rubricaHandler.CrearRubrica)
+	rubricas.Get("/", rubricaHandler.ListarRubricas)
+	rubricas.Get("/:id", rubricaHandler.ObtenerRubrica)
+	rubricas.Put("/:id", rubricaHandler.ActualizarRubrica)
+	rubricas.Delete("/:id", rubricaHandler.EliminarRubrica)
 
 	// ==================== ENTREGAS ====================
 	entregas := api.Group("/entregas")
@@ -157,6 +278,15 @@ func SetupRoutes(
 	entregas.Post("/:id/archivos", entregaHandler.SubirArchivoEntrega)
 	entregas.Delete("/archivos/:archivoId", entregaHandler.EliminarArchivoEntrega)
 	entregas.Put("/:id/calificar", entregaHandler.CalificarEntrega)
+	entregas.Put("/:id/ajuste", middleware.RequireRole("docente", "administrador"), entregaHandler.AjustarCalificacionIndividual)
+	entregas.Get("/:id/versiones", entregaHandler.ListarVersiones)
+	entregas.Get("/:id/versiones/diferencias", entregaHandler.CompararVersiones)
+
+	// Historial de notas y solicitudes de recalificación
+	entregas.Get("/:id/historial", recalificacionHandler.ObtenerHistorial)
+	entregas.Get("/:id/recalificaciones", recalificacionHandler.Listar)
+	entregas.Post("/:id/recalificaciones", middleware.RequireRole("estudiante"), recalificacionHandler.Solicitar)
+	entregas.Put("/:id/recalificaciones/:solicitudId", middleware.RequireRole("docente", "administrador"), recalificacionHandler.Resolver)
 
 	// ==================== ✅ CATEGORÍAS (PÚBLICO) ====================
 	categorias := api.Group("/categorias")
@@ -188,18 +318,33 @@ func SetupRoutes(
 	portafolio.Get("/:id/comentarios", portafolioHandler.ObtenerComentarios)
 
 	// ==================== ✅ NOTIFICACIONES ====================
+	// Stream SSE (antes del grupo para aceptar el ticket por query)
+	api.Get("/notificaciones/stream", notificationHandler.AutenticarStream, notificationHandler.Stream)
+
 	notificaciones := api.Group("/notificaciones")
 	notificaciones.Use(middleware.AuthRequired)
 
+	// Ticket de un solo uso para abrir el stream
+	notificaciones.Post("/stream/ticket", notificationHandler.EmitirTicketStream)
+
 	// Compartir recetas
 	notificaciones.Post("/compartir-receta", notificationHandler.CompartirReceta)
 
 	// Mis notificaciones
+	notificaciones.Get("/", notificationHandler.ListarBandeja)
 	notificaciones.Get("/mis-notificaciones", notificationHandler.ObtenerMisNotificaciones)
 	notificaciones.Patch("/:id/leer", notificationHandler.MarcarComoLeida)
 	notificaciones.Patch("/leer-todas", notificationHandler.MarcarTodasComoLeidas)
 	notificaciones.Get("/no-leidas/count", notificationHandler.ContarNoLeidas)
 
+	// Archivar / eliminar (individual y en bloque)
+	notificaciones.Patch("/:id/archivar", notificationHandler.Archivar)
+	notificaciones.Patch("/:id/desarchivar", notificationHandler.Desarchivar)
+	notificaciones.Delete("/:id", notificationHandler.Eliminar)
+	notificaciones.Post("/archivar", notificationHandler.ArchivarVarias)
+	notificaciones.Post("/eliminar", notificationHandler.EliminarVarias)
+
 	// Registrar dispositivo FCM
 	notificaciones.Post("/registrar-dispositivo", notificationHandler.RegistrarDispositivo)
+	notificaciones.Post("/desactivar-dispositivo", notificationHandler.DesactivarDispositivo)
 }
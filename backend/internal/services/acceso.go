@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+)
+
+// Errores de acceso compartidos por los servicios académicos
+var (
+	ErrCursoNoEncontrado = errors.New("curso no encontrado")
+	ErrSinAccesoCurso    = errors.New("no tienes acceso a este curso")
+	ErrSinPermiso        = errors.New("no tienes permiso para realizar esta acción")
+)
+
+// obtenerCurso busca un curso por ID
+func obtenerCurso(cursoRepo repository.CursoRepository, cursoID string) (*models.Curso, error) {
+	respBody, err := cursoRepo.GetCursoByID(cursoID)
+	if err != nil {
+		return nil, ErrCursoNoEncontrado
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil || len(cursos) == 0 {
+		return nil, ErrCursoNoEncontrado
+	}
+
+	return &cursos[0], nil
+}
+
+// validarDocenteOAdmin permite el acceso al administrador y al docente del curso
+func validarDocenteOAdmin(cursoRepo repository.CursoRepository, cursoID, usuarioID, rol string) (*models.Curso, error) {
+	curso, err := obtenerCurso(cursoRepo, cursoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rol != "administrador" && curso.DocenteID != usuarioID {
+		return nil, ErrSinAccesoCurso
+	}
+
+	return curso, nil
+}
+
+// verificarMatricula exige que el estudiante tenga matrícula activa en el curso
+func verificarMatricula(matriculaRepo repository.MatriculaRepository, cursoID, estudianteID string) error {
+	respBody, err := matriculaRepo.GetMatriculasByCurso(cursoID)
+	if err != nil {
+		return fmt.Errorf("error al obtener matrículas: %w", err)
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return fmt.Errorf("error al parsear matrículas")
+	}
+
+	for _, m := range matriculas {
+		if m.EstudianteID == estudianteID && m.Estado == "activo" {
+			return nil
+		}
+	}
+	return ErrSinAccesoCurso
+}
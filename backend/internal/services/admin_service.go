@@ -1,8 +1,12 @@
 package services
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math/big"
+	"recetario-backend/internal/models"
 	"recetario-backend/internal/repository"
 	"strconv"
 	"strings"
@@ -10,15 +14,17 @@ import (
 
 // ✅ AdminService con dependency injection
 type AdminService struct {
-	authRepo    repository.AuthRepository
-	usuarioRepo repository.UsuarioRepository
+	authRepo     repository.AuthRepository
+	usuarioRepo  repository.UsuarioRepository
+	emailService *EmailService
 }
 
 // ✅ Constructor actualizado
-func NewAdminService(authRepo repository.AuthRepository, usuarioRepo repository.UsuarioRepository) *AdminService {
+func NewAdminService(authRepo repository.AuthRepository, usuarioRepo repository.UsuarioRepository, emailService *EmailService) *AdminService {
 	return &AdminService{
-		authRepo:    authRepo,
-		usuarioRepo: usuarioRepo,
+		authRepo:     authRepo,
+		usuarioRepo:  usuarioRepo,
+		emailService: emailService,
 	}
 }
 
@@ -48,21 +54,11 @@ func (s *AdminService) CrearUsuario(req *CrearUsuarioRequest) (string, error) {
 
 	// Manejar tanto string numérico como romano
 	if req.Ciclo != "" {
-		cicloStr := strings.ToUpper(strings.TrimSpace(req.Ciclo))
-
-		if cicloNum, err := strconv.Atoi(cicloStr); err == nil {
-			if cicloNum >= 1 && cicloNum <= 10 {
-				req.CicloActual = cicloNum
-			} else {
-				return "", fmt.Errorf("ciclo debe estar entre 1 y 10")
-			}
-		} else {
-			cicloInt, err := RomanoAEntero(cicloStr)
-			if err != nil {
-				return "", fmt.Errorf("ciclo inválido: %v", err)
-			}
-			req.CicloActual = cicloInt
+		ciclo, err := ParsearCiclo(req.Ciclo)
+		if err != nil {
+			return "", err
 		}
+		req.CicloActual = ciclo
 	}
 
 	if req.CicloActual == 0 {
@@ -309,6 +305,63 @@ func (s *AdminService) EditarUsuario(userID string, updates map[string]interface
 	return nil
 }
 
+// RestablecerPassword asigna una contraseña temporal y la envía por correo.
+// Devuelve la contraseña solo si el correo no pudo enviarse (o no hay SMTP
+// configurado), para que el administrador se la entregue al usuario.
+func (s *AdminService) RestablecerPassword(userID string) (string, error) {
+	respBody, err := s.usuarioRepo.GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("usuario no encontrado")
+	}
+
+	var usuarios []models.Usuario
+	if err := json.Unmarshal(respBody, &usuarios); err != nil || len(usuarios) == 0 {
+		return "", fmt.Errorf("usuario no encontrado")
+	}
+	usuario := usuarios[0]
+
+	temporal, err := generarPasswordTemporal(10)
+	if err != nil {
+		return "", fmt.Errorf("error al generar contraseña: %w", err)
+	}
+
+	if err := s.authRepo.UpdatePassword(userID, temporal); err != nil {
+		return "", fmt.Errorf("error al cambiar contraseña: %w", err)
+	}
+
+	// Obligar a cambiarla en el próximo inicio de sesión
+	if err := s.usuarioRepo.UpdateUser(userID, map[string]interface{}{"primera_vez": true}); err != nil {
+		return "", fmt.Errorf("error al actualizar usuario: %w", err)
+	}
+
+	err = s.emailService.EnviarPlantilla(usuario.Email, EmailRestablecerPassword, IdiomaPorDefecto, map[string]interface{}{
+		"Nombre":           usuario.NombreCompleto,
+		"Email":            usuario.Email,
+		"PasswordTemporal": temporal,
+	})
+	if err != nil {
+		log.Printf("⚠️ No se pudo enviar el correo de restablecimiento a %s: %v", usuario.Email, err)
+		return temporal, nil
+	}
+
+	return "", nil
+}
+
+func generarPasswordTemporal(longitud int) (string, error) {
+	// Sin caracteres ambiguos (0/O, 1/l/I)
+	const caracteres = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	b := make([]byte, longitud)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(caracteres))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = caracteres[n.Int64()]
+	}
+	return string(b), nil
+}
+
 func (s *AdminService) EliminarUsuario(userID string) error {
 	if err := s.authRepo.DeleteAuthUser(userID); err != nil {
 		return fmt.Errorf("error al eliminar usuario: %w", err)
@@ -352,6 +405,24 @@ func (s *AdminService) GetDocentes() ([]byte, error) {
 	return s.usuarioRepo.GetDocentes()
 }
 
+// ParsearCiclo acepta el ciclo en número (1-10) o en romano (I-X)
+func ParsearCiclo(ciclo string) (int, error) {
+	cicloStr := strings.ToUpper(strings.TrimSpace(ciclo))
+
+	if cicloNum, err := strconv.Atoi(cicloStr); err == nil {
+		if cicloNum < 1 || cicloNum > 10 {
+			return 0, fmt.Errorf("ciclo debe estar entre 1 y 10")
+		}
+		return cicloNum, nil
+	}
+
+	cicloInt, err := RomanoAEntero(cicloStr)
+	if err != nil {
+		return 0, fmt.Errorf("ciclo inválido: %v", err)
+	}
+	return cicloInt, nil
+}
+
 func RomanoAEntero(romano string) (int, error) {
 	switch strings.ToUpper(romano) {
 	case "I":
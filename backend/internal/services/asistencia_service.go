@@ -0,0 +1,719 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrSesionNoEncontrada: la sesión de clase no existe
+var ErrSesionNoEncontrada = errors.New("sesión no encontrada")
+
+// Estados válidos al pasar lista
+var estadosAsistencia = []string{
+	models.AsistenciaPresente,
+	models.AsistenciaTarde,
+	models.AsistenciaAusente,
+	models.AsistenciaJustificado,
+}
+
+// Letra de cada estado en la exportación a Excel
+var letraAsistencia = map[string]string{
+	models.AsistenciaPresente:    "P",
+	models.AsistenciaTarde:       "T",
+	models.AsistenciaAusente:     "A",
+	models.AsistenciaJustificado: "J",
+}
+
+// AsistenciaService gestiona las sesiones de clase y la asistencia por matrícula
+type AsistenciaService struct {
+	asistenciaRepo *repository.AsistenciaRepository
+	cursoRepo      repository.CursoRepository
+	cicloRepo      repository.CicloRepository
+	matriculaRepo  repository.MatriculaRepository
+	storageService *StorageService
+}
+
+func NewAsistenciaService(
+	asistenciaRepo *repository.AsistenciaRepository,
+	cursoRepo repository.CursoRepository,
+	cicloRepo repository.CicloRepository,
+	matriculaRepo repository.MatriculaRepository,
+	storageService *StorageService,
+) *AsistenciaService {
+	return &AsistenciaService{
+		asistenciaRepo: asistenciaRepo,
+		cursoRepo:      cursoRepo,
+		cicloRepo:      cicloRepo,
+		matriculaRepo:  matriculaRepo,
+		storageService: storageService,
+	}
+}
+
+// ==================== SESIONES ====================
+
+// ListarSesiones devuelve las sesiones del curso con cuántas marcas tiene cada una
+func (s *AsistenciaService) ListarSesiones(ctx context.Context, cursoID, usuarioID, rol string) ([]models.SesionClase, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	sesiones, err := s.asistenciaRepo.GetSesionesByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+	marcas, err := s.asistenciaRepo.GetByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+
+	registradas := make(map[uuid.UUID]int, len(sesiones))
+	for _, m := range marcas {
+		registradas[m.SesionID]++
+	}
+	for i := range sesiones {
+		sesiones[i].Registradas = registradas[sesiones[i].ID]
+	}
+
+	return sesiones, nil
+}
+
+// CrearSesion registra una clase fuera del horario regular
+func (s *AsistenciaService) CrearSesion(ctx context.Context, cursoID, usuarioID, rol string, req *models.CrearSesionRequest) (*models.SesionClase, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	if _, err := time.Parse("2006-01-02", req.Fecha); err != nil {
+		return nil, fmt.Errorf("fecha inválida (usar YYYY-MM-DD)")
+	}
+	inicio, err := time.Parse("15:04", req.HoraInicio)
+	if err != nil {
+		return nil, fmt.Errorf("hora de inicio inválida (usar HH:MM)")
+	}
+	if req.HoraFin != nil && *req.HoraFin != "" {
+		fin, err := time.Parse("15:04", *req.HoraFin)
+		if err != nil {
+			return nil, fmt.Errorf("hora de fin inválida (usar HH:MM)")
+		}
+		if !fin.After(inicio) {
+			return nil, fmt.Errorf("la hora de fin debe ser posterior a la de inicio")
+		}
+	}
+
+	existentes, err := s.asistenciaRepo.GetSesionesByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existentes {
+		if e.Fecha == req.Fecha && e.HoraInicio == req.HoraInicio {
+			return nil, fmt.Errorf("ya existe una sesión el %s a las %s", req.Fecha, req.HoraInicio)
+		}
+	}
+
+	return s.asistenciaRepo.CrearSesion(ctx, map[string]interface{}{
+		"curso_id":    cursoID,
+		"fecha":       req.Fecha,
+		"hora_inicio": req.HoraInicio,
+		"hora_fin":    req.HoraFin,
+		"aula":        req.Aula,
+		"tema":        req.Tema,
+		"origen":      models.SesionManual,
+		"created_by":  usuarioID,
+	})
+}
+
+// GenerarSesiones crea una sesión por cada bloque del horario semanal entre
+// el inicio y el fin del ciclo. Las sesiones que ya existen se conservan.
+func (s *AsistenciaService) GenerarSesiones(ctx context.Context, cursoID, usuarioID, rol string) (*models.ResultadoGeneracionSesiones, error) {
+	curso, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+
+	bloques := SesionesDeCurso(curso)
+	if len(bloques) == 0 {
+		return nil, fmt.Errorf("el curso no tiene un horario estructurado para generar sesiones")
+	}
+
+	respBody, err := s.cicloRepo.GetCicloByID(curso.CicloID)
+	if err != nil {
+		return nil, ErrCicloNoEncontrado
+	}
+	var ciclos []models.Ciclo
+	if err := json.Unmarshal(respBody, &ciclos); err != nil || len(ciclos) == 0 {
+		return nil, ErrCicloNoEncontrado
+	}
+
+	loc := config.AppConfig.Ubicacion()
+	inicio, errIni := time.ParseInLocation("2006-01-02", ciclos[0].FechaInicio, loc)
+	fin, errFin := time.ParseInLocation("2006-01-02", ciclos[0].FechaFin, loc)
+	if errIni != nil || errFin != nil || fin.Before(inicio) {
+		return nil, fmt.Errorf("el ciclo %s no tiene fechas válidas", ciclos[0].Nombre)
+	}
+
+	filas := make([]map[string]interface{}, 0)
+	for dia := inicio; !dia.After(fin); dia = dia.AddDate(0, 0, 1) {
+		for _, b := range bloques {
+			if time.Weekday(b.DiaSemana%7) != dia.Weekday() {
+				continue
+			}
+			fila := map[string]interface{}{
+				"curso_id":    cursoID,
+				"fecha":       dia.Format("2006-01-02"),
+				"hora_inicio": b.HoraInicio,
+				"hora_fin":    b.HoraFin,
+				"aula":        nil,
+				"origen":      models.SesionDesdeHorario,
+				"created_by":  usuarioID,
+			}
+			if b.Aula != "" {
+				fila["aula"] = b.Aula
+			}
+			filas = append(filas, fila)
+		}
+	}
+
+	if len(filas) == 0 {
+		return &models.ResultadoGeneracionSesiones{Sesiones: []models.SesionClase{}}, nil
+	}
+
+	nuevas, err := s.asistenciaRepo.CrearSesionesSiNoExisten(ctx, filas)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Sesiones generadas para el curso %s: %d nuevas de %d", cursoID, len(nuevas), len(filas))
+
+	return &models.ResultadoGeneracionSesiones{
+		Creadas:  len(nuevas),
+		Omitidas: len(filas) - len(nuevas),
+		Sesiones: nuevas,
+	}, nil
+}
+
+// EliminarSesion borra la sesión, sus marcas y los justificantes subidos
+func (s *AsistenciaService) EliminarSesion(ctx context.Context, sesionID uuid.UUID, usuarioID, rol string) error {
+	sesion, err := s.sesionConAcceso(ctx, sesionID, usuarioID, rol)
+	if err != nil {
+		return err
+	}
+
+	marcas, err := s.asistenciaRepo.GetBySesion(ctx, sesion.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.asistenciaRepo.DeleteSesion(ctx, sesion.ID); err != nil {
+		return err
+	}
+
+	archivos := make([]string, 0)
+	for _, m := range marcas {
+		if m.JustificacionURL != nil && *m.JustificacionURL != "" {
+			archivos = append(archivos, *m.JustificacionURL)
+		}
+	}
+	if err := s.storageService.DeleteMultipleFiles(archivos); err != nil {
+		log.Printf("⚠️ No se pudieron eliminar los justificantes de la sesión %s: %v", sesion.ID, err)
+	}
+
+	return nil
+}
+
+// sesionConAcceso busca la sesión y verifica que el usuario dicte el curso
+func (s *AsistenciaService) sesionConAcceso(ctx context.Context, sesionID uuid.UUID, usuarioID, rol string) (*models.SesionClase, error) {
+	sesion, err := s.asistenciaRepo.GetSesionByID(ctx, sesionID)
+	if err != nil {
+		return nil, ErrSesionNoEncontrada
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, sesion.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return sesion, nil
+}
+
+// ==================== PASAR LISTA ====================
+
+// ObtenerLista devuelve la sesión con cada estudiante activo y su marca
+func (s *AsistenciaService) ObtenerLista(ctx context.Context, sesionID uuid.UUID, usuarioID, rol string) (*models.ListaAsistencia, error) {
+	sesion, err := s.sesionConAcceso(ctx, sesionID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+	return s.armarLista(ctx, sesion)
+}
+
+// MarcarAsistencia registra las marcas enviadas; EstadoPorDefecto completa a
+// los estudiantes activos que aún no tienen marca en la sesión
+func (s *AsistenciaService) MarcarAsistencia(ctx context.Context, sesionID uuid.UUID, usuarioID, rol string, req *models.MarcarAsistenciaRequest) (*models.ListaAsistencia, error) {
+	sesion, err := s.sesionConAcceso(ctx, sesionID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EstadoPorDefecto != "" && !contieneTexto(estadosAsistencia, req.EstadoPorDefecto) {
+		return nil, fmt.Errorf("estado por defecto inválido: use %s", strings.Join(estadosAsistencia, ", "))
+	}
+	if len(req.Marcas) == 0 && req.EstadoPorDefecto == "" {
+		return nil, fmt.Errorf("no hay marcas para registrar")
+	}
+
+	matriculas, err := s.matriculasDeCurso(sesion.CursoID.String())
+	if err != nil {
+		return nil, err
+	}
+	activas := make(map[string]bool, len(matriculas))
+	for _, m := range matriculas {
+		activas[m.ID] = m.Estado == "activo"
+	}
+
+	ahora := time.Now().UTC()
+	marcadas := make(map[string]bool, len(req.Marcas))
+	filas := make([]map[string]interface{}, 0, len(matriculas))
+	for _, marca := range req.Marcas {
+		activa, ok := activas[marca.MatriculaID]
+		if !ok {
+			return nil, fmt.Errorf("la matrícula %s no pertenece al curso", marca.MatriculaID)
+		}
+		if !activa {
+			return nil, fmt.Errorf("la matrícula %s no está activa", marca.MatriculaID)
+		}
+		if !contieneTexto(estadosAsistencia, marca.Estado) {
+			return nil, fmt.Errorf("estado inválido para %s: use %s", marca.MatriculaID, strings.Join(estadosAsistencia, ", "))
+		}
+		if marcadas[marca.MatriculaID] {
+			return nil, fmt.Errorf("la matrícula %s aparece más de una vez", marca.MatriculaID)
+		}
+		marcadas[marca.MatriculaID] = true
+
+		filas = append(filas, map[string]interface{}{
+			"sesion_id":      sesion.ID.String(),
+			"matricula_id":   marca.MatriculaID,
+			"estado":         marca.Estado,
+			"observacion":    marca.Observacion,
+			"registrado_por": usuarioID,
+			"updated_at":     ahora,
+		})
+	}
+
+	if req.EstadoPorDefecto != "" {
+		existentes, err := s.asistenciaRepo.GetBySesion(ctx, sesion.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range existentes {
+			marcadas[e.MatriculaID] = true
+		}
+		for _, m := range matriculas {
+			if m.Estado != "activo" || marcadas[m.ID] {
+				continue
+			}
+			filas = append(filas, map[string]interface{}{
+				"sesion_id":      sesion.ID.String(),
+				"matricula_id":   m.ID,
+				"estado":         req.EstadoPorDefecto,
+				"observacion":    nil,
+				"registrado_por": usuarioID,
+				"updated_at":     ahora,
+			})
+		}
+	}
+
+	if len(filas) > 0 {
+		if err := s.asistenciaRepo.Guardar(ctx, filas); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.armarLista(ctx, sesion)
+}
+
+// SubirJustificacion guarda el justificante de una falta o tardanza. Si lo
+// sube el docente la marca pasa a justificado; si lo sube el estudiante queda
+// adjunto para que el docente lo revise.
+func (s *AsistenciaService) SubirJustificacion(ctx context.Context, sesionID uuid.UUID, matriculaID, usuarioID, rol string, archivo multipart.File, header *multipart.FileHeader) (*models.FilaAsistencia, error) {
+	sesion, err := s.asistenciaRepo.GetSesionByID(ctx, sesionID)
+	if err != nil {
+		return nil, ErrSesionNoEncontrada
+	}
+
+	matriculas, err := s.matriculasDeCurso(sesion.CursoID.String())
+	if err != nil {
+		return nil, err
+	}
+	var matricula *models.Matricula
+	for i := range matriculas {
+		if matriculas[i].ID == matriculaID {
+			matricula = &matriculas[i]
+			break
+		}
+	}
+	if matricula == nil {
+		return nil, fmt.Errorf("la matrícula no pertenece al curso")
+	}
+
+	esEstudiante := rol == "estudiante"
+	if esEstudiante {
+		if matricula.EstudianteID != usuarioID {
+			return nil, ErrSinPermiso
+		}
+	} else if _, err := validarDocenteOAdmin(s.cursoRepo, sesion.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	marcas, err := s.asistenciaRepo.GetBySesion(ctx, sesion.ID)
+	if err != nil {
+		return nil, err
+	}
+	var actual *models.Asistencia
+	for i := range marcas {
+		if marcas[i].MatriculaID == matriculaID {
+			actual = &marcas[i]
+			break
+		}
+	}
+	if esEstudiante && (actual == nil || actual.Estado == models.AsistenciaPresente) {
+		return nil, fmt.Errorf("solo se puede justificar una falta o tardanza registrada")
+	}
+
+	url, _, err := s.storageService.UploadFile("asistencia/"+sesion.ID.String(), archivo, header)
+	if err != nil {
+		return nil, fmt.Errorf("error al subir justificante: %w", err)
+	}
+
+	fila := map[string]interface{}{
+		"sesion_id":         sesion.ID.String(),
+		"matricula_id":      matriculaID,
+		"justificacion_url": url,
+	}
+	if esEstudiante {
+		// El estado lo cambia el docente al revisar el justificante
+		fila["estado"] = actual.Estado
+	} else {
+		fila["estado"] = models.AsistenciaJustificado
+		fila["registrado_por"] = usuarioID
+		fila["updated_at"] = time.Now().UTC()
+	}
+	if err := s.asistenciaRepo.Guardar(ctx, []map[string]interface{}{fila}); err != nil {
+		if errDel := s.storageService.DeleteFile(url); errDel != nil {
+			log.Printf("⚠️ No se pudo eliminar el justificante huérfano: %v", errDel)
+		}
+		return nil, err
+	}
+
+	if actual != nil && actual.JustificacionURL != nil && *actual.JustificacionURL != "" {
+		if err := s.storageService.DeleteFile(*actual.JustificacionURL); err != nil {
+			log.Printf("⚠️ No se pudo eliminar el justificante anterior: %v", err)
+		}
+	}
+
+	lista, err := s.armarLista(ctx, sesion)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lista.Filas {
+		if lista.Filas[i].MatriculaID == matriculaID {
+			return &lista.Filas[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no se pudo leer la asistencia registrada")
+}
+
+// armarLista cruza las matrículas del curso con las marcas de la sesión.
+// Aparecen los estudiantes activos y quien tenga marca aunque ya no lo esté.
+func (s *AsistenciaService) armarLista(ctx context.Context, sesion *models.SesionClase) (*models.ListaAsistencia, error) {
+	matriculas, err := s.matriculasDeCurso(sesion.CursoID.String())
+	if err != nil {
+		return nil, err
+	}
+	marcas, err := s.asistenciaRepo.GetBySesion(ctx, sesion.ID)
+	if err != nil {
+		return nil, err
+	}
+	porMatricula := make(map[string]*models.Asistencia, len(marcas))
+	for i := range marcas {
+		porMatricula[marcas[i].MatriculaID] = &marcas[i]
+		sesion.Registradas++
+	}
+
+	lista := &models.ListaAsistencia{Sesion: *sesion, Filas: []models.FilaAsistencia{}}
+	for _, m := range matriculas {
+		marca := porMatricula[m.ID]
+		if m.Estado != "activo" && marca == nil {
+			continue
+		}
+		fila := models.FilaAsistencia{
+			MatriculaID:  m.ID,
+			EstudianteID: m.EstudianteID,
+		}
+		fila.Codigo, fila.Nombre = datosEstudiante(&m)
+		if marca != nil {
+			estado := marca.Estado
+			fila.Estado = &estado
+			fila.Observacion = marca.Observacion
+			fila.JustificacionURL = marca.JustificacionURL
+		}
+		lista.Filas = append(lista.Filas, fila)
+	}
+
+	sort.Slice(lista.Filas, func(i, j int) bool {
+		return lista.Filas[i].Nombre < lista.Filas[j].Nombre
+	})
+
+	return lista, nil
+}
+
+// ==================== RESUMEN POR MATRÍCULA ====================
+
+// ResumenCurso calcula el porcentaje de asistencia de cada matrícula
+func (s *AsistenciaService) ResumenCurso(ctx context.Context, cursoID, usuarioID, rol string) (*models.ReporteAsistencia, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	reporte, _, _, err := s.reporteCurso(ctx, cursoID)
+	return reporte, err
+}
+
+// MiAsistencia devuelve el resumen del estudiante con sus marcas por sesión
+func (s *AsistenciaService) MiAsistencia(ctx context.Context, cursoID, estudianteID string) (*models.ResumenAsistencia, error) {
+	reporte, _, marcas, err := s.reporteCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range reporte.Resumenes {
+		if r.EstudianteID != estudianteID {
+			continue
+		}
+		resumen := r
+		resumen.Marcas = []models.Asistencia{}
+		for _, m := range marcas {
+			if m.MatriculaID == r.MatriculaID {
+				resumen.Marcas = append(resumen.Marcas, m)
+			}
+		}
+		return &resumen, nil
+	}
+
+	return nil, ErrSinAccesoCurso
+}
+
+// reporteCurso arma el resumen de todas las matrículas del curso
+func (s *AsistenciaService) reporteCurso(ctx context.Context, cursoID string) (*models.ReporteAsistencia, []models.SesionClase, []models.Asistencia, error) {
+	if _, err := obtenerCurso(s.cursoRepo, cursoID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	sesiones, err := s.asistenciaRepo.GetSesionesByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	marcas, err := s.asistenciaRepo.GetByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	matriculas, err := s.matriculasDeCurso(cursoID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resumenes := make(map[string]*models.ResumenAsistencia, len(matriculas))
+	reporte := &models.ReporteAsistencia{
+		CursoID:   cursoID,
+		Sesiones:  len(sesiones),
+		Resumenes: make([]models.ResumenAsistencia, 0, len(matriculas)),
+	}
+	for _, m := range matriculas {
+		r := models.ResumenAsistencia{MatriculaID: m.ID, EstudianteID: m.EstudianteID}
+		r.Codigo, r.Nombre = datosEstudiante(&m)
+		reporte.Resumenes = append(reporte.Resumenes, r)
+	}
+	for i := range reporte.Resumenes {
+		resumenes[reporte.Resumenes[i].MatriculaID] = &reporte.Resumenes[i]
+	}
+
+	for _, m := range marcas {
+		r, ok := resumenes[m.MatriculaID]
+		if !ok {
+			continue
+		}
+		switch m.Estado {
+		case models.AsistenciaPresente:
+			r.Presente++
+		case models.AsistenciaTarde:
+			r.Tarde++
+		case models.AsistenciaAusente:
+			r.Ausente++
+		case models.AsistenciaJustificado:
+			r.Justificado++
+		}
+		r.Registradas++
+	}
+	for i := range reporte.Resumenes {
+		r := &reporte.Resumenes[i]
+		if r.Registradas > 0 {
+			r.Porcentaje = redondear2(float64(r.Presente+r.Tarde+r.Justificado) * 100 / float64(r.Registradas))
+		}
+	}
+
+	sort.Slice(reporte.Resumenes, func(i, j int) bool {
+		return reporte.Resumenes[i].Nombre < reporte.Resumenes[j].Nombre
+	})
+
+	return reporte, sesiones, marcas, nil
+}
+
+func (s *AsistenciaService) matriculasDeCurso(cursoID string) ([]models.Matricula, error) {
+	respBody, err := s.matriculaRepo.GetMatriculasByCurso(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener matrículas: %w", err)
+	}
+
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil, fmt.Errorf("error al parsear matrículas")
+	}
+
+	return matriculas, nil
+}
+
+// datosEstudiante devuelve el código y el nombre del estudiante de la matrícula
+func datosEstudiante(m *models.Matricula) (string, string) {
+	if m.Estudiante == nil {
+		return "", ""
+	}
+	nombre := ""
+	if m.Estudiante.Usuario != nil {
+		nombre = m.Estudiante.Usuario.NombreCompleto
+	}
+	return m.Estudiante.CodigoEstudiante, nombre
+}
+
+// ==================== EXPORTAR A EXCEL ====================
+
+// ExportarAsistenciaExcel genera la planilla con una columna por sesión y el
+// porcentaje de asistencia de cada estudiante
+func (s *AsistenciaService) ExportarAsistenciaExcel(ctx context.Context, cursoID, usuarioID, rol string) (*bytes.Buffer, string, error) {
+	curso, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reporte, sesiones, marcas, err := s.reporteCurso(ctx, cursoID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(reporte.Resumenes) == 0 {
+		return nil, "", fmt.Errorf("no hay participantes en este curso")
+	}
+
+	estados := make(map[string]string, len(marcas))
+	for _, m := range marcas {
+		estados[m.SesionID.String()+"|"+m.MatriculaID] = m.Estado
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Asistencia"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, "", fmt.Errorf("error al crear hoja: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#2E7D32"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+	})
+	centroStyle, _ := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+
+	headers := []string{"Código", "Nombre Completo"}
+	for _, sesion := range sesiones {
+		headers = append(headers, fmt.Sprintf("%s %s", formatearFechaSesion(sesion.Fecha), sesion.HoraInicio))
+	}
+	headers = append(headers, "Presente", "Tarde", "Ausente", "Justificado", "% Asistencia")
+
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	ultima, _ := excelize.ColumnNumberToName(len(headers))
+	f.SetCellStyle(sheetName, "A1", ultima+"1", headerStyle)
+	f.SetColWidth(sheetName, "A", "A", 15)
+	f.SetColWidth(sheetName, "B", "B", 35)
+	if len(sesiones) > 0 {
+		desde, _ := excelize.ColumnNumberToName(3)
+		hasta, _ := excelize.ColumnNumberToName(2 + len(sesiones))
+		f.SetColWidth(sheetName, desde, hasta, 11)
+	}
+	f.SetRowHeight(sheetName, 1, 30)
+
+	for i, r := range reporte.Resumenes {
+		fila := i + 2
+		valores := []interface{}{r.Codigo, r.Nombre}
+		for _, sesion := range sesiones {
+			valores = append(valores, letraAsistencia[estados[sesion.ID.String()+"|"+r.MatriculaID]])
+		}
+		valores = append(valores, r.Presente, r.Tarde, r.Ausente, r.Justificado)
+		if r.Porcentaje != nil {
+			valores = append(valores, *r.Porcentaje)
+		} else {
+			valores = append(valores, "-")
+		}
+
+		inicio, _ := excelize.CoordinatesToCellName(1, fila)
+		f.SetSheetRow(sheetName, inicio, &valores)
+		desde, _ := excelize.CoordinatesToCellName(3, fila)
+		hasta, _ := excelize.CoordinatesToCellName(len(headers), fila)
+		f.SetCellStyle(sheetName, desde, hasta, centroStyle)
+	}
+
+	f.SetPanes(sheetName, &excelize.Panes{
+		Freeze:      true,
+		XSplit:      2,
+		YSplit:      1,
+		TopLeftCell: "C2",
+		ActivePane:  "bottomRight",
+	})
+
+	leyenda := len(reporte.Resumenes) + 3
+	celda, _ := excelize.CoordinatesToCellName(1, leyenda)
+	f.SetCellValue(sheetName, celda, "P = presente, T = tarde, A = ausente, J = justificado")
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, "", fmt.Errorf("error al generar archivo: %w", err)
+	}
+
+	return buffer, curso.Nombre, nil
+}
+
+// formatearFechaSesion muestra "2024-03-04" como "04/03"
+func formatearFechaSesion(fecha string) string {
+	t, err := time.Parse("2006-01-02", fecha)
+	if err != nil {
+		return fecha
+	}
+	return t.Format("02/01")
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrTokenCalendarioInvalido se devuelve cuando el enlace .ics no existe o fue regenerado
+var ErrTokenCalendarioInvalido = errors.New("enlace de calendario inválido")
+
+// Anticipación del aviso de las fechas límite en el calendario
+const avisoFechaLimiteICS = "-PT24H"
+
+// CalendarioService genera los feeds iCalendar de clases y fechas límite
+type CalendarioService struct {
+	calendarioRepo *repository.CalendarioRepository
+	usuarioRepo    repository.UsuarioRepository
+	cursoRepo      repository.CursoRepository
+	cicloRepo      repository.CicloRepository
+	tareaRepo      *repository.TareaRepository
+}
+
+func NewCalendarioService(
+	calendarioRepo *repository.CalendarioRepository,
+	usuarioRepo repository.UsuarioRepository,
+	cursoRepo repository.CursoRepository,
+	cicloRepo repository.CicloRepository,
+	tareaRepo *repository.TareaRepository,
+) *CalendarioService {
+	return &CalendarioService{
+		calendarioRepo: calendarioRepo,
+		usuarioRepo:    usuarioRepo,
+		cursoRepo:      cursoRepo,
+		cicloRepo:      cicloRepo,
+		tareaRepo:      tareaRepo,
+	}
+}
+
+// ==================== TOKENS ====================
+
+// ObtenerToken devuelve el token del usuario, creándolo la primera vez
+func (s *CalendarioService) ObtenerToken(ctx context.Context, usuarioID string) (string, error) {
+	token, err := s.calendarioRepo.GetTokenByUsuario(ctx, usuarioID)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	return s.RegenerarToken(ctx, usuarioID)
+}
+
+// RegenerarToken invalida el enlace anterior y crea uno nuevo
+func (s *CalendarioService) RegenerarToken(ctx context.Context, usuarioID string) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error al generar token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := s.calendarioRepo.GuardarToken(ctx, usuarioID, token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ==================== FEED ====================
+
+// GenerarFeed arma el .ics del dueño del token con sus clases del ciclo
+// activo y las fechas límite de las tareas de esos cursos
+func (s *CalendarioService) GenerarFeed(ctx context.Context, token string) ([]byte, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, ErrTokenCalendarioInvalido
+	}
+
+	usuarioID, err := s.calendarioRepo.GetUsuarioByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if usuarioID == "" {
+		return nil, ErrTokenCalendarioInvalido
+	}
+
+	respBody, err := s.usuarioRepo.GetUserByID(usuarioID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener usuario: %w", err)
+	}
+	var usuarios []models.Usuario
+	if err := json.Unmarshal(respBody, &usuarios); err != nil || len(usuarios) == 0 || !usuarios[0].Activo {
+		return nil, ErrTokenCalendarioInvalido
+	}
+	usuario := usuarios[0]
+
+	cursos, err := s.cursosDeUsuario(&usuario)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := config.AppConfig.Ubicacion()
+	ciclos := make(map[string]*models.Ciclo)
+	ahora := time.Now()
+
+	// Rango cubierto por el VTIMEZONE
+	desde := ahora.AddDate(-1, 0, 0)
+	hasta := ahora.AddDate(1, 0, 0)
+
+	type cursoConCiclo struct {
+		curso       models.Curso
+		inicio, fin time.Time
+		tieneFechas bool
+	}
+	vigentes := make([]cursoConCiclo, 0, len(cursos))
+
+	for _, curso := range cursos {
+		if !curso.Activo {
+			continue
+		}
+		ciclo := s.obtenerCiclo(ciclos, curso.CicloID)
+		if ciclo == nil || !ciclo.Activo {
+			continue
+		}
+
+		cc := cursoConCiclo{curso: curso}
+		inicio, errIni := time.ParseInLocation("2006-01-02", ciclo.FechaInicio, loc)
+		fin, errFin := time.ParseInLocation("2006-01-02", ciclo.FechaFin, loc)
+		if errIni == nil && errFin == nil && !fin.Before(inicio) {
+			cc.inicio, cc.fin, cc.tieneFechas = inicio, fin, true
+			if inicio.Before(desde) {
+				desde = inicio
+			}
+			if fin.After(hasta) {
+				hasta = fin.AddDate(0, 0, 1)
+			}
+		} else {
+			log.Printf("⚠️ Ciclo %s sin fechas válidas, se omiten sus clases en el calendario", ciclo.ID)
+		}
+		vigentes = append(vigentes, cc)
+	}
+
+	w := nuevoEscritorICS(loc)
+	w.linea("BEGIN", "VCALENDAR")
+	w.linea("VERSION", "2.0")
+	w.linea("PRODID", "-//Sistema de Recetas//Calendario Academico//ES")
+	w.linea("CALSCALE", "GREGORIAN")
+	w.linea("METHOD", "PUBLISH")
+	w.texto("X-WR-CALNAME", "Horario - "+usuario.NombreCompleto)
+	w.linea("X-WR-TIMEZONE", loc.String())
+	// Sugerencia a los clientes para volver a consultar el feed
+	w.linea("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	w.linea("X-PUBLISHED-TTL", "PT1H")
+	w.zonaHoraria(desde, hasta)
+
+	for _, cc := range vigentes {
+		if cc.tieneFechas {
+			s.escribirClases(w, &cc.curso, cc.inicio, cc.fin, ahora)
+		}
+		s.escribirFechasLimite(ctx, w, &cc.curso, ahora)
+	}
+
+	w.linea("END", "VCALENDAR")
+	return w.Bytes(), nil
+}
+
+// cursosDeUsuario devuelve los cursos que dicta el docente o en los que está
+// matriculado el estudiante
+func (s *CalendarioService) cursosDeUsuario(usuario *models.Usuario) ([]models.Curso, error) {
+	var (
+		respBody []byte
+		err      error
+	)
+	switch usuario.Rol {
+	case "docente":
+		respBody, err = s.cursoRepo.GetCursosByDocente(usuario.ID)
+	case "estudiante":
+		respBody, err = s.cursoRepo.GetCursosByEstudiante(usuario.ID)
+	default:
+		return []models.Curso{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cursos: %w", err)
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil {
+		return nil, fmt.Errorf("error al parsear cursos")
+	}
+
+	return cursos, nil
+}
+
+func (s *CalendarioService) obtenerCiclo(cache map[string]*models.Ciclo, cicloID string) *models.Ciclo {
+	if ciclo, ok := cache[cicloID]; ok {
+		return ciclo
+	}
+
+	var ciclo *models.Ciclo
+	if respBody, err := s.cicloRepo.GetCicloByID(cicloID); err == nil {
+		var ciclos []models.Ciclo
+		if err := json.Unmarshal(respBody, &ciclos); err == nil && len(ciclos) > 0 {
+			ciclo = &ciclos[0]
+		}
+	}
+
+	cache[cicloID] = ciclo
+	return ciclo
+}
+
+// escribirClases genera un evento semanal recurrente por cada sesión del curso
+func (s *CalendarioService) escribirClases(w *escritorICS, curso *models.Curso, inicio, fin time.Time, ahora time.Time) {
+	// UNTIL en UTC: último segundo del día de fin del ciclo
+	hasta := fin.AddDate(0, 0, 1).Add(-time.Second)
+
+	for _, sesion := range SesionesDeCurso(curso) {
+		primera, ok := primeraSesion(sesion, inicio, w.loc)
+		if !ok || primera.After(hasta) {
+			continue
+		}
+		hFin, err := time.Parse("15:04", sesion.HoraFin)
+		if err != nil {
+			continue
+		}
+		terminaEn := time.Date(primera.Year(), primera.Month(), primera.Day(), hFin.Hour(), hFin.Minute(), 0, 0, w.loc)
+
+		w.linea("BEGIN", "VEVENT")
+		w.linea("UID", fmt.Sprintf("clase-%s-%s-%d-%s@recetario", curso.ID, curso.CicloID, sesion.DiaSemana,
+			strings.ReplaceAll(sesion.HoraInicio, ":", "")))
+		w.fechaUTC("DTSTAMP", ahora)
+		if !curso.UpdatedAt.IsZero() {
+			w.fechaUTC("LAST-MODIFIED", curso.UpdatedAt)
+		}
+		w.fechaLocal("DTSTART", primera)
+		w.fechaLocal("DTEND", terminaEn)
+		w.linea("RRULE", fmt.Sprintf("FREQ=WEEKLY;BYDAY=%s;UNTIL=%s", diasICS[sesion.DiaSemana], hasta.UTC().Format(formatoICSUTC)))
+		w.texto("SUMMARY", curso.Nombre)
+		if sesion.Aula != "" {
+			w.texto("LOCATION", sesion.Aula)
+		}
+		if descripcion := descripcionClase(curso); descripcion != "" {
+			w.texto("DESCRIPTION", descripcion)
+		}
+		w.linea("CATEGORIES", "CLASE")
+		w.linea("END", "VEVENT")
+	}
+}
+
+// primeraSesion calcula la primera fecha del ciclo que cae en el día de la sesión
+func primeraSesion(sesion models.SesionHorario, inicioCiclo time.Time, loc *time.Location) (time.Time, bool) {
+	if sesion.DiaSemana < 1 || sesion.DiaSemana > 7 {
+		return time.Time{}, false
+	}
+	hora, err := time.Parse("15:04", sesion.HoraInicio)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	objetivo := time.Weekday(sesion.DiaSemana % 7) // domingo = 0 en Go
+	dias := (int(objetivo) - int(inicioCiclo.Weekday()) + 7) % 7
+	fecha := inicioCiclo.AddDate(0, 0, dias)
+
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day(), hora.Hour(), hora.Minute(), 0, 0, loc), true
+}
+
+func descripcionClase(curso *models.Curso) string {
+	partes := make([]string, 0, 3)
+	if curso.Seccion != "" {
+		partes = append(partes, "Sección "+curso.Seccion)
+	}
+	if curso.Docente != nil && curso.Docente.Usuario.NombreCompleto != "" {
+		partes = append(partes, "Docente: "+curso.Docente.Usuario.NombreCompleto)
+	}
+	if curso.Ciclo != nil && curso.Ciclo.Nombre != "" {
+		partes = append(partes, "Ciclo "+curso.Ciclo.Nombre)
+	}
+	return strings.Join(partes, "\n")
+}
+
+// escribirFechasLimite genera un evento por cada tarea activa del curso
+func (s *CalendarioService) escribirFechasLimite(ctx context.Context, w *escritorICS, curso *models.Curso, ahora time.Time) {
+	cursoID, err := uuid.Parse(curso.ID)
+	if err != nil {
+		return
+	}
+
+	tareas, err := s.tareaRepo.GetByCursoID(ctx, cursoID)
+	if err != nil {
+		log.Printf("❌ Error obteniendo tareas del curso %s para el calendario: %v", curso.ID, err)
+		return
+	}
+
+	for _, tarea := range tareas {
+		if !tarea.Activo || tarea.FechaLimite.IsZero() {
+			continue
+		}
+
+		w.linea("BEGIN", "VEVENT")
+		w.linea("UID", fmt.Sprintf("tarea-%s@recetario", tarea.ID))
+		w.fechaUTC("DTSTAMP", ahora)
+		// Sin DTEND: el evento dura cero y marca el instante de la fecha límite
+		w.fechaLocal("DTSTART", tarea.FechaLimite)
+		w.texto("SUMMARY", fmt.Sprintf("Entrega: %s (%s)", tarea.Titulo, curso.Nombre))
+		if tarea.Descripcion != nil && *tarea.Descripcion != "" {
+			w.texto("DESCRIPTION", *tarea.Descripcion)
+		}
+		w.linea("TRANSP", "TRANSPARENT")
+		w.linea("CATEGORIES", "TAREA")
+		w.linea("BEGIN", "VALARM")
+		w.linea("ACTION", "DISPLAY")
+		w.texto("DESCRIPTION", "Vence: "+tarea.Titulo)
+		w.linea("TRIGGER", avisoFechaLimiteICS)
+		w.linea("END", "VALARM")
+		w.linea("END", "VEVENT")
+	}
+}
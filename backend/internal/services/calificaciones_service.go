@@ -0,0 +1,549 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de tarea que admite el esquema por tipo
+var tiposTarea = []string{"practica", "evaluacion", "proyecto"}
+
+// CalificacionesService arma el libro de calificaciones y calcula la nota final
+type CalificacionesService struct {
+	calificacionesRepo *repository.CalificacionesRepository
+	cursoRepo          repository.CursoRepository
+	matriculaRepo      repository.MatriculaRepository
+	tareaRepo          *repository.TareaRepository
+	entregaRepo        *repository.EntregaRepository
+}
+
+func NewCalificacionesService(
+	calificacionesRepo *repository.CalificacionesRepository,
+	cursoRepo repository.CursoRepository,
+	matriculaRepo repository.MatriculaRepository,
+	tareaRepo *repository.TareaRepository,
+	entregaRepo *repository.EntregaRepository,
+) *CalificacionesService {
+	return &CalificacionesService{
+		calificacionesRepo: calificacionesRepo,
+		cursoRepo:          cursoRepo,
+		matriculaRepo:      matriculaRepo,
+		tareaRepo:          tareaRepo,
+		entregaRepo:        entregaRepo,
+	}
+}
+
+// esquemaPorDefecto: mismo peso para cada tipo, faltantes en cero, escala vigesimal
+func esquemaPorDefecto(cursoID string) *models.EsquemaCalificacion {
+	return &models.EsquemaCalificacion{
+		CursoID:         cursoID,
+		Modo:            models.ModoPesoPorTipo,
+		Pesos:           map[string]float64{},
+		EliminarMenores: map[string]int{},
+		Faltantes:       models.FaltantesCero,
+		Escala:          20,
+		NotaAprobatoria: 10.5,
+	}
+}
+
+// ==================== ESQUEMA ====================
+
+// ObtenerEsquema devuelve el esquema del curso o el de por defecto
+func (s *CalificacionesService) ObtenerEsquema(ctx context.Context, cursoID, usuarioID, rol string) (*models.EsquemaCalificacion, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return s.esquemaDeCurso(ctx, cursoID)
+}
+
+func (s *CalificacionesService) esquemaDeCurso(ctx context.Context, cursoID string) (*models.EsquemaCalificacion, error) {
+	esquema, err := s.calificacionesRepo.GetEsquema(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+	if esquema == nil {
+		return esquemaPorDefecto(cursoID), nil
+	}
+	if esquema.Pesos == nil {
+		esquema.Pesos = map[string]float64{}
+	}
+	if esquema.EliminarMenores == nil {
+		esquema.EliminarMenores = map[string]int{}
+	}
+	return esquema, nil
+}
+
+// GuardarEsquema valida y guarda el esquema, y recalcula las notas del curso
+func (s *CalificacionesService) GuardarEsquema(ctx context.Context, cursoID, usuarioID, rol string, req *models.GuardarEsquemaRequest) (*models.EsquemaCalificacion, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	esquema := esquemaPorDefecto(cursoID)
+	if req.Modo != "" {
+		esquema.Modo = req.Modo
+	}
+	if req.Faltantes != "" {
+		esquema.Faltantes = req.Faltantes
+	}
+	if req.Pesos != nil {
+		esquema.Pesos = req.Pesos
+	}
+	if req.EliminarMenores != nil {
+		esquema.EliminarMenores = req.EliminarMenores
+	}
+	if req.Escala != nil {
+		esquema.Escala = *req.Escala
+	}
+	if req.NotaAprobatoria != nil {
+		esquema.NotaAprobatoria = *req.NotaAprobatoria
+	}
+
+	if err := s.validarEsquema(ctx, esquema); err != nil {
+		return nil, err
+	}
+
+	if err := s.calificacionesRepo.GuardarEsquema(ctx, esquema); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.RecalcularCurso(ctx, cursoID); err != nil {
+		log.Printf("⚠️ Esquema guardado pero no se pudo recalcular el curso %s: %v", cursoID, err)
+	}
+
+	return esquema, nil
+}
+
+func (s *CalificacionesService) validarEsquema(ctx context.Context, e *models.EsquemaCalificacion) error {
+	if e.Modo != models.ModoPesoPorTipo && e.Modo != models.ModoPesoPorTarea {
+		return fmt.Errorf("modo inválido: use '%s' o '%s'", models.ModoPesoPorTipo, models.ModoPesoPorTarea)
+	}
+	if e.Faltantes != models.FaltantesCero && e.Faltantes != models.FaltantesExcluir {
+		return fmt.Errorf("faltantes inválido: use '%s' o '%s'", models.FaltantesCero, models.FaltantesExcluir)
+	}
+	if e.Escala <= 0 {
+		return fmt.Errorf("la escala debe ser mayor a cero")
+	}
+	if e.NotaAprobatoria < 0 || e.NotaAprobatoria > e.Escala {
+		return fmt.Errorf("la nota aprobatoria debe estar entre 0 y %.2f", e.Escala)
+	}
+
+	// Claves válidas según el modo
+	validas := make(map[string]bool)
+	if e.Modo == models.ModoPesoPorTipo {
+		for _, t := range tiposTarea {
+			validas[t] = true
+		}
+	} else {
+		cursoID, err := uuid.Parse(e.CursoID)
+		if err != nil {
+			return ErrCursoNoEncontrado
+		}
+		tareas, err := s.tareaRepo.GetByCursoID(ctx, cursoID)
+		if err != nil {
+			return err
+		}
+		for _, t := range tareas {
+			validas[t.ID.String()] = true
+		}
+	}
+
+	total := 0.0
+	for clave, peso := range e.Pesos {
+		if !validas[clave] {
+			return fmt.Errorf("peso para '%s' no corresponde a un %s del curso", clave, e.Modo)
+		}
+		if peso < 0 {
+			return fmt.Errorf("el peso de '%s' no puede ser negativo", clave)
+		}
+		total += peso
+	}
+	if len(e.Pesos) > 0 && total == 0 {
+		return fmt.Errorf("al menos un peso debe ser mayor a cero")
+	}
+
+	for tipo, n := range e.EliminarMenores {
+		if !contieneTexto(tiposTarea, tipo) {
+			return fmt.Errorf("tipo de tarea inválido en eliminar_menores: %s", tipo)
+		}
+		if n < 0 {
+			return fmt.Errorf("eliminar_menores de '%s' no puede ser negativo", tipo)
+		}
+	}
+
+	return nil
+}
+
+// ==================== LIBRO ====================
+
+// ObtenerLibro devuelve la matriz completa del curso (docente o administrador)
+func (s *CalificacionesService) ObtenerLibro(ctx context.Context, cursoID, usuarioID, rol string) (*models.LibroCalificaciones, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return s.armarLibro(ctx, cursoID, "", true)
+}
+
+// ObtenerMiFila devuelve solo la fila del estudiante matriculado
+func (s *CalificacionesService) ObtenerMiFila(ctx context.Context, cursoID, estudianteID string) (*models.LibroCalificaciones, error) {
+	libro, err := s.armarLibro(ctx, cursoID, estudianteID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(libro.Filas) == 0 {
+		return nil, ErrSinAccesoCurso
+	}
+	return libro, nil
+}
+
+// armarLibro carga tareas, matrículas y entregas y calcula cada fila.
+// Con estudianteID solo se incluye la fila de ese estudiante. Las notas sin
+// publicar solo aparecen en la vista del docente, como borrador.
+func (s *CalificacionesService) armarLibro(ctx context.Context, cursoID, estudianteID string, vistaDocente bool) (*models.LibroCalificaciones, error) {
+	cursoUUID, err := uuid.Parse(cursoID)
+	if err != nil {
+		return nil, ErrCursoNoEncontrado
+	}
+
+	esquema, err := s.esquemaDeCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+
+	todas, err := s.tareaRepo.GetByCursoID(ctx, cursoUUID)
+	if err != nil {
+		return nil, err
+	}
+	tareas := make([]models.Tarea, 0, len(todas))
+	ids := make([]uuid.UUID, 0, len(todas))
+	for _, t := range todas {
+		if t.Activo {
+			tareas = append(tareas, t)
+			ids = append(ids, t.ID)
+		}
+	}
+
+	respBody, err := s.matriculaRepo.GetMatriculasByCurso(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener matrículas: %w", err)
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil, fmt.Errorf("error al parsear matrículas")
+	}
+	if estudianteID != "" {
+		propias := make([]models.Matricula, 0, 1)
+		for _, m := range matriculas {
+			if m.EstudianteID == estudianteID {
+				propias = append(propias, m)
+			}
+		}
+		matriculas = propias
+	}
+
+	entregas, err := s.entregaRepo.GetByTareaIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	ocultarNoPublicadas(tareas, entregas, vistaDocente)
+
+	return CalcularLibro(esquema, tareas, matriculas, entregas, time.Now()), nil
+}
+
+// ocultarNoPublicadas quita las notas de tareas sin publicar para que no
+// cuenten en la nota final. El docente las conserva salvo en calificación
+// anónima, donde la matriz revelaría a quién pertenece cada nota.
+func ocultarNoPublicadas(tareas []models.Tarea, entregas []models.Entrega, vistaDocente bool) {
+	ocultas := make(map[uuid.UUID]bool)
+	for i := range tareas {
+		if !calificacionesPublicadas(&tareas[i]) && (!vistaDocente || tareas[i].CalificacionAnonima) {
+			ocultas[tareas[i].ID] = true
+		}
+	}
+	for i := range entregas {
+		if ocultas[entregas[i].TareaID] {
+			ocultarCalificacion(&entregas[i])
+		}
+	}
+}
+
+// CalcularLibro aplica el esquema sobre las notas. Es puro: no accede a la base de datos.
+func CalcularLibro(esquema *models.EsquemaCalificacion, tareas []models.Tarea, matriculas []models.Matricula, entregas []models.Entrega, ahora time.Time) *models.LibroCalificaciones {
+	sort.SliceStable(tareas, func(i, j int) bool { return tareas[i].FechaLimite.Before(tareas[j].FechaLimite) })
+
+	libro := &models.LibroCalificaciones{
+		CursoID: esquema.CursoID,
+		Esquema: *esquema,
+		Tareas:  make([]models.ColumnaLibro, 0, len(tareas)),
+		Filas:   make([]models.FilaLibro, 0, len(matriculas)),
+	}
+
+	for _, t := range tareas {
+		libro.Tareas = append(libro.Tareas, models.ColumnaLibro{
+			TareaID:       t.ID,
+			Titulo:        t.Titulo,
+			Tipo:          t.Tipo,
+			PuntajeMaximo: t.PuntajeMaximo,
+			FechaLimite:   t.FechaLimite,
+			Peso:          pesoDeTarea(esquema, &t),
+			Publicada:     calificacionesPublicadas(&t),
+		})
+	}
+
+	// Última entrega por tarea y estudiante (vienen ordenadas de la más reciente)
+	type clave struct{ tarea, estudiante uuid.UUID }
+	porClave := make(map[clave]*models.Entrega, len(entregas))
+	for i := range entregas {
+		k := clave{entregas[i].TareaID, entregas[i].EstudianteID}
+		if _, ok := porClave[k]; !ok {
+			porClave[k] = &entregas[i]
+		}
+	}
+
+	for _, m := range matriculas {
+		fila := models.FilaLibro{
+			MatriculaID:  m.ID,
+			EstudianteID: m.EstudianteID,
+			Estado:       m.Estado,
+			Celdas:       make([]models.CeldaLibro, 0, len(tareas)),
+			NotaFinal:    m.NotaFinal,
+		}
+		if m.Estudiante != nil {
+			fila.Codigo = m.Estudiante.CodigoEstudiante
+			if m.Estudiante.Usuario != nil {
+				fila.Nombre = m.Estudiante.Usuario.NombreCompleto
+			}
+		}
+
+		estudianteID, _ := uuid.Parse(m.EstudianteID)
+		fracciones := make([]*float64, len(tareas))
+		for i := range tareas {
+			celda, fraccion := calcularCelda(esquema, &tareas[i], porClave[clave{tareas[i].ID, estudianteID}], ahora)
+			fila.Celdas = append(fila.Celdas, celda)
+			fracciones[i] = fraccion
+		}
+
+		descartarMenores(esquema, tareas, fracciones, fila.Celdas)
+		fila.PromedioTipo, fila.NotaCalculada = notaPonderada(esquema, tareas, fracciones, fila.Celdas)
+
+		libro.Filas = append(libro.Filas, fila)
+	}
+
+	return libro
+}
+
+// calcularCelda devuelve la celda y la fracción (0-1) que cuenta para el promedio,
+// o nil si la tarea no cuenta para este estudiante
+func calcularCelda(esquema *models.EsquemaCalificacion, tarea *models.Tarea, entrega *models.Entrega, ahora time.Time) (models.CeldaLibro, *float64) {
+	celda := models.CeldaLibro{TareaID: tarea.ID}
+
+	switch {
+	case entrega != nil && entrega.Calificacion != nil:
+		id := entrega.ID
+		celda.EntregaID = &id
+		celda.Calificacion = entrega.Calificacion
+		celda.Estado = "calificada"
+		if tarea.PuntajeMaximo <= 0 {
+			return celda, nil
+		}
+		fraccion := math.Max(0, math.Min(1, *entrega.Calificacion/tarea.PuntajeMaximo))
+		celda.Porcentaje = redondear2(fraccion * 100)
+		return celda, &fraccion
+
+	case entrega != nil:
+		id := entrega.ID
+		celda.EntregaID = &id
+		celda.Estado = "por_calificar"
+		return celda, nil
+
+	case tarea.FechaLimite.Before(ahora):
+		celda.Estado = "sin_entrega"
+		if esquema.Faltantes == models.FaltantesExcluir {
+			return celda, nil
+		}
+		cero := 0.0
+		celda.Porcentaje = redondear2(0)
+		return celda, &cero
+
+	default:
+		celda.Estado = "pendiente"
+		return celda, nil
+	}
+}
+
+// descartarMenores marca las N notas más bajas de cada tipo, dejando al menos una
+func descartarMenores(esquema *models.EsquemaCalificacion, tareas []models.Tarea, fracciones []*float64, celdas []models.CeldaLibro) {
+	for tipo, n := range esquema.EliminarMenores {
+		if n <= 0 {
+			continue
+		}
+
+		indices := make([]int, 0)
+		for i := range tareas {
+			if tareas[i].Tipo == tipo && fracciones[i] != nil {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) <= 1 {
+			continue
+		}
+		if n > len(indices)-1 {
+			n = len(indices) - 1
+		}
+
+		sort.SliceStable(indices, func(a, b int) bool { return *fracciones[indices[a]] < *fracciones[indices[b]] })
+		for _, i := range indices[:n] {
+			fracciones[i] = nil
+			celdas[i].Descartada = true
+		}
+	}
+}
+
+// notaPonderada devuelve el promedio por tipo (0-100) y la nota en la escala del esquema
+func notaPonderada(esquema *models.EsquemaCalificacion, tareas []models.Tarea, fracciones []*float64, celdas []models.CeldaLibro) (map[string]*float64, *float64) {
+	suma := make(map[string]float64)
+	cuenta := make(map[string]int)
+	for i := range tareas {
+		if fracciones[i] == nil {
+			continue
+		}
+		suma[tareas[i].Tipo] += *fracciones[i]
+		cuenta[tareas[i].Tipo]++
+	}
+
+	promedios := make(map[string]*float64, len(cuenta))
+	for _, t := range tareas {
+		if _, ok := promedios[t.Tipo]; ok {
+			continue
+		}
+		promedios[t.Tipo] = nil
+		if cuenta[t.Tipo] > 0 {
+			promedios[t.Tipo] = redondear2(suma[t.Tipo] / float64(cuenta[t.Tipo]) * 100)
+		}
+	}
+
+	total, pesoTotal := 0.0, 0.0
+	if esquema.Modo == models.ModoPesoPorTarea {
+		for i := range tareas {
+			if fracciones[i] == nil {
+				continue
+			}
+			peso := pesoDeTarea(esquema, &tareas[i])
+			total += peso * *fracciones[i]
+			pesoTotal += peso
+		}
+	} else {
+		for tipo, n := range cuenta {
+			peso := pesoDeTipo(esquema, tipo)
+			total += peso * suma[tipo] / float64(n)
+			pesoTotal += peso
+		}
+	}
+
+	if pesoTotal == 0 {
+		return promedios, nil
+	}
+	return promedios, redondear2(total / pesoTotal * esquema.Escala)
+}
+
+// Sin pesos configurados todo pesa lo mismo
+func pesoDeTipo(esquema *models.EsquemaCalificacion, tipo string) float64 {
+	if len(esquema.Pesos) == 0 {
+		return 1
+	}
+	return esquema.Pesos[tipo]
+}
+
+func pesoDeTarea(esquema *models.EsquemaCalificacion, tarea *models.Tarea) float64 {
+	if esquema.Modo != models.ModoPesoPorTarea {
+		return pesoDeTipo(esquema, tarea.Tipo)
+	}
+	if len(esquema.Pesos) == 0 {
+		return 1
+	}
+	return esquema.Pesos[tarea.ID.String()]
+}
+
+func redondear2(v float64) *float64 {
+	r := math.Round(v*100) / 100
+	return &r
+}
+
+func contieneTexto(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================== RECÁLCULO ====================
+
+// RecalcularCurso guarda la nota calculada en cada matrícula activa del curso
+func (s *CalificacionesService) RecalcularCurso(ctx context.Context, cursoID string) (int, error) {
+	return s.recalcular(ctx, cursoID, "")
+}
+
+// RecalcularEstudiante actualiza solo la nota de un estudiante
+func (s *CalificacionesService) RecalcularEstudiante(ctx context.Context, cursoID, estudianteID string) error {
+	_, err := s.recalcular(ctx, cursoID, estudianteID)
+	return err
+}
+
+// RecalcularCursoDocente es el recálculo manual desde el endpoint
+func (s *CalificacionesService) RecalcularCursoDocente(ctx context.Context, cursoID, usuarioID, rol string) (int, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return 0, err
+	}
+	return s.RecalcularCurso(ctx, cursoID)
+}
+
+func (s *CalificacionesService) recalcular(ctx context.Context, cursoID, estudianteID string) (int, error) {
+	libro, err := s.armarLibro(ctx, cursoID, estudianteID, false)
+	if err != nil {
+		return 0, err
+	}
+
+	actualizadas := 0
+	for _, fila := range libro.Filas {
+		if fila.Estado != "activo" || mismaNota(fila.NotaFinal, fila.NotaCalculada) {
+			continue
+		}
+		if err := s.calificacionesRepo.ActualizarNotaFinal(ctx, fila.MatriculaID, fila.NotaCalculada); err != nil {
+			return actualizadas, err
+		}
+		actualizadas++
+	}
+
+	return actualizadas, nil
+}
+
+func mismaNota(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return math.Abs(*a-*b) < 0.005
+}
+
+// RecalcularEnSegundoPlano se usa tras calificar o modificar tareas
+func (s *CalificacionesService) RecalcularEnSegundoPlano(cursoID, estudianteID string) {
+	if s == nil {
+		return
+	}
+	go func() {
+		if _, err := s.recalcular(context.Background(), cursoID, estudianteID); err != nil {
+			log.Printf("❌ Error recalculando notas del curso %s: %v", cursoID, err)
+		}
+	}()
+}
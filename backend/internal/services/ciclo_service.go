@@ -10,13 +10,24 @@ import (
 
 // ✅ CicloService con dependency injection
 type CicloService struct {
-	cicloRepo repository.CicloRepository
+	cicloRepo          repository.CicloRepository
+	cursoRepo          repository.CursoRepository
+	matriculaRepo      repository.MatriculaRepository
+	calificacionesRepo *repository.CalificacionesRepository
 }
 
 // ✅ Constructor actualizado
-func NewCicloService(cicloRepo repository.CicloRepository) *CicloService {
+func NewCicloService(
+	cicloRepo repository.CicloRepository,
+	cursoRepo repository.CursoRepository,
+	matriculaRepo repository.MatriculaRepository,
+	calificacionesRepo *repository.CalificacionesRepository,
+) *CicloService {
 	return &CicloService{
-		cicloRepo: cicloRepo,
+		cicloRepo:          cicloRepo,
+		cursoRepo:          cursoRepo,
+		matriculaRepo:      matriculaRepo,
+		calificacionesRepo: calificacionesRepo,
 	}
 }
 
@@ -71,12 +82,12 @@ func (s *CicloService) ListarCiclos() ([]models.Ciclo, error) {
 func (s *CicloService) ObtenerCicloPorID(cicloID string) (*models.Ciclo, error) {
 	respBody, err := s.cicloRepo.GetCicloByID(cicloID)
 	if err != nil {
-		return nil, fmt.Errorf("ciclo no encontrado")
+		return nil, ErrCicloNoEncontrado
 	}
 
 	var ciclos []models.Ciclo
 	if err := json.Unmarshal(respBody, &ciclos); err != nil || len(ciclos) == 0 {
-		return nil, fmt.Errorf("ciclo no encontrado")
+		return nil, ErrCicloNoEncontrado
 	}
 
 	return &ciclos[0], nil
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"recetario-backend/internal/models"
+)
+
+// Último ciclo de la carrera: quien lo aprueba no pasa a otro
+const cicloMaximoEstudiante = 10
+
+var (
+	ErrCicloNoEncontrado = errors.New("ciclo no encontrado")
+	// ErrCicloCerrado: el ciclo ya se cerró y sus notas no se pueden modificar
+	ErrCicloCerrado = errors.New("el ciclo está cerrado: sus notas ya no se pueden modificar")
+)
+
+// esCicloCerrado reconoce el error que lanza la base de datos al intentar
+// cambiar notas de un ciclo cerrado
+func esCicloCerrado(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "ciclo_cerrado")
+}
+
+// planCierre es lo que cerrar_ciclo aplica en la base de datos
+type planCierre struct {
+	matriculas  []map[string]interface{}
+	promociones []map[string]interface{}
+}
+
+// ==================== CIERRE DE CICLO ====================
+
+// CerrarCiclo finaliza las matrículas del ciclo según la nota aprobatoria de
+// cada curso, bloquea sus notas, promueve a los estudiantes sin cursos
+// desaprobados, archiva los cursos y activa el ciclo siguiente. Sin confirmar
+// devuelve la vista previa; al confirmar todo se aplica en una transacción.
+func (s *CicloService) CerrarCiclo(cicloID string, req *models.CierreCicloRequest) (*models.ResultadoCierreCiclo, error) {
+	ciclo, err := s.ObtenerCicloPorID(cicloID)
+	if err != nil {
+		return nil, err
+	}
+	if ciclo.CerradoAt != nil {
+		return nil, ErrCicloCerrado
+	}
+
+	if req.CicloSiguienteID == "" {
+		return nil, fmt.Errorf("debe indicar el ciclo siguiente")
+	}
+	if req.CicloSiguienteID == cicloID {
+		return nil, fmt.Errorf("el ciclo siguiente debe ser distinto al que se cierra")
+	}
+	siguiente, err := s.ObtenerCicloPorID(req.CicloSiguienteID)
+	if err != nil {
+		return nil, fmt.Errorf("el ciclo siguiente no existe")
+	}
+	if siguiente.CerradoAt != nil {
+		return nil, fmt.Errorf("el ciclo %s ya fue cerrado", siguiente.Nombre)
+	}
+
+	resultado, plan, err := s.planificarCierre(ciclo, siguiente)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Confirmar {
+		return resultado, nil
+	}
+
+	respBody, err := s.cicloRepo.CerrarCiclo(map[string]interface{}{
+		"p_ciclo_id":           cicloID,
+		"p_ciclo_siguiente_id": siguiente.ID,
+		"p_matriculas":         plan.matriculas,
+		"p_promociones":        plan.promociones,
+	})
+	if err != nil {
+		if esCicloCerrado(err) {
+			return nil, ErrCicloCerrado
+		}
+		return nil, fmt.Errorf("el cierre no se aplicó: %w", err)
+	}
+
+	var conteos struct {
+		MatriculasFinalizadas int `json:"matriculas_finalizadas"`
+		EstudiantesPromovidos int `json:"estudiantes_promovidos"`
+		CursosArchivados      int `json:"cursos_archivados"`
+		ListaEsperaEliminada  int `json:"lista_espera_eliminada"`
+	}
+	if err := json.Unmarshal(respBody, &conteos); err != nil {
+		return nil, fmt.Errorf("error al parsear resultado del cierre: %w", err)
+	}
+
+	for i := range resultado.Pasos {
+		paso := &resultado.Pasos[i]
+		paso.Estado = models.PasoCompletado
+		switch paso.Paso {
+		case models.PasoFinalizarMatriculas:
+			paso.Afectados = conteos.MatriculasFinalizadas
+		case models.PasoPromoverEstudiantes:
+			paso.Afectados = conteos.EstudiantesPromovidos
+		case models.PasoArchivarCursos:
+			paso.Afectados = conteos.CursosArchivados
+			paso.Detalle = fmt.Sprintf("%d cursos archivados; %d solicitudes en lista de espera eliminadas",
+				conteos.CursosArchivados, conteos.ListaEsperaEliminada)
+		}
+	}
+	resultado.Confirmado = true
+
+	fmt.Printf("✅ Ciclo %s cerrado: %d matrículas finalizadas, %d estudiantes promovidos, %s activo\n",
+		ciclo.Nombre, conteos.MatriculasFinalizadas, conteos.EstudiantesPromovidos, siguiente.Nombre)
+
+	return resultado, nil
+}
+
+// planificarCierre calcula el estado final de cada matrícula activa y la
+// promoción de cada estudiante del ciclo
+func (s *CicloService) planificarCierre(ciclo, siguiente *models.Ciclo) (*models.ResultadoCierreCiclo, *planCierre, error) {
+	ctx := context.Background()
+
+	respBody, err := s.cursoRepo.GetCursosByCiclo(ciclo.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener cursos del ciclo: %w", err)
+	}
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil {
+		return nil, nil, fmt.Errorf("error al parsear cursos")
+	}
+
+	respBody, err = s.matriculaRepo.GetMatriculasByCiclo(ciclo.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener matrículas del ciclo: %w", err)
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil, nil, fmt.Errorf("error al parsear matrículas")
+	}
+
+	resultado := &models.ResultadoCierreCiclo{
+		CicloID:          ciclo.ID,
+		Ciclo:            ciclo.Nombre,
+		CicloSiguienteID: siguiente.ID,
+		CicloSiguiente:   siguiente.Nombre,
+		Cursos:           make([]models.CierreCurso, 0, len(cursos)),
+		Matriculas:       []models.CierreMatricula{},
+		Estudiantes:      []models.PromocionEstudiante{},
+		Advertencias:     []string{},
+	}
+	plan := &planCierre{
+		matriculas:  []map[string]interface{}{},
+		promociones: []map[string]interface{}{},
+	}
+
+	// Nota aprobatoria del esquema de cada curso
+	resumen := make(map[string]*models.CierreCurso, len(cursos))
+	for _, curso := range cursos {
+		notaAprobatoria := esquemaPorDefecto(curso.ID).NotaAprobatoria
+		esquema, err := s.calificacionesRepo.GetEsquema(ctx, curso.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error al obtener esquema de %s: %w", curso.Nombre, err)
+		}
+		if esquema != nil {
+			notaAprobatoria = esquema.NotaAprobatoria
+		}
+		resultado.Cursos = append(resultado.Cursos, models.CierreCurso{
+			CursoID:         curso.ID,
+			Codigo:          curso.Codigo,
+			Nombre:          curso.Nombre,
+			NotaAprobatoria: notaAprobatoria,
+		})
+	}
+	for i := range resultado.Cursos {
+		resumen[resultado.Cursos[i].CursoID] = &resultado.Cursos[i]
+	}
+
+	type situacion struct {
+		promocion    models.PromocionEstudiante
+		aprobados    int
+		desaprobados int
+	}
+	estudiantes := make(map[string]*situacion)
+	aprobadas, desaprobadas := 0, 0
+
+	for _, m := range matriculas {
+		estado := m.Estado
+		curso := resumen[m.CursoID]
+
+		if estado == "activo" && curso != nil {
+			estado = models.EstadoMatriculaDesaprobado
+			if m.NotaFinal != nil && *m.NotaFinal >= curso.NotaAprobatoria {
+				estado = models.EstadoMatriculaAprobado
+			}
+
+			if estado == models.EstadoMatriculaAprobado {
+				curso.Aprobados++
+				aprobadas++
+			} else {
+				curso.Desaprobados++
+				desaprobadas++
+			}
+			if m.NotaFinal == nil {
+				curso.SinNota++
+			}
+
+			nombre := ""
+			if m.Estudiante != nil && m.Estudiante.Usuario != nil {
+				nombre = m.Estudiante.Usuario.NombreCompleto
+			}
+			resultado.Matriculas = append(resultado.Matriculas, models.CierreMatricula{
+				MatriculaID:  m.ID,
+				EstudianteID: m.EstudianteID,
+				Estudiante:   nombre,
+				CursoID:      m.CursoID,
+				Curso:        curso.Nombre,
+				NotaFinal:    m.NotaFinal,
+				EstadoNuevo:  estado,
+			})
+			plan.matriculas = append(plan.matriculas, map[string]interface{}{
+				"id":            m.ID,
+				"estado":        estado,
+				"nota_esperada": m.NotaFinal,
+			})
+		}
+
+		// Solo cuentan para la promoción los cursos con resultado final
+		if estado != models.EstadoMatriculaAprobado && estado != models.EstadoMatriculaDesaprobado {
+			continue
+		}
+		if m.Estudiante == nil {
+			resultado.Advertencias = append(resultado.Advertencias,
+				fmt.Sprintf("la matrícula %s no tiene datos de estudiante; no se evalúa su promoción", m.ID))
+			continue
+		}
+
+		sit, ok := estudiantes[m.EstudianteID]
+		if !ok {
+			sit = &situacion{promocion: models.PromocionEstudiante{
+				EstudianteID:  m.EstudianteID,
+				Codigo:        m.Estudiante.CodigoEstudiante,
+				CicloAnterior: m.Estudiante.CicloActual,
+				CicloNuevo:    m.Estudiante.CicloActual,
+			}}
+			if m.Estudiante.Usuario != nil {
+				sit.promocion.Nombre = m.Estudiante.Usuario.NombreCompleto
+			}
+			estudiantes[m.EstudianteID] = sit
+		}
+		if estado == models.EstadoMatriculaAprobado {
+			sit.aprobados++
+		} else {
+			sit.desaprobados++
+		}
+	}
+
+	promovidos := 0
+	for _, sit := range estudiantes {
+		p := sit.promocion
+		switch {
+		case sit.desaprobados > 0:
+			p.Motivo = fmt.Sprintf("desaprobó %d de %d cursos", sit.desaprobados, sit.aprobados+sit.desaprobados)
+		case p.CicloAnterior >= cicloMaximoEstudiante:
+			p.Motivo = "ya está en el último ciclo"
+		default:
+			p.Promovido = true
+			p.CicloNuevo = p.CicloAnterior + 1
+			promovidos++
+			plan.promociones = append(plan.promociones, map[string]interface{}{
+				"usuario_id":     p.EstudianteID,
+				"ciclo_anterior": p.CicloAnterior,
+				"ciclo_nuevo":    p.CicloNuevo,
+			})
+		}
+		resultado.Estudiantes = append(resultado.Estudiantes, p)
+	}
+
+	sort.Slice(resultado.Matriculas, func(i, j int) bool {
+		a, b := resultado.Matriculas[i], resultado.Matriculas[j]
+		if a.Curso != b.Curso {
+			return a.Curso < b.Curso
+		}
+		return a.Estudiante < b.Estudiante
+	})
+	sort.Slice(resultado.Estudiantes, func(i, j int) bool {
+		return resultado.Estudiantes[i].Nombre < resultado.Estudiantes[j].Nombre
+	})
+
+	for _, curso := range resultado.Cursos {
+		if curso.SinNota > 0 {
+			resultado.Advertencias = append(resultado.Advertencias,
+				fmt.Sprintf("%s: %d matrículas sin nota final quedarán desaprobadas", curso.Nombre, curso.SinNota))
+		}
+	}
+	if siguiente.FechaInicio < ciclo.FechaFin {
+		resultado.Advertencias = append(resultado.Advertencias,
+			fmt.Sprintf("el ciclo %s empieza antes de que termine %s", siguiente.Nombre, ciclo.Nombre))
+	}
+
+	resultado.Pasos = []models.PasoCierre{
+		{
+			Paso:      models.PasoFinalizarMatriculas,
+			Afectados: len(plan.matriculas),
+			Detalle:   fmt.Sprintf("%d aprobadas y %d desaprobadas", aprobadas, desaprobadas),
+		},
+		{
+			Paso:      models.PasoBloquearNotas,
+			Afectados: len(cursos),
+			Detalle:   fmt.Sprintf("las notas de los %d cursos del ciclo dejan de poder modificarse", len(cursos)),
+		},
+		{
+			Paso:      models.PasoPromoverEstudiantes,
+			Afectados: promovidos,
+			Detalle:   fmt.Sprintf("%d promovidos y %d permanecen en su ciclo", promovidos, len(estudiantes)-promovidos),
+		},
+		{
+			Paso:      models.PasoArchivarCursos,
+			Afectados: len(cursos),
+			Detalle:   "los cursos del ciclo se desactivan y su lista de espera se elimina",
+		},
+		{
+			Paso:      models.PasoActivarCiclo,
+			Afectados: 1,
+			Detalle:   fmt.Sprintf("%s pasa a ser el ciclo activo", siguiente.Nombre),
+		},
+	}
+	for i := range resultado.Pasos {
+		resultado.Pasos[i].Estado = models.PasoPendiente
+	}
+
+	return resultado, plan, nil
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ==================== CLONAR CURSO ====================
+
+// ClonarCurso copia el curso a otro ciclo con sus temas, materiales, tareas,
+// banco de preguntas y cuestionarios.
+// Las fechas se desplazan según el inicio del nuevo ciclo. Si falla la copia
+// del contenido se elimina el curso creado para no dejar un clon a medias.
+func (s *CursoService) ClonarCurso(cursoID string, req *models.ClonarCursoRequest) (*models.ResultadoClonacion, error) {
+	ctx := context.Background()
+
+	origen, err := s.ObtenerCursoPorID(cursoID)
+	if err != nil {
+		return nil, ErrCursoNoEncontrado
+	}
+	if req.CicloID == "" {
+		return nil, fmt.Errorf("debe indicar el ciclo destino")
+	}
+
+	cicloOrigen, err := s.obtenerCiclo(origen.CicloID)
+	if err != nil {
+		return nil, err
+	}
+	cicloDestino, err := s.obtenerCiclo(req.CicloID)
+	if err != nil {
+		return nil, fmt.Errorf("el ciclo seleccionado no existe")
+	}
+	desfase, err := desfaseEntreCiclos(cicloOrigen, cicloDestino)
+	if err != nil {
+		return nil, err
+	}
+
+	temas, err := s.temasConMateriales(cursoID)
+	if err != nil {
+		return nil, err
+	}
+	origenID, err := uuid.Parse(origen.ID)
+	if err != nil {
+		return nil, ErrCursoNoEncontrado
+	}
+	tareas, err := s.tareaRepo.GetByCursoID(ctx, origenID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tareas del curso: %w", err)
+	}
+
+	nuevo := &models.CrearCursoRequest{
+		Codigo:      origen.Codigo,
+		Nombre:      origen.Nombre,
+		Descripcion: origen.Descripcion,
+		DocenteID:   origen.DocenteID,
+		CicloID:     req.CicloID,
+		Nivel:       origen.Nivel,
+		Seccion:     origen.Seccion,
+		Creditos:    origen.Creditos,
+		Capacidad:   origen.Capacidad,
+
+		DesbloqueoSecuencial: origen.DesbloqueoSecuencial,
+		Horario:              origen.Horario,
+		Sesiones:             origen.Sesiones,
+	}
+	if req.DocenteID != "" {
+		nuevo.DocenteID = req.DocenteID
+	}
+	if req.Seccion != nil {
+		nuevo.Seccion = *req.Seccion
+	}
+	if req.Sesiones != nil {
+		nuevo.Horario = ""
+		nuevo.Sesiones = *req.Sesiones
+	}
+
+	// Valida docente, ciclo y cruces de horario en el ciclo destino
+	nuevoID, err := s.crearCurso(nuevo)
+	if err != nil {
+		return nil, err
+	}
+	nuevoUUID, err := uuid.Parse(nuevoID)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo obtener el ID del curso creado")
+	}
+
+	resultado := &models.ResultadoClonacion{
+		CursoID:       nuevoID,
+		CursoOrigenID: cursoID,
+		CicloID:       req.CicloID,
+		DocenteID:     nuevo.DocenteID,
+		DesfaseDias:   desfase,
+		Advertencias:  []string{},
+	}
+	clon := &clonacionCurso{
+		servicio:  s,
+		ctx:       ctx,
+		req:       req,
+		cursoID:   nuevoUUID,
+		desfase:   desfase,
+		resultado: resultado,
+		temas:     make(map[uuid.UUID]uuid.UUID, len(temas)),
+		tareas:    make(map[uuid.UUID]uuid.UUID, len(tareas)),
+		preguntas: make(map[uuid.UUID]uuid.UUID),
+	}
+
+	if err := clon.copiarContenido(cursoID, temas, tareas); err != nil {
+		clon.deshacer()
+		return nil, err
+	}
+
+	fmt.Printf("✅ Curso %s clonado como %s: %d temas, %d materiales, %d tareas\n",
+		cursoID, nuevoID, resultado.Temas, resultado.Materiales, resultado.Tareas)
+
+	return resultado, nil
+}
+
+// clonacionCurso lleva el estado de una clonación en curso
+type clonacionCurso struct {
+	servicio  *CursoService
+	ctx       context.Context
+	req       *models.ClonarCursoRequest
+	cursoID   uuid.UUID
+	desfase   int
+	resultado *models.ResultadoClonacion
+
+	temas     map[uuid.UUID]uuid.UUID // tema original -> tema nuevo
+	tareas    map[uuid.UUID]uuid.UUID // tarea original -> tarea nueva
+	preguntas map[uuid.UUID]uuid.UUID // pregunta original -> pregunta nueva
+	archivos  []string                // archivos duplicados, para deshacer
+}
+
+func (c *clonacionCurso) copiarContenido(cursoOrigenID string, temas []models.Tema, tareas []models.Tarea) error {
+	for i := range temas {
+		if err := c.copiarTema(&temas[i]); err != nil {
+			return err
+		}
+	}
+
+	c.copiarPreguntas(cursoOrigenID)
+
+	inactivas := 0
+	for i := range tareas {
+		if !tareas[i].Activo {
+			inactivas++
+			continue
+		}
+		if err := c.copiarTarea(&tareas[i]); err != nil {
+			return err
+		}
+	}
+	if inactivas > 0 {
+		c.advertir("%d tareas inactivas no se copiaron", inactivas)
+	}
+
+	c.copiarEsquema(cursoOrigenID)
+	return nil
+}
+
+func (c *clonacionCurso) copiarTema(tema *models.Tema) error {
+	temaData := map[string]interface{}{
+		"curso_id":    c.cursoID.String(),
+		"titulo":      tema.Titulo,
+		"descripcion": tema.Descripcion,
+		"orden":       tema.Orden,
+		"activo":      tema.Activo,
+
+		"notificar_desbloqueo": tema.NotificarDesbloqueo,
+	}
+	if tema.FechaDesbloqueo != nil {
+		temaData["fecha_desbloqueo"] = desplazarFecha(*tema.FechaDesbloqueo, c.desfase)
+	}
+
+	respBody, err := c.servicio.temaRepo.CreateTema(temaData)
+	if err != nil {
+		return fmt.Errorf("error al copiar el tema %q: %w", tema.Titulo, err)
+	}
+	var creados []models.Tema
+	if err := json.Unmarshal(respBody, &creados); err != nil || len(creados) == 0 {
+		return fmt.Errorf("error al copiar el tema %q: respuesta inválida", tema.Titulo)
+	}
+	nuevoTemaID := creados[0].ID
+	c.temas[tema.ID] = nuevoTemaID
+	c.resultado.Temas++
+
+	sort.SliceStable(tema.Materiales, func(i, j int) bool {
+		return tema.Materiales[i].Orden < tema.Materiales[j].Orden
+	})
+	for i := range tema.Materiales {
+		material := &tema.Materiales[i]
+		if !material.Activo {
+			continue
+		}
+		if err := c.copiarMaterial(material, nuevoTemaID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copiarMaterial reutiliza el archivo del original salvo que se pida
+// duplicarlo; si la copia falla, el material apunta al archivo original
+func (c *clonacionCurso) copiarMaterial(material *models.Material, temaID uuid.UUID) error {
+	urlArchivo := material.URLArchivo
+	storage := c.servicio.storageService
+	if c.req.DuplicarArchivos && storage.IsStoredFile(urlArchivo) {
+		copia, err := storage.CopyFile(urlArchivo, "materiales/"+temaID.String())
+		if err != nil {
+			c.advertir("no se pudo duplicar el archivo de %q; se reutiliza el original: %v", material.Titulo, err)
+		} else {
+			urlArchivo = copia
+			c.archivos = append(c.archivos, copia)
+			c.resultado.ArchivosDuplicados++
+		}
+	}
+
+	_, err := c.servicio.materialRepo.Create(c.ctx, &models.CreateMaterialRequest{
+		TemaID:          temaID,
+		Titulo:          material.Titulo,
+		Tipo:            material.Tipo,
+		URLArchivo:      urlArchivo,
+		TamanoMB:        material.TamanoMB,
+		DuracionMinutos: material.DuracionMinutos,
+		Descripcion:     material.Descripcion,
+		Orden:           material.Orden,
+		Obligatorio:     &material.Obligatorio,
+	})
+	if err != nil {
+		return fmt.Errorf("error al copiar el material %q: %w", material.Titulo, err)
+	}
+	c.resultado.Materiales++
+
+	return nil
+}
+
+// copiarTarea crea la tarea con la fecha límite desplazada y le asigna la
+// misma rúbrica y el mismo cuestionario. Las calificaciones empiezan sin publicar.
+func (c *clonacionCurso) copiarTarea(tarea *models.Tarea) error {
+	var temaID *uuid.UUID
+	if tarea.TemaID != nil {
+		if nuevo, ok := c.temas[*tarea.TemaID]; ok {
+			temaID = &nuevo
+		}
+	}
+
+	creada, err := c.servicio.tareaRepo.Create(c.ctx, &models.CreateTareaRequest{
+		CursoID:              c.cursoID,
+		TemaID:               temaID,
+		Titulo:               tarea.Titulo,
+		Descripcion:          tarea.Descripcion,
+		Semana:               tarea.Semana,
+		FechaLimite:          desplazarFecha(tarea.FechaLimite, c.desfase),
+		PuntajeMaximo:        tarea.PuntajeMaximo,
+		PermiteEntregaTardia: tarea.PermiteEntregaTardia,
+		PenalizacionPorDia:   tarea.PenalizacionPorDia,
+		DiasTolerancia:       tarea.DiasTolerancia,
+		Tipo:                 tarea.Tipo,
+		CalificacionAnonima:  tarea.CalificacionAnonima,
+		EntregaGrupal:        tarea.EntregaGrupal,
+		PermiteReenvio:       &tarea.PermiteReenvio,
+		MaxReenvios:          tarea.MaxReenvios,
+	})
+	if err != nil {
+		return fmt.Errorf("error al copiar la tarea %q: %w", tarea.Titulo, err)
+	}
+	c.tareas[tarea.ID] = creada.ID
+	c.resultado.Tareas++
+
+	c.copiarRubrica(tarea, creada.ID)
+	c.copiarCuestionario(tarea, creada.ID)
+	return nil
+}
+
+func (c *clonacionCurso) copiarRubrica(tarea *models.Tarea, nuevaID uuid.UUID) {
+	rubrica, err := c.servicio.rubricaRepo.GetDeTarea(c.ctx, tarea.ID)
+	if err != nil {
+		c.advertir("no se pudo leer la rúbrica de %q: %v", tarea.Titulo, err)
+		return
+	}
+	if rubrica == nil {
+		return
+	}
+	rubrica.TareaID = nuevaID
+	if err := c.servicio.rubricaRepo.AsignarATarea(c.ctx, rubrica); err != nil {
+		c.advertir("no se pudo copiar la rúbrica de %q: %v", tarea.Titulo, err)
+		return
+	}
+	c.resultado.Rubricas++
+}
+
+// copiarPreguntas replica las preguntas activas del banco en el curso nuevo
+func (c *clonacionCurso) copiarPreguntas(cursoOrigenID string) {
+	origenID, err := uuid.Parse(cursoOrigenID)
+	if err != nil {
+		return
+	}
+	preguntas, err := c.servicio.cuestionarioRepo.GetPreguntasByCurso(c.ctx, origenID)
+	if err != nil {
+		c.advertir("no se pudo leer el banco de preguntas: %v", err)
+		return
+	}
+
+	fallidas := 0
+	for _, p := range preguntas {
+		nueva, err := c.servicio.cuestionarioRepo.CrearPregunta(c.ctx, map[string]interface{}{
+			"curso_id":             c.cursoID.String(),
+			"tipo":                 p.Tipo,
+			"enunciado":            p.Enunciado,
+			"opciones":             p.Opciones,
+			"respuesta_verdadera":  p.RespuestaVerdadera,
+			"respuestas_aceptadas": p.RespuestasAceptadas,
+			"pares":                p.Pares,
+			"puntaje":              p.Puntaje,
+			"creado_por":           p.CreadoPor,
+			"activo":               true,
+		})
+		if err != nil {
+			fallidas++
+			continue
+		}
+		c.preguntas[p.ID] = nueva.ID
+		c.resultado.Preguntas++
+	}
+	if fallidas > 0 {
+		c.advertir("%d preguntas del banco no se pudieron copiar", fallidas)
+	}
+}
+
+// copiarCuestionario copia la foto de preguntas del cuestionario. Las que
+// se copiaron al banco nuevo quedan enlazadas con su nueva pregunta.
+func (c *clonacionCurso) copiarCuestionario(tarea *models.Tarea, nuevaID uuid.UUID) {
+	cuestionario, err := c.servicio.cuestionarioRepo.GetDeTarea(c.ctx, tarea.ID)
+	if err != nil {
+		c.advertir("no se pudo leer el cuestionario de %q: %v", tarea.Titulo, err)
+		return
+	}
+	if cuestionario == nil {
+		return
+	}
+
+	cuestionario.TareaID = nuevaID
+	for i := range cuestionario.Preguntas {
+		p := &cuestionario.Preguntas[i]
+		if nueva, ok := c.preguntas[p.ID]; ok {
+			p.ID = nueva
+		}
+		p.CursoID = c.cursoID
+	}
+	if err := c.servicio.cuestionarioRepo.Guardar(c.ctx, cuestionario); err != nil {
+		c.advertir("no se pudo copiar el cuestionario de %q: %v", tarea.Titulo, err)
+		return
+	}
+	c.resultado.Cuestionarios++
+}
+
+// copiarEsquema replica el esquema de calificación; en modo tarea los pesos
+// pasan a las tareas nuevas
+func (c *clonacionCurso) copiarEsquema(cursoOrigenID string) {
+	esquema, err := c.servicio.calificacionesRepo.GetEsquema(c.ctx, cursoOrigenID)
+	if err != nil {
+		c.advertir("no se pudo leer el esquema de calificación: %v", err)
+		return
+	}
+	if esquema == nil {
+		return
+	}
+
+	esquema.CursoID = c.cursoID.String()
+	if esquema.Modo == models.ModoPesoPorTarea {
+		pesos := make(map[string]float64, len(esquema.Pesos))
+		for tareaID, peso := range esquema.Pesos {
+			original, err := uuid.Parse(tareaID)
+			if err != nil {
+				continue
+			}
+			if nueva, ok := c.tareas[original]; ok {
+				pesos[nueva.String()] = peso
+			}
+		}
+		if len(pesos) < len(esquema.Pesos) {
+			c.advertir("%d pesos del esquema corresponden a tareas que no se copiaron", len(esquema.Pesos)-len(pesos))
+		}
+		esquema.Pesos = pesos
+	}
+
+	if err := c.servicio.calificacionesRepo.GuardarEsquema(c.ctx, esquema); err != nil {
+		c.advertir("no se pudo copiar el esquema de calificación: %v", err)
+		return
+	}
+	c.resultado.EsquemaCopiado = true
+}
+
+// deshacer elimina el curso clonado y los archivos ya duplicados
+func (c *clonacionCurso) deshacer() {
+	if err := c.servicio.storageService.DeleteMultipleFiles(c.archivos); err != nil {
+		fmt.Printf("⚠️ No se pudieron eliminar los archivos duplicados: %v\n", err)
+	}
+	if err := c.servicio.cursoRepo.DeleteCurso(c.cursoID.String()); err != nil {
+		fmt.Printf("❌ No se pudo eliminar el curso clonado %s: %v\n", c.cursoID, err)
+	}
+}
+
+func (c *clonacionCurso) advertir(formato string, args ...interface{}) {
+	c.resultado.Advertencias = append(c.resultado.Advertencias, fmt.Sprintf(formato, args...))
+}
+
+// temasConMateriales lista los temas del curso con sus materiales
+func (s *CursoService) temasConMateriales(cursoID string) ([]models.Tema, error) {
+	respBody, err := s.temaRepo.GetTemasByCursoID(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener temas del curso: %w", err)
+	}
+
+	var temas []models.Tema
+	if err := json.Unmarshal(respBody, &temas); err != nil {
+		return nil, fmt.Errorf("error al parsear temas")
+	}
+
+	return temas, nil
+}
+
+func (s *CursoService) obtenerCiclo(cicloID string) (*models.Ciclo, error) {
+	respBody, err := s.cicloRepo.GetCicloByID(cicloID)
+	if err != nil {
+		return nil, fmt.Errorf("ciclo no encontrado")
+	}
+
+	var ciclos []models.Ciclo
+	if err := json.Unmarshal(respBody, &ciclos); err != nil || len(ciclos) == 0 {
+		return nil, fmt.Errorf("ciclo no encontrado")
+	}
+
+	return &ciclos[0], nil
+}
+
+// desfaseEntreCiclos devuelve los días entre el inicio de ambos ciclos
+func desfaseEntreCiclos(origen, destino *models.Ciclo) (int, error) {
+	inicioOrigen, err := time.Parse("2006-01-02", origen.FechaInicio)
+	if err != nil {
+		return 0, fmt.Errorf("fecha de inicio inválida en el ciclo %s", origen.Nombre)
+	}
+	inicioDestino, err := time.Parse("2006-01-02", destino.FechaInicio)
+	if err != nil {
+		return 0, fmt.Errorf("fecha de inicio inválida en el ciclo %s", destino.Nombre)
+	}
+
+	return int(inicioDestino.Sub(inicioOrigen).Hours() / 24), nil
+}
+
+// desplazarFecha suma días de calendario conservando la hora local
+func desplazarFecha(fecha time.Time, dias int) time.Time {
+	return fecha.AddDate(0, 0, dias)
+}
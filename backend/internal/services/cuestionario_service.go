@@ -0,0 +1,1047 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPreguntaNoEncontrada     = errors.New("pregunta no encontrada")
+	ErrPreguntaInvalida         = errors.New("pregunta inválida")
+	ErrCuestionarioNoEncontrado = errors.New("la tarea no tiene cuestionario")
+	ErrCuestionarioConIntentos  = errors.New("el cuestionario ya fue iniciado por estudiantes y no se puede modificar")
+	ErrIntentoNoEncontrado      = errors.New("intento no encontrado")
+	ErrIntentoEnviado           = errors.New("el cuestionario ya fue enviado")
+	ErrIntentoAbierto           = errors.New("el intento aún no ha sido enviado")
+	ErrTiempoAgotado            = errors.New("el tiempo del cuestionario terminó; se calificaron las respuestas guardadas")
+)
+
+func errPregunta(formato string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrPreguntaInvalida, fmt.Sprintf(formato, args...))
+}
+
+// graciaCuestionario es el margen para respuestas que llegan justo al vencer
+// el tiempo (latencia de red). Pasado ese margen se ignoran.
+const graciaCuestionario = 30 * time.Second
+
+// CuestionarioService maneja el banco de preguntas, los cuestionarios de las
+// tareas y los intentos de los estudiantes con su corrección automática
+type CuestionarioService struct {
+	cuestionarioRepo *repository.CuestionarioRepository
+	tareaRepo        *repository.TareaRepository
+	entregaRepo      *repository.EntregaRepository
+	cursoRepo        repository.CursoRepository
+	matriculaRepo    repository.MatriculaRepository
+	tareaService     *TareaService
+	temaService      *TemaService
+}
+
+func NewCuestionarioService(
+	cuestionarioRepo *repository.CuestionarioRepository,
+	tareaRepo *repository.TareaRepository,
+	entregaRepo *repository.EntregaRepository,
+	cursoRepo repository.CursoRepository,
+	matriculaRepo repository.MatriculaRepository,
+	tareaService *TareaService,
+	temaService *TemaService,
+) *CuestionarioService {
+	return &CuestionarioService{
+		cuestionarioRepo: cuestionarioRepo,
+		tareaRepo:        tareaRepo,
+		entregaRepo:      entregaRepo,
+		cursoRepo:        cursoRepo,
+		matriculaRepo:    matriculaRepo,
+		tareaService:     tareaService,
+		temaService:      temaService,
+	}
+}
+
+// ==================== BANCO DE PREGUNTAS ====================
+
+// ListarPreguntas devuelve el banco de preguntas del curso
+func (s *CuestionarioService) ListarPreguntas(ctx context.Context, cursoID uuid.UUID, usuarioID, rol string) ([]models.Pregunta, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return s.cuestionarioRepo.GetPreguntasByCurso(ctx, cursoID)
+}
+
+// CrearPregunta agrega una pregunta al banco del curso
+func (s *CuestionarioService) CrearPregunta(ctx context.Context, cursoID uuid.UUID, usuarioID, rol string, req *models.PreguntaRequest) (*models.Pregunta, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if err := normalizarPregunta(req); err != nil {
+		return nil, err
+	}
+
+	data := datosPregunta(req)
+	data["curso_id"] = cursoID.String()
+	data["creado_por"] = usuarioID
+	data["activo"] = true
+
+	return s.cuestionarioRepo.CrearPregunta(ctx, data)
+}
+
+// ActualizarPregunta reemplaza el contenido de la pregunta. Los cuestionarios
+// ya configurados conservan su copia.
+func (s *CuestionarioService) ActualizarPregunta(ctx context.Context, preguntaID uuid.UUID, usuarioID, rol string, req *models.PreguntaRequest) error {
+	if _, err := s.preguntaEditable(ctx, preguntaID, usuarioID, rol); err != nil {
+		return err
+	}
+	if err := normalizarPregunta(req); err != nil {
+		return err
+	}
+	return s.cuestionarioRepo.UpdatePregunta(ctx, preguntaID, datosPregunta(req))
+}
+
+// EliminarPregunta la quita del banco (los cuestionarios conservan su copia)
+func (s *CuestionarioService) EliminarPregunta(ctx context.Context, preguntaID uuid.UUID, usuarioID, rol string) error {
+	if _, err := s.preguntaEditable(ctx, preguntaID, usuarioID, rol); err != nil {
+		return err
+	}
+	return s.cuestionarioRepo.UpdatePregunta(ctx, preguntaID, map[string]interface{}{"activo": false})
+}
+
+func (s *CuestionarioService) preguntaEditable(ctx context.Context, preguntaID uuid.UUID, usuarioID, rol string) (*models.Pregunta, error) {
+	pregunta, err := s.cuestionarioRepo.GetPregunta(ctx, preguntaID)
+	if err != nil {
+		return nil, err
+	}
+	if pregunta == nil || !pregunta.Activo {
+		return nil, ErrPreguntaNoEncontrada
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, pregunta.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return pregunta, nil
+}
+
+func datosPregunta(req *models.PreguntaRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"tipo":                 req.Tipo,
+		"enunciado":            req.Enunciado,
+		"opciones":             req.Opciones,
+		"respuesta_verdadera":  req.RespuestaVerdadera,
+		"respuestas_aceptadas": req.RespuestasAceptadas,
+		"pares":                req.Pares,
+		"puntaje":              req.Puntaje,
+	}
+}
+
+// normalizarPregunta valida la pregunta según su tipo, asigna IDs a opciones
+// y pares y descarta los campos que no corresponden al tipo
+func normalizarPregunta(req *models.PreguntaRequest) error {
+	req.Enunciado = strings.TrimSpace(req.Enunciado)
+	if req.Enunciado == "" {
+		return errPregunta("el enunciado es obligatorio")
+	}
+	if req.Puntaje < 0 {
+		return errPregunta("el puntaje no puede ser negativo")
+	}
+	if req.Puntaje == 0 {
+		req.Puntaje = 1
+	}
+
+	opciones, verdadera, aceptadas, pares := req.Opciones, req.RespuestaVerdadera, req.RespuestasAceptadas, req.Pares
+	req.Opciones, req.RespuestaVerdadera, req.RespuestasAceptadas, req.Pares = nil, nil, nil, nil
+
+	switch req.Tipo {
+	case models.PreguntaOpcionMultiple:
+		if len(opciones) < 2 {
+			return errPregunta("una pregunta de opción múltiple necesita al menos dos opciones")
+		}
+		correctas := 0
+		for _, o := range opciones {
+			o.Texto = strings.TrimSpace(o.Texto)
+			if o.Texto == "" {
+				return errPregunta("hay una opción sin texto")
+			}
+			if o.ID == "" {
+				o.ID = uuid.New().String()
+			}
+			if o.Correcta {
+				correctas++
+			}
+			req.Opciones = append(req.Opciones, o)
+		}
+		if correctas == 0 {
+			return errPregunta("marca al menos una opción correcta")
+		}
+
+	case models.PreguntaVerdaderoFalso:
+		if verdadera == nil {
+			return errPregunta("indica si la afirmación es verdadera o falsa")
+		}
+		req.RespuestaVerdadera = verdadera
+
+	case models.PreguntaRespuestaCorta:
+		for _, a := range aceptadas {
+			if a = strings.TrimSpace(a); a != "" {
+				req.RespuestasAceptadas = append(req.RespuestasAceptadas, a)
+			}
+		}
+		if len(req.RespuestasAceptadas) == 0 {
+			return errPregunta("indica al menos una respuesta aceptada")
+		}
+
+	case models.PreguntaEmparejamiento:
+		if len(pares) < 2 {
+			return errPregunta("una pregunta de emparejamiento necesita al menos dos pares")
+		}
+		for _, p := range pares {
+			p.Izquierda = strings.TrimSpace(p.Izquierda)
+			p.Derecha = strings.TrimSpace(p.Derecha)
+			if p.Izquierda == "" || p.Derecha == "" {
+				return errPregunta("hay un par incompleto")
+			}
+			if p.ID == "" {
+				p.ID = uuid.New().String()
+			}
+			req.Pares = append(req.Pares, p)
+		}
+
+	default:
+		return errPregunta("tipo de pregunta no soportado: %s", req.Tipo)
+	}
+
+	return nil
+}
+
+// ==================== CUESTIONARIO DE TAREA ====================
+
+// ObtenerCuestionario devuelve el cuestionario de la tarea. El docente ve las
+// preguntas con sus respuestas; el estudiante solo el resumen y su intento.
+func (s *CuestionarioService) ObtenerCuestionario(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) (*models.Cuestionario, error) {
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, tareaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rol != "estudiante" {
+		if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+			return nil, err
+		}
+		return cuestionario, nil
+	}
+
+	if err := verificarMatricula(s.matriculaRepo, tarea.CursoID.String(), usuarioID); err != nil {
+		return nil, err
+	}
+	estudianteID, _ := uuid.Parse(usuarioID)
+	intento, err := s.cuestionarioRepo.GetIntento(ctx, tareaID, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if intento != nil {
+		intento, err = s.intentoVigente(ctx, tarea, cuestionario, intento, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		cuestionario.MiIntento = vistaEstudiante(tarea, cuestionario, intento, time.Now())
+	}
+
+	cuestionario.Preguntas = nil
+	return cuestionario, nil
+}
+
+// ConfigurarCuestionario copia las preguntas elegidas del banco a la tarea.
+// Solo las tareas de tipo evaluación admiten cuestionario y no se puede
+// cambiar una vez que algún estudiante lo inició.
+func (s *CuestionarioService) ConfigurarCuestionario(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string, req *models.ConfigurarCuestionarioRequest) (*models.Cuestionario, error) {
+	tarea, err := s.tareaEditable(ctx, tareaID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+	if tarea.Tipo != "evaluacion" {
+		return nil, fmt.Errorf("solo las tareas de tipo evaluación pueden tener cuestionario")
+	}
+	if tarea.EntregaGrupal {
+		return nil, fmt.Errorf("el cuestionario es individual: la tarea no puede ser grupal")
+	}
+	if req.TiempoLimiteMinutos < 0 {
+		return nil, fmt.Errorf("el tiempo límite no puede ser negativo")
+	}
+	if len(req.PreguntaIDs) == 0 {
+		return nil, fmt.Errorf("elige al menos una pregunta")
+	}
+
+	banco, err := s.cuestionarioRepo.GetPreguntasByIDs(ctx, req.PreguntaIDs)
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[uuid.UUID]models.Pregunta, len(banco))
+	for _, p := range banco {
+		if p.Activo && p.CursoID == tarea.CursoID {
+			porID[p.ID] = p
+		}
+	}
+
+	// Se respeta el orden elegido por el docente
+	preguntas := make([]models.Pregunta, 0, len(req.PreguntaIDs))
+	elegidas := make(map[uuid.UUID]bool, len(req.PreguntaIDs))
+	for _, id := range req.PreguntaIDs {
+		p, ok := porID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s no pertenece al banco del curso", ErrPreguntaNoEncontrada, id)
+		}
+		if elegidas[id] {
+			return nil, fmt.Errorf("la pregunta %s está repetida", id)
+		}
+		elegidas[id] = true
+		preguntas = append(preguntas, p)
+	}
+
+	cuestionario := &models.Cuestionario{
+		TareaID:             tareaID,
+		TiempoLimiteMinutos: req.TiempoLimiteMinutos,
+		OrdenAleatorio:      req.OrdenAleatorio,
+		Preguntas:           preguntas,
+		ConfiguradoAt:       time.Now(),
+	}
+	if err := s.cuestionarioRepo.Guardar(ctx, cuestionario); err != nil {
+		return nil, err
+	}
+	completarCuestionario(cuestionario)
+
+	return cuestionario, nil
+}
+
+// QuitarCuestionario deja la tarea como entrega de archivos
+func (s *CuestionarioService) QuitarCuestionario(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) error {
+	if _, err := s.tareaEditable(ctx, tareaID, usuarioID, rol); err != nil {
+		return err
+	}
+	return s.cuestionarioRepo.Quitar(ctx, tareaID)
+}
+
+// tareaEditable valida que el usuario sea docente del curso y que nadie haya
+// iniciado el cuestionario
+func (s *CuestionarioService) tareaEditable(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) (*models.Tarea, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada")
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	iniciado, err := s.cuestionarioRepo.TieneIntentos(ctx, tareaID)
+	if err != nil {
+		return nil, err
+	}
+	if iniciado {
+		return nil, ErrCuestionarioConIntentos
+	}
+
+	return tarea, nil
+}
+
+func (s *CuestionarioService) cuestionarioDeTarea(ctx context.Context, tareaID uuid.UUID) (*models.Tarea, *models.Cuestionario, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tarea no encontrada")
+	}
+	cuestionario, err := s.cuestionarioRepo.GetDeTarea(ctx, tareaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cuestionario == nil {
+		return nil, nil, ErrCuestionarioNoEncontrado
+	}
+	completarCuestionario(cuestionario)
+
+	return tarea, cuestionario, nil
+}
+
+func completarCuestionario(c *models.Cuestionario) {
+	c.CantidadPreguntas = len(c.Preguntas)
+	c.PuntajeTotal = PuntajeTotalCuestionario(c.Preguntas)
+}
+
+// PuntajeTotalCuestionario suma el puntaje de todas las preguntas
+func PuntajeTotalCuestionario(preguntas []models.Pregunta) float64 {
+	total := 0.0
+	for _, p := range preguntas {
+		total += p.Puntaje
+	}
+	return total
+}
+
+// ==================== INTENTOS (ESTUDIANTE) ====================
+
+// IniciarIntento empieza el cuestionario o devuelve el intento en curso. El
+// servidor fija la hora de vencimiento: el tiempo límite desde ahora, sin
+// pasar del cierre de entregas de la tarea.
+func (s *CuestionarioService) IniciarIntento(ctx context.Context, tareaID uuid.UUID, estudianteID string) (*models.IntentoCuestionario, error) {
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, tareaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verificarMatricula(s.matriculaRepo, tarea.CursoID.String(), estudianteID); err != nil {
+		return nil, err
+	}
+	estudianteUUID, err := uuid.Parse(estudianteID)
+	if err != nil {
+		return nil, ErrSinPermiso
+	}
+	if err := tareaBloqueada(ctx, s.temaService, tarea, estudianteUUID); err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	intento, err := s.cuestionarioRepo.GetIntento(ctx, tareaID, estudianteUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if intento == nil {
+		if !tarea.Activo || tarea.FechaPublicacion.After(ahora) {
+			return nil, fmt.Errorf("el cuestionario aún no está disponible")
+		}
+		cierre := cierreEntregas(tarea)
+		if ahora.After(cierre) {
+			return nil, fmt.Errorf("la fecha límite ha expirado")
+		}
+
+		vence := cierre
+		if cuestionario.TiempoLimiteMinutos > 0 {
+			if limite := ahora.Add(time.Duration(cuestionario.TiempoLimiteMinutos) * time.Minute); limite.Before(vence) {
+				vence = limite
+			}
+		}
+
+		orden := make([]string, 0, len(cuestionario.Preguntas))
+		for _, p := range cuestionario.Preguntas {
+			orden = append(orden, p.ID.String())
+		}
+		if cuestionario.OrdenAleatorio {
+			rand.Shuffle(len(orden), func(i, j int) { orden[i], orden[j] = orden[j], orden[i] })
+		}
+
+		intento, err = s.cuestionarioRepo.CrearIntentoSiNoExiste(ctx, map[string]interface{}{
+			"tarea_id":      tareaID.String(),
+			"estudiante_id": estudianteID,
+			"orden":         orden,
+			"respuestas":    []models.RespuestaPregunta{},
+			"iniciado_at":   ahora.UTC(),
+			"vence_at":      vence.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("📝 Cuestionario de la tarea %s iniciado por %s (vence %s)", tareaID, estudianteID, intento.VenceAt.Format(time.RFC3339))
+	}
+
+	intento, err = s.intentoVigente(ctx, tarea, cuestionario, intento, ahora)
+	if err != nil {
+		return nil, err
+	}
+	return vistaEstudiante(tarea, cuestionario, intento, ahora), nil
+}
+
+// GuardarRespuestas guarda el avance del intento. Si el tiempo ya terminó,
+// el intento se cierra con lo guardado antes y se devuelve ErrTiempoAgotado.
+func (s *CuestionarioService) GuardarRespuestas(ctx context.Context, tareaID uuid.UUID, estudianteID string, respuestas []models.RespuestaPregunta) (*models.IntentoCuestionario, error) {
+	tarea, cuestionario, intento, err := s.intentoDeEstudiante(ctx, tareaID, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if intento.EnviadoAt != nil {
+		return nil, ErrIntentoEnviado
+	}
+	if err := validarRespuestas(cuestionario, respuestas); err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	if ahora.After(intento.VenceAt.Add(graciaCuestionario)) {
+		if err := s.finalizarIntento(ctx, tarea, cuestionario, intento, ahora); err != nil {
+			return nil, err
+		}
+		return nil, ErrTiempoAgotado
+	}
+
+	guardado, err := s.cuestionarioRepo.GuardarRespuestas(ctx, intento.ID, respuestas)
+	if err != nil {
+		return nil, err
+	}
+	if !guardado {
+		return nil, ErrIntentoEnviado
+	}
+	intento.Respuestas = respuestas
+
+	return vistaEstudiante(tarea, cuestionario, intento, ahora), nil
+}
+
+// EnviarIntento cierra el intento y lo califica. Las respuestas que llegan
+// fuera de tiempo se ignoran y se califica lo guardado antes del vencimiento.
+func (s *CuestionarioService) EnviarIntento(ctx context.Context, tareaID uuid.UUID, estudianteID string, respuestas []models.RespuestaPregunta) (*models.IntentoCuestionario, error) {
+	tarea, cuestionario, intento, err := s.intentoDeEstudiante(ctx, tareaID, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if intento.EnviadoAt != nil {
+		return nil, ErrIntentoEnviado
+	}
+
+	ahora := time.Now()
+	if respuestas != nil && !ahora.After(intento.VenceAt.Add(graciaCuestionario)) {
+		if err := validarRespuestas(cuestionario, respuestas); err != nil {
+			return nil, err
+		}
+		intento.Respuestas = respuestas
+	}
+
+	if err := s.finalizarIntento(ctx, tarea, cuestionario, intento, ahora); err != nil {
+		return nil, err
+	}
+
+	intento, err = s.cuestionarioRepo.GetIntentoByID(ctx, intento.ID)
+	if err != nil {
+		return nil, err
+	}
+	return vistaEstudiante(tarea, cuestionario, intento, ahora), nil
+}
+
+func (s *CuestionarioService) intentoDeEstudiante(ctx context.Context, tareaID uuid.UUID, estudianteID string) (*models.Tarea, *models.Cuestionario, *models.IntentoCuestionario, error) {
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, tareaID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	estudianteUUID, err := uuid.Parse(estudianteID)
+	if err != nil {
+		return nil, nil, nil, ErrSinPermiso
+	}
+
+	intento, err := s.cuestionarioRepo.GetIntento(ctx, tareaID, estudianteUUID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if intento == nil {
+		return nil, nil, nil, ErrIntentoNoEncontrado
+	}
+
+	return tarea, cuestionario, intento, nil
+}
+
+// validarRespuestas rechaza respuestas a preguntas ajenas al cuestionario o repetidas
+func validarRespuestas(c *models.Cuestionario, respuestas []models.RespuestaPregunta) error {
+	validas := make(map[string]bool, len(c.Preguntas))
+	for _, p := range c.Preguntas {
+		validas[p.ID.String()] = true
+	}
+
+	vistas := make(map[string]bool, len(respuestas))
+	for _, r := range respuestas {
+		if !validas[r.PreguntaID] {
+			return fmt.Errorf("la pregunta %s no pertenece al cuestionario", r.PreguntaID)
+		}
+		if vistas[r.PreguntaID] {
+			return fmt.Errorf("la pregunta %s se respondió más de una vez", r.PreguntaID)
+		}
+		vistas[r.PreguntaID] = true
+	}
+	return nil
+}
+
+// vistaEstudiante arma el intento como lo ve el estudiante: las preguntas sin
+// sus respuestas y, mientras no se publiquen las notas, sin la corrección
+func vistaEstudiante(tarea *models.Tarea, c *models.Cuestionario, intento *models.IntentoCuestionario, ahora time.Time) *models.IntentoCuestionario {
+	vista := *intento
+	vista.Preguntas = preguntasParaEstudiante(c, &vista)
+
+	if vista.EnviadoAt == nil {
+		restantes := int(vista.VenceAt.Sub(ahora).Seconds())
+		if restantes < 0 {
+			restantes = 0
+		}
+		vista.SegundosRestantes = &restantes
+	}
+	if !calificacionesPublicadas(tarea) {
+		vista.Resultado = nil
+		vista.PuntajeObtenido = nil
+	}
+
+	return &vista
+}
+
+// preguntasParaEstudiante devuelve las preguntas en el orden del intento. Las
+// opciones y las parejas se mezclan con una semilla fija por intento para que
+// no cambien al recargar.
+func preguntasParaEstudiante(c *models.Cuestionario, intento *models.IntentoCuestionario) []models.PreguntaCuestionario {
+	porID := make(map[string]*models.Pregunta, len(c.Preguntas))
+	for i := range c.Preguntas {
+		porID[c.Preguntas[i].ID.String()] = &c.Preguntas[i]
+	}
+	mezclador := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(intento.ID[:8]))))
+
+	preguntas := make([]models.PreguntaCuestionario, 0, len(intento.Orden))
+	for _, id := range intento.Orden {
+		p, ok := porID[id]
+		if !ok {
+			continue
+		}
+		v := models.PreguntaCuestionario{
+			ID:        id,
+			Tipo:      p.Tipo,
+			Enunciado: p.Enunciado,
+			Puntaje:   p.Puntaje,
+		}
+
+		switch p.Tipo {
+		case models.PreguntaOpcionMultiple:
+			for _, o := range p.Opciones {
+				v.Opciones = append(v.Opciones, models.OpcionPregunta{ID: o.ID, Texto: o.Texto})
+			}
+			if c.OrdenAleatorio {
+				mezclador.Shuffle(len(v.Opciones), func(i, j int) { v.Opciones[i], v.Opciones[j] = v.Opciones[j], v.Opciones[i] })
+			}
+		case models.PreguntaEmparejamiento:
+			for _, par := range p.Pares {
+				v.Pares = append(v.Pares, models.ParEmparejamiento{ID: par.ID, Izquierda: par.Izquierda})
+				v.Derechas = append(v.Derechas, par.Derecha)
+			}
+			mezclador.Shuffle(len(v.Derechas), func(i, j int) { v.Derechas[i], v.Derechas[j] = v.Derechas[j], v.Derechas[i] })
+		}
+
+		preguntas = append(preguntas, v)
+	}
+
+	return preguntas
+}
+
+// ==================== CORRECCIÓN ====================
+
+// intentoVigente cierra el intento si su tiempo terminó y devuelve el estado actual
+func (s *CuestionarioService) intentoVigente(ctx context.Context, tarea *models.Tarea, c *models.Cuestionario, intento *models.IntentoCuestionario, ahora time.Time) (*models.IntentoCuestionario, error) {
+	if intento.EnviadoAt != nil || !ahora.After(intento.VenceAt.Add(graciaCuestionario)) {
+		return intento, nil
+	}
+	if err := s.finalizarIntento(ctx, tarea, c, intento, ahora); err != nil {
+		return nil, err
+	}
+	return s.cuestionarioRepo.GetIntentoByID(ctx, intento.ID)
+}
+
+// finalizarIntento corrige las respuestas, registra la entrega y escribe la
+// calificación. Si otro proceso lo cerró antes no hace nada.
+func (s *CuestionarioService) finalizarIntento(ctx context.Context, tarea *models.Tarea, c *models.Cuestionario, intento *models.IntentoCuestionario, ahora time.Time) error {
+	enviado := ahora
+	if enviado.After(intento.VenceAt) {
+		enviado = intento.VenceAt
+	}
+
+	entrega, err := s.entregaDeIntento(ctx, tarea, intento, enviado)
+	if err != nil {
+		return err
+	}
+
+	resultado, obtenido := CorregirCuestionario(c.Preguntas, intento.Respuestas)
+	intento.EntregaID = &entrega.ID
+	intento.Resultado = resultado
+	intento.PuntajeObtenido = redondear2(obtenido)
+	intento.EnviadoAt = &enviado
+
+	cerrado, err := s.cuestionarioRepo.Finalizar(ctx, intento)
+	if err != nil {
+		return err
+	}
+	if !cerrado {
+		return nil
+	}
+	log.Printf("✅ Cuestionario de la tarea %s enviado por %s: %.2f de %.2f puntos",
+		tarea.ID, intento.EstudianteID, *intento.PuntajeObtenido, c.PuntajeTotal)
+
+	return s.registrarNota(ctx, tarea, c, intento, entrega, nil)
+}
+
+// entregaDeIntento devuelve la entrega del estudiante o la crea con la hora
+// de envío del intento
+func (s *CuestionarioService) entregaDeIntento(ctx context.Context, tarea *models.Tarea, intento *models.IntentoCuestionario, enviado time.Time) (*models.Entrega, error) {
+	existente, err := s.entregaRepo.GetByTareaAndEstudiante(ctx, tarea.ID, intento.EstudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if existente != nil {
+		return existente, nil
+	}
+
+	diasRetraso, penalizacion := retrasoEntrega(tarea, enviado)
+	return s.entregaRepo.Create(ctx, &models.Entrega{
+		TareaID:              tarea.ID,
+		EstudianteID:         intento.EstudianteID,
+		Titulo:               "Cuestionario: " + tarea.Titulo,
+		FechaEntrega:         enviado,
+		DiasRetraso:          diasRetraso,
+		PenalizacionAplicada: penalizacion,
+		EntregaTardia:        enviado.After(tarea.FechaLimite),
+	})
+}
+
+// registrarNota escala el puntaje del cuestionario al puntaje máximo de la
+// tarea, descuenta la penalización por retraso y lo guarda en la entrega.
+// Sin docente, la calificación queda como automática.
+func (s *CuestionarioService) registrarNota(ctx context.Context, tarea *models.Tarea, c *models.Cuestionario, intento *models.IntentoCuestionario, entrega *models.Entrega, docenteID *uuid.UUID) error {
+	nota := 0.0
+	if c.PuntajeTotal > 0 && intento.PuntajeObtenido != nil {
+		nota = *intento.PuntajeObtenido / c.PuntajeTotal * tarea.PuntajeMaximo
+	}
+	nota = notaConPenalizacion(nota, entrega)
+	comentario := comentarioCuestionario(c, intento, entrega)
+
+	if docenteID != nil {
+		return s.tareaService.guardarCalificacion(ctx, entrega, &models.NuevaCalificacion{
+			Calificacion:  nota,
+			Comentario:    comentario,
+			CalificadoPor: docenteID,
+			Origen:        models.OrigenCalificacion,
+		})
+	}
+
+	err := s.entregaRepo.GuardarCalificacion(ctx, entrega.ID, &models.NuevaCalificacion{
+		Calificacion: nota,
+		Comentario:   comentario,
+		Origen:       models.OrigenCuestionario,
+	})
+	if err != nil {
+		if esCicloCerrado(err) {
+			return ErrCicloCerrado
+		}
+		return err
+	}
+
+	go s.tareaService.despuesDeCalificar(entrega.ID, nota, comentario, true)
+	return nil
+}
+
+func comentarioCuestionario(c *models.Cuestionario, intento *models.IntentoCuestionario, entrega *models.Entrega) string {
+	obtenido := 0.0
+	if intento.PuntajeObtenido != nil {
+		obtenido = *intento.PuntajeObtenido
+	}
+	comentario := fmt.Sprintf("Cuestionario: %.2f de %.2f puntos.", obtenido, c.PuntajeTotal)
+	if pendientes := pendientesDeRevision(intento.Resultado); pendientes > 0 {
+		comentario += fmt.Sprintf(" %d respuestas pendientes de revisión.", pendientes)
+	}
+	if entrega.PenalizacionAplicada > 0 {
+		comentario += fmt.Sprintf(" Penalización por retraso: %.2f.", entrega.PenalizacionAplicada)
+	}
+	return comentario
+}
+
+func pendientesDeRevision(resultado []models.ResultadoPregunta) int {
+	pendientes := 0
+	for _, r := range resultado {
+		if r.RequiereRevision {
+			pendientes++
+		}
+	}
+	return pendientes
+}
+
+// CorregirCuestionario califica cada respuesta y devuelve el puntaje
+// obtenido. Es pura: no accede a la base de datos.
+//   - opción múltiple: puntaje completo solo si se marcan exactamente las correctas
+//   - verdadero/falso: puntaje completo si coincide
+//   - respuesta corta: se compara sin mayúsculas, tildes ni espacios extra;
+//     si no coincide queda en 0 y marcada para revisión del docente
+//   - emparejamiento: puntaje proporcional a los pares acertados
+func CorregirCuestionario(preguntas []models.Pregunta, respuestas []models.RespuestaPregunta) ([]models.ResultadoPregunta, float64) {
+	porPregunta := make(map[string]*models.RespuestaPregunta, len(respuestas))
+	for i := range respuestas {
+		porPregunta[respuestas[i].PreguntaID] = &respuestas[i]
+	}
+
+	resultado := make([]models.ResultadoPregunta, 0, len(preguntas))
+	total := 0.0
+	for i := range preguntas {
+		p := &preguntas[i]
+		r := models.ResultadoPregunta{PreguntaID: p.ID.String(), PuntajeMaximo: p.Puntaje}
+
+		if respuesta := porPregunta[r.PreguntaID]; respuesta != nil {
+			switch p.Tipo {
+			case models.PreguntaOpcionMultiple:
+				r.Correcta = opcionesCorrectas(p.Opciones, respuesta.Opciones)
+			case models.PreguntaVerdaderoFalso:
+				r.Correcta = respuesta.Valor != nil && p.RespuestaVerdadera != nil && *respuesta.Valor == *p.RespuestaVerdadera
+			case models.PreguntaRespuestaCorta:
+				r.Correcta = respuestaAceptada(p.RespuestasAceptadas, respuesta.Texto)
+				r.RequiereRevision = !r.Correcta && strings.TrimSpace(respuesta.Texto) != ""
+			case models.PreguntaEmparejamiento:
+				aciertos := 0
+				for _, par := range p.Pares {
+					if normalizarRespuesta(respuesta.Pares[par.ID]) == normalizarRespuesta(par.Derecha) {
+						aciertos++
+					}
+				}
+				if len(p.Pares) > 0 {
+					r.Puntaje = *redondear2(p.Puntaje * float64(aciertos) / float64(len(p.Pares)))
+				}
+				r.Correcta = aciertos == len(p.Pares)
+			}
+		}
+		if r.Correcta {
+			r.Puntaje = p.Puntaje
+		}
+
+		total += r.Puntaje
+		resultado = append(resultado, r)
+	}
+
+	return resultado, total
+}
+
+func opcionesCorrectas(opciones []models.OpcionPregunta, marcadas []string) bool {
+	elegidas := make(map[string]bool, len(marcadas))
+	for _, id := range marcadas {
+		elegidas[id] = true
+	}
+	for _, o := range opciones {
+		if o.Correcta != elegidas[o.ID] {
+			return false
+		}
+		delete(elegidas, o.ID)
+	}
+	return len(elegidas) == 0
+}
+
+func respuestaAceptada(aceptadas []string, texto string) bool {
+	texto = normalizarRespuesta(texto)
+	if texto == "" {
+		return false
+	}
+	for _, a := range aceptadas {
+		if normalizarRespuesta(a) == texto {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizarRespuesta ignora mayúsculas, tildes y espacios repetidos
+func normalizarRespuesta(s string) string {
+	return strings.Join(strings.Fields(normalizarTexto(s)), " ")
+}
+
+// ==================== REVISIÓN (DOCENTE) ====================
+
+// ListarIntentos devuelve los intentos de la tarea; antes cierra los que vencieron
+func (s *CuestionarioService) ListarIntentos(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) ([]models.IntentoCuestionario, error) {
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, tareaID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	intentos, err := s.cuestionarioRepo.GetIntentosByTarea(ctx, tareaID)
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	for i := range intentos {
+		estudiante := intentos[i].Estudiante
+		vigente, err := s.intentoVigente(ctx, tarea, cuestionario, &intentos[i], ahora)
+		if err != nil {
+			return nil, err
+		}
+		intentos[i] = *vigente
+		intentos[i].Estudiante = estudiante
+		intentos[i].PendientesRevisar = pendientesDeRevision(intentos[i].Resultado)
+
+		// Mientras la calificación sea anónima no se identifica al estudiante
+		if tarea.CalificacionAnonima && !calificacionesPublicadas(tarea) {
+			intentos[i].EstudianteID = uuid.Nil
+			intentos[i].Estudiante = nil
+		}
+	}
+
+	return intentos, nil
+}
+
+// ObtenerIntento devuelve un intento: completo para el docente del curso y
+// como lo ve el estudiante para su dueño
+func (s *CuestionarioService) ObtenerIntento(ctx context.Context, intentoID uuid.UUID, usuarioID, rol string) (*models.IntentoCuestionario, error) {
+	intento, err := s.cuestionarioRepo.GetIntentoByID(ctx, intentoID)
+	if err != nil {
+		return nil, err
+	}
+	if intento == nil {
+		return nil, ErrIntentoNoEncontrado
+	}
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, intento.TareaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rol == "estudiante" {
+		if intento.EstudianteID.String() != usuarioID {
+			return nil, ErrSinPermiso
+		}
+		intento, err = s.intentoVigente(ctx, tarea, cuestionario, intento, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return vistaEstudiante(tarea, cuestionario, intento, time.Now()), nil
+	}
+
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	intento, err = s.intentoVigente(ctx, tarea, cuestionario, intento, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	intento.PendientesRevisar = pendientesDeRevision(intento.Resultado)
+	if tarea.CalificacionAnonima && !calificacionesPublicadas(tarea) {
+		intento.EstudianteID = uuid.Nil
+	}
+
+	return intento, nil
+}
+
+// RevisarIntento fija a mano el puntaje de algunas respuestas (por ejemplo
+// respuestas cortas correctas que no estaban entre las aceptadas) y vuelve a
+// calcular la calificación de la entrega
+func (s *CuestionarioService) RevisarIntento(ctx context.Context, intentoID uuid.UUID, usuarioID, rol string, req *models.RevisarIntentoRequest) (*models.IntentoCuestionario, error) {
+	if len(req.Ajustes) == 0 {
+		return nil, fmt.Errorf("no hay ajustes para guardar")
+	}
+	docenteID, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return nil, ErrSinPermiso
+	}
+
+	intento, err := s.cuestionarioRepo.GetIntentoByID(ctx, intentoID)
+	if err != nil {
+		return nil, err
+	}
+	if intento == nil {
+		return nil, ErrIntentoNoEncontrado
+	}
+	tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, intento.TareaID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if intento.EnviadoAt == nil || intento.EntregaID == nil {
+		return nil, ErrIntentoAbierto
+	}
+
+	porPregunta := make(map[string]*models.ResultadoPregunta, len(intento.Resultado))
+	for i := range intento.Resultado {
+		porPregunta[intento.Resultado[i].PreguntaID] = &intento.Resultado[i]
+	}
+	for _, a := range req.Ajustes {
+		r, ok := porPregunta[a.PreguntaID]
+		if !ok {
+			return nil, fmt.Errorf("la pregunta %s no pertenece al cuestionario", a.PreguntaID)
+		}
+		if a.Puntaje < 0 || a.Puntaje > r.PuntajeMaximo+0.001 {
+			return nil, fmt.Errorf("el puntaje de la pregunta %s debe estar entre 0 y %.2f", a.PreguntaID, r.PuntajeMaximo)
+		}
+		r.Puntaje = a.Puntaje
+		r.Correcta = a.Puntaje >= r.PuntajeMaximo
+		r.RequiereRevision = false
+		r.Ajustado = true
+		r.Comentario = strings.TrimSpace(a.Comentario)
+	}
+
+	obtenido := 0.0
+	for _, r := range intento.Resultado {
+		obtenido += r.Puntaje
+	}
+	ahora := time.Now()
+	intento.PuntajeObtenido = redondear2(obtenido)
+	intento.RevisadoPor = &docenteID
+	intento.RevisadoAt = &ahora
+
+	if err := s.cuestionarioRepo.GuardarRevision(ctx, intento); err != nil {
+		return nil, err
+	}
+
+	entrega, err := s.entregaRepo.GetByID(ctx, *intento.EntregaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.registrarNota(ctx, tarea, cuestionario, intento, entrega, &docenteID); err != nil {
+		return nil, err
+	}
+
+	intento.PendientesRevisar = pendientesDeRevision(intento.Resultado)
+	return intento, nil
+}
+
+// ==================== CIERRE AUTOMÁTICO ====================
+
+// IniciarCierreIntentos califica periódicamente los intentos cuyo tiempo
+// terminó sin que el estudiante los enviara, hasta que se cancele el contexto
+func (s *CuestionarioService) IniciarCierreIntentos(ctx context.Context, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		s.CerrarIntentosVencidos(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.CerrarIntentosVencidos(ctx)
+			}
+		}
+	}()
+}
+
+// CerrarIntentosVencidos envía con lo guardado los intentos vencidos
+func (s *CuestionarioService) CerrarIntentosVencidos(ctx context.Context) {
+	ahora := time.Now()
+	intentos, err := s.cuestionarioRepo.GetIntentosVencidos(ctx, ahora.Add(-graciaCuestionario))
+	if err != nil {
+		log.Printf("❌ Error obteniendo cuestionarios vencidos: %v", err)
+		return
+	}
+
+	type datosTarea struct {
+		tarea        *models.Tarea
+		cuestionario *models.Cuestionario
+	}
+	cache := make(map[uuid.UUID]*datosTarea)
+
+	for i := range intentos {
+		intento := &intentos[i]
+		datos, ok := cache[intento.TareaID]
+		if !ok {
+			tarea, cuestionario, err := s.cuestionarioDeTarea(ctx, intento.TareaID)
+			if err != nil {
+				log.Printf("⚠️ No se pudo cerrar el cuestionario de la tarea %s: %v", intento.TareaID, err)
+			} else {
+				datos = &datosTarea{tarea: tarea, cuestionario: cuestionario}
+			}
+			cache[intento.TareaID] = datos
+		}
+		if datos == nil {
+			continue
+		}
+
+		if err := s.finalizarIntento(ctx, datos.tarea, datos.cuestionario, intento, ahora); err != nil {
+			log.Printf("❌ Error cerrando el intento %s: %v", intento.ID, err)
+		}
+	}
+}
@@ -3,16 +3,25 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/repository"
+	"strings"
 )
 
 // ✅ CursoService con dependency injection
 type CursoService struct {
-	cursoRepo   repository.CursoRepository
-	cicloRepo   repository.CicloRepository
-	usuarioRepo repository.UsuarioRepository
-	temaRepo    *repository.TemaRepository // ✅ AGREGADO
+	cursoRepo          repository.CursoRepository
+	cicloRepo          repository.CicloRepository
+	usuarioRepo        repository.UsuarioRepository
+	temaRepo           *repository.TemaRepository // ✅ AGREGADO
+	materialRepo       *repository.MaterialRepository
+	tareaRepo          *repository.TareaRepository
+	rubricaRepo        *repository.RubricaRepository
+	calificacionesRepo *repository.CalificacionesRepository
+	cuestionarioRepo   *repository.CuestionarioRepository
+	storageService     *StorageService
+	matriculaService   *MatriculaService
 }
 
 // ✅ Constructor actualizado con temaRepo
@@ -21,16 +30,48 @@ func NewCursoService(
 	cicloRepo repository.CicloRepository,
 	usuarioRepo repository.UsuarioRepository,
 	temaRepo *repository.TemaRepository, // ✅ NUEVO PARÁMETRO
+	materialRepo *repository.MaterialRepository,
+	tareaRepo *repository.TareaRepository,
+	rubricaRepo *repository.RubricaRepository,
+	calificacionesRepo *repository.CalificacionesRepository,
+	cuestionarioRepo *repository.CuestionarioRepository,
+	storageService *StorageService,
+	matriculaService *MatriculaService,
 ) *CursoService {
 	return &CursoService{
-		cursoRepo:   cursoRepo,
-		cicloRepo:   cicloRepo,
-		usuarioRepo: usuarioRepo,
-		temaRepo:    temaRepo, // ✅ ASIGNAR
+		cursoRepo:          cursoRepo,
+		cicloRepo:          cicloRepo,
+		usuarioRepo:        usuarioRepo,
+		temaRepo:           temaRepo, // ✅ ASIGNAR
+		materialRepo:       materialRepo,
+		tareaRepo:          tareaRepo,
+		rubricaRepo:        rubricaRepo,
+		calificacionesRepo: calificacionesRepo,
+		cuestionarioRepo:   cuestionarioRepo,
+		storageService:     storageService,
+		matriculaService:   matriculaService,
 	}
 }
 
 func (s *CursoService) CrearCurso(req *models.CrearCursoRequest) (string, error) {
+	cursoID, err := s.crearCurso(req)
+	if err != nil {
+		return "", err
+	}
+
+	// ✅ NUEVO: Crear los 16 temas automáticamente
+	if err := s.crearTemasIniciales(cursoID); err != nil {
+		// Log el error pero no falla la creación del curso
+		fmt.Printf("⚠️ Error al crear temas iniciales: %v\n", err)
+	} else {
+		fmt.Printf("✅ Creados 16 temas para el curso %s\n", cursoID)
+	}
+
+	return cursoID, nil
+}
+
+// crearCurso valida e inserta el curso sin temas; devuelve el ID creado
+func (s *CursoService) crearCurso(req *models.CrearCursoRequest) (string, error) {
 	// Validar datos
 	if err := s.validarCurso(req); err != nil {
 		return "", err
@@ -46,6 +87,15 @@ func (s *CursoService) CrearCurso(req *models.CrearCursoRequest) (string, error)
 		return "", fmt.Errorf("el docente seleccionado no existe")
 	}
 
+	// Horario estructurado y cruces con otros cursos del ciclo
+	sesiones, horario, err := resolverHorario(req.Horario, req.Sesiones)
+	if err != nil {
+		return "", err
+	}
+	if err := s.validarCrucesHorario("", req.CicloID, req.DocenteID, sesiones); err != nil {
+		return "", err
+	}
+
 	// Crear curso
 	cursoData := map[string]interface{}{
 		"nombre":      req.Nombre,
@@ -55,8 +105,17 @@ func (s *CursoService) CrearCurso(req *models.CrearCursoRequest) (string, error)
 		"nivel":       req.Nivel,
 		"seccion":     req.Seccion,
 		"creditos":    req.Creditos,
-		"horario":     req.Horario,
+		"horario":     horario,
+		"sesiones":    sesiones,
 		"activo":      true,
+
+		"desbloqueo_secuencial": req.DesbloqueoSecuencial,
+	}
+	if req.Capacidad != nil {
+		cursoData["capacidad"] = *req.Capacidad
+	}
+	if codigo := normalizarCodigoCurso(req.Codigo); codigo != "" {
+		cursoData["codigo"] = codigo
 	}
 
 	respBody, err := s.cursoRepo.CreateCurso(cursoData)
@@ -75,14 +134,6 @@ func (s *CursoService) CrearCurso(req *models.CrearCursoRequest) (string, error)
 		return "", fmt.Errorf("no se pudo obtener el ID del curso creado")
 	}
 
-	// ✅ NUEVO: Crear los 16 temas automáticamente
-	if err := s.crearTemasIniciales(cursoID); err != nil {
-		// Log el error pero no falla la creación del curso
-		fmt.Printf("⚠️ Error al crear temas iniciales: %v\n", err)
-	} else {
-		fmt.Printf("✅ Creados 16 temas para el curso %s\n", cursoID)
-	}
-
 	return cursoID, nil
 }
 
@@ -166,6 +217,13 @@ func (s *CursoService) ActualizarCurso(cursoID string, req *models.ActualizarCur
 	// Construir datos a actualizar
 	updateData := make(map[string]interface{})
 
+	if req.Codigo != nil {
+		if codigo := normalizarCodigoCurso(*req.Codigo); codigo != "" {
+			updateData["codigo"] = codigo
+		} else {
+			updateData["codigo"] = nil
+		}
+	}
 	if req.Nombre != nil {
 		updateData["nombre"] = *req.Nombre
 	}
@@ -195,8 +253,37 @@ func (s *CursoService) ActualizarCurso(cursoID string, req *models.ActualizarCur
 	if req.Creditos != nil {
 		updateData["creditos"] = *req.Creditos
 	}
-	if req.Horario != nil {
-		updateData["horario"] = *req.Horario
+	if req.Capacidad != nil {
+		switch {
+		case *req.Capacidad < 0:
+			return fmt.Errorf("la capacidad no puede ser negativa")
+		case *req.Capacidad == 0:
+			updateData["capacidad"] = nil
+		default:
+			updateData["capacidad"] = *req.Capacidad
+		}
+	}
+	if req.Horario != nil || req.Sesiones != nil {
+		horario := ""
+		if req.Horario != nil {
+			horario = *req.Horario
+		}
+		var sesiones []models.SesionHorario
+		if req.Sesiones != nil {
+			sesiones = *req.Sesiones
+		}
+
+		sesiones, horario, err := resolverHorario(horario, sesiones)
+		if err != nil {
+			return err
+		}
+		updateData["sesiones"] = sesiones
+		if req.Horario != nil || len(sesiones) > 0 {
+			updateData["horario"] = horario
+		}
+	}
+	if req.DesbloqueoSecuencial != nil {
+		updateData["desbloqueo_secuencial"] = *req.DesbloqueoSecuencial
 	}
 	if req.Activo != nil {
 		updateData["activo"] = *req.Activo
@@ -206,10 +293,88 @@ func (s *CursoService) ActualizarCurso(cursoID string, req *models.ActualizarCur
 		return fmt.Errorf("no hay datos para actualizar")
 	}
 
+	// Revalidar cruces si cambia algo que afecta al horario
+	if req.Horario != nil || req.Sesiones != nil || req.DocenteID != nil || req.CicloID != nil || req.Activo != nil && *req.Activo {
+		if err := s.validarCrucesActualizacion(cursoID, req, updateData); err != nil {
+			return err
+		}
+	}
+
 	if err := s.cursoRepo.UpdateCurso(cursoID, updateData); err != nil {
 		return fmt.Errorf("error al actualizar curso: %w", err)
 	}
 
+	// Si aumentó la capacidad, los cupos nuevos pasan a la lista de espera
+	if req.Capacidad != nil && s.matriculaService != nil {
+		go func() {
+			if _, err := s.matriculaService.PromoverListaEsperaDeCurso(cursoID); err != nil {
+				log.Printf("❌ %v", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// validarCrucesActualizacion combina el curso actual con los cambios y
+// busca cruces con el resto de cursos del ciclo
+func (s *CursoService) validarCrucesActualizacion(cursoID string, req *models.ActualizarCursoRequest, updateData map[string]interface{}) error {
+	actual, err := s.ObtenerCursoPorID(cursoID)
+	if err != nil {
+		return err
+	}
+	// Un curso inactivo no ocupa horario
+	activo := actual.Activo
+	if req.Activo != nil {
+		activo = *req.Activo
+	}
+	if !activo {
+		return nil
+	}
+
+	docenteID := actual.DocenteID
+	if req.DocenteID != nil {
+		docenteID = *req.DocenteID
+	}
+	cicloID := actual.CicloID
+	if req.CicloID != nil {
+		cicloID = *req.CicloID
+	}
+
+	sesiones := SesionesDeCurso(actual)
+	if nuevas, ok := updateData["sesiones"].([]models.SesionHorario); ok {
+		sesiones = nuevas
+	}
+
+	return s.validarCrucesHorario(cursoID, cicloID, docenteID, sesiones)
+}
+
+// validarCrucesHorario rechaza sesiones que se cruzan con otro curso del
+// mismo ciclo dictado por el mismo docente o en el mismo ambiente
+func (s *CursoService) validarCrucesHorario(cursoID, cicloID, docenteID string, sesiones []models.SesionHorario) error {
+	if len(sesiones) == 0 {
+		return nil
+	}
+
+	otros, err := s.ListarCursosPorCiclo(cicloID)
+	if err != nil {
+		return fmt.Errorf("error al verificar cruces de horario: %w", err)
+	}
+
+	conflictos := buscarConflictos(sesiones, otros, cursoID, func(otro *models.Curso, propia, ajena models.SesionHorario) []string {
+		tipos := make([]string, 0, 2)
+		if otro.DocenteID == docenteID {
+			tipos = append(tipos, "docente")
+		}
+		if propia.Aula != "" && strings.EqualFold(propia.Aula, ajena.Aula) {
+			tipos = append(tipos, "aula")
+		}
+		return tipos
+	})
+	if len(conflictos) > 0 {
+		return &ConflictoHorarioError{Conflictos: conflictos}
+	}
+
 	return nil
 }
 
@@ -277,6 +442,10 @@ func (s *CursoService) validarCurso(req *models.CrearCursoRequest) error {
 		return fmt.Errorf("los créditos deben estar entre 1 y 10")
 	}
 
+	if req.Capacidad != nil && *req.Capacidad < 1 {
+		return fmt.Errorf("la capacidad debe ser mayor a 0")
+	}
+
 	return nil
 }
 
@@ -294,3 +463,9 @@ func (s *CursoService) ListarCursosPorEstudiante(estudianteID string) ([]models.
 
 	return cursos, nil
 }
+
+// normalizarCodigoCurso guarda los códigos en mayúsculas y sin espacios extremos
+// para que "past-101" y "PAST-101 " sean el mismo curso en todos los ciclos
+func normalizarCodigoCurso(codigo string) string {
+	return strings.ToUpper(strings.TrimSpace(codigo))
+}
@@ -338,9 +338,11 @@ func (s *DashboardService) obtenerMatriculasPorCurso(cicloID string, limit int)
 		Nombre     string                   `json:"nombre"`
 		Seccion    string                   `json:"seccion"`
 		Creditos   int                      `json:"creditos"`
+		Capacidad  *int                     `json:"capacidad"`
 		DocenteID  string                   `json:"docente_id"`
 		Docentes   map[string]interface{}   `json:"docentes"`
 		Matriculas []map[string]interface{} `json:"matriculas"`
+		Espera     []map[string]interface{} `json:"lista_espera"`
 	}
 	if err := json.Unmarshal(respBody, &cursos); err != nil {
 		return nil, err
@@ -349,9 +351,21 @@ func (s *DashboardService) obtenerMatriculasPorCurso(cicloID string, limit int)
 	// Procesar cada curso
 	resultado := []models.MatriculasPorCurso{}
 	for _, curso := range cursos {
-		matriculados := len(curso.Matriculas)
-		capacidad := 50
-		porcentaje := (float64(matriculados) / float64(capacidad)) * 100
+		// Solo las matrículas activas ocupan cupo
+		matriculados := 0
+		for _, m := range curso.Matriculas {
+			if estado, _ := m["estado"].(string); estado == "activo" {
+				matriculados++
+			}
+		}
+
+		// Sin capacidad definida el curso no tiene límite ni porcentaje de ocupación
+		capacidad := 0
+		porcentaje := 0.0
+		if curso.Capacidad != nil && *curso.Capacidad > 0 {
+			capacidad = *curso.Capacidad
+			porcentaje = (float64(matriculados) / float64(capacidad)) * 100
+		}
 
 		docenteNombre := "Sin docente"
 		if usuarios, ok := curso.Docentes["usuarios"].(map[string]interface{}); ok {
@@ -365,6 +379,7 @@ func (s *DashboardService) obtenerMatriculasPorCurso(cicloID string, limit int)
 			CursoNombre:   curso.Nombre,
 			Matriculados:  matriculados,
 			Capacidad:     capacidad,
+			EnEspera:      len(curso.Espera),
 			Porcentaje:    math.Round(porcentaje*100) / 100,
 			DocenteNombre: docenteNombre,
 			Seccion:       curso.Seccion,
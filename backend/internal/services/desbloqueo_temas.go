@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTemaNoEncontrado = errors.New("tema no encontrado")
+	ErrTemaBloqueado    = errors.New("el tema aún no está disponible")
+)
+
+// ==================== DESBLOQUEO PARA ESTUDIANTES ====================
+
+// marcarBloqueos recorre los temas en orden y marca los que el estudiante aún
+// no puede abrir. Un tema sigue bloqueado hasta su fecha de desbloqueo y, si
+// el curso es secuencial, mientras el tema anterior esté bloqueado o le falte
+// ver alguno de sus materiales obligatorios.
+func marcarBloqueos(temas []models.Tema, secuencial bool, vistos map[uuid.UUID]bool, ahora time.Time) {
+	sort.SliceStable(temas, func(i, j int) bool {
+		return temas[i].Orden < temas[j].Orden
+	})
+
+	anteriorCompleto := true
+	for i := range temas {
+		tema := &temas[i]
+		if !tema.Activo {
+			continue
+		}
+
+		switch {
+		case tema.FechaDesbloqueo != nil && ahora.Before(*tema.FechaDesbloqueo):
+			tema.Bloqueado, tema.MotivoBloqueo = true, models.BloqueoPorFecha
+		case secuencial && !anteriorCompleto:
+			tema.Bloqueado, tema.MotivoBloqueo = true, models.BloqueoPorSecuencia
+		}
+
+		anteriorCompleto = !tema.Bloqueado && obligatoriosVistos(tema.Materiales, vistos)
+	}
+}
+
+func obligatoriosVistos(materiales []models.Material, vistos map[uuid.UUID]bool) bool {
+	for _, m := range materiales {
+		if m.Activo && m.Obligatorio && !vistos[m.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// aplicarDesbloqueo marca los temas bloqueados para el estudiante. Los temas
+// deben traer sus materiales para evaluar el modo secuencial.
+func (s *TemaService) aplicarDesbloqueo(ctx context.Context, curso *models.Curso, temas []models.Tema, estudianteID uuid.UUID) error {
+	vistos := map[uuid.UUID]bool{}
+	if curso.DesbloqueoSecuencial {
+		ids := make([]uuid.UUID, 0)
+		for _, t := range temas {
+			for _, m := range t.Materiales {
+				if m.Activo && m.Obligatorio {
+					ids = append(ids, m.ID)
+				}
+			}
+		}
+
+		var err error
+		vistos, err = s.materialRepo.GetVistosPorEstudiante(ctx, estudianteID, ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	marcarBloqueos(temas, curso.DesbloqueoSecuencial, vistos, time.Now())
+	return nil
+}
+
+// ocultarContenido deja solo los datos generales de un tema bloqueado
+func ocultarContenido(tema *models.Tema) {
+	tema.Materiales = nil
+	tema.Tareas = nil
+}
+
+// temasConMaterialesDeCurso obtiene los temas del curso con sus materiales
+func (s *TemaService) temasConMaterialesDeCurso(cursoID string) ([]models.Tema, error) {
+	respBody, err := s.temaRepo.GetTemasByCursoID(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener temas: %w", err)
+	}
+
+	var temas []models.Tema
+	if err := json.Unmarshal(respBody, &temas); err != nil {
+		return nil, fmt.Errorf("error al parsear temas: %w", err)
+	}
+
+	return temas, nil
+}
+
+// estadoTemaParaEstudiante indica si el tema sigue bloqueado para el
+// estudiante y por qué
+func (s *TemaService) estadoTemaParaEstudiante(ctx context.Context, cursoID string, temaID, estudianteID uuid.UUID) (bool, string, error) {
+	curso, err := obtenerCurso(s.cursoRepo, cursoID)
+	if err != nil {
+		return false, "", err
+	}
+	temas, err := s.temasConMaterialesDeCurso(cursoID)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := s.aplicarDesbloqueo(ctx, curso, temas, estudianteID); err != nil {
+		return false, "", err
+	}
+
+	for _, t := range temas {
+		if t.ID == temaID {
+			return t.Bloqueado, t.MotivoBloqueo, nil
+		}
+	}
+	return false, "", ErrTemaNoEncontrado
+}
+
+// VerificarDesbloqueo devuelve ErrTemaBloqueado si el estudiante aún no puede
+// ver el contenido del tema
+func (s *TemaService) VerificarDesbloqueo(ctx context.Context, temaID, estudianteID string) error {
+	temaUUID, err := uuid.Parse(temaID)
+	if err != nil {
+		return fmt.Errorf("ID de tema inválido: %w", err)
+	}
+	estudianteUUID, err := uuid.Parse(estudianteID)
+	if err != nil {
+		return ErrSinPermiso
+	}
+
+	respBody, err := s.temaRepo.GetTemaByIDWithRelations(temaID, "?id=eq."+temaID+"&select=id,curso_id")
+	if err != nil {
+		return fmt.Errorf("error al obtener tema: %w", err)
+	}
+	var temas []models.Tema
+	if err := json.Unmarshal(respBody, &temas); err != nil || len(temas) == 0 {
+		return ErrTemaNoEncontrado
+	}
+
+	bloqueado, _, err := s.estadoTemaParaEstudiante(ctx, temas[0].CursoID.String(), temaUUID, estudianteUUID)
+	if err != nil {
+		return err
+	}
+	if bloqueado {
+		return ErrTemaBloqueado
+	}
+	return nil
+}
+
+// tareaBloqueada devuelve ErrTemaBloqueado si la tarea pertenece a un tema
+// que el estudiante aún no desbloquea. Cubre el acceso directo por ID, que
+// no pasa por las rutas del tema.
+func tareaBloqueada(ctx context.Context, temas *TemaService, tarea *models.Tarea, estudianteID uuid.UUID) error {
+	if temas == nil || tarea.TemaID == nil {
+		return nil
+	}
+	bloqueado, _, err := temas.estadoTemaParaEstudiante(ctx, tarea.CursoID.String(), *tarea.TemaID, estudianteID)
+	if err != nil {
+		return err
+	}
+	if bloqueado {
+		return ErrTemaBloqueado
+	}
+	return nil
+}
+
+// ==================== AVISOS DE DESBLOQUEO ====================
+
+// IniciarAvisosDesbloqueo revisa periódicamente los temas que llegaron a su
+// fecha de desbloqueo hasta que se cancele el contexto
+func (s *TemaService) IniciarAvisosDesbloqueo(ctx context.Context, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		s.NotificarDesbloqueos(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.NotificarDesbloqueos(ctx)
+			}
+		}
+	}()
+}
+
+// NotificarDesbloqueos avisa a los estudiantes de los temas con aviso
+// activado cuya fecha de desbloqueo ya pasó
+func (s *TemaService) NotificarDesbloqueos(ctx context.Context) int {
+	temas, err := s.temaRepo.GetDesbloqueosPorNotificar(time.Now())
+	if err != nil {
+		log.Printf("❌ Error buscando temas por desbloquear: %v", err)
+		return 0
+	}
+
+	enviados := 0
+	for i := range temas {
+		enviados += s.notificarDesbloqueo(ctx, &temas[i])
+	}
+
+	if enviados > 0 {
+		log.Printf("🔓 Avisos de temas desbloqueados enviados: %d", enviados)
+	}
+	return enviados
+}
+
+// notificarDesbloqueo avisa a los estudiantes activos del curso. En cursos
+// secuenciales se omite a quien todavía no completa el tema anterior: el tema
+// se le abrirá al avanzar.
+func (s *TemaService) notificarDesbloqueo(ctx context.Context, tema *models.Tema) int {
+	// Marcar antes de enviar para no repetir el aviso entre ejecuciones
+	nuevo, err := s.temaRepo.MarcarDesbloqueoNotificado(tema.ID.String())
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 0
+	}
+	if !nuevo {
+		return 0
+	}
+
+	curso, err := obtenerCurso(s.cursoRepo, tema.CursoID.String())
+	if err != nil {
+		log.Printf("❌ Error obteniendo el curso del tema %s: %v", tema.ID, err)
+		return 0
+	}
+
+	respBody, err := s.matriculaRepo.GetMatriculasByCurso(curso.ID)
+	if err != nil {
+		log.Printf("❌ Error obteniendo matrículas del curso %s: %v", curso.ID, err)
+		return 0
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return 0
+	}
+
+	var temasCurso []models.Tema
+	if curso.DesbloqueoSecuencial {
+		if temasCurso, err = s.temasConMaterialesDeCurso(curso.ID); err != nil {
+			log.Printf("❌ %v", err)
+			return 0
+		}
+	}
+
+	enviados := 0
+	for _, m := range matriculas {
+		if m.Estado != "activo" {
+			continue
+		}
+		estudianteID, err := uuid.Parse(m.EstudianteID)
+		if err != nil {
+			continue
+		}
+
+		if curso.DesbloqueoSecuencial {
+			copia := append([]models.Tema(nil), temasCurso...)
+			if err := s.aplicarDesbloqueo(ctx, curso, copia, estudianteID); err != nil {
+				log.Printf("❌ %v", err)
+				continue
+			}
+			if temaBloqueado(copia, tema.ID) {
+				continue
+			}
+		}
+
+		if err := s.notificationService.NotificarTemaDesbloqueado(estudianteID, tema, curso.Nombre); err != nil {
+			log.Printf("❌ Error avisando desbloqueo a %s: %v", estudianteID, err)
+			continue
+		}
+		enviados++
+	}
+
+	return enviados
+}
+
+func temaBloqueado(temas []models.Tema, temaID uuid.UUID) bool {
+	for _, t := range temas {
+		if t.ID == temaID {
+			return t.Bloqueado
+		}
+	}
+	return false
+}
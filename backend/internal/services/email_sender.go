@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// MensajeEmail es un correo listo para enviar
+type MensajeEmail struct {
+	Para   string `json:"para"`
+	Asunto string `json:"asunto"`
+	Texto  string `json:"texto"`
+	HTML   string `json:"html"`
+}
+
+// EmailSender abstrae el canal de envío de correos
+type EmailSender interface {
+	Enviar(msg *MensajeEmail) error
+}
+
+// NewEmailSender devuelve el sender SMTP si está configurado, o nil si no
+// hay transporte: en ese caso EnviarPlantilla falla y quien llama decide
+// cómo entregar la información por otro medio
+func NewEmailSender() EmailSender {
+	cfg := config.AppConfig
+	if cfg.SMTPHost == "" {
+		log.Println("⚠️ SMTP_HOST no configurado, el envío de correos está deshabilitado")
+		return nil
+	}
+
+	remitente := cfg.SMTPRemitente
+	if remitente == "" {
+		remitente = cfg.SMTPUsuario
+	}
+
+	log.Printf("✅ Email SMTP configurado (%s:%s)", cfg.SMTPHost, cfg.SMTPPort)
+	return NewSMTPEmailSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsuario, cfg.SMTPPassword, remitente)
+}
+
+// ==================== SMTP ====================
+
+type SMTPEmailSender struct {
+	host      string
+	port      string
+	usuario   string
+	password  string
+	remitente string
+}
+
+func NewSMTPEmailSender(host, port, usuario, password, remitente string) *SMTPEmailSender {
+	return &SMTPEmailSender{
+		host:      host,
+		port:      port,
+		usuario:   usuario,
+		password:  password,
+		remitente: remitente,
+	}
+}
+
+func (s *SMTPEmailSender) Enviar(msg *MensajeEmail) error {
+	from, err := mail.ParseAddress(s.remitente)
+	if err != nil {
+		return fmt.Errorf("remitente inválido: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.Para)
+	if err != nil {
+		return fmt.Errorf("destinatario inválido: %w", err)
+	}
+
+	cuerpo, err := construirMIME(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.usuario != "" {
+		auth = smtp.PlainAuth("", s.usuario, s.password, s.host)
+	}
+
+	addr := s.host + ":" + s.port
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to.Address}, cuerpo); err != nil {
+		return fmt.Errorf("error enviando correo: %w", err)
+	}
+
+	log.Printf("📧 Correo enviado a %s: %s", to.Address, msg.Asunto)
+	return nil
+}
+
+// construirMIME arma un multipart/alternative con texto y HTML
+func construirMIME(from, to *mail.Address, msg *MensajeEmail) ([]byte, error) {
+	limite := "frontera-" + strings.ReplaceAll(uuid.New().String(), "-", "")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Asunto))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", limite)
+
+	partes := []struct {
+		tipo      string
+		contenido string
+	}{
+		{"text/plain", msg.Texto},
+		{"text/html", msg.HTML},
+	}
+
+	for _, p := range partes {
+		if p.contenido == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", limite)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", p.tipo)
+		fmt.Fprintf(&buf, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(p.contenido)); err != nil {
+			return nil, fmt.Errorf("error codificando correo: %w", err)
+		}
+		qp.Close()
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", limite)
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Tipos de correo con plantilla propia
+const (
+	EmailCalificacionPublicada = "calificacion_publicada"
+	EmailRestablecerPassword   = "restablecer_password"
+	EmailRecordatorioEntrega   = "recordatorio_entrega"
+	EmailRecalificacion        = "recalificacion"
+	EmailListaEspera           = "lista_espera"
+	EmailTemaDesbloqueado      = "tema_desbloqueado"
+	EmailGeneral               = "general"
+)
+
+// Idioma por defecto de las plantillas
+const IdiomaPorDefecto = "es"
+
+//go:embed plantillas/email
+var plantillasEmail embed.FS
+
+type EmailService struct {
+	sender EmailSender
+}
+
+func NewEmailService(sender EmailSender) *EmailService {
+	return &EmailService{sender: sender}
+}
+
+// EnviarPlantilla renderiza la plantilla del tipo indicado y envía el correo.
+// Si no hay plantilla para el tipo o idioma se usa la general en español.
+func (s *EmailService) EnviarPlantilla(para, tipo, idioma string, datos map[string]interface{}) error {
+	if s == nil || s.sender == nil {
+		return fmt.Errorf("canal de email no disponible")
+	}
+	if strings.TrimSpace(para) == "" {
+		return fmt.Errorf("destinatario vacío")
+	}
+
+	msg, err := RenderizarEmail(tipo, idioma, datos)
+	if err != nil {
+		return err
+	}
+	msg.Para = para
+
+	return s.sender.Enviar(msg)
+}
+
+// RenderizarEmail genera asunto, texto y HTML de una plantilla
+func RenderizarEmail(tipo, idioma string, datos map[string]interface{}) (*MensajeEmail, error) {
+	base := resolverPlantilla(tipo, idioma)
+
+	txt, err := texttemplate.ParseFS(plantillasEmail, "plantillas/email/"+base+".txt")
+	if err != nil {
+		return nil, fmt.Errorf("error al cargar plantilla de texto %s: %w", base, err)
+	}
+	html, err := htmltemplate.ParseFS(plantillasEmail,
+		"plantillas/email/layout.html",
+		"plantillas/email/"+base+".html",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al cargar plantilla HTML %s: %w", base, err)
+	}
+
+	var asunto, texto, cuerpo bytes.Buffer
+	if err := txt.ExecuteTemplate(&asunto, "asunto", datos); err != nil {
+		return nil, fmt.Errorf("error al generar asunto: %w", err)
+	}
+	if err := txt.ExecuteTemplate(&texto, "contenido", datos); err != nil {
+		return nil, fmt.Errorf("error al generar texto: %w", err)
+	}
+	if err := html.ExecuteTemplate(&cuerpo, "layout", datos); err != nil {
+		return nil, fmt.Errorf("error al generar HTML: %w", err)
+	}
+
+	return &MensajeEmail{
+		Asunto: strings.TrimSpace(asunto.String()),
+		Texto:  strings.TrimSpace(texto.String()) + "\n",
+		HTML:   cuerpo.String(),
+	}, nil
+}
+
+// resolverPlantilla busca "<tipo>.<idioma>" con respaldo al idioma por defecto
+// y luego a la plantilla general
+func resolverPlantilla(tipo, idioma string) string {
+	if idioma == "" {
+		idioma = IdiomaPorDefecto
+	}
+
+	candidatos := []string{
+		tipo + "." + idioma,
+		tipo + "." + IdiomaPorDefecto,
+		EmailGeneral + "." + idioma,
+	}
+	for _, c := range candidatos {
+		if _, err := fs.Stat(plantillasEmail, "plantillas/email/"+c+".txt"); err == nil {
+			return c
+		}
+	}
+
+	log.Printf("⚠️ Sin plantilla de email para '%s' (%s), usando general", tipo, idioma)
+	return EmailGeneral + "." + IdiomaPorDefecto
+}
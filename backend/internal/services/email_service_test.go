@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"recetario-backend/internal/config"
+)
+
+// memoriaEmailSender guarda los correos enviados para revisarlos en las pruebas
+type memoriaEmailSender struct {
+	enviados []MensajeEmail
+}
+
+func (s *memoriaEmailSender) Enviar(msg *MensajeEmail) error {
+	s.enviados = append(s.enviados, *msg)
+	return nil
+}
+
+func TestNewEmailSenderSinSMTP(t *testing.T) {
+	anterior := config.AppConfig
+	config.AppConfig = &config.Config{}
+	defer func() { config.AppConfig = anterior }()
+
+	if sender := NewEmailSender(); sender != nil {
+		t.Fatalf("sin SMTP_HOST se esperaba nil, se obtuvo %T", sender)
+	}
+}
+
+func TestEnviarPlantillaSinCanalFalla(t *testing.T) {
+	svc := NewEmailService(nil)
+
+	err := svc.EnviarPlantilla("a@test.com", EmailRestablecerPassword, IdiomaPorDefecto, map[string]interface{}{
+		"PasswordTemporal": "abc",
+	})
+	if err == nil {
+		t.Fatal("se esperaba error sin canal de email")
+	}
+}
+
+func TestEnviarPlantillaRestablecerPassword(t *testing.T) {
+	sender := &memoriaEmailSender{}
+	svc := NewEmailService(sender)
+
+	err := svc.EnviarPlantilla("a@test.com", EmailRestablecerPassword, IdiomaPorDefecto, map[string]interface{}{
+		"Nombre":           "Ana",
+		"Email":            "a@test.com",
+		"PasswordTemporal": "Xy7kPq2mRt",
+	})
+	if err != nil {
+		t.Fatalf("EnviarPlantilla: %v", err)
+	}
+	if len(sender.enviados) != 1 {
+		t.Fatalf("enviados = %d, se esperaba 1", len(sender.enviados))
+	}
+
+	msg := sender.enviados[0]
+	if msg.Para != "a@test.com" || msg.Asunto == "" {
+		t.Errorf("correo incompleto: %+v", msg)
+	}
+	if !strings.Contains(msg.Texto, "Xy7kPq2mRt") || !strings.Contains(msg.HTML, "Xy7kPq2mRt") {
+		t.Error("el correo no incluye la contraseña temporal")
+	}
+}
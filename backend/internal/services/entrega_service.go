@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"recetario-backend/internal/models"
@@ -14,18 +15,27 @@ import (
 type EntregaService struct {
 	entregaRepo    *repository.EntregaRepository
 	tareaRepo      *repository.TareaRepository
+	grupoRepo      *repository.GrupoRepository
+	cursoRepo      repository.CursoRepository
 	storageService *StorageService
+	temaService    *TemaService
 }
 
 func NewEntregaService(
 	entregaRepo *repository.EntregaRepository,
 	tareaRepo *repository.TareaRepository,
+	grupoRepo *repository.GrupoRepository,
+	cursoRepo repository.CursoRepository,
 	storageService *StorageService,
+	temaService *TemaService,
 ) *EntregaService {
 	return &EntregaService{
 		entregaRepo:    entregaRepo,
 		tareaRepo:      tareaRepo,
+		grupoRepo:      grupoRepo,
+		cursoRepo:      cursoRepo,
 		storageService: storageService,
+		temaService:    temaService,
 	}
 }
 
@@ -42,23 +52,17 @@ func (s *EntregaService) CrearEntrega(ctx context.Context, estudianteID uuid.UUI
 	if err != nil {
 		return nil, fmt.Errorf("tarea no encontrada: %w", err)
 	}
+	if err := tareaBloqueada(ctx, s.temaService, tarea, estudianteID); err != nil {
+		return nil, err
+	}
 
 	// Calcular si está tarde
 	now := time.Now()
-	diasRetraso := 0
-	entregaTardia := false
-	penalizacion := 0.0
-
-	if now.After(tarea.FechaLimite) {
-		entregaTardia = true
-		diasRetraso = int(now.Sub(tarea.FechaLimite).Hours() / 24)
-
-		if !tarea.PermiteEntregaTardia || diasRetraso > tarea.DiasTolerancia {
-			return nil, fmt.Errorf("la fecha límite ha expirado")
-		}
-
-		penalizacion = float64(diasRetraso) * tarea.PenalizacionPorDia
+	if now.After(cierreEntregas(tarea)) {
+		return nil, fmt.Errorf("la fecha límite ha expirado")
 	}
+	entregaTardia := now.After(tarea.FechaLimite)
+	diasRetraso, penalizacion := retrasoEntrega(tarea, now)
 
 	// Crear entrega
 	entrega := &models.Entrega{
@@ -73,40 +77,141 @@ func (s *EntregaService) CrearEntrega(ctx context.Context, estudianteID uuid.UUI
 		EntregaTardia:        entregaTardia,
 	}
 
-	return s.entregaRepo.Create(ctx, entrega)
+	var creada *models.Entrega
+	if tarea.EntregaGrupal {
+		creada, err = s.crearEntregaGrupal(ctx, tarea, entrega)
+	} else {
+		creada, err = s.entregaRepo.Create(ctx, entrega)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Primera versión de la entrega
+	s.registrarVersion(ctx, creada, 1, models.MotivoVersionEntrega, estudianteID)
+	return creada, nil
 }
 
-// Agregar archivo a entrega
-func (s *EntregaService) AgregarArchivo(ctx context.Context, entregaID uuid.UUID, archivo *models.ArchivoEntrega) error {
-	return s.entregaRepo.AddArchivo(ctx, archivo)
+// crearEntregaGrupal registra la entrega para cada integrante del grupo del
+// estudiante, todas con el mismo grupo_id. Devuelve la fila del estudiante.
+func (s *EntregaService) crearEntregaGrupal(ctx context.Context, tarea *models.Tarea, entrega *models.Entrega) (*models.Entrega, error) {
+	grupo, err := s.grupoRepo.GetDeEstudiante(ctx, tarea.CursoID, entrega.EstudianteID)
+	if err != nil {
+		return nil, err
+	}
+	if grupo == nil {
+		return nil, ErrSinGrupo
+	}
+
+	existentes, err := s.entregaRepo.GetByTareaAndGrupo(ctx, tarea.ID, grupo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existentes) > 0 {
+		return nil, fmt.Errorf("tu grupo ya entregó esta tarea. Si deseas modificarla, edita la entrega del grupo")
+	}
+
+	filas := make([]models.Entrega, 0, len(grupo.Integrantes))
+	for _, integrante := range grupo.Integrantes {
+		fila := *entrega
+		fila.EstudianteID = integrante.EstudianteID
+		fila.GrupoID = &grupo.ID
+		filas = append(filas, fila)
+	}
+
+	creadas, err := s.entregaRepo.CreateGrupal(ctx, filas)
+	if err != nil {
+		return nil, err
+	}
+	for i := range creadas {
+		if creadas[i].EstudianteID == entrega.EstudianteID {
+			creadas[i].Grupo = grupo
+			return &creadas[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no se pudo crear la entrega")
+}
+
+// Agregar archivo a entrega. Queda una nueva versión con el archivo.
+func (s *EntregaService) AgregarArchivo(ctx context.Context, entregaID, estudianteID uuid.UUID, archivo *models.ArchivoEntrega) error {
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return err
+	}
+	envio, err := s.prepararCambio(ctx, entrega, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := s.entregaRepo.AddArchivo(ctx, archivo); err != nil {
+		return err
+	}
+
+	s.registrarVersion(ctx, entrega, envio, models.MotivoVersionArchivoAgregado, estudianteID)
+	return nil
 }
 
-// Obtener mi entrega (estudiante)
+// Obtener mi entrega (estudiante). La nota solo se ve si la tarea está publicada.
 func (s *EntregaService) ObtenerMiEntrega(ctx context.Context, tareaID, estudianteID uuid.UUID) (*models.Entrega, error) {
 	entrega, err := s.entregaRepo.GetByTareaAndEstudiante(ctx, tareaID, estudianteID)
 	if err != nil {
 		return nil, err
 	}
 
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if !calificacionesPublicadas(tarea) {
+		ocultarCalificacion(entrega)
+	}
+
 	// Cargar archivos
-	archivos, err := s.entregaRepo.GetArchivosByEntregaID(ctx, entrega.ID)
+	archivos, err := archivosDeEntrega(ctx, s.entregaRepo, entrega)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo archivos: %w", err)
 	}
 	entrega.Archivos = archivos
 
+	// Entrega grupal: mostrar con quiénes cuenta
+	if entrega.GrupoID != nil {
+		grupo, err := s.grupoRepo.GetByID(ctx, *entrega.GrupoID)
+		if err == nil && grupo != nil {
+			completarGrupo(grupo)
+			entrega.Grupo = grupo
+		}
+	}
+
 	return entrega, nil
 }
 
-// ✅ NUEVO: Obtener entrega por ID
-func (s *EntregaService) ObtenerEntregaPorID(ctx context.Context, entregaID uuid.UUID) (*models.Entrega, error) {
+// ✅ NUEVO: Obtener entrega por ID. Al estudiante se le oculta la nota sin
+// publicar; al docente, la identidad si la calificación es anónima.
+func (s *EntregaService) ObtenerEntregaPorID(ctx context.Context, entregaID uuid.UUID, rol string) (*models.Entrega, error) {
 	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
 	if err != nil {
 		return nil, err
 	}
 
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if rol == "estudiante" {
+		if !calificacionesPublicadas(tarea) {
+			ocultarCalificacion(entrega)
+		}
+	} else {
+		entregas := []models.Entrega{*entrega}
+		anonimizarEntregas(tarea, entregas)
+		if err := adjuntarVersionAlCierre(ctx, s.entregaRepo, tarea, entregas); err != nil {
+			return nil, err
+		}
+		*entrega = entregas[0]
+	}
+
 	// Cargar archivos
-	archivos, err := s.entregaRepo.GetArchivosByEntregaID(ctx, entrega.ID)
+	archivos, err := archivosDeEntrega(ctx, s.entregaRepo, entrega)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo archivos: %w", err)
 	}
@@ -122,26 +227,44 @@ func (s *EntregaService) ValidarPropietario(ctx context.Context, entregaID, estu
 		return fmt.Errorf("entrega no encontrada: %w", err)
 	}
 
-	if entrega.EstudianteID != estudianteID {
-		return fmt.Errorf("no tienes permiso para realizar esta acción")
+	if entrega.EstudianteID == estudianteID {
+		return nil
 	}
 
-	return nil
+	// En una entrega grupal cualquier integrante puede modificarla
+	if entrega.GrupoID != nil {
+		propia, err := s.entregaRepo.GetByTareaAndEstudiante(ctx, entrega.TareaID, estudianteID)
+		if err == nil && propia != nil && propia.GrupoID != nil && *propia.GrupoID == *entrega.GrupoID {
+			return nil
+		}
+	}
+
+	return ErrSinPermiso
 }
 
-// Editar entrega (solo si no está calificada)
-func (s *EntregaService) EditarEntrega(ctx context.Context, entregaID uuid.UUID, req *models.CreateEntregaRequest) error {
-	// Verificar que no esté calificada
+// Editar entrega (solo si no está calificada, dentro del plazo y según la
+// política de reenvío de la tarea). La versión anterior se conserva.
+func (s *EntregaService) EditarEntrega(ctx context.Context, entregaID, estudianteID uuid.UUID, req *models.CreateEntregaRequest) error {
 	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
 	if err != nil {
 		return err
 	}
+	envio, err := s.prepararCambio(ctx, entrega, time.Now())
+	if err != nil {
+		return err
+	}
 
-	if entrega.Calificacion != nil {
-		return fmt.Errorf("no se puede editar una entrega ya calificada")
+	if entrega.GrupoID != nil {
+		err = s.entregaRepo.UpdateGrupo(ctx, entrega.TareaID, *entrega.GrupoID, req)
+	} else {
+		err = s.entregaRepo.Update(ctx, entregaID, req)
+	}
+	if err != nil {
+		return err
 	}
 
-	return s.entregaRepo.Update(ctx, entregaID, req)
+	s.registrarVersion(ctx, entrega, envio, models.MotivoVersionEdicion, estudianteID)
+	return nil
 }
 
 // Eliminar entrega (solo si no está calificada)
@@ -155,12 +278,28 @@ func (s *EntregaService) EliminarEntrega(ctx context.Context, entregaID uuid.UUI
 		return fmt.Errorf("no se puede eliminar una entrega ya calificada")
 	}
 
+	// Pasada la fecha límite lo entregado queda como registro
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if time.Now().After(tarea.FechaLimite) {
+		return fmt.Errorf("%w: la entrega ya no se puede eliminar", ErrEntregaCerrada)
+	}
+
+	if entrega.GrupoID != nil {
+		return s.entregaRepo.DeleteGrupo(ctx, entrega.TareaID, *entrega.GrupoID)
+	}
 	return s.entregaRepo.Delete(ctx, entregaID)
 }
 
-// ✅ NUEVO: Obtener archivos por entrega ID
+// ✅ NUEVO: Obtener archivos por entrega ID (de todo el grupo si es grupal)
 func (s *EntregaService) ObtenerArchivosPorEntregaID(ctx context.Context, entregaID uuid.UUID) ([]models.ArchivoEntrega, error) {
-	return s.entregaRepo.GetArchivosByEntregaID(ctx, entregaID)
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return nil, err
+	}
+	return archivosDeEntrega(ctx, s.entregaRepo, entrega)
 }
 
 // ✅ NUEVO: Obtener archivo por ID
@@ -168,55 +307,60 @@ func (s *EntregaService) ObtenerArchivoPorID(ctx context.Context, archivoID uuid
 	return s.entregaRepo.GetArchivoByID(ctx, archivoID)
 }
 
-// ✅ NUEVO: Eliminar archivo individual
-func (s *EntregaService) EliminarArchivo(ctx context.Context, archivoID uuid.UUID) error {
-	return s.entregaRepo.DeleteArchivo(ctx, archivoID)
+// ✅ NUEVO: Eliminar archivo individual. Solo se quita de la entrega actual:
+// las versiones anteriores lo siguen mostrando.
+func (s *EntregaService) EliminarArchivo(ctx context.Context, archivoID, estudianteID uuid.UUID) error {
+	archivo, err := s.entregaRepo.GetArchivoByID(ctx, archivoID)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidarPropietario(ctx, archivo.EntregaID, estudianteID); err != nil {
+		return err
+	}
+	entrega, err := s.entregaRepo.GetByID(ctx, archivo.EntregaID)
+	if err != nil {
+		return err
+	}
+	envio, err := s.prepararCambio(ctx, entrega, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := s.entregaRepo.DeleteArchivo(ctx, archivoID); err != nil {
+		return err
+	}
+
+	s.registrarVersion(ctx, entrega, envio, models.MotivoVersionArchivoEliminado, estudianteID)
+	return nil
 }
 
 // ========================================
 // MÉTODOS PARA DOCENTES
 // ========================================
 
-// Obtener entregas con información del estudiante (DOCENTE)
+// Obtener entregas con información del estudiante (DOCENTE).
+// En calificación anónima el estudiante se reemplaza por un alias hasta publicar.
 func (s *EntregaService) GetEntregasConEstudiante(ctx context.Context, tareaID uuid.UUID) ([]models.Entrega, error) {
-	return s.entregaRepo.GetByTareaIDWithEstudiante(ctx, tareaID)
-}
-
-// Obtener estadísticas de entregas (DOCENTE)
-func (s *EntregaService) GetEstadisticas(ctx context.Context, tareaID, cursoID uuid.UUID) (map[string]int, error) {
-	return s.entregaRepo.GetEstadisticasByTareaID(ctx, tareaID, cursoID)
-}
-
-// Calificar entrega (DOCENTE)
-func (s *EntregaService) CalificarEntrega(ctx context.Context, entregaID uuid.UUID, calificacion float64, comentario string) error {
-	// Validar calificación
-	if calificacion < 0 {
-		return fmt.Errorf("la calificación no puede ser negativa")
-	}
-
-	// Obtener la entrega para validar
-	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
 	if err != nil {
-		return fmt.Errorf("entrega no encontrada: %w", err)
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
 	}
 
-	// Obtener la tarea para validar el puntaje máximo
-	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	entregas, err := s.entregaRepo.GetByTareaIDWithEstudiante(ctx, tareaID)
 	if err != nil {
-		return fmt.Errorf("tarea no encontrada: %w", err)
-	}
-
-	if calificacion > tarea.PuntajeMaximo {
-		return fmt.Errorf("la calificación no puede ser mayor al puntaje máximo de %.2f", tarea.PuntajeMaximo)
+		return nil, err
 	}
 
-	// Aplicar penalización si hay
-	calificacionFinal := calificacion - entrega.PenalizacionAplicada
-	if calificacionFinal < 0 {
-		calificacionFinal = 0
+	anonimizarEntregas(tarea, entregas)
+	if err := adjuntarVersionAlCierre(ctx, s.entregaRepo, tarea, entregas); err != nil {
+		return nil, err
 	}
+	return entregas, nil
+}
 
-	return s.entregaRepo.Calificar(ctx, entregaID, calificacionFinal, comentario)
+// Obtener estadísticas de entregas (DOCENTE)
+func (s *EntregaService) GetEstadisticas(ctx context.Context, tareaID, cursoID uuid.UUID) (map[string]int, error) {
+	return s.entregaRepo.GetEstadisticasByTareaID(ctx, tareaID, cursoID)
 }
 
 // Obtener entrega por ID con todos los detalles (DOCENTE)
@@ -227,7 +371,7 @@ func (s *EntregaService) GetEntregaDetalle(ctx context.Context, entregaID uuid.U
 	}
 
 	// Cargar archivos
-	archivos, err := s.entregaRepo.GetArchivosByEntregaID(ctx, entrega.ID)
+	archivos, err := archivosDeEntrega(ctx, s.entregaRepo, entrega)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo archivos: %w", err)
 	}
@@ -240,5 +384,83 @@ func (s *EntregaService) GetEntregaDetalle(ctx context.Context, entregaID uuid.U
 	}
 	entrega.Tarea = tarea
 
+	entregas := []models.Entrega{*entrega}
+	if err := adjuntarVersionAlCierre(ctx, s.entregaRepo, tarea, entregas); err != nil {
+		return nil, err
+	}
+	*entrega = entregas[0]
+
 	return entrega, nil
 }
+
+// archivosDeEntrega devuelve los archivos de la entrega; si es grupal, los
+// que subió cualquiera de los integrantes
+func archivosDeEntrega(ctx context.Context, entregaRepo *repository.EntregaRepository, entrega *models.Entrega) ([]models.ArchivoEntrega, error) {
+	if entrega.GrupoID != nil {
+		return entregaRepo.GetArchivosDeGrupo(ctx, entrega.TareaID, *entrega.GrupoID)
+	}
+	return entregaRepo.GetArchivosByEntregaID(ctx, entrega.ID)
+}
+
+// ========================================
+// PLAZOS
+// ========================================
+
+// cierreEntregas es el último momento en que la tarea acepta entregas: la
+// fecha límite o, con entrega tardía, el fin del último día de tolerancia
+func cierreEntregas(tarea *models.Tarea) time.Time {
+	if !tarea.PermiteEntregaTardia {
+		return tarea.FechaLimite
+	}
+	return tarea.FechaLimite.Add(time.Duration(tarea.DiasTolerancia+1)*24*time.Hour - time.Nanosecond)
+}
+
+// retrasoEntrega calcula los días completos de retraso y la penalización
+// de una entrega hecha en 'fecha'
+func retrasoEntrega(tarea *models.Tarea, fecha time.Time) (int, float64) {
+	if !fecha.After(tarea.FechaLimite) {
+		return 0, 0
+	}
+	dias := int(fecha.Sub(tarea.FechaLimite).Hours() / 24)
+	return dias, float64(dias) * tarea.PenalizacionPorDia
+}
+
+// ========================================
+// PUBLICACIÓN Y ANONIMATO
+// ========================================
+
+// calificacionesPublicadas indica si el estudiante ya puede ver las notas de la tarea
+func calificacionesPublicadas(tarea *models.Tarea) bool {
+	return tarea.CalificacionesPublicadasAt != nil
+}
+
+// ocultarCalificacion deja la entrega como el estudiante la veía antes de ser calificada
+func ocultarCalificacion(entrega *models.Entrega) {
+	entrega.Calificacion = nil
+	entrega.ComentarioDocente = nil
+	entrega.RubricaEvaluacion = nil
+	entrega.CalificacionGrupal = nil
+	entrega.AjusteIndividual = 0
+	entrega.MotivoAjuste = nil
+	if entrega.Estado == "evaluada" {
+		entrega.Estado = "entregada"
+	}
+}
+
+// anonimizarEntregas reemplaza al estudiante por un alias mientras la tarea
+// se califica en modo anónimo y sus notas no se han publicado
+func anonimizarEntregas(tarea *models.Tarea, entregas []models.Entrega) {
+	if !tarea.CalificacionAnonima || calificacionesPublicadas(tarea) {
+		return
+	}
+	for i := range entregas {
+		entregas[i].EstudianteID = uuid.Nil
+		entregas[i].Estudiante = nil
+		entregas[i].Alias = aliasAnonimo(entregas[i].ID)
+	}
+}
+
+// aliasAnonimo deriva un alias estable del ID de la entrega (no del estudiante)
+func aliasAnonimo(entregaID uuid.UUID) string {
+	return "Anónimo " + strings.ToUpper(entregaID.String()[:6])
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// FakeMessagingClient implementa MessagingClient en memoria.
+// Sirve para desarrollo local (FIREBASE_FAKE=true) y para simular fallos por token.
+type FakeMessagingClient struct {
+	mu sync.Mutex
+
+	// Errores a devolver por token (p.ej. &ErrorFCM{Codigo: CodigoFCMUnregistered})
+	ErroresPorToken map[string]error
+
+	Mensajes      []*messaging.Message
+	Multicasts    []*messaging.MulticastMessage
+	Suscripciones map[string]map[string]bool // topic -> tokens
+	contador      int
+}
+
+func NewFakeMessagingClient() *FakeMessagingClient {
+	return &FakeMessagingClient{
+		ErroresPorToken: make(map[string]error),
+		Suscripciones:   make(map[string]map[string]bool),
+	}
+}
+
+// MarcarTokenInvalido hace que los envíos a ese token fallen como UNREGISTERED
+func (f *FakeMessagingClient) MarcarTokenInvalido(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ErroresPorToken[token] = &ErrorFCM{Codigo: CodigoFCMUnregistered, Mensaje: "token no registrado"}
+}
+
+func (f *FakeMessagingClient) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Mensajes = append(f.Mensajes, message)
+	if message.Token != "" {
+		if err, ok := f.ErroresPorToken[message.Token]; ok {
+			return "", err
+		}
+	}
+
+	f.contador++
+	id := fmt.Sprintf("fake-message-%d", f.contador)
+	log.Printf("🧪 [FakeFCM] Send token=%s topic=%s titulo=%q", abreviarToken(message.Token), message.Topic, tituloDe(message.Notification))
+	return id, nil
+}
+
+func (f *FakeMessagingClient) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(message.Tokens) == 0 {
+		return nil, fmt.Errorf("tokens must not be nil or empty")
+	}
+	if len(message.Tokens) > maxTokensPorMulticast {
+		return nil, fmt.Errorf("tokens must not contain more than %d elements", maxTokensPorMulticast)
+	}
+
+	f.Multicasts = append(f.Multicasts, message)
+
+	batch := &messaging.BatchResponse{}
+	for _, token := range message.Tokens {
+		if err, ok := f.ErroresPorToken[token]; ok {
+			batch.FailureCount++
+			batch.Responses = append(batch.Responses, &messaging.SendResponse{Error: err})
+			continue
+		}
+		f.contador++
+		batch.SuccessCount++
+		batch.Responses = append(batch.Responses, &messaging.SendResponse{
+			Success:   true,
+			MessageID: fmt.Sprintf("fake-message-%d", f.contador),
+		})
+	}
+
+	log.Printf("🧪 [FakeFCM] Multicast a %d tokens: %d exitosos, %d fallidos", len(message.Tokens), batch.SuccessCount, batch.FailureCount)
+	return batch, nil
+}
+
+func (f *FakeMessagingClient) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Suscripciones[topic] == nil {
+		f.Suscripciones[topic] = make(map[string]bool)
+	}
+	for _, token := range tokens {
+		f.Suscripciones[topic][token] = true
+	}
+	return &messaging.TopicManagementResponse{SuccessCount: len(tokens)}, nil
+}
+
+func (f *FakeMessagingClient) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, token := range tokens {
+		delete(f.Suscripciones[topic], token)
+	}
+	return &messaging.TopicManagementResponse{SuccessCount: len(tokens)}, nil
+}
+
+func tituloDe(n *messaging.Notification) string {
+	if n == nil {
+		return ""
+	}
+	return n.Title
+}
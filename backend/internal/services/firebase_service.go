@@ -2,22 +2,101 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync/atomic"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"google.golang.org/api/option"
 )
 
+// Máximo de tokens que FCM acepta por envío multicast
+const maxTokensPorMulticast = 500
+
+// Códigos de error de FCM usados para clasificar los resultados por token
+const (
+	CodigoFCMUnregistered     = "UNREGISTERED"
+	CodigoFCMInvalidArgument  = "INVALID_ARGUMENT"
+	CodigoFCMSenderIDMismatch = "SENDER_ID_MISMATCH"
+	CodigoFCMQuotaExceeded    = "QUOTA_EXCEEDED"
+	CodigoFCMUnavailable      = "UNAVAILABLE"
+	CodigoFCMInternal         = "INTERNAL"
+	CodigoFCMThirdPartyAuth   = "THIRD_PARTY_AUTH_ERROR"
+	CodigoFCMDesconocido      = "UNKNOWN"
+)
+
+// MessagingClient abstrae las operaciones de FCM que usa el backend.
+// *messaging.Client la implementa; FakeMessagingClient permite probar sin Firebase.
+type MessagingClient interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+	SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+	UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+}
+
+// ResultadoToken es el resultado del envío a un token individual
+type ResultadoToken struct {
+	Token     string `json:"token"`
+	Exito     bool   `json:"exito"`
+	MessageID string `json:"message_id,omitempty"`
+	Codigo    string `json:"codigo,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// El lote completo falló: el código no describe al token en sí
+	errorDeLote bool
+}
+
+// ResultadoEnvio agrupa los resultados de un envío a varios tokens
+type ResultadoEnvio struct {
+	Exitosos   int              `json:"exitosos"`
+	Fallidos   int              `json:"fallidos"`
+	Resultados []ResultadoToken `json:"resultados"`
+}
+
+// TokensInvalidos devuelve los tokens que FCM reportó como muertos
+func (r *ResultadoEnvio) TokensInvalidos() []string {
+	var invalidos []string
+	for _, res := range r.Resultados {
+		if !res.Exito && !res.errorDeLote && EsTokenInvalido(res.Codigo) {
+			invalidos = append(invalidos, res.Token)
+		}
+	}
+	return invalidos
+}
+
+// MetricasPush es una foto de los contadores de entrega push
+type MetricasPush struct {
+	Enviados        int64   `json:"enviados"`
+	Exitosos        int64   `json:"exitosos"`
+	Fallidos        int64   `json:"fallidos"`
+	TokensInvalidos int64   `json:"tokens_invalidos"`
+	TasaExito       float64 `json:"tasa_exito"`
+}
+
+type contadoresPush struct {
+	enviados        atomic.Int64
+	exitosos        atomic.Int64
+	fallidos        atomic.Int64
+	tokensInvalidos atomic.Int64
+}
+
 type FirebaseService struct {
-	client *messaging.Client
+	client     MessagingClient
+	contadores contadoresPush
 }
 
 func NewFirebaseService() (*FirebaseService, error) {
 	ctx := context.Background()
 
+	// ✅ Cliente falso para desarrollo local (no envía nada a Google)
+	if os.Getenv("FIREBASE_FAKE") == "true" {
+		log.Println("✅ Firebase: Usando cliente de mensajería falso (FIREBASE_FAKE)")
+		return NewFirebaseServiceWithClient(NewFakeMessagingClient()), nil
+	}
+
 	var opt option.ClientOption
 
 	// ✅ Intentar cargar desde variable de entorno (PRODUCCIÓN)
@@ -47,7 +126,38 @@ func NewFirebaseService() (*FirebaseService, error) {
 	}
 
 	log.Println("✅ Firebase Service inicializado correctamente")
-	return &FirebaseService{client: client}, nil
+	return NewFirebaseServiceWithClient(client), nil
+}
+
+// NewFirebaseServiceWithClient crea el servicio sobre un cliente de mensajería ya construido
+func NewFirebaseServiceWithClient(client MessagingClient) *FirebaseService {
+	return &FirebaseService{client: client}
+}
+
+// Configuración Android/APNS común a todos los envíos
+func configAndroid() *messaging.AndroidConfig {
+	return &messaging.AndroidConfig{
+		Priority: "high",
+		Notification: &messaging.AndroidNotification{
+			Sound:        "default",
+			ChannelID:    "recetas_compartidas",
+			Priority:     messaging.PriorityHigh,
+			DefaultSound: true,
+		},
+	}
+}
+
+func configAPNS() *messaging.APNSConfig {
+	return &messaging.APNSConfig{
+		Headers: map[string]string{
+			"apns-priority": "10",
+		},
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Sound: "default",
+			},
+		},
+	}
 }
 
 // Enviar notificación push a un token
@@ -61,96 +171,150 @@ func (s *FirebaseService) EnviarNotificacion(token, titulo, mensaje string, data
 			Title: titulo,
 			Body:  mensaje,
 		},
-		Data: data,
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				Sound:        "default",
-				ChannelID:    "recetas_compartidas",
-				Priority:     messaging.PriorityHigh,
-				DefaultSound: true,
-			},
-		},
-		APNS: &messaging.APNSConfig{
-			Headers: map[string]string{
-				"apns-priority": "10",
-			},
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					Sound: "default",
-					Badge: nil,
-				},
-			},
-		},
+		Data:    data,
+		Android: configAndroid(),
+		APNS:    configAPNS(),
 	}
 
 	// Enviar mensaje
 	response, err := s.client.Send(ctx, message)
+	s.contadores.enviados.Add(1)
 	if err != nil {
+		s.contadores.fallidos.Add(1)
+		if EsTokenInvalido(ClasificarErrorFCM(err)) {
+			s.contadores.tokensInvalidos.Add(1)
+		}
 		return fmt.Errorf("error enviando notificación: %v", err)
 	}
+	s.contadores.exitosos.Add(1)
 
 	log.Printf("✅ Notificación enviada exitosamente: %s", response)
 	return nil
 }
 
-// Enviar notificación a múltiples tokens
-func (s *FirebaseService) EnviarNotificacionMultiple(tokens []string, titulo, mensaje string, data map[string]string) error {
+// Enviar notificación a múltiples tokens en lotes multicast de hasta 500 tokens.
+// Devuelve el resultado por token para que el llamador pueda limpiar tokens muertos.
+func (s *FirebaseService) EnviarNotificacionMultiple(tokens []string, titulo, mensaje string, data map[string]string) (*ResultadoEnvio, error) {
 	ctx := context.Background()
 
 	if len(tokens) == 0 {
-		return fmt.Errorf("no hay tokens para enviar")
+		return nil, fmt.Errorf("no hay tokens para enviar")
 	}
 
-	// ✅ CAMBIO: Enviar uno por uno en lugar de multicast
-	successCount := 0
-	failureCount := 0
+	resultado := &ResultadoEnvio{Resultados: make([]ResultadoToken, 0, len(tokens))}
 
-	for _, token := range tokens {
-		message := &messaging.Message{
-			Token: token,
+	for inicio := 0; inicio < len(tokens); inicio += maxTokensPorMulticast {
+		fin := inicio + maxTokensPorMulticast
+		if fin > len(tokens) {
+			fin = len(tokens)
+		}
+		lote := tokens[inicio:fin]
+
+		message := &messaging.MulticastMessage{
+			Tokens: lote,
 			Notification: &messaging.Notification{
 				Title: titulo,
 				Body:  mensaje,
 			},
-			Data: data,
-			Android: &messaging.AndroidConfig{
-				Priority: "high",
-				Notification: &messaging.AndroidNotification{
-					Sound:        "default",
-					ChannelID:    "recetas_compartidas",
-					Priority:     messaging.PriorityHigh,
-					DefaultSound: true,
-				},
-			},
-			APNS: &messaging.APNSConfig{
-				Headers: map[string]string{
-					"apns-priority": "10",
-				},
-				Payload: &messaging.APNSPayload{
-					Aps: &messaging.Aps{
-						Sound: "default",
-					},
-				},
-			},
+			Data:    data,
+			Android: configAndroid(),
+			APNS:    configAPNS(),
 		}
 
-		_, err := s.client.Send(ctx, message)
+		response, err := s.client.SendEachForMulticast(ctx, message)
 		if err != nil {
-			log.Printf("⚠️ Error enviando a token %s: %v", token[:20]+"...", err)
-			failureCount++
-		} else {
-			successCount++
+			// El lote completo falló (credenciales, red, payload inválido)
+			codigo := ClasificarErrorFCM(err)
+			for _, token := range lote {
+				resultado.Resultados = append(resultado.Resultados, ResultadoToken{
+					Token:       token,
+					Codigo:      codigo,
+					Error:       err.Error(),
+					errorDeLote: true,
+				})
+			}
+			resultado.Fallidos += len(lote)
+			log.Printf("⚠️ Error enviando lote multicast de %d tokens: %v", len(lote), err)
+			continue
+		}
+
+		for i, resp := range response.Responses {
+			res := ResultadoToken{Token: lote[i]}
+			if resp.Success {
+				res.Exito = true
+				res.MessageID = resp.MessageID
+				resultado.Exitosos++
+			} else {
+				res.Codigo = ClasificarErrorFCM(resp.Error)
+				if resp.Error != nil {
+					res.Error = resp.Error.Error()
+				}
+				resultado.Fallidos++
+				log.Printf("⚠️ Error enviando a token %s: [%s] %v", abreviarToken(lote[i]), res.Codigo, resp.Error)
+			}
+			resultado.Resultados = append(resultado.Resultados, res)
 		}
 	}
 
-	log.Printf("✅ Notificaciones enviadas: %d exitosas, %d fallidas", successCount, failureCount)
+	s.registrarMetricas(resultado)
 
-	if successCount == 0 {
-		return fmt.Errorf("todas las notificaciones fallaron")
+	log.Printf("✅ Notificaciones enviadas: %d exitosas, %d fallidas", resultado.Exitosos, resultado.Fallidos)
+
+	if resultado.Exitosos == 0 {
+		return resultado, fmt.Errorf("todas las notificaciones fallaron")
 	}
 
-	return nil
+	return resultado, nil
+}
+
+func (s *FirebaseService) registrarMetricas(resultado *ResultadoEnvio) {
+	s.contadores.enviados.Add(int64(len(resultado.Resultados)))
+	s.contadores.exitosos.Add(int64(resultado.Exitosos))
+	s.contadores.fallidos.Add(int64(resultado.Fallidos))
+	s.contadores.tokensInvalidos.Add(int64(len(resultado.TokensInvalidos())))
+}
+
+// Metricas devuelve los contadores de entrega acumulados desde el arranque
+func (s *FirebaseService) Metricas() MetricasPush {
+	m := MetricasPush{
+		Enviados:        s.contadores.enviados.Load(),
+		Exitosos:        s.contadores.exitosos.Load(),
+		Fallidos:        s.contadores.fallidos.Load(),
+		TokensInvalidos: s.contadores.tokensInvalidos.Load(),
+	}
+	if m.Enviados > 0 {
+		m.TasaExito = float64(m.Exitosos) / float64(m.Enviados) * 100
+	}
+	return m
+}
+
+// TopicCurso devuelve el nombre del topic FCM de un curso
+func TopicCurso(cursoID string) string {
+	return "curso_" + cursoID
+}
+
+// Enviar notificación a todos los suscritos a un topic
+func (s *FirebaseService) EnviarATopic(topic, titulo, mensaje string, data map[string]string) (string, error) {
+	ctx := context.Background()
+
+	message := &messaging.Message{
+		Topic: topic,
+		Notification: &messaging.Notification{
+			Title: titulo,
+			Body:  mensaje,
+		},
+		Data:    data,
+		Android: configAndroid(),
+		APNS:    configAPNS(),
+	}
+
+	response, err := s.client.Send(ctx, message)
+	if err != nil {
+		return "", fmt.Errorf("error enviando a topic '%s': %v", topic, err)
+	}
+
+	log.Printf("✅ Notificación enviada a topic '%s': %s", topic, response)
+	return response, nil
 }
 
 // Suscribir token a un topic
@@ -178,3 +342,65 @@ func (s *FirebaseService) DesuscribirDeTopic(tokens []string, topic string) erro
 		topic, response.SuccessCount, response.FailureCount)
 	return nil
 }
+
+// ==================== CLASIFICACIÓN DE ERRORES ====================
+
+// ErrorFCM es un error con código FCM explícito (lo usa el cliente falso)
+type ErrorFCM struct {
+	Codigo  string
+	Mensaje string
+}
+
+func (e *ErrorFCM) Error() string {
+	return fmt.Sprintf("%s: %s", e.Codigo, e.Mensaje)
+}
+
+// ClasificarErrorFCM traduce un error del SDK a su código FCM
+func ClasificarErrorFCM(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var errFCM *ErrorFCM
+	if errors.As(err, &errFCM) {
+		return errFCM.Codigo
+	}
+
+	switch {
+	case messaging.IsUnregistered(err):
+		return CodigoFCMUnregistered
+	case messaging.IsInvalidArgument(err):
+		return CodigoFCMInvalidArgument
+	case messaging.IsSenderIDMismatch(err):
+		return CodigoFCMSenderIDMismatch
+	case messaging.IsQuotaExceeded(err):
+		return CodigoFCMQuotaExceeded
+	case messaging.IsUnavailable(err):
+		return CodigoFCMUnavailable
+	case messaging.IsInternal(err):
+		return CodigoFCMInternal
+	case messaging.IsThirdPartyAuthError(err):
+		return CodigoFCMThirdPartyAuth
+	default:
+		return CodigoFCMDesconocido
+	}
+}
+
+// EsTokenInvalido indica si el código implica que el token ya no sirve y debe desactivarse.
+// INVALID_ARGUMENT no cuenta: FCM también lo devuelve por problemas del mensaje
+// (data demasiado grande o mal formada) y desactivaría todos los dispositivos.
+func EsTokenInvalido(codigo string) bool {
+	switch codigo {
+	case CodigoFCMUnregistered, CodigoFCMSenderIDMismatch:
+		return true
+	}
+	return false
+}
+
+// Acortar token para logs sin exponerlo completo
+func abreviarToken(token string) string {
+	if len(token) <= 20 {
+		return token
+	}
+	return token[:20] + "..."
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestClasificarErrorFCM(t *testing.T) {
+	casos := []struct {
+		nombre string
+		err    error
+		codigo string
+	}{
+		{"sin error", nil, ""},
+		{"token no registrado", &ErrorFCM{Codigo: CodigoFCMUnregistered}, CodigoFCMUnregistered},
+		{"envuelto", fmt.Errorf("lote: %w", &ErrorFCM{Codigo: CodigoFCMSenderIDMismatch}), CodigoFCMSenderIDMismatch},
+		{"argumento inválido", &ErrorFCM{Codigo: CodigoFCMInvalidArgument}, CodigoFCMInvalidArgument},
+		{"error cualquiera", errors.New("timeout"), CodigoFCMDesconocido},
+	}
+	for _, c := range casos {
+		if got := ClasificarErrorFCM(c.err); got != c.codigo {
+			t.Errorf("%s: ClasificarErrorFCM = %q, se esperaba %q", c.nombre, got, c.codigo)
+		}
+	}
+}
+
+func TestEsTokenInvalido(t *testing.T) {
+	invalidos := map[string]bool{
+		CodigoFCMUnregistered:     true,
+		CodigoFCMSenderIDMismatch: true,
+		CodigoFCMInvalidArgument:  false, // también lo causa un payload mal formado
+		CodigoFCMQuotaExceeded:    false,
+		CodigoFCMUnavailable:      false,
+		CodigoFCMInternal:         false,
+		CodigoFCMDesconocido:      false,
+	}
+	for codigo, esperado := range invalidos {
+		if got := EsTokenInvalido(codigo); got != esperado {
+			t.Errorf("EsTokenInvalido(%q) = %v, se esperaba %v", codigo, got, esperado)
+		}
+	}
+}
+
+func TestEnviarNotificacionMultipleClasificaTokens(t *testing.T) {
+	fake := NewFakeMessagingClient()
+	fake.MarcarTokenInvalido("muerto")
+	fake.ErroresPorToken["payload"] = &ErrorFCM{Codigo: CodigoFCMInvalidArgument, Mensaje: "data demasiado grande"}
+	fake.ErroresPorToken["saturado"] = &ErrorFCM{Codigo: CodigoFCMUnavailable, Mensaje: "reintentar"}
+	servicio := NewFirebaseServiceWithClient(fake)
+
+	resultado, err := servicio.EnviarNotificacionMultiple([]string{"vivo", "muerto", "payload", "saturado"}, "Hola", "Mensaje", nil)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if resultado.Exitosos != 1 || resultado.Fallidos != 3 {
+		t.Fatalf("exitosos=%d fallidos=%d, se esperaba 1 y 3", resultado.Exitosos, resultado.Fallidos)
+	}
+
+	invalidos := resultado.TokensInvalidos()
+	if len(invalidos) != 1 || invalidos[0] != "muerto" {
+		t.Fatalf("TokensInvalidos = %v, se esperaba [muerto]", invalidos)
+	}
+
+	m := servicio.Metricas()
+	if m.Enviados != 4 || m.Exitosos != 1 || m.Fallidos != 3 || m.TokensInvalidos != 1 {
+		t.Fatalf("métricas inesperadas: %+v", m)
+	}
+	if math.Abs(m.TasaExito-25) > 0.001 {
+		t.Fatalf("TasaExito = %.2f, se esperaba 25", m.TasaExito)
+	}
+}
+
+func TestEnviarNotificacionMultipleDivideEnLotes(t *testing.T) {
+	fake := NewFakeMessagingClient()
+	servicio := NewFirebaseServiceWithClient(fake)
+
+	tokens := make([]string, maxTokensPorMulticast+1)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("token-%d", i)
+	}
+
+	resultado, err := servicio.EnviarNotificacionMultiple(tokens, "Hola", "Mensaje", nil)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if len(fake.Multicasts) != 2 {
+		t.Fatalf("se enviaron %d lotes, se esperaban 2", len(fake.Multicasts))
+	}
+	if resultado.Exitosos != len(tokens) {
+		t.Fatalf("exitosos=%d, se esperaba %d", resultado.Exitosos, len(tokens))
+	}
+	if got := servicio.Metricas().Enviados; got != int64(len(tokens)) {
+		t.Fatalf("Enviados = %d, se esperaba %d", got, len(tokens))
+	}
+}
+
+func TestEnviarNotificacionCuentaTokenInvalido(t *testing.T) {
+	fake := NewFakeMessagingClient()
+	fake.MarcarTokenInvalido("muerto")
+	servicio := NewFirebaseServiceWithClient(fake)
+
+	if err := servicio.EnviarNotificacion("vivo", "Hola", "Mensaje", nil); err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if err := servicio.EnviarNotificacion("muerto", "Hola", "Mensaje", nil); err == nil {
+		t.Fatal("se esperaba error al enviar a un token no registrado")
+	}
+
+	m := servicio.Metricas()
+	if m.Enviados != 2 || m.Exitosos != 1 || m.Fallidos != 1 || m.TokensInvalidos != 1 {
+		t.Fatalf("métricas inesperadas: %+v", m)
+	}
+}
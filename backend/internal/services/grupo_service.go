@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Errores de los grupos de trabajo
+var (
+	ErrGrupoNoEncontrado = errors.New("grupo no encontrado")
+	ErrGrupoInvalido     = errors.New("datos del grupo inválidos")
+	ErrGrupoLleno        = errors.New("el grupo alcanzó su máximo de integrantes")
+	ErrYaEnGrupo         = errors.New("el estudiante ya pertenece a un grupo en este curso")
+	ErrGrupoConEntregas  = errors.New("el grupo ya entregó tareas: sus integrantes no se pueden cambiar")
+	ErrSinGrupo          = errors.New("esta tarea es grupal: únete a un grupo del curso antes de entregar")
+)
+
+// esGrupoLleno detecta el rechazo del trigger de cupo del grupo
+func esGrupoLleno(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "grupo_lleno")
+}
+
+// esIntegranteDuplicado detecta la restricción de un grupo por estudiante y curso
+func esIntegranteDuplicado(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "23505") || strings.Contains(err.Error(), "duplicate key"))
+}
+
+type GrupoService struct {
+	grupoRepo     *repository.GrupoRepository
+	cursoRepo     repository.CursoRepository
+	matriculaRepo repository.MatriculaRepository
+}
+
+func NewGrupoService(
+	grupoRepo *repository.GrupoRepository,
+	cursoRepo repository.CursoRepository,
+	matriculaRepo repository.MatriculaRepository,
+) *GrupoService {
+	return &GrupoService{
+		grupoRepo:     grupoRepo,
+		cursoRepo:     cursoRepo,
+		matriculaRepo: matriculaRepo,
+	}
+}
+
+// ==================== CONSULTA ====================
+
+// ListarGrupos devuelve los grupos del curso al docente o a un estudiante
+// matriculado, que los necesita para elegir dónde inscribirse
+func (s *GrupoService) ListarGrupos(ctx context.Context, cursoID uuid.UUID, usuarioID, rol string) ([]models.Grupo, error) {
+	if err := s.validarAccesoCurso(cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	grupos, err := s.grupoRepo.GetByCurso(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range grupos {
+		completarGrupo(&grupos[i])
+	}
+	return grupos, nil
+}
+
+// MiGrupo devuelve el grupo del estudiante en el curso
+func (s *GrupoService) MiGrupo(ctx context.Context, cursoID uuid.UUID, estudianteID string) (*models.Grupo, error) {
+	estudianteUUID, err := uuid.Parse(estudianteID)
+	if err != nil {
+		return nil, ErrSinPermiso
+	}
+
+	grupo, err := s.grupoRepo.GetDeEstudiante(ctx, cursoID, estudianteUUID)
+	if err != nil {
+		return nil, err
+	}
+	if grupo == nil {
+		return nil, fmt.Errorf("%w: no perteneces a ningún grupo de este curso", ErrGrupoNoEncontrado)
+	}
+	completarGrupo(grupo)
+	return grupo, nil
+}
+
+// ==================== GESTIÓN (DOCENTE) ====================
+
+// CrearGrupo crea el grupo y, si se indican, inscribe a sus primeros integrantes
+func (s *GrupoService) CrearGrupo(ctx context.Context, cursoID uuid.UUID, usuarioID, rol string, req *models.GrupoRequest) (*models.Grupo, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if err := validarGrupo(req); err != nil {
+		return nil, err
+	}
+	if req.MaxIntegrantes != nil && len(req.EstudianteIDs) > *req.MaxIntegrantes {
+		return nil, ErrGrupoLleno
+	}
+	for _, estudianteID := range req.EstudianteIDs {
+		if err := verificarMatricula(s.matriculaRepo, cursoID.String(), estudianteID.String()); err != nil {
+			return nil, fmt.Errorf("%w: el estudiante %s no está matriculado en el curso", ErrGrupoInvalido, estudianteID)
+		}
+	}
+
+	data := map[string]interface{}{
+		"curso_id":        cursoID,
+		"nombre":          strings.TrimSpace(req.Nombre),
+		"max_integrantes": req.MaxIntegrantes,
+		"autoinscripcion": req.Autoinscripcion,
+	}
+	if creador, err := uuid.Parse(usuarioID); err == nil {
+		data["creado_por"] = creador
+	}
+
+	grupo, err := s.grupoRepo.Create(ctx, data)
+	if err != nil {
+		if esIntegranteDuplicado(err) {
+			return nil, fmt.Errorf("%w: ya existe un grupo con ese nombre", ErrGrupoInvalido)
+		}
+		return nil, err
+	}
+
+	for _, estudianteID := range req.EstudianteIDs {
+		if err := s.inscribir(ctx, grupo, estudianteID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.obtenerGrupo(ctx, grupo.ID)
+}
+
+// ActualizarGrupo cambia nombre, cupo y autoinscripción. El cupo no puede
+// quedar por debajo de los integrantes actuales.
+func (s *GrupoService) ActualizarGrupo(ctx context.Context, grupoID uuid.UUID, usuarioID, rol string, req *models.GrupoRequest) (*models.Grupo, error) {
+	grupo, err := s.grupoDelDocente(ctx, grupoID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+	if err := validarGrupo(req); err != nil {
+		return nil, err
+	}
+	if req.MaxIntegrantes != nil && *req.MaxIntegrantes < len(grupo.Integrantes) {
+		return nil, fmt.Errorf("%w: el grupo ya tiene %d integrantes", ErrGrupoInvalido, len(grupo.Integrantes))
+	}
+
+	data := map[string]interface{}{
+		"nombre":          strings.TrimSpace(req.Nombre),
+		"max_integrantes": req.MaxIntegrantes,
+		"autoinscripcion": req.Autoinscripcion,
+	}
+	if err := s.grupoRepo.Update(ctx, grupoID, data); err != nil {
+		if esIntegranteDuplicado(err) {
+			return nil, fmt.Errorf("%w: ya existe un grupo con ese nombre", ErrGrupoInvalido)
+		}
+		return nil, err
+	}
+
+	return s.obtenerGrupo(ctx, grupoID)
+}
+
+// EliminarGrupo borra un grupo que todavía no entregó nada
+func (s *GrupoService) EliminarGrupo(ctx context.Context, grupoID uuid.UUID, usuarioID, rol string) error {
+	if _, err := s.grupoDelDocente(ctx, grupoID, usuarioID, rol); err != nil {
+		return err
+	}
+	if err := s.verificarSinEntregas(ctx, grupoID); err != nil {
+		return err
+	}
+	return s.grupoRepo.Delete(ctx, grupoID)
+}
+
+// AgregarIntegrante inscribe a un estudiante matriculado en el grupo
+func (s *GrupoService) AgregarIntegrante(ctx context.Context, grupoID, estudianteID uuid.UUID, usuarioID, rol string) (*models.Grupo, error) {
+	grupo, err := s.grupoDelDocente(ctx, grupoID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+	if err := verificarMatricula(s.matriculaRepo, grupo.CursoID.String(), estudianteID.String()); err != nil {
+		return nil, fmt.Errorf("%w: el estudiante no está matriculado en el curso", ErrGrupoInvalido)
+	}
+	if err := s.verificarSinEntregas(ctx, grupoID); err != nil {
+		return nil, err
+	}
+	if err := s.inscribir(ctx, grupo, estudianteID); err != nil {
+		return nil, err
+	}
+	return s.obtenerGrupo(ctx, grupoID)
+}
+
+// QuitarIntegrante saca a un estudiante del grupo
+func (s *GrupoService) QuitarIntegrante(ctx context.Context, grupoID, estudianteID uuid.UUID, usuarioID, rol string) (*models.Grupo, error) {
+	if _, err := s.grupoDelDocente(ctx, grupoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if err := s.verificarSinEntregas(ctx, grupoID); err != nil {
+		return nil, err
+	}
+	if err := s.grupoRepo.QuitarIntegrante(ctx, grupoID, estudianteID); err != nil {
+		return nil, err
+	}
+	return s.obtenerGrupo(ctx, grupoID)
+}
+
+// ==================== AUTOINSCRIPCIÓN (ESTUDIANTE) ====================
+
+// Unirse inscribe al estudiante en un grupo abierto a la autoinscripción
+func (s *GrupoService) Unirse(ctx context.Context, grupoID uuid.UUID, estudianteID string) (*models.Grupo, error) {
+	grupo, err := s.grupoAutoinscribible(ctx, grupoID, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+	estudianteUUID, _ := uuid.Parse(estudianteID)
+	if err := s.inscribir(ctx, grupo, estudianteUUID); err != nil {
+		return nil, err
+	}
+	return s.obtenerGrupo(ctx, grupoID)
+}
+
+// Salir retira al estudiante de un grupo de autoinscripción
+func (s *GrupoService) Salir(ctx context.Context, grupoID uuid.UUID, estudianteID string) error {
+	grupo, err := s.grupoAutoinscribible(ctx, grupoID, estudianteID)
+	if err != nil {
+		return err
+	}
+	estudianteUUID, _ := uuid.Parse(estudianteID)
+	if !esIntegrante(grupo, estudianteUUID) {
+		return fmt.Errorf("%w: no perteneces a este grupo", ErrGrupoNoEncontrado)
+	}
+	return s.grupoRepo.QuitarIntegrante(ctx, grupoID, estudianteUUID)
+}
+
+// ==================== AUXILIARES ====================
+
+// grupoAutoinscribible valida que el estudiante pueda unirse o salir del grupo
+func (s *GrupoService) grupoAutoinscribible(ctx context.Context, grupoID uuid.UUID, estudianteID string) (*models.Grupo, error) {
+	if _, err := uuid.Parse(estudianteID); err != nil {
+		return nil, ErrSinPermiso
+	}
+	grupo, err := s.obtenerGrupo(ctx, grupoID)
+	if err != nil {
+		return nil, err
+	}
+	if !grupo.Autoinscripcion {
+		return nil, fmt.Errorf("%w: el docente asigna los integrantes de este grupo", ErrSinPermiso)
+	}
+	if err := verificarMatricula(s.matriculaRepo, grupo.CursoID.String(), estudianteID); err != nil {
+		return nil, err
+	}
+	if err := s.verificarSinEntregas(ctx, grupoID); err != nil {
+		return nil, err
+	}
+	return grupo, nil
+}
+
+// grupoDelDocente busca el grupo y exige ser docente de su curso o administrador
+func (s *GrupoService) grupoDelDocente(ctx context.Context, grupoID uuid.UUID, usuarioID, rol string) (*models.Grupo, error) {
+	grupo, err := s.obtenerGrupo(ctx, grupoID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, grupo.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return grupo, nil
+}
+
+func (s *GrupoService) obtenerGrupo(ctx context.Context, grupoID uuid.UUID) (*models.Grupo, error) {
+	grupo, err := s.grupoRepo.GetByID(ctx, grupoID)
+	if err != nil {
+		return nil, err
+	}
+	if grupo == nil {
+		return nil, ErrGrupoNoEncontrado
+	}
+	completarGrupo(grupo)
+	return grupo, nil
+}
+
+// inscribir agrega al integrante traduciendo los rechazos de la BD
+func (s *GrupoService) inscribir(ctx context.Context, grupo *models.Grupo, estudianteID uuid.UUID) error {
+	err := s.grupoRepo.AgregarIntegrante(ctx, grupo.ID, grupo.CursoID, estudianteID)
+	if esGrupoLleno(err) {
+		return ErrGrupoLleno
+	}
+	if esIntegranteDuplicado(err) {
+		return ErrYaEnGrupo
+	}
+	return err
+}
+
+func (s *GrupoService) verificarSinEntregas(ctx context.Context, grupoID uuid.UUID) error {
+	tiene, err := s.grupoRepo.TieneEntregas(ctx, grupoID)
+	if err != nil {
+		return err
+	}
+	if tiene {
+		return ErrGrupoConEntregas
+	}
+	return nil
+}
+
+// validarAccesoCurso permite al docente del curso, al administrador y a los
+// estudiantes matriculados
+func (s *GrupoService) validarAccesoCurso(cursoID uuid.UUID, usuarioID, rol string) error {
+	if rol == "estudiante" {
+		return verificarMatricula(s.matriculaRepo, cursoID.String(), usuarioID)
+	}
+	_, err := validarDocenteOAdmin(s.cursoRepo, cursoID.String(), usuarioID, rol)
+	return err
+}
+
+func validarGrupo(req *models.GrupoRequest) error {
+	if strings.TrimSpace(req.Nombre) == "" {
+		return fmt.Errorf("%w: el nombre es obligatorio", ErrGrupoInvalido)
+	}
+	if req.MaxIntegrantes != nil && *req.MaxIntegrantes <= 0 {
+		return fmt.Errorf("%w: el máximo de integrantes debe ser mayor a cero", ErrGrupoInvalido)
+	}
+	return nil
+}
+
+// completarGrupo marca si el grupo ya no admite integrantes
+func completarGrupo(grupo *models.Grupo) {
+	if grupo.Integrantes == nil {
+		grupo.Integrantes = []models.IntegranteGrupo{}
+	}
+	grupo.Lleno = grupo.MaxIntegrantes != nil && len(grupo.Integrantes) >= *grupo.MaxIntegrantes
+}
+
+func esIntegrante(grupo *models.Grupo, estudianteID uuid.UUID) bool {
+	for _, integrante := range grupo.Integrantes {
+		if integrante.EstudianteID == estudianteID {
+			return true
+		}
+	}
+	return false
+}
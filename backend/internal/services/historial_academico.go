@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrEstudianteNoEncontrado = errors.New("estudiante no encontrado")
+
+// HistorialAcademicoService arma el historial de notas de un estudiante
+type HistorialAcademicoService struct {
+	matriculaRepo repository.MatriculaRepository
+	usuarioRepo   repository.UsuarioRepository
+}
+
+func NewHistorialAcademicoService(
+	matriculaRepo repository.MatriculaRepository,
+	usuarioRepo repository.UsuarioRepository,
+) *HistorialAcademicoService {
+	return &HistorialAcademicoService{
+		matriculaRepo: matriculaRepo,
+		usuarioRepo:   usuarioRepo,
+	}
+}
+
+// ObtenerHistorial devuelve el historial del estudiante. El administrador
+// puede consultar a cualquiera; el estudiante solo el suyo.
+func (s *HistorialAcademicoService) ObtenerHistorial(estudianteID, usuarioID, rol string) (*models.HistorialAcademico, error) {
+	if rol != "administrador" && !(rol == "estudiante" && estudianteID == usuarioID) {
+		return nil, ErrSinPermiso
+	}
+
+	respBody, err := s.usuarioRepo.GetEstudianteByUserID(estudianteID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener estudiante: %w", err)
+	}
+	var estudiantes []models.EstudianteDetalle
+	if err := json.Unmarshal(respBody, &estudiantes); err != nil || len(estudiantes) == 0 {
+		return nil, ErrEstudianteNoEncontrado
+	}
+
+	respBody, err = s.matriculaRepo.GetHistorialEstudiante(estudianteID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener matrículas: %w", err)
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil, fmt.Errorf("error al parsear matrículas")
+	}
+
+	historial := CalcularHistorial(matriculas)
+	historial.EstudianteID = estudianteID
+	historial.Codigo = estudiantes[0].CodigoEstudiante
+	if estudiantes[0].Usuario != nil {
+		historial.Nombre = estudiantes[0].Usuario.NombreCompleto
+	}
+	historial.GeneradoAt = time.Now()
+
+	return historial, nil
+}
+
+// CalcularHistorial agrupa las matrículas por ciclo y calcula los promedios
+// ponderados por créditos. Es puro: no accede a la base de datos.
+func CalcularHistorial(matriculas []models.Matricula) *models.HistorialAcademico {
+	historial := &models.HistorialAcademico{Ciclos: []models.CicloHistorial{}}
+
+	porCiclo := make(map[string]*models.CicloHistorial)
+	orden := make([]string, 0)
+	for _, m := range matriculas {
+		ciclo, ok := porCiclo[m.CicloID]
+		if !ok {
+			ciclo = &models.CicloHistorial{CicloID: m.CicloID, Cursos: []models.CursoHistorial{}}
+			if m.Ciclo != nil {
+				ciclo.Nombre = m.Ciclo.Nombre
+				ciclo.FechaInicio = m.Ciclo.FechaInicio
+				ciclo.FechaFin = m.Ciclo.FechaFin
+			}
+			porCiclo[m.CicloID] = ciclo
+			orden = append(orden, m.CicloID)
+		}
+
+		curso := models.CursoHistorial{
+			MatriculaID: m.ID,
+			CursoID:     m.CursoID,
+			NotaFinal:   m.NotaFinal,
+			Estado:      m.Estado,
+		}
+		if m.Curso != nil {
+			curso.Codigo = m.Curso.Codigo
+			curso.Nombre = m.Curso.Nombre
+			curso.Creditos = m.Curso.Creditos
+		}
+		ciclo.Cursos = append(ciclo.Cursos, curso)
+	}
+
+	var sumaTotal float64
+	for _, id := range orden {
+		ciclo := porCiclo[id]
+		sort.SliceStable(ciclo.Cursos, func(i, j int) bool { return ciclo.Cursos[i].Nombre < ciclo.Cursos[j].Nombre })
+
+		var suma float64
+		for _, c := range ciclo.Cursos {
+			if c.Estado == "retirado" {
+				continue
+			}
+			ciclo.CreditosMatriculados += c.Creditos
+			if c.Estado == models.EstadoMatriculaAprobado {
+				ciclo.CreditosAprobados += c.Creditos
+			}
+			if c.NotaFinal != nil && c.Creditos > 0 {
+				suma += *c.NotaFinal * float64(c.Creditos)
+				ciclo.CreditosComputados += c.Creditos
+			}
+		}
+		if ciclo.CreditosComputados > 0 {
+			ciclo.PromedioPonderado = redondear2(suma / float64(ciclo.CreditosComputados))
+		}
+
+		sumaTotal += suma
+		historial.CreditosMatriculados += ciclo.CreditosMatriculados
+		historial.CreditosAprobados += ciclo.CreditosAprobados
+		historial.CreditosComputados += ciclo.CreditosComputados
+		historial.Ciclos = append(historial.Ciclos, *ciclo)
+	}
+	if historial.CreditosComputados > 0 {
+		historial.PromedioPonderado = redondear2(sumaTotal / float64(historial.CreditosComputados))
+	}
+
+	// Del ciclo más antiguo al más reciente
+	sort.SliceStable(historial.Ciclos, func(i, j int) bool {
+		return historial.Ciclos[i].FechaInicio < historial.Ciclos[j].FechaInicio
+	})
+
+	return historial
+}
+
+// ==================== EXPORTAR ====================
+
+func textoNota(nota *float64) string {
+	if nota == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *nota)
+}
+
+// ExportarHistorialExcel genera el historial en una hoja con un subtotal por ciclo
+func ExportarHistorialExcel(h *models.HistorialAcademico) (*bytes.Buffer, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Historial"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear hoja: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	tituloStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#2E7D32"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	subtotalStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E8F5E9"}, Pattern: 1},
+	})
+	totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 12}})
+
+	f.SetCellValue(sheetName, "A1", "Historial académico")
+	f.SetCellStyle(sheetName, "A1", "A1", tituloStyle)
+	f.SetCellValue(sheetName, "A2", "Estudiante:")
+	f.SetCellValue(sheetName, "B2", h.Nombre)
+	f.SetCellValue(sheetName, "A3", "Código:")
+	f.SetCellValue(sheetName, "B3", h.Codigo)
+	f.SetCellValue(sheetName, "A4", "Generado:")
+	f.SetCellValue(sheetName, "B4", h.GeneradoAt.In(config.AppConfig.Ubicacion()).Format("02/01/2006 15:04"))
+
+	headers := []string{"Ciclo", "Código", "Curso", "Créditos", "Nota final", "Estado"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 6)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	f.SetCellStyle(sheetName, "A6", "F6", headerStyle)
+
+	fila := 7
+	for _, ciclo := range h.Ciclos {
+		for _, c := range ciclo.Cursos {
+			valores := []interface{}{ciclo.Nombre, c.Codigo, c.Nombre, c.Creditos, "-", c.Estado}
+			if c.NotaFinal != nil {
+				valores[4] = *c.NotaFinal
+			}
+			inicio, _ := excelize.CoordinatesToCellName(1, fila)
+			f.SetSheetRow(sheetName, inicio, &valores)
+			fila++
+		}
+
+		subtotal := []interface{}{
+			ciclo.Nombre, "", fmt.Sprintf("Promedio ponderado del ciclo (aprobados %d de %d créditos)", ciclo.CreditosAprobados, ciclo.CreditosMatriculados),
+			ciclo.CreditosComputados, textoNota(ciclo.PromedioPonderado), "",
+		}
+		if ciclo.PromedioPonderado != nil {
+			subtotal[4] = *ciclo.PromedioPonderado
+		}
+		inicio, _ := excelize.CoordinatesToCellName(1, fila)
+		f.SetSheetRow(sheetName, inicio, &subtotal)
+		fin, _ := excelize.CoordinatesToCellName(len(headers), fila)
+		f.SetCellStyle(sheetName, inicio, fin, subtotalStyle)
+		fila += 2
+	}
+
+	total := []interface{}{
+		"Acumulado", "", fmt.Sprintf("Promedio ponderado acumulado (aprobados %d de %d créditos)", h.CreditosAprobados, h.CreditosMatriculados),
+		h.CreditosComputados, textoNota(h.PromedioPonderado), "",
+	}
+	if h.PromedioPonderado != nil {
+		total[4] = *h.PromedioPonderado
+	}
+	inicio, _ := excelize.CoordinatesToCellName(1, fila)
+	f.SetSheetRow(sheetName, inicio, &total)
+	fin, _ := excelize.CoordinatesToCellName(len(headers), fila)
+	f.SetCellStyle(sheetName, inicio, fin, totalStyle)
+
+	f.SetColWidth(sheetName, "A", "A", 14)
+	f.SetColWidth(sheetName, "B", "B", 12)
+	f.SetColWidth(sheetName, "C", "C", 60)
+	f.SetColWidth(sheetName, "D", "E", 12)
+	f.SetColWidth(sheetName, "F", "F", 14)
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error al generar archivo: %w", err)
+	}
+	return buffer, nil
+}
+
+// Columnas de la tabla del PDF
+const (
+	colCodigoPDF   = 50.0
+	colCursoPDF    = 115.0
+	colCreditosPDF = 390.0
+	colNotaPDF     = 445.0
+	colEstadoPDF   = 495.0
+	margenInferior = 70.0
+)
+
+// ExportarHistorialPDF genera el historial en PDF con una tabla por ciclo
+func ExportarHistorialPDF(h *models.HistorialAcademico) *bytes.Buffer {
+	d := nuevoDocumentoPDF()
+	d.pie = "Historial académico de " + h.Nombre
+
+	y := altoPaginaPDF - 60
+	d.Texto(colCodigoPDF, y, 16, true, "Historial académico")
+	y -= 24
+	d.Texto(colCodigoPDF, y, 10, false, "Estudiante: "+h.Nombre)
+	y -= 14
+	d.Texto(colCodigoPDF, y, 10, false, "Código: "+h.Codigo)
+	y -= 14
+	d.Texto(colCodigoPDF, y, 10, false, "Generado: "+h.GeneradoAt.In(config.AppConfig.Ubicacion()).Format("02/01/2006 15:04"))
+	y -= 28
+
+	encabezado := func() {
+		d.Color(0.180, 0.490, 0.196) // #2E7D32
+		d.Rectangulo(colCodigoPDF-4, y-5, anchoPaginaPDF-2*(colCodigoPDF-4), 17)
+		d.Color(1, 1, 1)
+		d.Texto(colCodigoPDF, y, 9, true, "Código")
+		d.Texto(colCursoPDF, y, 9, true, "Curso")
+		d.Texto(colCreditosPDF, y, 9, true, "Créditos")
+		d.Texto(colNotaPDF, y, 9, true, "Nota")
+		d.Texto(colEstadoPDF, y, 9, true, "Estado")
+		d.Color(0, 0, 0)
+		y -= 18
+	}
+	saltoSiFalta := func(alto float64, conEncabezado bool) {
+		if y-alto >= margenInferior {
+			return
+		}
+		d.NuevaPagina()
+		y = altoPaginaPDF - 60
+		if conEncabezado {
+			encabezado()
+		}
+	}
+
+	if len(h.Ciclos) == 0 {
+		d.Texto(colCodigoPDF, y, 10, false, "El estudiante no registra matrículas.")
+		y -= 20
+	}
+
+	for _, ciclo := range h.Ciclos {
+		saltoSiFalta(60, false)
+		d.Texto(colCodigoPDF, y, 12, true, "Ciclo "+ciclo.Nombre)
+		y -= 20
+		encabezado()
+
+		for _, c := range ciclo.Cursos {
+			saltoSiFalta(14, true)
+			d.Texto(colCodigoPDF, y, 9, false, c.Codigo)
+			d.Texto(colCursoPDF, y, 9, false, recortarTexto(c.Nombre, 52))
+			d.Texto(colCreditosPDF, y, 9, false, fmt.Sprintf("%d", c.Creditos))
+			d.Texto(colNotaPDF, y, 9, false, textoNota(c.NotaFinal))
+			d.Texto(colEstadoPDF, y, 9, false, c.Estado)
+			y -= 14
+		}
+
+		d.Linea(colCodigoPDF-4, y+9, anchoPaginaPDF-colCodigoPDF+4, y+9)
+		y -= 4
+		d.Texto(colCursoPDF, y, 9, true, fmt.Sprintf("Promedio ponderado: %s    Créditos aprobados: %d de %d",
+			textoNota(ciclo.PromedioPonderado), ciclo.CreditosAprobados, ciclo.CreditosMatriculados))
+		y -= 28
+	}
+
+	saltoSiFalta(40, false)
+	d.Texto(colCodigoPDF, y, 11, true, fmt.Sprintf("Promedio ponderado acumulado: %s", textoNota(h.PromedioPonderado)))
+	y -= 16
+	d.Texto(colCodigoPDF, y, 10, false, fmt.Sprintf("Créditos aprobados: %d de %d matriculados (%d con nota)",
+		h.CreditosAprobados, h.CreditosMatriculados, h.CreditosComputados))
+
+	return bytes.NewBuffer(d.Bytes())
+}
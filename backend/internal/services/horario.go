@@ -0,0 +1,427 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/models"
+)
+
+// Nombres de los días en el orden de SesionHorario.DiaSemana (1 = lunes)
+var nombresDias = []string{"", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado", "Domingo"}
+
+// Nombres y abreviaturas aceptados en los horarios antiguos (sin tildes)
+var diasPorNombre = map[string]int{
+	"lunes": 1, "lun": 1,
+	"martes": 2, "mar": 2,
+	"miercoles": 3, "mie": 3, "mier": 3,
+	"jueves": 4, "jue": 4, "juev": 4,
+	"viernes": 5, "vie": 5, "vier": 5,
+	"sabado": 6, "sab": 6,
+	"domingo": 7, "dom": 7,
+}
+
+// Nombre con el que se guarda cada tipo de ambiente
+var tiposAula = map[string]string{
+	"aula":        "Aula",
+	"salon":       "Salón",
+	"sala":        "Sala",
+	"lab":         "Laboratorio",
+	"laboratorio": "Laboratorio",
+	"taller":      "Taller",
+	"ambiente":    "Ambiente",
+}
+
+var (
+	patronDia       = `(lunes|lun|martes|mar|miercoles|mier|mie|jueves|juev|jue|viernes|vier|vie|sabado|sab|domingo|dom)\.?`
+	regexRangoDias  = regexp.MustCompile(`\b` + patronDia + `\s*(?:a|al|-)\s*` + patronDia + `(?:\W|$)`)
+	regexDia        = regexp.MustCompile(`\b` + patronDia + `(?:\W|$)`)
+	regexRangoHoras = regexp.MustCompile(`\b(\d{1,2})(?:[:.h](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?\s*(?:-|–|a|hasta)\s*(\d{1,2})(?:[:.h](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?`)
+	regexAula       = regexp.MustCompile(`\b(aula|salon|sala|laboratorio|lab|taller|ambiente)\.?\s*(?:n[°ºo]\.?\s*)?[:#]?\s*([a-z0-9][a-z0-9-]*)`)
+)
+
+// ConflictoHorario describe un cruce entre dos sesiones
+type ConflictoHorario struct {
+	Tipo        string               `json:"tipo"` // docente, aula o estudiante
+	CursoID     string               `json:"curso_id"`
+	CursoNombre string               `json:"curso_nombre"`
+	Sesion      models.SesionHorario `json:"sesion"`
+	CruceCon    models.SesionHorario `json:"cruce_con"`
+}
+
+// ConflictoHorarioError se devuelve cuando un horario se cruza con otro
+type ConflictoHorarioError struct {
+	Conflictos []ConflictoHorario
+}
+
+func (e *ConflictoHorarioError) Error() string {
+	if len(e.Conflictos) == 0 {
+		return "conflicto de horario"
+	}
+
+	c := e.Conflictos[0]
+	var motivo string
+	switch c.Tipo {
+	case "docente":
+		motivo = "el docente ya dicta"
+	case "aula":
+		motivo = fmt.Sprintf("el ambiente %s ya está ocupado por", c.CruceCon.Aula)
+	default:
+		motivo = "el estudiante ya está matriculado en"
+	}
+
+	msg := fmt.Sprintf("conflicto de horario: %s %s el %s de %s a %s",
+		motivo, c.CursoNombre, nombresDias[c.CruceCon.DiaSemana], c.CruceCon.HoraInicio, c.CruceCon.HoraFin)
+	if len(e.Conflictos) > 1 {
+		msg += fmt.Sprintf(" (y %d cruces más)", len(e.Conflictos)-1)
+	}
+	return msg
+}
+
+// ==================== PARSEO DE HORARIOS ANTIGUOS ====================
+
+type tokenHorario struct {
+	inicio, fin int
+	tipo        string // dias, horas o aula
+	dias        []int
+	sesion      models.SesionHorario
+}
+
+// ParsearHorarioLegacy interpreta el texto libre de Curso.Horario, por ejemplo
+// "Lun y Mié 8:00-10:00 Aula 201" o "Lunes a viernes 2-4pm (Lab 3)"
+func ParsearHorarioLegacy(horario string) ([]models.SesionHorario, error) {
+	texto := normalizarTexto(horario)
+	if strings.TrimSpace(texto) == "" {
+		return nil, fmt.Errorf("horario vacío")
+	}
+
+	tokens := make([]tokenHorario, 0)
+	ocupado := make([]bool, len(texto)+1)
+	marcar := func(inicio, fin int) bool {
+		for i := inicio; i < fin; i++ {
+			if ocupado[i] {
+				return false
+			}
+		}
+		for i := inicio; i < fin; i++ {
+			ocupado[i] = true
+		}
+		return true
+	}
+
+	// Primero los ambientes, para que "Aula 201" no se lea como hora
+	for _, m := range regexAula.FindAllStringSubmatchIndex(texto, -1) {
+		if !marcar(m[0], m[1]) {
+			continue
+		}
+		aula := tiposAula[texto[m[2]:m[3]]] + " " + strings.ToUpper(texto[m[4]:m[5]])
+		tokens = append(tokens, tokenHorario{inicio: m[0], fin: m[1], tipo: "aula", sesion: models.SesionHorario{Aula: aula}})
+	}
+
+	for _, m := range regexRangoHoras.FindAllStringSubmatchIndex(texto, -1) {
+		if !marcar(m[0], m[1]) {
+			continue
+		}
+		inicio, fin, err := parsearRangoHoras(texto, m)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tokenHorario{
+			inicio: m[0], fin: m[1], tipo: "horas",
+			sesion: models.SesionHorario{HoraInicio: inicio, HoraFin: fin},
+		})
+	}
+
+	for _, m := range regexRangoDias.FindAllStringSubmatchIndex(texto, -1) {
+		if !marcar(m[0], m[1]) {
+			continue
+		}
+		desde := diasPorNombre[texto[m[2]:m[3]]]
+		hasta := diasPorNombre[texto[m[4]:m[5]]]
+		if desde > hasta {
+			return nil, fmt.Errorf("rango de días inválido: %s", texto[m[0]:m[1]])
+		}
+		dias := make([]int, 0, hasta-desde+1)
+		for d := desde; d <= hasta; d++ {
+			dias = append(dias, d)
+		}
+		tokens = append(tokens, tokenHorario{inicio: m[0], fin: m[1], tipo: "dias", dias: dias})
+	}
+
+	for _, m := range regexDia.FindAllStringSubmatchIndex(texto, -1) {
+		if !marcar(m[0], m[1]) {
+			continue
+		}
+		dia := diasPorNombre[texto[m[2]:m[3]]]
+		tokens = append(tokens, tokenHorario{inicio: m[0], fin: m[1], tipo: "dias", dias: []int{dia}})
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].inicio < tokens[j].inicio })
+
+	// Recorrer en orden: los días se acumulan hasta encontrar un rango de horas
+	// y el ambiente se aplica al grupo de sesiones más cercano
+	var (
+		sesiones      []models.SesionHorario
+		diasPend      []int
+		grupo         []int // índices en sesiones del último rango de horas
+		aulaPendiente string
+		vistoHoras    bool
+	)
+	for _, t := range tokens {
+		switch t.tipo {
+		case "dias":
+			if vistoHoras {
+				diasPend = nil
+				grupo = nil
+				vistoHoras = false
+			}
+			diasPend = append(diasPend, t.dias...)
+
+		case "horas":
+			if len(diasPend) == 0 {
+				return nil, fmt.Errorf("falta el día para el rango %s-%s", t.sesion.HoraInicio, t.sesion.HoraFin)
+			}
+			// "Lunes 8-10 y 14-16" repite los mismos días
+			grupo = nil
+			for _, d := range diasPend {
+				s := t.sesion
+				s.DiaSemana = d
+				s.Aula = aulaPendiente
+				grupo = append(grupo, len(sesiones))
+				sesiones = append(sesiones, s)
+			}
+			aulaPendiente = ""
+			vistoHoras = true
+
+		case "aula":
+			asignada := false
+			for _, i := range grupo {
+				if sesiones[i].Aula == "" {
+					sesiones[i].Aula = t.sesion.Aula
+					asignada = true
+				}
+			}
+			if !asignada {
+				aulaPendiente = t.sesion.Aula
+			}
+		}
+	}
+
+	if len(sesiones) == 0 {
+		return nil, fmt.Errorf("no se reconoce el horario %q", horario)
+	}
+
+	return NormalizarSesiones(sesiones)
+}
+
+// parsearRangoHoras convierte un rango como "2-4pm" u "8:30 am - 10" a "HH:MM"
+func parsearRangoHoras(texto string, m []int) (string, string, error) {
+	grupo := func(i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return texto[m[2*i]:m[2*i+1]]
+	}
+
+	hIni, _ := strconv.Atoi(grupo(1))
+	mIni, _ := strconv.Atoi(grupo(2))
+	hFin, _ := strconv.Atoi(grupo(4))
+	mFin, _ := strconv.Atoi(grupo(5))
+	merIni := strings.ReplaceAll(grupo(3), ".", "")
+	merFin := strings.ReplaceAll(grupo(6), ".", "")
+
+	// "2-4pm": el meridiano final aplica también al inicio si tiene sentido
+	if merIni == "" && merFin == "pm" && hIni < 12 && hIni+12 <= aHora24(hFin, merFin) {
+		merIni = "pm"
+	}
+
+	hIni = aHora24(hIni, merIni)
+	hFin = aHora24(hFin, merFin)
+
+	if hIni > 23 || hFin > 24 || mIni > 59 || mFin > 59 {
+		return "", "", fmt.Errorf("hora inválida en %q", texto[m[0]:m[1]])
+	}
+	if hFin == 24 {
+		hFin, mFin = 23, 59
+	}
+
+	inicio := fmt.Sprintf("%02d:%02d", hIni, mIni)
+	fin := fmt.Sprintf("%02d:%02d", hFin, mFin)
+	if inicio >= fin {
+		return "", "", fmt.Errorf("la hora de inicio debe ser anterior a la de fin en %q", texto[m[0]:m[1]])
+	}
+	return inicio, fin, nil
+}
+
+func aHora24(hora int, meridiano string) int {
+	switch meridiano {
+	case "am":
+		if hora == 12 {
+			return 0
+		}
+	case "pm":
+		if hora < 12 {
+			return hora + 12
+		}
+	}
+	return hora
+}
+
+// normalizarTexto pasa a minúsculas y quita tildes
+func normalizarTexto(s string) string {
+	reemplazos := strings.NewReplacer(
+		"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u",
+		"Á", "a", "É", "e", "Í", "i", "Ó", "o", "Ú", "u", "Ü", "u",
+	)
+	return strings.ToLower(reemplazos.Replace(s))
+}
+
+// ==================== VALIDACIÓN Y FORMATO ====================
+
+// NormalizarSesiones valida las sesiones, unifica el formato de las horas
+// y las ordena por día y hora
+func NormalizarSesiones(sesiones []models.SesionHorario) ([]models.SesionHorario, error) {
+	resultado := make([]models.SesionHorario, 0, len(sesiones))
+	vistas := make(map[models.SesionHorario]bool)
+
+	for _, s := range sesiones {
+		if s.DiaSemana < 1 || s.DiaSemana > 7 {
+			return nil, fmt.Errorf("día de la semana inválido: %d (debe estar entre 1 y 7)", s.DiaSemana)
+		}
+
+		inicio, err := time.Parse("15:04", strings.TrimSpace(s.HoraInicio))
+		if err != nil {
+			return nil, fmt.Errorf("hora de inicio inválida: %s", s.HoraInicio)
+		}
+		fin, err := time.Parse("15:04", strings.TrimSpace(s.HoraFin))
+		if err != nil {
+			return nil, fmt.Errorf("hora de fin inválida: %s", s.HoraFin)
+		}
+		if !inicio.Before(fin) {
+			return nil, fmt.Errorf("la sesión del %s debe terminar después de empezar", nombresDias[s.DiaSemana])
+		}
+
+		s.HoraInicio = inicio.Format("15:04")
+		s.HoraFin = fin.Format("15:04")
+		s.Aula = strings.TrimSpace(s.Aula)
+
+		if vistas[s] {
+			continue
+		}
+		vistas[s] = true
+		resultado = append(resultado, s)
+	}
+
+	sort.Slice(resultado, func(i, j int) bool {
+		if resultado[i].DiaSemana != resultado[j].DiaSemana {
+			return resultado[i].DiaSemana < resultado[j].DiaSemana
+		}
+		return resultado[i].HoraInicio < resultado[j].HoraInicio
+	})
+
+	// Un mismo curso no puede cruzarse consigo mismo
+	for i := 1; i < len(resultado); i++ {
+		if sesionesSeCruzan(resultado[i-1], resultado[i]) {
+			return nil, fmt.Errorf("las sesiones del %s se cruzan entre sí", nombresDias[resultado[i].DiaSemana])
+		}
+	}
+
+	return resultado, nil
+}
+
+// FormatearHorario genera el texto legible que se guarda en Curso.Horario
+func FormatearHorario(sesiones []models.SesionHorario) string {
+	partes := make([]string, 0, len(sesiones))
+	for _, s := range sesiones {
+		if s.DiaSemana < 1 || s.DiaSemana > 7 {
+			continue
+		}
+		parte := fmt.Sprintf("%s %s-%s", nombresDias[s.DiaSemana], s.HoraInicio, s.HoraFin)
+		if s.Aula != "" {
+			parte += " (" + s.Aula + ")"
+		}
+		partes = append(partes, parte)
+	}
+	return strings.Join(partes, ", ")
+}
+
+// resolverHorario decide las sesiones y el texto a guardar: las sesiones
+// estructuradas tienen prioridad y el texto antiguo se interpreta si es posible
+func resolverHorario(horario string, sesiones []models.SesionHorario) ([]models.SesionHorario, string, error) {
+	if len(sesiones) > 0 {
+		normalizadas, err := NormalizarSesiones(sesiones)
+		if err != nil {
+			return nil, "", err
+		}
+		if strings.TrimSpace(horario) == "" {
+			horario = FormatearHorario(normalizadas)
+		}
+		return normalizadas, horario, nil
+	}
+
+	if strings.TrimSpace(horario) == "" {
+		return []models.SesionHorario{}, horario, nil
+	}
+
+	parseadas, err := ParsearHorarioLegacy(horario)
+	if err != nil {
+		// Se conserva el texto libre aunque no tenga estructura
+		return []models.SesionHorario{}, horario, nil
+	}
+	return parseadas, horario, nil
+}
+
+// SesionesDeCurso devuelve las sesiones del curso, interpretando el texto
+// antiguo si todavía no fue migrado
+func SesionesDeCurso(curso *models.Curso) []models.SesionHorario {
+	if len(curso.Sesiones) > 0 {
+		return curso.Sesiones
+	}
+	if curso.Horario == "" {
+		return nil
+	}
+	sesiones, err := ParsearHorarioLegacy(curso.Horario)
+	if err != nil {
+		return nil
+	}
+	return sesiones
+}
+
+// ==================== CONFLICTOS ====================
+
+func sesionesSeCruzan(a, b models.SesionHorario) bool {
+	return a.DiaSemana == b.DiaSemana && a.HoraInicio < b.HoraFin && b.HoraInicio < a.HoraFin
+}
+
+// buscarConflictos compara las sesiones propuestas contra otros cursos activos.
+// tipos decide qué cruces se reportan para cada par de sesiones que se solapan.
+func buscarConflictos(sesiones []models.SesionHorario, otros []models.Curso, excluirID string, tipos func(otro *models.Curso, propia, ajena models.SesionHorario) []string) []ConflictoHorario {
+	conflictos := make([]ConflictoHorario, 0)
+	for i := range otros {
+		otro := &otros[i]
+		if otro.ID == excluirID || !otro.Activo {
+			continue
+		}
+		for _, ajena := range SesionesDeCurso(otro) {
+			for _, propia := range sesiones {
+				if !sesionesSeCruzan(propia, ajena) {
+					continue
+				}
+				for _, tipo := range tipos(otro, propia, ajena) {
+					conflictos = append(conflictos, ConflictoHorario{
+						Tipo:        tipo,
+						CursoID:     otro.ID,
+						CursoNombre: otro.Nombre,
+						Sesion:      propia,
+						CruceCon:    ajena,
+					})
+				}
+			}
+		}
+	}
+	return conflictos
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatos de fecha de iCalendar (RFC 5545)
+const (
+	formatoICSLocal = "20060102T150405"
+	formatoICSUTC   = "20060102T150405Z"
+)
+
+// Días en formato BYDAY, indexados por SesionHorario.DiaSemana
+var diasICS = []string{"", "MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// escritorICS arma un documento iCalendar con líneas plegadas a 75 octetos
+type escritorICS struct {
+	buf bytes.Buffer
+	loc *time.Location
+}
+
+func nuevoEscritorICS(loc *time.Location) *escritorICS {
+	return &escritorICS{loc: loc}
+}
+
+// linea escribe "NOMBRE:valor" plegando según la RFC (CRLF + espacio)
+func (w *escritorICS) linea(nombre, valor string) {
+	contenido := nombre + ":" + valor
+
+	limite := 75
+	for len(contenido) > limite {
+		corte := limite
+		for corte > 0 && !utf8.RuneStart(contenido[corte]) {
+			corte--
+		}
+		w.buf.WriteString(contenido[:corte])
+		w.buf.WriteString("\r\n ")
+		contenido = contenido[corte:]
+		limite = 74 // el espacio inicial cuenta
+	}
+	w.buf.WriteString(contenido)
+	w.buf.WriteString("\r\n")
+}
+
+// texto escribe una propiedad de tipo TEXT escapando caracteres especiales
+func (w *escritorICS) texto(nombre, valor string) {
+	w.linea(nombre, escaparTextoICS(valor))
+}
+
+// fechaLocal escribe una fecha con TZID, o en UTC si la zona es UTC
+func (w *escritorICS) fechaLocal(nombre string, t time.Time) {
+	if w.loc == time.UTC {
+		w.linea(nombre, t.UTC().Format(formatoICSUTC))
+		return
+	}
+	w.linea(nombre+";TZID="+w.loc.String(), t.In(w.loc).Format(formatoICSLocal))
+}
+
+func (w *escritorICS) fechaUTC(nombre string, t time.Time) {
+	w.linea(nombre, t.UTC().Format(formatoICSUTC))
+}
+
+func (w *escritorICS) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func escaparTextoICS(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// zonaHoraria escribe el VTIMEZONE de la ubicación con las transiciones
+// reales (horario de verano) que ocurren entre desde y hasta
+func (w *escritorICS) zonaHoraria(desde, hasta time.Time) {
+	if w.loc == time.UTC {
+		return
+	}
+
+	w.linea("BEGIN", "VTIMEZONE")
+	w.linea("TZID", w.loc.String())
+
+	inicio := desde.In(w.loc)
+	nombre, offset := inicio.Zone()
+	w.componenteZona(inicio.IsDST(), inicio, offset, offset, nombre)
+
+	for _, t := range transicionesZona(w.loc, desde, hasta) {
+		_, antes := t.Add(-time.Second).Zone()
+		nombre, despues := t.Zone()
+		// DTSTART es la hora local previa al cambio
+		w.componenteZona(t.IsDST(), t.In(time.FixedZone("", antes)), antes, despues, nombre)
+	}
+
+	w.linea("END", "VTIMEZONE")
+}
+
+func (w *escritorICS) componenteZona(verano bool, dtstart time.Time, desde, hacia int, nombre string) {
+	tipo := "STANDARD"
+	if verano {
+		tipo = "DAYLIGHT"
+	}
+	w.linea("BEGIN", tipo)
+	w.linea("DTSTART", dtstart.Format(formatoICSLocal))
+	w.linea("TZOFFSETFROM", formatearOffsetICS(desde))
+	w.linea("TZOFFSETTO", formatearOffsetICS(hacia))
+	if nombre != "" {
+		w.linea("TZNAME", nombre)
+	}
+	w.linea("END", tipo)
+}
+
+// transicionesZona devuelve los instantes en que cambia el offset de la zona
+func transicionesZona(loc *time.Location, desde, hasta time.Time) []time.Time {
+	var cambios []time.Time
+
+	t := desde.In(loc)
+	_, offset := t.Zone()
+	for t.Before(hasta) {
+		sig := t.Add(24 * time.Hour)
+		if _, o := sig.Zone(); o != offset {
+			// Búsqueda binaria del segundo exacto del cambio
+			a, b := t, sig
+			for b.Sub(a) > time.Second {
+				medio := a.Add(b.Sub(a) / 2)
+				if _, om := medio.Zone(); om == offset {
+					a = medio
+				} else {
+					b = medio
+				}
+			}
+			cambios = append(cambios, b.Truncate(time.Second))
+			offset = o
+		}
+		t = sig
+	}
+
+	return cambios
+}
+
+func formatearOffsetICS(segundos int) string {
+	signo := "+"
+	if segundos < 0 {
+		signo = "-"
+		segundos = -segundos
+	}
+	return fmt.Sprintf("%s%02d%02d", signo, segundos/3600, (segundos%3600)/60)
+}
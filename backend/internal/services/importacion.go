@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"recetario-backend/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Límite de filas de datos por archivo importado
+const maxFilasImportacion = 5000
+
+// leerPlanilla devuelve las filas de la primera hoja de un .xlsx o de un .csv
+// (separado por coma o punto y coma, con o sin BOM)
+func leerPlanilla(nombreArchivo string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(nombreArchivo)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer el archivo Excel: %w", err)
+		}
+		defer f.Close()
+
+		hojas := f.GetSheetList()
+		if len(hojas) == 0 {
+			return nil, fmt.Errorf("el archivo no tiene hojas")
+		}
+		filas, err := f.GetRows(hojas[0])
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer la hoja '%s': %w", hojas[0], err)
+		}
+		return filas, nil
+
+	case ".csv":
+		contenido, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer el archivo: %w", err)
+		}
+		contenido = bytes.TrimPrefix(contenido, []byte("\xef\xbb\xbf"))
+
+		lector := csv.NewReader(bytes.NewReader(contenido))
+		lector.Comma = separadorCSV(contenido)
+		lector.FieldsPerRecord = -1
+		lector.TrimLeadingSpace = true
+		filas, err := lector.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido: %w", err)
+		}
+		return filas, nil
+
+	default:
+		return nil, fmt.Errorf("formato no soportado: usa .xlsx o .csv")
+	}
+}
+
+// separadorCSV elige ';' si la primera línea lo usa más que ','
+// (Excel en español exporta con punto y coma)
+func separadorCSV(contenido []byte) rune {
+	primera, _ := bufio.NewReader(bytes.NewReader(contenido)).ReadString('\n')
+	if strings.Count(primera, ";") > strings.Count(primera, ",") {
+		return ';'
+	}
+	return ','
+}
+
+// tablaImportacion da acceso a las columnas por nombre de encabezado
+type tablaImportacion struct {
+	columnas map[string]int
+	filas    [][]string
+}
+
+// nuevaTablaImportacion toma la primera fila como encabezados y descarta
+// las filas completamente vacías
+func nuevaTablaImportacion(filas [][]string) (*tablaImportacion, error) {
+	if len(filas) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+
+	t := &tablaImportacion{columnas: make(map[string]int)}
+	for i, encabezado := range filas[0] {
+		if nombre := normalizarEncabezado(encabezado); nombre != "" {
+			if _, repetida := t.columnas[nombre]; !repetida {
+				t.columnas[nombre] = i
+			}
+		}
+	}
+
+	for _, fila := range filas[1:] {
+		if strings.TrimSpace(strings.Join(fila, "")) == "" {
+			t.filas = append(t.filas, nil)
+			continue
+		}
+		t.filas = append(t.filas, fila)
+	}
+	if len(t.filas) > maxFilasImportacion {
+		return nil, fmt.Errorf("el archivo supera el máximo de %d filas", maxFilasImportacion)
+	}
+
+	return t, nil
+}
+
+// tiene indica si existe alguna de las columnas
+func (t *tablaImportacion) tiene(nombres ...string) bool {
+	for _, n := range nombres {
+		if _, ok := t.columnas[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// valor devuelve la celda de la primera columna existente entre los nombres
+func (t *tablaImportacion) valor(fila []string, nombres ...string) string {
+	for _, n := range nombres {
+		if i, ok := t.columnas[n]; ok {
+			if i < len(fila) {
+				return strings.TrimSpace(fila[i])
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// numeroFila es el número de fila que ve el usuario en la planilla
+func numeroFila(indice int) int {
+	return indice + 2
+}
+
+var sinTildes = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+)
+
+// normalizarEncabezado convierte "Código Estudiante" en "codigo_estudiante"
+func normalizarEncabezado(s string) string {
+	s = sinTildes.Replace(strings.ToLower(strings.TrimSpace(s)))
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// parsearDecimal acepta coma o punto como separador decimal
+func parsearDecimal(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+// ==================== ARCHIVO DE RESULTADOS ====================
+
+// Color de la celda "Estado" en el archivo de resultados
+var colorEstadoImportacion = map[string]string{
+	models.FilaCreada:        "#C8E6C9",
+	models.FilaValida:        "#E8F5E9",
+	models.FilaSinCambios:    "#E8F5E9",
+	models.FilaYaMatriculado: "#E8F5E9",
+	models.FilaEnEspera:      "#FFF9C4",
+	models.FilaDuplicada:     "#FFF9C4",
+	models.FilaError:         "#FFCDD2",
+}
+
+// generarReporteImportacion arma el Excel de resultados de una importación:
+// una fila por fila del archivo original, coloreando la columna "Estado"
+func generarReporteImportacion(headers []string, anchos []float64, filas [][]interface{}, estados []string) (*bytes.Buffer, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Resultado"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear hoja: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 12, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#2E7D32"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	estilos := make(map[string]int, len(colorEstadoImportacion))
+	for estado, color := range colorEstadoImportacion {
+		estilos[estado], _ = f.NewStyle(&excelize.Style{
+			Fill: excelize.Fill{Type: "pattern", Color: []string{color}, Pattern: 1},
+		})
+	}
+
+	columnaEstado := 0
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+		f.SetCellStyle(sheetName, cell, cell, headerStyle)
+		if header == "Estado" {
+			columnaEstado = i + 1
+		}
+		if i < len(anchos) {
+			col, _ := excelize.ColumnNumberToName(i + 1)
+			f.SetColWidth(sheetName, col, col, anchos[i])
+		}
+	}
+
+	for i, valores := range filas {
+		row := i + 2
+		for j, v := range valores {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			f.SetCellValue(sheetName, cell, v)
+		}
+		if estilo, ok := estilos[estados[i]]; ok && columnaEstado > 0 {
+			cell, _ := excelize.CoordinatesToCellName(columnaEstado, row)
+			f.SetCellStyle(sheetName, cell, cell, estilo)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		return nil, fmt.Errorf("error al escribir archivo: %w", err)
+	}
+	return &buffer, nil
+}
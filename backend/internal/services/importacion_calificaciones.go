@@ -0,0 +1,391 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrImportacionConErrores impide aplicar un lote con filas inválidas o en conflicto
+	ErrImportacionConErrores = errors.New("la planilla tiene filas con errores o conflictos; corrígelas antes de aplicar")
+	// ErrImportacionGrupal: la nota de un grupo se reparte con su ajuste
+	// individual, algo que una fila por estudiante no puede expresar
+	ErrImportacionGrupal = errors.New("las tareas grupales se califican desde la plataforma, no por planilla")
+)
+
+// Encabezados de la plantilla (el importador los busca por nombre, no por posición)
+var columnasPlantillaCalificaciones = []string{
+	"Entrega ID", "Código", "Estudiante", "Fecha Entrega", "Días Retraso",
+	"Penalización", "Calificación Actual", "Calificación", "Comentario",
+}
+
+// ==================== PLANTILLA ====================
+
+// GenerarPlantillaCalificaciones arma el Excel que el docente completa offline:
+// una fila por entrega, con la nota actual como referencia
+func (s *TareaService) GenerarPlantillaCalificaciones(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) (*bytes.Buffer, string, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, "", fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, "", err
+	}
+	if tarea.EntregaGrupal {
+		return nil, "", ErrImportacionGrupal
+	}
+
+	entregas, err := s.entregaRepo.GetByTareaIDWithEstudiante(ctx, tareaID)
+	if err != nil {
+		return nil, "", fmt.Errorf("error al obtener entregas: %w", err)
+	}
+	anonimizarEntregas(tarea, entregas)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Calificaciones"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, "", fmt.Errorf("error al crear hoja: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 12, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#2E7D32"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	editableStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#FFF9C4"}, Pattern: 1},
+	})
+
+	for i, header := range columnasPlantillaCalificaciones {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+		f.SetCellStyle(sheetName, cell, cell, headerStyle)
+	}
+	f.SetColWidth(sheetName, "A", "A", 38)
+	f.SetColWidth(sheetName, "B", "B", 15)
+	f.SetColWidth(sheetName, "C", "C", 30)
+	f.SetColWidth(sheetName, "D", "G", 16)
+	f.SetColWidth(sheetName, "H", "H", 14)
+	f.SetColWidth(sheetName, "I", "I", 50)
+
+	loc := config.AppConfig.Ubicacion()
+	for i, e := range entregas {
+		row := i + 2
+		codigo, nombre := identidadEntrega(&e)
+
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), e.ID.String())
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), codigo)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), nombre)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), e.FechaEntrega.In(loc).Format("02/01/2006 15:04"))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), e.DiasRetraso)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), e.PenalizacionAplicada)
+		if e.Calificacion != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), *e.Calificacion)
+		}
+		f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("I%d", row), editableStyle)
+	}
+
+	// Instrucciones en una hoja aparte para no interferir con la importación
+	ayuda := "Instrucciones"
+	f.NewSheet(ayuda)
+	f.SetColWidth(ayuda, "A", "A", 100)
+	for i, linea := range []string{
+		fmt.Sprintf("Tarea: %s (puntaje máximo %.2f)", tarea.Titulo, tarea.PuntajeMaximo),
+		"Completa solo las columnas Calificación y Comentario (en amarillo).",
+		"La penalización por entrega tardía se descuenta automáticamente, igual que al calificar desde la plataforma.",
+		"Deja la calificación vacía para no modificar esa entrega.",
+		"Un comentario vacío conserva el comentario actual.",
+		"No cambies la columna Entrega ID.",
+	} {
+		f.SetCellValue(ayuda, fmt.Sprintf("A%d", i+1), linea)
+	}
+
+	var buffer bytes.Buffer
+	if err := f.Write(&buffer); err != nil {
+		return nil, "", fmt.Errorf("error al escribir archivo: %w", err)
+	}
+
+	return &buffer, tarea.Titulo, nil
+}
+
+// identidadEntrega devuelve código y nombre del estudiante (o el alias si es anónima)
+func identidadEntrega(e *models.Entrega) (string, string) {
+	if e.Alias != "" {
+		return "", e.Alias
+	}
+	if e.Estudiante == nil {
+		return "", ""
+	}
+	codigo := ""
+	if e.Estudiante.CodigoEstudiante != nil {
+		codigo = *e.Estudiante.CodigoEstudiante
+	}
+	return codigo, e.Estudiante.Usuario.NombreCompleto
+}
+
+// ==================== IMPORTACIÓN ====================
+
+// ImportarCalificaciones valida la planilla y, si confirmar es true, aplica
+// todas las notas en una sola transacción. Sin confirmar devuelve la vista
+// previa. Con sobrescribir se reemplazan las notas existentes; si no, son
+// conflictos. La penalización por retraso se descuenta con notaConPenalizacion,
+// igual que en registrarCalificacion.
+func (s *TareaService) ImportarCalificaciones(ctx context.Context, tareaID uuid.UUID, usuarioID, rol, nombreArchivo string, archivo io.Reader, confirmar, sobrescribir bool) (*models.ResultadoImportacionCalificaciones, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if tarea.EntregaGrupal {
+		return nil, ErrImportacionGrupal
+	}
+	calificadoPor, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return nil, fmt.Errorf("usuario inválido")
+	}
+
+	filas, err := leerPlanilla(nombreArchivo, archivo)
+	if err != nil {
+		return nil, err
+	}
+	tabla, err := nuevaTablaImportacion(filas)
+	if err != nil {
+		return nil, err
+	}
+	if !tabla.tiene("calificacion") {
+		return nil, fmt.Errorf("falta la columna 'Calificación'")
+	}
+	if !tabla.tiene("entrega_id", "codigo", "codigo_estudiante") {
+		return nil, fmt.Errorf("falta la columna 'Entrega ID' o 'Código' para identificar al estudiante")
+	}
+
+	entregas, err := s.entregaRepo.GetByTareaIDWithEstudiante(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener entregas: %w", err)
+	}
+
+	resultado := validarImportacionCalificaciones(tarea, entregas, tabla, sobrescribir)
+	if !confirmar {
+		return resultado, nil
+	}
+	if resultado.ConErrores > 0 || resultado.Conflictos > 0 {
+		return resultado, ErrImportacionConErrores
+	}
+
+	lote := make([]models.CalificacionImportada, 0, resultado.Validas)
+	for _, f := range resultado.Filas {
+		if f.Estado != models.FilaValida {
+			continue
+		}
+		lote = append(lote, models.CalificacionImportada{
+			EntregaID:            *f.EntregaID,
+			Calificacion:         *f.CalificacionFinal,
+			Comentario:           f.Comentario,
+			CalificacionEsperada: f.CalificacionActual,
+		})
+	}
+
+	resultado.Confirmada = true
+	if len(lote) == 0 {
+		return resultado, nil
+	}
+
+	aplicadas, err := s.entregaRepo.ImportarCalificaciones(ctx, tareaID, calificadoPor, lote)
+	if err != nil {
+		if esCicloCerrado(err) {
+			return nil, ErrCicloCerrado
+		}
+		return nil, err
+	}
+	resultado.Aplicadas = aplicadas
+
+	log.Printf("✅ Calificaciones importadas: tarea %s (%d aplicadas)", tareaID, aplicadas)
+	go s.despuesDeImportar(tarea, lote)
+	return resultado, nil
+}
+
+// validarImportacionCalificaciones clasifica cada fila sin tocar la base de datos
+func validarImportacionCalificaciones(tarea *models.Tarea, entregas []models.Entrega, tabla *tablaImportacion, sobrescribir bool) *models.ResultadoImportacionCalificaciones {
+	porID := make(map[uuid.UUID]*models.Entrega, len(entregas))
+	porCodigo := make(map[string]*models.Entrega, len(entregas))
+	for i := range entregas {
+		porID[entregas[i].ID] = &entregas[i]
+		if codigo, _ := identidadEntrega(&entregas[i]); codigo != "" {
+			porCodigo[strings.ToUpper(codigo)] = &entregas[i]
+		}
+	}
+	anonima := tarea.CalificacionAnonima && !calificacionesPublicadas(tarea)
+
+	resultado := &models.ResultadoImportacionCalificaciones{
+		TareaID: tarea.ID,
+		Filas:   make([]models.FilaImportacionCalificacion, 0, len(tabla.filas)),
+	}
+	vistas := make(map[uuid.UUID]int)
+
+	for i, celdas := range tabla.filas {
+		if celdas == nil {
+			continue
+		}
+		fila := models.FilaImportacionCalificacion{
+			Fila:       numeroFila(i),
+			Codigo:     tabla.valor(celdas, "codigo", "codigo_estudiante"),
+			Comentario: tabla.valor(celdas, "comentario", "comentario_docente"),
+		}
+		agregarError := func(formato string, args ...interface{}) {
+			fila.Errores = append(fila.Errores, fmt.Sprintf(formato, args...))
+		}
+
+		// Identificar la entrega: por ID y, si además viene el código, que coincidan
+		var entrega *models.Entrega
+		if texto := tabla.valor(celdas, "entrega_id"); texto != "" {
+			if id, err := uuid.Parse(texto); err != nil {
+				agregarError("Entrega ID inválido")
+			} else if entrega = porID[id]; entrega == nil {
+				agregarError("la entrega no pertenece a esta tarea")
+			}
+		}
+		if fila.Codigo != "" {
+			porCod := porCodigo[strings.ToUpper(fila.Codigo)]
+			switch {
+			case entrega == nil && len(fila.Errores) == 0 && porCod == nil:
+				agregarError("el estudiante %s no tiene entrega en esta tarea", fila.Codigo)
+			case entrega == nil && len(fila.Errores) == 0:
+				entrega = porCod
+			case entrega != nil && porCod != entrega:
+				agregarError("el código %s no corresponde a la entrega", fila.Codigo)
+				entrega = nil
+			}
+		}
+		if entrega == nil && len(fila.Errores) == 0 {
+			agregarError("indica el Entrega ID o el código del estudiante")
+		}
+
+		if entrega != nil {
+			id := entrega.ID
+			fila.EntregaID = &id
+			fila.Penalizacion = entrega.PenalizacionAplicada
+			fila.CalificacionActual = entrega.Calificacion
+			if anonima {
+				fila.Codigo = ""
+				fila.Estudiante = aliasAnonimo(entrega.ID)
+			} else {
+				fila.Codigo, fila.Estudiante = identidadEntrega(entrega)
+			}
+			if previa, repetida := vistas[id]; repetida {
+				agregarError("entrega repetida (ya aparece en la fila %d)", previa)
+			}
+			vistas[id] = fila.Fila
+			if entrega.GrupoID != nil {
+				agregarError("la entrega es grupal: califícala desde la plataforma")
+			}
+		}
+
+		texto := tabla.valor(celdas, "calificacion", "nota")
+		switch {
+		case texto == "" && fila.Comentario != "" && len(fila.Errores) == 0:
+			agregarError("hay comentario pero falta la calificación")
+		case texto == "":
+			if len(fila.Errores) == 0 {
+				fila.Estado = models.FilaOmitida
+			}
+		default:
+			calificacion, err := parsearDecimal(texto)
+			if err != nil || math.IsNaN(calificacion) {
+				agregarError("calificación '%s' no es un número", texto)
+				break
+			}
+			if calificacion < 0 || calificacion > tarea.PuntajeMaximo {
+				agregarError("la calificación debe estar entre 0 y %.2f", tarea.PuntajeMaximo)
+				break
+			}
+			fila.Calificacion = &calificacion
+
+			// Penalización por retraso, igual que al calificar una entrega
+			if entrega != nil {
+				final := notaConPenalizacion(calificacion, entrega)
+				fila.CalificacionFinal = &final
+				if fila.Comentario == "" && entrega.ComentarioDocente != nil {
+					fila.Comentario = *entrega.ComentarioDocente
+				}
+			}
+		}
+
+		switch {
+		case len(fila.Errores) > 0:
+			fila.Estado = models.FilaError
+		case fila.Estado == models.FilaOmitida:
+		case fila.CalificacionActual == nil:
+			fila.Estado = models.FilaValida
+		case mismaNota(fila.CalificacionActual, fila.CalificacionFinal) && mismoComentario(porID[*fila.EntregaID], fila.Comentario):
+			fila.Estado = models.FilaSinCambios
+		case sobrescribir:
+			fila.Estado = models.FilaValida
+		default:
+			fila.Estado = models.FilaConflicto
+			agregarError("la entrega ya tiene calificación %.2f (usa sobrescribir para reemplazarla)", *fila.CalificacionActual)
+		}
+
+		switch fila.Estado {
+		case models.FilaValida:
+			resultado.Validas++
+		case models.FilaConflicto:
+			resultado.Conflictos++
+		case models.FilaError:
+			resultado.ConErrores++
+		case models.FilaSinCambios:
+			resultado.SinCambios++
+		case models.FilaOmitida:
+			resultado.Omitidas++
+		}
+		resultado.Filas = append(resultado.Filas, fila)
+	}
+
+	resultado.Total = len(resultado.Filas)
+	return resultado
+}
+
+func mismoComentario(e *models.Entrega, comentario string) bool {
+	actual := ""
+	if e != nil && e.ComentarioDocente != nil {
+		actual = *e.ComentarioDocente
+	}
+	return actual == comentario
+}
+
+// despuesDeImportar recalcula y avisa a los estudiantes si las notas ya son visibles
+func (s *TareaService) despuesDeImportar(tarea *models.Tarea, lote []models.CalificacionImportada) {
+	if !calificacionesPublicadas(tarea) {
+		return
+	}
+	s.calificacionesService.RecalcularEnSegundoPlano(tarea.CursoID.String(), "")
+
+	entregas := make([]models.Entrega, 0, len(lote))
+	for _, c := range lote {
+		entrega, err := s.entregaRepo.GetByID(context.Background(), c.EntregaID)
+		if err != nil {
+			log.Printf("⚠️ No se pudo notificar calificación de %s: %v", c.EntregaID, err)
+			continue
+		}
+		entregas = append(entregas, *entrega)
+	}
+	s.notificarPublicacion(tarea, entregas)
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"recetario-backend/internal/models"
+)
+
+// Estudiantes que se matriculan en paralelo durante una importación
+const maxMatriculasConcurrentes = 5
+
+// estudianteImportado es el estudiante resuelto a partir de su código
+type estudianteImportado struct {
+	UsuarioID        string `json:"usuario_id"`
+	CodigoEstudiante string `json:"codigo_estudiante"`
+	Usuario          struct {
+		NombreCompleto string `json:"nombre_completo"`
+		Activo         bool   `json:"activo"`
+	} `json:"usuarios"`
+}
+
+// ImportarMatriculas matricula en el ciclo indicado (o en el activo) a los
+// estudiantes de una planilla con las columnas código de estudiante, curso
+// (nombre o ID) y sección. Los ya matriculados se informan sin error, así que
+// el mismo archivo puede reenviarse tras corregir las filas fallidas.
+func (s *MatriculaService) ImportarMatriculas(nombreArchivo string, archivo io.Reader, cicloID string) (*models.ResultadoImportacionMatriculas, error) {
+	filas, err := leerPlanilla(nombreArchivo, archivo)
+	if err != nil {
+		return nil, err
+	}
+	tabla, err := nuevaTablaImportacion(filas)
+	if err != nil {
+		return nil, err
+	}
+	if !tabla.tiene("codigo_estudiante", "codigo") {
+		return nil, fmt.Errorf("falta la columna 'codigo_estudiante'")
+	}
+	if !tabla.tiene("curso", "curso_id") {
+		return nil, fmt.Errorf("falta la columna 'curso'")
+	}
+
+	cicloID, err = s.resolverCicloImportacion(cicloID)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := s.cursoRepo.GetCursosByCiclo(cicloID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cursos del ciclo: %w", err)
+	}
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil {
+		return nil, fmt.Errorf("error al parsear cursos")
+	}
+
+	resultado := &models.ResultadoImportacionMatriculas{CicloID: cicloID}
+	var codigos []string
+	for i, celdas := range tabla.filas {
+		if celdas == nil {
+			continue
+		}
+		fila := models.FilaImportacionMatricula{
+			Fila:             numeroFila(i),
+			CodigoEstudiante: tabla.valor(celdas, "codigo_estudiante", "codigo"),
+			Curso:            tabla.valor(celdas, "curso", "curso_id"),
+			Seccion:          tabla.valor(celdas, "seccion"),
+			Estado:           models.FilaValida,
+		}
+		if fila.CodigoEstudiante == "" {
+			fila.Errores = append(fila.Errores, "falta el código de estudiante")
+		} else {
+			codigos = append(codigos, fila.CodigoEstudiante)
+		}
+		if fila.Curso == "" {
+			fila.Errores = append(fila.Errores, "falta el curso")
+		} else if curso, err := buscarCursoImportacion(cursos, fila.Curso, fila.Seccion); err != nil {
+			fila.Errores = append(fila.Errores, err.Error())
+		} else {
+			fila.CursoID = curso.ID
+		}
+		resultado.Filas = append(resultado.Filas, fila)
+	}
+
+	estudiantes, err := s.estudiantesPorCodigo(codigos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolver estudiantes y descartar repetidos dentro del archivo
+	usuarioDeFila := make([]string, len(resultado.Filas))
+	vistas := make(map[string]int)
+	for i := range resultado.Filas {
+		fila := &resultado.Filas[i]
+		if fila.CodigoEstudiante != "" {
+			est, ok := estudiantes[strings.ToUpper(fila.CodigoEstudiante)]
+			switch {
+			case !ok:
+				fila.Errores = append(fila.Errores, "estudiante no encontrado")
+			case !est.Usuario.Activo:
+				fila.Errores = append(fila.Errores, "el estudiante está inactivo")
+			default:
+				usuarioDeFila[i] = est.UsuarioID
+				fila.Estudiante = est.Usuario.NombreCompleto
+			}
+		}
+		if len(fila.Errores) > 0 {
+			fila.Estado = models.FilaError
+			continue
+		}
+
+		clave := usuarioDeFila[i] + "|" + fila.CursoID
+		if previa, repetida := vistas[clave]; repetida {
+			fila.Estado = models.FilaYaMatriculado
+			fila.Errores = append(fila.Errores, fmt.Sprintf("repetida en el archivo (fila %d)", previa))
+			continue
+		}
+		vistas[clave] = fila.Fila
+	}
+
+	if err := s.marcarYaMatriculados(resultado, usuarioDeFila); err != nil {
+		return nil, err
+	}
+
+	s.matricularFilas(resultado, usuarioDeFila, cursos)
+	contarImportacionMatriculas(resultado)
+
+	log.Printf("✅ Importación de matrículas: %d nuevas, %d ya matriculadas, %d en espera, %d con errores",
+		resultado.Matriculadas, resultado.YaMatriculadas, resultado.EnEspera, resultado.ConErrores)
+	return resultado, nil
+}
+
+// resolverCicloImportacion valida el ciclo recibido o usa el ciclo activo
+func (s *MatriculaService) resolverCicloImportacion(cicloID string) (string, error) {
+	var respBody []byte
+	var err error
+	if cicloID != "" {
+		respBody, err = s.cicloRepo.GetCicloByID(cicloID)
+	} else {
+		respBody, err = s.cicloRepo.GetCicloActivo()
+	}
+	if err != nil {
+		return "", fmt.Errorf("ciclo no encontrado")
+	}
+
+	var ciclos []models.Ciclo
+	if err := json.Unmarshal(respBody, &ciclos); err != nil || len(ciclos) == 0 {
+		if cicloID == "" {
+			return "", fmt.Errorf("no hay ciclo activo: indica el ciclo_id")
+		}
+		return "", fmt.Errorf("ciclo no encontrado")
+	}
+	return ciclos[0].ID, nil
+}
+
+// buscarCursoImportacion ubica el curso del ciclo por ID o por nombre y sección
+func buscarCursoImportacion(cursos []models.Curso, curso, seccion string) (*models.Curso, error) {
+	var candidatos []*models.Curso
+	for i := range cursos {
+		c := &cursos[i]
+		if c.ID == curso {
+			return c, nil
+		}
+		if !strings.EqualFold(strings.TrimSpace(c.Nombre), curso) {
+			continue
+		}
+		if seccion != "" && !strings.EqualFold(strings.TrimSpace(c.Seccion), seccion) {
+			continue
+		}
+		candidatos = append(candidatos, c)
+	}
+
+	switch len(candidatos) {
+	case 0:
+		if seccion != "" {
+			return nil, fmt.Errorf("curso '%s' sección '%s' no existe en el ciclo", curso, seccion)
+		}
+		return nil, fmt.Errorf("curso '%s' no existe en el ciclo", curso)
+	case 1:
+		return candidatos[0], nil
+	default:
+		return nil, fmt.Errorf("el curso '%s' tiene varias secciones: indica la sección", curso)
+	}
+}
+
+// estudiantesPorCodigo resuelve los códigos en lotes; la clave es el código en mayúsculas
+func (s *MatriculaService) estudiantesPorCodigo(codigos []string) (map[string]estudianteImportado, error) {
+	unicos := make(map[string]bool)
+	var consulta []string
+	for _, c := range codigos {
+		for _, variante := range []string{c, strings.ToUpper(c)} {
+			if !unicos[variante] {
+				unicos[variante] = true
+				consulta = append(consulta, variante)
+			}
+		}
+	}
+
+	estudiantes := make(map[string]estudianteImportado)
+	for inicio := 0; inicio < len(consulta); inicio += loteConsultaDuplicados {
+		respBody, err := s.usuarioRepo.GetEstudiantesPorCodigo(tramo(consulta, inicio, loteConsultaDuplicados))
+		if err != nil {
+			return nil, fmt.Errorf("error al buscar estudiantes: %w", err)
+		}
+
+		var lote []estudianteImportado
+		if err := json.Unmarshal(respBody, &lote); err != nil {
+			return nil, fmt.Errorf("error al parsear estudiantes")
+		}
+		for _, e := range lote {
+			estudiantes[strings.ToUpper(e.CodigoEstudiante)] = e
+		}
+	}
+
+	return estudiantes, nil
+}
+
+// marcarYaMatriculados consulta las matrículas de cada curso del archivo
+func (s *MatriculaService) marcarYaMatriculados(resultado *models.ResultadoImportacionMatriculas, usuarioDeFila []string) error {
+	matriculasPorCurso := make(map[string]map[string]string)
+	for i := range resultado.Filas {
+		fila := &resultado.Filas[i]
+		if fila.Estado != models.FilaValida {
+			continue
+		}
+
+		existentes, ok := matriculasPorCurso[fila.CursoID]
+		if !ok {
+			matriculas, err := s.ListarMatriculasPorCurso(fila.CursoID)
+			if err != nil {
+				return err
+			}
+			existentes = make(map[string]string, len(matriculas))
+			for _, m := range matriculas {
+				if m.CicloID == resultado.CicloID {
+					existentes[m.EstudianteID] = m.ID
+				}
+			}
+			matriculasPorCurso[fila.CursoID] = existentes
+		}
+
+		if matriculaID, ok := existentes[usuarioDeFila[i]]; ok {
+			fila.Estado = models.FilaYaMatriculado
+			fila.MatriculaID = matriculaID
+		}
+	}
+	return nil
+}
+
+// matricularFilas crea las matrículas pendientes en paralelo. Las filas de un
+// mismo estudiante van en orden dentro de un solo worker para que la
+// validación de cruces de horario vea sus matrículas anteriores.
+func (s *MatriculaService) matricularFilas(resultado *models.ResultadoImportacionMatriculas, usuarioDeFila []string, cursos []models.Curso) {
+	cursoPorID := make(map[string]*models.Curso, len(cursos))
+	for i := range cursos {
+		cursoPorID[cursos[i].ID] = &cursos[i]
+	}
+
+	var orden []string
+	filasPorEstudiante := make(map[string][]int)
+	for i, fila := range resultado.Filas {
+		if fila.Estado != models.FilaValida {
+			continue
+		}
+		estudianteID := usuarioDeFila[i]
+		if _, ok := filasPorEstudiante[estudianteID]; !ok {
+			orden = append(orden, estudianteID)
+		}
+		filasPorEstudiante[estudianteID] = append(filasPorEstudiante[estudianteID], i)
+	}
+
+	var wg sync.WaitGroup
+	semaforo := make(chan struct{}, maxMatriculasConcurrentes)
+	for _, estudianteID := range orden {
+		wg.Add(1)
+		semaforo <- struct{}{}
+		go func(estudianteID string, indices []int) {
+			defer wg.Done()
+			defer func() { <-semaforo }()
+
+			for _, i := range indices {
+				fila := &resultado.Filas[i]
+				matricula, err := s.registrarMatricula(&models.CrearMatriculaRequest{
+					EstudianteID: estudianteID,
+					CursoID:      fila.CursoID,
+					CicloID:      resultado.CicloID,
+				}, cursoPorID[fila.CursoID])
+				var enEspera *EnListaEsperaError
+				if errors.As(err, &enEspera) {
+					fila.Estado = models.FilaEnEspera
+					fila.Errores = append(fila.Errores, fmt.Sprintf("curso lleno: posición %d en la lista de espera", enEspera.Entrada.Posicion))
+					continue
+				}
+				if err != nil {
+					fila.Estado = models.FilaError
+					fila.Errores = append(fila.Errores, err.Error())
+					continue
+				}
+				fila.Estado = models.FilaCreada
+				fila.MatriculaID = matricula.ID
+			}
+		}(estudianteID, filasPorEstudiante[estudianteID])
+	}
+	wg.Wait()
+}
+
+func contarImportacionMatriculas(r *models.ResultadoImportacionMatriculas) {
+	r.Total = len(r.Filas)
+	for _, f := range r.Filas {
+		switch f.Estado {
+		case models.FilaCreada:
+			r.Matriculadas++
+		case models.FilaYaMatriculado:
+			r.YaMatriculadas++
+		case models.FilaEnEspera:
+			r.EnEspera++
+		case models.FilaError:
+			r.ConErrores++
+		}
+	}
+}
+
+// GenerarReporteImportacionMatriculas devuelve un Excel con el resultado de cada fila
+func GenerarReporteImportacionMatriculas(resultado *models.ResultadoImportacionMatriculas) (*bytes.Buffer, error) {
+	headers := []string{"Fila", "Código Estudiante", "Estudiante", "Curso", "Sección", "Estado", "Matrícula ID", "Errores"}
+	anchos := []float64{8, 18, 30, 30, 10, 16, 38, 60}
+
+	filas := make([][]interface{}, len(resultado.Filas))
+	estados := make([]string, len(resultado.Filas))
+	for i, fila := range resultado.Filas {
+		filas[i] = []interface{}{
+			fila.Fila, fila.CodigoEstudiante, fila.Estudiante, fila.Curso, fila.Seccion,
+			fila.Estado, fila.MatriculaID, strings.Join(fila.Errores, "; "),
+		}
+		estados[i] = fila.Estado
+	}
+
+	return generarReporteImportacion(headers, anchos, filas, estados)
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"recetario-backend/internal/models"
+)
+
+// Cantidad de emails/códigos por consulta de duplicados
+const loteConsultaDuplicados = 100
+
+// ImportarUsuarios valida todas las filas de un CSV o XLSX con las columnas
+// nombre, email, código, rol, ciclo, sección y especialidad. Sin confirmar
+// solo devuelve la vista previa; al confirmar crea cada fila válida con
+// CrearUsuario (la contraseña temporal es el código, como en el alta manual).
+func (s *AdminService) ImportarUsuarios(nombreArchivo string, archivo io.Reader, confirmar bool) (*models.ResultadoImportacionUsuarios, error) {
+	filas, err := leerPlanilla(nombreArchivo, archivo)
+	if err != nil {
+		return nil, err
+	}
+	tabla, err := nuevaTablaImportacion(filas)
+	if err != nil {
+		return nil, err
+	}
+	for _, requerida := range [][]string{
+		{"nombre", "nombre_completo"},
+		{"email", "correo"},
+		{"codigo"},
+		{"rol"},
+	} {
+		if !tabla.tiene(requerida...) {
+			return nil, fmt.Errorf("falta la columna '%s'", requerida[0])
+		}
+	}
+
+	resultado := s.validarImportacionUsuarios(tabla)
+	if err := s.marcarDuplicadosExistentes(resultado); err != nil {
+		return nil, err
+	}
+	contarImportacionUsuarios(resultado)
+
+	if !confirmar {
+		return resultado, nil
+	}
+
+	resultado.Confirmada = true
+	for i := range resultado.Filas {
+		fila := &resultado.Filas[i]
+		if fila.Estado != models.FilaValida {
+			continue
+		}
+
+		usuarioID, err := s.CrearUsuario(solicitudDeFila(fila))
+		if err != nil {
+			fila.Estado = models.FilaError
+			fila.Errores = append(fila.Errores, err.Error())
+			continue
+		}
+		fila.Estado = models.FilaCreada
+		fila.UsuarioID = usuarioID
+	}
+	contarImportacionUsuarios(resultado)
+
+	log.Printf("✅ Importación de usuarios: %d creados, %d con errores, %d duplicados",
+		resultado.Creadas, resultado.ConErrores, resultado.Duplicadas)
+	return resultado, nil
+}
+
+func solicitudDeFila(fila *models.FilaImportacionUsuario) *CrearUsuarioRequest {
+	return &CrearUsuarioRequest{
+		NombreCompleto: fila.Nombre,
+		Email:          fila.Email,
+		Codigo:         fila.Codigo,
+		Rol:            fila.Rol,
+		CicloActual:    fila.Ciclo,
+		Seccion:        fila.Seccion,
+		Especialidad:   fila.Especialidad,
+	}
+}
+
+// validarImportacionUsuarios revisa cada fila y los duplicados dentro del archivo
+func (s *AdminService) validarImportacionUsuarios(tabla *tablaImportacion) *models.ResultadoImportacionUsuarios {
+	resultado := &models.ResultadoImportacionUsuarios{
+		Filas: make([]models.FilaImportacionUsuario, 0, len(tabla.filas)),
+	}
+	emails := make(map[string]int)
+	codigos := make(map[string]int)
+
+	for i, celdas := range tabla.filas {
+		if celdas == nil {
+			continue
+		}
+		fila := models.FilaImportacionUsuario{
+			Fila:         numeroFila(i),
+			Nombre:       strings.Join(strings.Fields(tabla.valor(celdas, "nombre", "nombre_completo")), " "),
+			Email:        strings.ToLower(tabla.valor(celdas, "email", "correo")),
+			Codigo:       strings.ToUpper(tabla.valor(celdas, "codigo")),
+			Rol:          strings.ToLower(tabla.valor(celdas, "rol")),
+			Seccion:      strings.ToUpper(tabla.valor(celdas, "seccion")),
+			Especialidad: tabla.valor(celdas, "especialidad"),
+			Estado:       models.FilaValida,
+		}
+
+		req := solicitudDeFila(&fila)
+		if err := s.validarCrearUsuario(req); err != nil {
+			fila.Errores = append(fila.Errores, err.Error())
+		}
+		if texto := tabla.valor(celdas, "ciclo"); texto != "" {
+			if fila.Rol != "estudiante" {
+				fila.Errores = append(fila.Errores, "el ciclo solo aplica a estudiantes")
+			} else if ciclo, err := ParsearCiclo(texto); err != nil {
+				fila.Errores = append(fila.Errores, err.Error())
+			} else {
+				fila.Ciclo = ciclo
+			}
+		}
+		if len(fila.Errores) > 0 {
+			fila.Estado = models.FilaError
+		}
+
+		if fila.Email != "" {
+			if previa, ok := emails[fila.Email]; ok {
+				marcarDuplicada(&fila, fmt.Sprintf("email repetido en el archivo (fila %d)", previa))
+			} else {
+				emails[fila.Email] = fila.Fila
+			}
+		}
+		if fila.Codigo != "" {
+			if previa, ok := codigos[fila.Codigo]; ok {
+				marcarDuplicada(&fila, fmt.Sprintf("código repetido en el archivo (fila %d)", previa))
+			} else {
+				codigos[fila.Codigo] = fila.Fila
+			}
+		}
+
+		resultado.Filas = append(resultado.Filas, fila)
+	}
+
+	return resultado
+}
+
+// marcarDuplicada registra el motivo; un error de formato tiene prioridad en el estado
+func marcarDuplicada(fila *models.FilaImportacionUsuario, motivo string) {
+	fila.Errores = append(fila.Errores, motivo)
+	if fila.Estado == models.FilaValida {
+		fila.Estado = models.FilaDuplicada
+	}
+}
+
+// marcarDuplicadosExistentes busca en la BD los emails y códigos del archivo
+func (s *AdminService) marcarDuplicadosExistentes(resultado *models.ResultadoImportacionUsuarios) error {
+	var emails, codigos []string
+	for _, f := range resultado.Filas {
+		if f.Email != "" {
+			emails = append(emails, f.Email)
+		}
+		if f.Codigo != "" {
+			codigos = append(codigos, f.Codigo)
+		}
+	}
+
+	existentesEmail := make(map[string]bool)
+	existentesCodigo := make(map[string]bool)
+	for inicio := 0; inicio < len(emails) || inicio < len(codigos); inicio += loteConsultaDuplicados {
+		respBody, err := s.usuarioRepo.GetUsuariosPorEmailOCodigo(
+			tramo(emails, inicio, loteConsultaDuplicados),
+			tramo(codigos, inicio, loteConsultaDuplicados),
+		)
+		if err != nil {
+			return fmt.Errorf("error al verificar duplicados: %w", err)
+		}
+
+		var usuarios []struct {
+			Email  string  `json:"email"`
+			Codigo *string `json:"codigo"`
+		}
+		if err := json.Unmarshal(respBody, &usuarios); err != nil {
+			return fmt.Errorf("error al verificar duplicados")
+		}
+		for _, u := range usuarios {
+			existentesEmail[strings.ToLower(u.Email)] = true
+			if u.Codigo != nil {
+				existentesCodigo[strings.ToUpper(*u.Codigo)] = true
+			}
+		}
+	}
+
+	for i := range resultado.Filas {
+		f := &resultado.Filas[i]
+		if existentesEmail[f.Email] {
+			marcarDuplicada(f, "el email ya está registrado")
+		}
+		if existentesCodigo[f.Codigo] {
+			marcarDuplicada(f, "el código ya está en uso")
+		}
+	}
+
+	return nil
+}
+
+func tramo(valores []string, inicio, tamano int) []string {
+	if inicio >= len(valores) {
+		return nil
+	}
+	fin := inicio + tamano
+	if fin > len(valores) {
+		fin = len(valores)
+	}
+	return valores[inicio:fin]
+}
+
+func contarImportacionUsuarios(r *models.ResultadoImportacionUsuarios) {
+	r.Total = len(r.Filas)
+	r.Validas, r.Duplicadas, r.ConErrores, r.Creadas = 0, 0, 0, 0
+	for _, f := range r.Filas {
+		switch f.Estado {
+		case models.FilaValida:
+			r.Validas++
+		case models.FilaDuplicada:
+			r.Duplicadas++
+		case models.FilaError:
+			r.ConErrores++
+		case models.FilaCreada:
+			r.Creadas++
+		}
+	}
+}
+
+// ==================== ARCHIVO DE RESULTADOS ====================
+
+// GenerarReporteImportacionUsuarios devuelve un Excel con el estado y los errores de cada fila
+func GenerarReporteImportacionUsuarios(resultado *models.ResultadoImportacionUsuarios) (*bytes.Buffer, error) {
+	headers := []string{"Fila", "Nombre", "Email", "Código", "Rol", "Ciclo", "Sección", "Especialidad", "Estado", "Usuario ID", "Errores"}
+	anchos := []float64{8, 30, 30, 14, 14, 8, 10, 20, 14, 38, 60}
+
+	filas := make([][]interface{}, len(resultado.Filas))
+	estados := make([]string, len(resultado.Filas))
+	for i, fila := range resultado.Filas {
+		ciclo := ""
+		if fila.Ciclo > 0 {
+			ciclo = fmt.Sprintf("%d", fila.Ciclo)
+		}
+		filas[i] = []interface{}{
+			fila.Fila, fila.Nombre, fila.Email, fila.Codigo, fila.Rol, ciclo,
+			fila.Seccion, fila.Especialidad, fila.Estado, fila.UsuarioID,
+			strings.Join(fila.Errores, "; "),
+		}
+		estados[i] = fila.Estado
+	}
+
+	return generarReporteImportacion(headers, anchos, filas, estados)
+}
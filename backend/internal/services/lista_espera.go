@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrCursoLleno se devuelve al activar una matrícula en un curso sin cupo
+var ErrCursoLleno = errors.New("el curso alcanzó su capacidad")
+
+// EnListaEsperaError indica que el curso estaba lleno y el estudiante quedó
+// en la lista de espera en lugar de ser matriculado
+type EnListaEsperaError struct {
+	Entrada *models.ListaEspera
+}
+
+func (e *EnListaEsperaError) Error() string {
+	return fmt.Sprintf("el curso está lleno: el estudiante quedó en la lista de espera (posición %d)", e.Entrada.Posicion)
+}
+
+// esCursoLleno reconoce la excepción del trigger de cupos en la respuesta de Supabase
+func esCursoLleno(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "curso_lleno")
+}
+
+// ListarListaEspera devuelve la lista de espera del curso en orden de llegada
+func (s *MatriculaService) ListarListaEspera(cursoID string) ([]models.ListaEspera, error) {
+	curso, err := s.obtenerCursoMatricula(cursoID)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := s.matriculaRepo.GetListaEspera(cursoID, curso.CicloID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener lista de espera: %w", err)
+	}
+
+	var lista []models.ListaEspera
+	if err := json.Unmarshal(respBody, &lista); err != nil {
+		return nil, fmt.Errorf("error al parsear lista de espera")
+	}
+	for i := range lista {
+		lista[i].Posicion = i + 1
+	}
+
+	return lista, nil
+}
+
+// QuitarDeListaEspera elimina una entrada de la lista de espera
+func (s *MatriculaService) QuitarDeListaEspera(id string) error {
+	if err := s.matriculaRepo.DeleteListaEspera(id); err != nil {
+		return fmt.Errorf("error al quitar de la lista de espera: %w", err)
+	}
+	return nil
+}
+
+// PromoverListaEspera llena los cupos libres del curso con los primeros de la
+// lista de espera, los suscribe al topic del curso y les avisa. Quien tenga
+// cruce de horario con sus cursos activos se salta y sigue en la lista.
+func (s *MatriculaService) PromoverListaEspera(cursoID, cicloID string) ([]models.Matricula, error) {
+	respBody, err := s.matriculaRepo.PromoverListaEspera(cursoID, cicloID)
+	if err != nil {
+		return nil, fmt.Errorf("error al promover lista de espera: %w", err)
+	}
+
+	var promovidas []models.Matricula
+	if err := json.Unmarshal(respBody, &promovidas); err != nil {
+		return nil, fmt.Errorf("error al parsear matrículas promovidas")
+	}
+
+	s.avisarPromovidos(cursoID, promovidas)
+	return promovidas, nil
+}
+
+// avisarPromovidos suscribe al topic del curso y avisa a quienes salieron
+// de la lista de espera
+func (s *MatriculaService) avisarPromovidos(cursoID string, promovidas []models.Matricula) {
+	if len(promovidas) > 0 {
+		log.Printf("✅ %d estudiante(s) promovido(s) desde la lista de espera del curso %s", len(promovidas), cursoID)
+	}
+	if s.notificationService == nil {
+		return
+	}
+
+	for _, m := range promovidas {
+		s.notificationService.SuscribirEstudianteACurso(m.EstudianteID, m.CursoID)
+
+		estudianteID, err := uuid.Parse(m.EstudianteID)
+		if err != nil {
+			continue
+		}
+		if err := s.notificationService.NotificarPromocionListaEspera(estudianteID, m.CursoID); err != nil {
+			log.Printf("⚠️ No se pudo avisar la promoción a %s: %v", m.EstudianteID, err)
+		}
+	}
+}
+
+// PromoverListaEsperaDeCurso promueve en el ciclo del curso
+func (s *MatriculaService) PromoverListaEsperaDeCurso(cursoID string) ([]models.Matricula, error) {
+	curso, err := s.obtenerCursoMatricula(cursoID)
+	if err != nil {
+		return nil, err
+	}
+	return s.PromoverListaEspera(cursoID, curso.CicloID)
+}
+
+// promoverEnSegundoPlano se usa tras liberar un cupo; los errores solo se registran
+func (s *MatriculaService) promoverEnSegundoPlano(cursoID, cicloID string) {
+	go func() {
+		if _, err := s.PromoverListaEspera(cursoID, cicloID); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}()
+}
+
+func (s *MatriculaService) obtenerCursoMatricula(cursoID string) (*models.Curso, error) {
+	respBody, err := s.cursoRepo.GetCursoByID(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("curso no encontrado")
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil || len(cursos) == 0 {
+		return nil, fmt.Errorf("curso no encontrado")
+	}
+	return &cursos[0], nil
+}
@@ -41,7 +41,8 @@ func (s *MaterialService) ActualizarMaterial(ctx context.Context, materialID uui
 		}
 
 		// Si el archivo viejo existe y es diferente al nuevo, eliminarlo
-		if materialActual.URLArchivo != "" && materialActual.URLArchivo != *req.URLArchivo {
+		if materialActual.URLArchivo != "" && materialActual.URLArchivo != *req.URLArchivo &&
+			!s.archivoCompartido(ctx, materialActual.URLArchivo) {
 			// Eliminar archivo viejo del Storage usando el método existente
 			if err := s.storageService.DeleteFile(materialActual.URLArchivo); err != nil {
 				// Log del error pero no fallar la actualización
@@ -68,7 +69,7 @@ func (s *MaterialService) EliminarMaterial(ctx context.Context, materialID uuid.
 	}
 
 	// 2. Eliminar el archivo del Storage si existe
-	if material.URLArchivo != "" {
+	if material.URLArchivo != "" && !s.archivoCompartido(ctx, material.URLArchivo) {
 		if err := s.storageService.DeleteFile(material.URLArchivo); err != nil {
 			// Log del error pero continuar con la eliminación del registro
 			fmt.Printf("Warning: No se pudo eliminar archivo del Storage: %v\n", err)
@@ -78,3 +79,14 @@ func (s *MaterialService) EliminarMaterial(ctx context.Context, materialID uuid.
 	// 3. Eliminar el registro de la BD
 	return s.materialRepo.Delete(ctx, materialID)
 }
+
+// archivoCompartido indica si otro material (por ejemplo, de un curso clonado)
+// sigue usando el archivo. Ante la duda se conserva.
+func (s *MaterialService) archivoCompartido(ctx context.Context, urlArchivo string) bool {
+	usos, err := s.materialRepo.ContarPorURL(ctx, urlArchivo)
+	if err != nil {
+		fmt.Printf("Warning: No se pudo verificar si el archivo está compartido: %v\n", err)
+		return true
+	}
+	return usos > 1
+}
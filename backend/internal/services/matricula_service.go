@@ -17,6 +17,9 @@ type MatriculaService struct {
 	usuarioRepo   repository.UsuarioRepository
 	cursoRepo     repository.CursoRepository
 	cicloRepo     repository.CicloRepository
+
+	prerrequisitoRepo   *repository.PrerrequisitoRepository
+	notificationService *NotificationService
 }
 
 // ✅ Constructor actualizado
@@ -25,12 +28,16 @@ func NewMatriculaService(
 	usuarioRepo repository.UsuarioRepository,
 	cursoRepo repository.CursoRepository,
 	cicloRepo repository.CicloRepository,
+	prerrequisitoRepo *repository.PrerrequisitoRepository,
+	notificationService *NotificationService,
 ) *MatriculaService {
 	return &MatriculaService{
-		matriculaRepo: matriculaRepo,
-		usuarioRepo:   usuarioRepo,
-		cursoRepo:     cursoRepo,
-		cicloRepo:     cicloRepo,
+		matriculaRepo:       matriculaRepo,
+		usuarioRepo:         usuarioRepo,
+		cursoRepo:           cursoRepo,
+		cicloRepo:           cicloRepo,
+		prerrequisitoRepo:   prerrequisitoRepo,
+		notificationService: notificationService,
 	}
 }
 
@@ -48,7 +55,12 @@ func (s *MatriculaService) CrearMatricula(req *models.CrearMatriculaRequest) (*m
 	}
 
 	// Validar que el curso existe
-	if _, err := s.cursoRepo.GetCursoByID(req.CursoID); err != nil {
+	cursoResp, err := s.cursoRepo.GetCursoByID(req.CursoID)
+	if err != nil {
+		return nil, fmt.Errorf("curso no encontrado")
+	}
+	var cursos []models.Curso
+	if err := json.Unmarshal(cursoResp, &cursos); err != nil || len(cursos) == 0 {
 		return nil, fmt.Errorf("curso no encontrado")
 	}
 
@@ -66,34 +78,108 @@ func (s *MatriculaService) CrearMatricula(req *models.CrearMatriculaRequest) (*m
 		}
 	}
 
-	// ✅ Crear la matrícula con los nuevos campos
+	return s.registrarMatricula(req, &cursos[0])
+}
+
+// registrarMatricula crea la matrícula ya validada (estudiante, curso y ciclo
+// existentes y sin duplicado) revisando antes prerrequisitos y cruces de horario.
+// Si el curso no tiene cupo devuelve *EnListaEsperaError.
+func (s *MatriculaService) registrarMatricula(req *models.CrearMatriculaRequest, curso *models.Curso) (*models.Matricula, error) {
+	if err := s.validarPrerrequisitos(req, curso); err != nil {
+		return nil, err
+	}
+
+	// Solo una matrícula activa ocupa el horario del estudiante
+	if req.Estado == nil || *req.Estado == "" || *req.Estado == "activo" {
+		if err := s.validarCrucesEstudiante(req.EstudianteID, req.CicloID, curso); err != nil {
+			return nil, err
+		}
+	}
+
+	// ✅ El cupo se verifica y ocupa en la misma transacción (matricular_con_cupo)
 	matriculaData := map[string]interface{}{
-		"estudiante_id":   req.EstudianteID,
-		"curso_id":        req.CursoID,
-		"ciclo_id":        req.CicloID,
-		"estado":          "activo",
-		"fecha_matricula": time.Now(),
+		"p_estudiante_id": req.EstudianteID,
+		"p_curso_id":      req.CursoID,
+		"p_ciclo_id":      req.CicloID,
+		"p_estado":        "activo",
+		"p_observaciones": nil,
 	}
 
 	if req.Estado != nil && *req.Estado != "" {
-		matriculaData["estado"] = *req.Estado
+		matriculaData["p_estado"] = *req.Estado
 	}
 
 	if req.Observaciones != nil && *req.Observaciones != "" {
-		matriculaData["observaciones"] = *req.Observaciones
+		matriculaData["p_observaciones"] = *req.Observaciones
+	}
+
+	if req.OmitirPrerrequisitos {
+		matriculaData["p_omitido_por"] = req.OmitidoPor
+		matriculaData["p_motivo"] = *req.MotivoOmision
 	}
 
-	respBody, err = s.matriculaRepo.CreateMatricula(matriculaData)
+	respBody, err := s.matriculaRepo.MatricularConCupo(matriculaData)
 	if err != nil {
 		return nil, fmt.Errorf("error al crear matrícula: %w", err)
 	}
 
-	var matriculas []models.Matricula
-	if err := json.Unmarshal(respBody, &matriculas); err != nil || len(matriculas) == 0 {
+	var resultado models.ResultadoMatriculaConCupo
+	if err := json.Unmarshal(respBody, &resultado); err != nil {
+		return nil, fmt.Errorf("error al parsear respuesta")
+	}
+	if len(resultado.Promovidos) > 0 {
+		go s.avisarPromovidos(req.CursoID, resultado.Promovidos)
+	}
+	if resultado.ListaEspera != nil {
+		resultado.ListaEspera.Posicion = resultado.Posicion
+		return nil, &EnListaEsperaError{Entrada: resultado.ListaEspera}
+	}
+	if resultado.Matricula == nil {
 		return nil, fmt.Errorf("error al parsear respuesta")
 	}
+	matricula := resultado.Matricula
 
-	return &matriculas[0], nil
+	// Suscribir sus dispositivos al topic del curso
+	if matricula.Estado == "activo" && s.notificationService != nil {
+		go s.notificationService.SuscribirEstudianteACurso(matricula.EstudianteID, matricula.CursoID)
+	}
+
+	return matricula, nil
+}
+
+// validarCrucesEstudiante rechaza la matrícula si el curso se cruza con
+// otro curso activo del estudiante en el mismo ciclo
+func (s *MatriculaService) validarCrucesEstudiante(estudianteID, cicloID string, curso *models.Curso) error {
+	sesiones := SesionesDeCurso(curso)
+	if len(sesiones) == 0 {
+		return nil
+	}
+
+	respBody, err := s.cursoRepo.GetCursosByEstudiante(estudianteID)
+	if err != nil {
+		return fmt.Errorf("error al verificar cruces de horario: %w", err)
+	}
+
+	var matriculados []models.Curso
+	if err := json.Unmarshal(respBody, &matriculados); err != nil {
+		return fmt.Errorf("error al parsear cursos del estudiante")
+	}
+
+	delCiclo := make([]models.Curso, 0, len(matriculados))
+	for _, c := range matriculados {
+		if c.CicloID == cicloID {
+			delCiclo = append(delCiclo, c)
+		}
+	}
+
+	conflictos := buscarConflictos(sesiones, delCiclo, curso.ID, func(*models.Curso, models.SesionHorario, models.SesionHorario) []string {
+		return []string{"estudiante"}
+	})
+	if len(conflictos) > 0 {
+		return &ConflictoHorarioError{Conflictos: conflictos}
+	}
+
+	return nil
 }
 
 func (s *MatriculaService) CrearMatriculaMasiva(req *models.MatriculaMasivaRequest) ([]models.Matricula, []string, error) {
@@ -108,6 +194,10 @@ func (s *MatriculaService) CrearMatriculaMasiva(req *models.MatriculaMasivaReque
 			CicloID:       req.CicloID,
 			Estado:        req.Estado,        // ✅ NUEVO: Pasar estado si existe
 			Observaciones: req.Observaciones, // ✅ NUEVO: Pasar observaciones si existen
+
+			OmitirPrerrequisitos: req.OmitirPrerrequisitos,
+			MotivoOmision:        req.MotivoOmision,
+			OmitidoPor:           req.OmitidoPor,
 		}
 
 		matricula, err := s.CrearMatricula(createReq)
@@ -334,7 +424,9 @@ func (s *MatriculaService) ListarMatriculasPorEstudiante(estudianteID string) ([
 	return matriculas, nil
 }
 
-func (s *MatriculaService) ListarEstudiantesDisponibles(cursoID, cicloID string) ([]models.Usuario, error) {
+// ListarEstudiantesDisponibles devuelve los estudiantes no matriculados en el
+// curso, marcando si cumplen los prerrequisitos (soloElegibles descarta al resto)
+func (s *MatriculaService) ListarEstudiantesDisponibles(cursoID, cicloID string, soloElegibles bool) ([]models.Usuario, error) {
 	// Obtener todos los estudiantes
 	respBody, err := s.usuarioRepo.GetEstudiantesDisponibles(cursoID, cicloID)
 	if err != nil {
@@ -370,7 +462,33 @@ func (s *MatriculaService) ListarEstudiantesDisponibles(cursoID, cicloID string)
 		}
 	}
 
-	return disponibles, nil
+	return s.marcarElegibles(cursoID, disponibles, soloElegibles)
+}
+
+// marcarElegibles completa Elegible y RequisitosPendientes de cada estudiante
+func (s *MatriculaService) marcarElegibles(cursoID string, estudiantes []models.Usuario, soloElegibles bool) ([]models.Usuario, error) {
+	curso, err := s.obtenerCursoMatricula(cursoID)
+	if err != nil {
+		return nil, err
+	}
+	evaluador, err := s.cargarPrerrequisitos(curso, "")
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := make([]models.Usuario, 0, len(estudiantes))
+	for _, est := range estudiantes {
+		pendientes := evaluador.pendientes(est.ID)
+		elegible := len(pendientes) == 0
+		if soloElegibles && !elegible {
+			continue
+		}
+		est.Elegible = &elegible
+		est.RequisitosPendientes = pendientes
+		resultado = append(resultado, est)
+	}
+
+	return resultado, nil
 }
 
 func (s *MatriculaService) ActualizarMatricula(matriculaID string, req *models.ActualizarMatriculaRequest) error {
@@ -393,21 +511,70 @@ func (s *MatriculaService) ActualizarMatricula(matriculaID string, req *models.A
 		return fmt.Errorf("no hay datos para actualizar")
 	}
 
+	// Estado previo para sincronizar el topic del curso
+	var anterior *models.Matricula
+	if req.Estado != nil {
+		anterior, _ = s.obtenerMatricula(matriculaID)
+	}
+
 	if err := s.matriculaRepo.UpdateMatricula(matriculaID, updateData); err != nil {
+		if esCursoLleno(err) {
+			return ErrCursoLleno
+		}
+		if esCicloCerrado(err) {
+			return ErrCicloCerrado
+		}
 		return fmt.Errorf("error al actualizar matrícula: %w", err)
 	}
 
+	// Al retirarse un estudiante su cupo pasa al primero de la lista de espera
+	if anterior != nil && anterior.Estado == "activo" && *req.Estado != "activo" {
+		s.promoverEnSegundoPlano(anterior.CursoID, anterior.CicloID)
+	}
+
+	if anterior != nil && s.notificationService != nil {
+		switch {
+		case anterior.Estado != "activo" && *req.Estado == "activo":
+			go s.notificationService.SuscribirEstudianteACurso(anterior.EstudianteID, anterior.CursoID)
+		case anterior.Estado == "activo" && *req.Estado != "activo":
+			go s.notificationService.DesuscribirEstudianteDeCurso(anterior.EstudianteID, anterior.CursoID)
+		}
+	}
+
 	return nil
 }
 
 func (s *MatriculaService) EliminarMatricula(matriculaID string) error {
+	anterior, _ := s.obtenerMatricula(matriculaID)
+
 	if err := s.matriculaRepo.DeleteMatricula(matriculaID); err != nil {
 		return fmt.Errorf("error al eliminar matrícula: %w", err)
 	}
 
+	if anterior != nil && anterior.Estado == "activo" {
+		if s.notificationService != nil {
+			go s.notificationService.DesuscribirEstudianteDeCurso(anterior.EstudianteID, anterior.CursoID)
+		}
+		s.promoverEnSegundoPlano(anterior.CursoID, anterior.CicloID)
+	}
+
 	return nil
 }
 
+func (s *MatriculaService) obtenerMatricula(matriculaID string) (*models.Matricula, error) {
+	respBody, err := s.matriculaRepo.GetMatriculaByID(matriculaID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener matrícula: %w", err)
+	}
+
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil || len(matriculas) == 0 {
+		return nil, fmt.Errorf("matrícula no encontrada")
+	}
+
+	return &matriculas[0], nil
+}
+
 func (s *MatriculaService) ListarTodasLasMatriculas() (json.RawMessage, error) {
 	respBody, err := s.matriculaRepo.GetAllMatriculas()
 	if err != nil {
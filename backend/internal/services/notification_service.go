@@ -2,11 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"recetario-backend/internal/config"
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/repository"
 
@@ -18,6 +22,9 @@ type NotificationService struct {
 	firebaseService *FirebaseService
 	usuarioRepo     repository.UsuarioRepository
 	portafolioRepo  *repository.PortafolioRepository
+	matriculaRepo   repository.MatriculaRepository
+	cursoRepo       repository.CursoRepository
+	emailService    *EmailService
 }
 
 func NewNotificationService(
@@ -25,12 +32,18 @@ func NewNotificationService(
 	firebaseService *FirebaseService,
 	usuarioRepo repository.UsuarioRepository,
 	portafolioRepo *repository.PortafolioRepository,
+	matriculaRepo repository.MatriculaRepository,
+	cursoRepo repository.CursoRepository,
+	emailService *EmailService,
 ) *NotificationService {
 	return &NotificationService{
 		repo:            repo,
 		firebaseService: firebaseService,
 		usuarioRepo:     usuarioRepo,
 		portafolioRepo:  portafolioRepo,
+		matriculaRepo:   matriculaRepo,
+		cursoRepo:       cursoRepo,
+		emailService:    emailService,
 	}
 }
 
@@ -115,40 +128,70 @@ func (s *NotificationService) CompartirReceta(recetaID uuid.UUID, usuariosIDs []
 	return nil
 }
 
-// Enviar notificación push a un usuario
 // Enviar notificación push a un usuario
 func (s *NotificationService) enviarPushNotificacion(usuarioID uuid.UUID, titulo, mensaje, recetaID string) {
+	// Datos adicionales para la notificación
+	data := map[string]string{
+		"receta_id": recetaID,
+		"tipo":      "receta_compartida",
+	}
+
+	s.enviarPush(usuarioID, titulo, mensaje, data)
+}
+
+// Enviar push a todos los dispositivos activos del usuario.
+// Devuelve true si al menos un dispositivo la recibió.
+func (s *NotificationService) enviarPush(usuarioID uuid.UUID, titulo, mensaje string, data map[string]string) bool {
 	// ✅ VALIDACIÓN CRÍTICA: Si Firebase no está disponible, salir silenciosamente
 	if s.firebaseService == nil {
 		log.Printf("⚠️ Firebase no disponible, notificación push omitida para usuario %s", usuarioID)
-		return
+		return false
 	}
 
 	// Obtener tokens FCM del usuario
 	tokens, err := s.repo.ObtenerTokensFCM(usuarioID)
 	if err != nil {
 		log.Printf("❌ Error obteniendo tokens FCM: %v", err)
-		return
+		return false
 	}
 
 	if len(tokens) == 0 {
 		log.Printf("⚠️ Usuario %s no tiene tokens FCM registrados", usuarioID)
-		return
-	}
-
-	// Datos adicionales para la notificación
-	data := map[string]string{
-		"receta_id": recetaID,
-		"tipo":      "receta_compartida",
+		return false
 	}
 
 	// Enviar notificación
-	err = s.firebaseService.EnviarNotificacionMultiple(tokens, titulo, mensaje, data)
+	resultado, err := s.firebaseService.EnviarNotificacionMultiple(tokens, titulo, mensaje, data)
+	if resultado != nil {
+		s.desactivarTokensInvalidos(resultado.TokensInvalidos())
+	}
 	if err != nil {
 		log.Printf("❌ Error enviando push notification: %v", err)
-	} else {
-		log.Printf("✅ Push notification enviada a %d dispositivos", len(tokens))
+		return false
+	}
+
+	log.Printf("✅ Push notification enviada a %d dispositivos", resultado.Exitosos)
+	return true
+}
+
+// Desactivar tokens que FCM reportó como no registrados o inválidos
+func (s *NotificationService) desactivarTokensInvalidos(tokens []string) {
+	for _, token := range tokens {
+		if err := s.repo.DesactivarDispositivo(token); err != nil {
+			log.Printf("⚠️ No se pudo desactivar token %s: %v", abreviarToken(token), err)
+			continue
+		}
+		log.Printf("🧹 Token FCM inválido desactivado: %s", abreviarToken(token))
+	}
+}
+
+// Métricas de entrega push (nil si Firebase no está disponible)
+func (s *NotificationService) ObtenerMetricasPush() *MetricasPush {
+	if s.firebaseService == nil {
+		return nil
 	}
+	m := s.firebaseService.Metricas()
+	return &m
 }
 
 // Obtener notificaciones de un usuario
@@ -156,9 +199,16 @@ func (s *NotificationService) ObtenerNotificaciones(usuarioID uuid.UUID) ([]mode
 	return s.repo.ObtenerNotificacionesPorUsuario(usuarioID)
 }
 
-// Marcar notificación como leída
-func (s *NotificationService) MarcarComoLeida(notificacionID uuid.UUID) error {
-	return s.repo.MarcarComoLeida(notificacionID)
+// Marcar como leída (solo notificaciones propias)
+func (s *NotificationService) MarcarComoLeida(usuarioID, notificacionID uuid.UUID) error {
+	n, err := s.repo.MarcarComoLeida(usuarioID, notificacionID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificacionNoEncontrada
+	}
+	return nil
 }
 
 // Marcar todas como leídas
@@ -174,10 +224,564 @@ func (s *NotificationService) RegistrarDispositivo(usuarioID uuid.UUID, fcmToken
 		Plataforma: plataforma,
 		Activo:     true,
 	}
-	return s.repo.RegistrarDispositivo(device)
+	if err := s.repo.RegistrarDispositivo(device); err != nil {
+		return err
+	}
+
+	// Suscribir el nuevo token a los topics de sus cursos activos
+	go s.suscribirTokenACursos(usuarioID, fcmToken)
+	return nil
+}
+
+// Desactivar un dispositivo del usuario (p.ej. al cerrar sesión)
+func (s *NotificationService) DesactivarDispositivo(usuarioID uuid.UUID, fcmToken string) error {
+	tokens, err := s.repo.ObtenerTokensFCM(usuarioID)
+	if err != nil {
+		return fmt.Errorf("error al obtener dispositivos: %w", err)
+	}
+
+	encontrado := false
+	for _, t := range tokens {
+		if t == fcmToken {
+			encontrado = true
+			break
+		}
+	}
+	if !encontrado {
+		return fmt.Errorf("dispositivo no encontrado")
+	}
+
+	s.desuscribirTokenDeCursos(usuarioID, fcmToken)
+
+	if err := s.repo.DesactivarDispositivo(fcmToken); err != nil {
+		return fmt.Errorf("error al desactivar dispositivo: %w", err)
+	}
+	return nil
 }
 
 // Contar notificaciones no leídas
 func (s *NotificationService) ContarNoLeidas(usuarioID uuid.UUID) (int, error) {
 	return s.repo.ContarNoLeidas(usuarioID)
 }
+
+// ==================== TOPICS POR CURSO ====================
+
+// Suscribir los dispositivos de un estudiante al topic de un curso
+func (s *NotificationService) SuscribirEstudianteACurso(estudianteID, cursoID string) {
+	if s.firebaseService == nil {
+		return
+	}
+
+	uid, err := uuid.Parse(estudianteID)
+	if err != nil {
+		log.Printf("⚠️ ID de estudiante inválido para topic: %s", estudianteID)
+		return
+	}
+
+	tokens, err := s.repo.ObtenerTokensFCM(uid)
+	if err != nil {
+		log.Printf("❌ Error obteniendo tokens FCM: %v", err)
+		return
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	if err := s.firebaseService.SuscribirATopic(tokens, TopicCurso(cursoID)); err != nil {
+		log.Printf("❌ Error suscribiendo estudiante %s al curso %s: %v", estudianteID, cursoID, err)
+	}
+}
+
+// Desuscribir los dispositivos de un estudiante del topic de un curso
+func (s *NotificationService) DesuscribirEstudianteDeCurso(estudianteID, cursoID string) {
+	if s.firebaseService == nil {
+		return
+	}
+
+	uid, err := uuid.Parse(estudianteID)
+	if err != nil {
+		log.Printf("⚠️ ID de estudiante inválido para topic: %s", estudianteID)
+		return
+	}
+
+	tokens, err := s.repo.ObtenerTokensFCM(uid)
+	if err != nil {
+		log.Printf("❌ Error obteniendo tokens FCM: %v", err)
+		return
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	if err := s.firebaseService.DesuscribirDeTopic(tokens, TopicCurso(cursoID)); err != nil {
+		log.Printf("❌ Error desuscribiendo estudiante %s del curso %s: %v", estudianteID, cursoID, err)
+	}
+}
+
+// Cursos con matrícula activa de un estudiante
+func (s *NotificationService) cursosActivosDeEstudiante(usuarioID uuid.UUID) []string {
+	if s.matriculaRepo == nil {
+		return nil
+	}
+
+	respBody, err := s.matriculaRepo.GetMatriculasByEstudiante(usuarioID.String())
+	if err != nil {
+		log.Printf("❌ Error obteniendo matrículas de %s: %v", usuarioID, err)
+		return nil
+	}
+
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil
+	}
+
+	var cursos []string
+	for _, m := range matriculas {
+		if m.Estado == "activo" {
+			cursos = append(cursos, m.CursoID)
+		}
+	}
+	return cursos
+}
+
+func (s *NotificationService) suscribirTokenACursos(usuarioID uuid.UUID, fcmToken string) {
+	if s.firebaseService == nil {
+		return
+	}
+
+	for _, cursoID := range s.cursosActivosDeEstudiante(usuarioID) {
+		if err := s.firebaseService.SuscribirATopic([]string{fcmToken}, TopicCurso(cursoID)); err != nil {
+			log.Printf("❌ Error suscribiendo dispositivo al curso %s: %v", cursoID, err)
+		}
+	}
+}
+
+func (s *NotificationService) desuscribirTokenDeCursos(usuarioID uuid.UUID, fcmToken string) {
+	if s.firebaseService == nil {
+		return
+	}
+
+	for _, cursoID := range s.cursosActivosDeEstudiante(usuarioID) {
+		if err := s.firebaseService.DesuscribirDeTopic([]string{fcmToken}, TopicCurso(cursoID)); err != nil {
+			log.Printf("❌ Error desuscribiendo dispositivo del curso %s: %v", cursoID, err)
+		}
+	}
+}
+
+// Validar que el usuario sea el docente del curso
+func (s *NotificationService) ValidarDocenteDelCurso(cursoID, usuarioID string) error {
+	respBody, err := s.cursoRepo.GetCursoByID(cursoID)
+	if err != nil {
+		return fmt.Errorf("curso no encontrado")
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil || len(cursos) == 0 {
+		return fmt.Errorf("curso no encontrado")
+	}
+
+	if cursos[0].DocenteID != usuarioID {
+		return fmt.Errorf("no eres el docente de este curso")
+	}
+	return nil
+}
+
+// Enviar un anuncio a todos los estudiantes de un curso con un solo envío
+func (s *NotificationService) EnviarAnuncioCurso(cursoID string, enviadoPorID uuid.UUID, titulo, mensaje string) (string, error) {
+	if s.firebaseService == nil {
+		return "", fmt.Errorf("notificaciones push no disponibles")
+	}
+
+	data := map[string]string{
+		"tipo":           "anuncio_curso",
+		"curso_id":       cursoID,
+		"enviado_por_id": enviadoPorID.String(),
+	}
+
+	messageID, err := s.firebaseService.EnviarATopic(TopicCurso(cursoID), titulo, mensaje, data)
+	if err != nil {
+		return "", fmt.Errorf("error al enviar anuncio: %w", err)
+	}
+	return messageID, nil
+}
+
+// ==================== NOTIFICACIONES CON RESPALDO POR EMAIL ====================
+
+// NotificacionSaliente describe un aviso a un usuario por todos los canales
+type NotificacionSaliente struct {
+	UsuarioID    uuid.UUID
+	Tipo         string // tipo de notificación y de plantilla de email
+	Titulo       string
+	Mensaje      string
+	EnviadoPorID *uuid.UUID
+	Data         map[string]string      // datos extra para el push
+	DatosEmail   map[string]interface{} // datos para la plantilla de email
+}
+
+// Notificar guarda la notificación en la app y la envía por push;
+// si el usuario no tiene dispositivos se envía por correo
+func (s *NotificationService) Notificar(n *NotificacionSaliente) error {
+	notif := &models.Notificacion{
+		UsuarioID:    n.UsuarioID,
+		Tipo:         n.Tipo,
+		Titulo:       n.Titulo,
+		Mensaje:      n.Mensaje,
+		EnviadoPorID: n.EnviadoPorID,
+		Leida:        false,
+	}
+	if err := s.repo.CrearNotificacion(notif); err != nil {
+		return fmt.Errorf("error al crear notificación: %w", err)
+	}
+
+	data := map[string]string{"tipo": n.Tipo}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+
+	if s.enviarPush(n.UsuarioID, n.Titulo, n.Mensaje, data) {
+		return nil
+	}
+
+	return s.enviarEmail(n)
+}
+
+func (s *NotificationService) enviarEmail(n *NotificacionSaliente) error {
+	if s.emailService == nil {
+		return fmt.Errorf("sin dispositivos y canal de email no disponible")
+	}
+
+	email, nombre, err := s.contactoUsuario(n.UsuarioID.String())
+	if err != nil {
+		return err
+	}
+
+	datos := map[string]interface{}{
+		"Nombre":  nombre,
+		"Email":   email,
+		"Titulo":  n.Titulo,
+		"Mensaje": n.Mensaje,
+	}
+	for k, v := range n.DatosEmail {
+		datos[k] = v
+	}
+
+	if err := s.emailService.EnviarPlantilla(email, n.Tipo, IdiomaPorDefecto, datos); err != nil {
+		return fmt.Errorf("error al enviar correo: %w", err)
+	}
+	return nil
+}
+
+// Email y nombre de un usuario
+func (s *NotificationService) contactoUsuario(usuarioID string) (string, string, error) {
+	respBody, err := s.usuarioRepo.GetUserByID(usuarioID)
+	if err != nil {
+		return "", "", fmt.Errorf("error al obtener usuario: %w", err)
+	}
+
+	var usuarios []models.Usuario
+	if err := json.Unmarshal(respBody, &usuarios); err != nil || len(usuarios) == 0 {
+		return "", "", fmt.Errorf("usuario no encontrado")
+	}
+
+	return usuarios[0].Email, usuarios[0].NombreCompleto, nil
+}
+
+// Nombre de un curso (vacío si no se encuentra)
+func (s *NotificationService) nombreCurso(cursoID string) string {
+	if s.cursoRepo == nil {
+		return ""
+	}
+
+	respBody, err := s.cursoRepo.GetCursoByID(cursoID)
+	if err != nil {
+		return ""
+	}
+
+	var cursos []models.Curso
+	if err := json.Unmarshal(respBody, &cursos); err != nil || len(cursos) == 0 {
+		return ""
+	}
+	return cursos[0].Nombre
+}
+
+// Avisar al estudiante que su entrega fue calificada
+func (s *NotificationService) NotificarCalificacion(estudianteID uuid.UUID, tarea *models.Tarea, entregaID uuid.UUID, calificacion float64, comentario string) error {
+	curso := s.nombreCurso(tarea.CursoID.String())
+
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: estudianteID,
+		Tipo:      EmailCalificacionPublicada,
+		Titulo:    "Nueva calificación",
+		Mensaje:   fmt.Sprintf("Tu entrega de '%s' fue calificada: %.2f / %.2f", tarea.Titulo, calificacion, tarea.PuntajeMaximo),
+		Data: map[string]string{
+			"tarea_id":   tarea.ID.String(),
+			"entrega_id": entregaID.String(),
+		},
+		DatosEmail: map[string]interface{}{
+			"Tarea":         tarea.Titulo,
+			"Curso":         curso,
+			"Calificacion":  fmt.Sprintf("%.2f", calificacion),
+			"PuntajeMaximo": fmt.Sprintf("%.2f", tarea.PuntajeMaximo),
+			"Comentario":    comentario,
+		},
+	})
+}
+
+// Avisar al docente que un estudiante pidió recalificar una entrega
+func (s *NotificationService) NotificarSolicitudRecalificacion(docenteID uuid.UUID, tarea *models.Tarea, curso string, solicitud *models.SolicitudRecalificacion) error {
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: docenteID,
+		Tipo:      EmailRecalificacion,
+		Titulo:    "Solicitud de recalificación",
+		Mensaje:   fmt.Sprintf("Un estudiante pidió revisar su nota de '%s'", tarea.Titulo),
+		Data: map[string]string{
+			"tarea_id":     tarea.ID.String(),
+			"entrega_id":   solicitud.EntregaID.String(),
+			"solicitud_id": solicitud.ID.String(),
+		},
+		DatosEmail: map[string]interface{}{
+			"Tarea":         tarea.Titulo,
+			"Curso":         curso,
+			"Justificacion": solicitud.Justificacion,
+		},
+	})
+}
+
+// Avisar al estudiante el resultado de su solicitud de recalificación
+func (s *NotificationService) NotificarResolucionRecalificacion(tarea *models.Tarea, solicitud *models.SolicitudRecalificacion) error {
+	aceptada := solicitud.Estado == models.RecalificacionAceptada
+
+	mensaje := fmt.Sprintf("Tu solicitud de recalificación de '%s' fue rechazada", tarea.Titulo)
+	calificacion := ""
+	if aceptada && solicitud.CalificacionNueva != nil {
+		calificacion = fmt.Sprintf("%.2f", *solicitud.CalificacionNueva)
+		mensaje = fmt.Sprintf("Tu solicitud de recalificación de '%s' fue aceptada: %s / %.2f",
+			tarea.Titulo, calificacion, tarea.PuntajeMaximo)
+	}
+	respuesta := ""
+	if solicitud.Respuesta != nil {
+		respuesta = *solicitud.Respuesta
+	}
+
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: solicitud.EstudianteID,
+		Tipo:      EmailRecalificacion,
+		Titulo:    "Resultado de recalificación",
+		Mensaje:   mensaje,
+		Data: map[string]string{
+			"tarea_id":     tarea.ID.String(),
+			"entrega_id":   solicitud.EntregaID.String(),
+			"solicitud_id": solicitud.ID.String(),
+			"estado":       solicitud.Estado,
+		},
+		DatosEmail: map[string]interface{}{
+			"Tarea":         tarea.Titulo,
+			"Curso":         s.nombreCurso(tarea.CursoID.String()),
+			"Aceptada":      aceptada,
+			"Calificacion":  calificacion,
+			"PuntajeMaximo": fmt.Sprintf("%.2f", tarea.PuntajeMaximo),
+			"Respuesta":     respuesta,
+		},
+	})
+}
+
+// Avisar al estudiante que salió de la lista de espera y ya está matriculado
+func (s *NotificationService) NotificarPromocionListaEspera(estudianteID uuid.UUID, cursoID string) error {
+	curso := s.nombreCurso(cursoID)
+
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: estudianteID,
+		Tipo:      EmailListaEspera,
+		Titulo:    "Matrícula confirmada",
+		Mensaje:   fmt.Sprintf("Se liberó un cupo en '%s' y ya estás matriculado", curso),
+		Data: map[string]string{
+			"curso_id": cursoID,
+		},
+		DatosEmail: map[string]interface{}{
+			"Curso": curso,
+		},
+	})
+}
+
+// Avisar al estudiante que un tema del curso ya está disponible
+func (s *NotificationService) NotificarTemaDesbloqueado(estudianteID uuid.UUID, tema *models.Tema, curso string) error {
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: estudianteID,
+		Tipo:      EmailTemaDesbloqueado,
+		Titulo:    "Nuevo tema disponible",
+		Mensaje:   fmt.Sprintf("'%s' ya está disponible en %s", tema.Titulo, curso),
+		Data: map[string]string{
+			"tema_id":  tema.ID.String(),
+			"curso_id": tema.CursoID.String(),
+		},
+		DatosEmail: map[string]interface{}{
+			"Tema":  tema.Titulo,
+			"Curso": curso,
+		},
+	})
+}
+
+// Recordar al estudiante una tarea próxima a vencer
+func (s *NotificationService) NotificarRecordatorioEntrega(estudianteID uuid.UUID, tarea *models.Tarea, curso string) error {
+	fechaLimite := tarea.FechaLimite.In(config.AppConfig.Ubicacion()).Format("02/01/2006 15:04")
+
+	return s.Notificar(&NotificacionSaliente{
+		UsuarioID: estudianteID,
+		Tipo:      EmailRecordatorioEntrega,
+		Titulo:    "Tarea por vencer",
+		Mensaje:   fmt.Sprintf("'%s' vence el %s y aún no registras tu entrega", tarea.Titulo, fechaLimite),
+		Data: map[string]string{
+			"tarea_id": tarea.ID.String(),
+		},
+		DatosEmail: map[string]interface{}{
+			"Tarea":       tarea.Titulo,
+			"Curso":       curso,
+			"FechaLimite": fechaLimite,
+		},
+	})
+}
+
+// ==================== BANDEJA ====================
+
+// Tamaño de página de la bandeja
+const (
+	limitePorDefectoBandeja = 20
+	limiteMaximoBandeja     = 100
+	maxIDsPorAccion         = 100
+)
+
+var ErrNotificacionNoEncontrada = errors.New("notificación no encontrada")
+
+// Listar la bandeja paginada por cursor
+func (s *NotificationService) ListarBandeja(usuarioID uuid.UUID, tipo string, leida *bool, archivadas bool, limite int, cursor string) (*models.PaginaNotificaciones, error) {
+	if limite <= 0 {
+		limite = limitePorDefectoBandeja
+	}
+	if limite > limiteMaximoBandeja {
+		limite = limiteMaximoBandeja
+	}
+
+	filtro := &models.FiltroNotificaciones{
+		Tipo:      tipo,
+		Leida:     leida,
+		Archivada: archivadas,
+		Limite:    limite + 1, // uno extra para saber si hay más
+	}
+
+	if cursor != "" {
+		fecha, id, err := decodificarCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filtro.AntesDe = &fecha
+		filtro.AntesDeID = &id
+	}
+
+	notificaciones, err := s.repo.ListarNotificaciones(usuarioID, filtro)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener notificaciones: %w", err)
+	}
+
+	pagina := &models.PaginaNotificaciones{Notificaciones: notificaciones}
+	if len(notificaciones) > limite {
+		pagina.Notificaciones = notificaciones[:limite]
+		ultima := pagina.Notificaciones[limite-1]
+		siguiente := codificarCursor(ultima.CreatedAt, ultima.ID)
+		pagina.SiguienteCursor = &siguiente
+	}
+
+	return pagina, nil
+}
+
+// Archivar o desarchivar notificaciones propias
+func (s *NotificationService) Archivar(usuarioID uuid.UUID, ids []uuid.UUID, archivada bool) (int, error) {
+	if err := validarIDsAccion(ids); err != nil {
+		return 0, err
+	}
+	return s.repo.ActualizarArchivada(usuarioID, ids, archivada)
+}
+
+// Eliminar notificaciones propias
+func (s *NotificationService) Eliminar(usuarioID uuid.UUID, ids []uuid.UUID) (int, error) {
+	if err := validarIDsAccion(ids); err != nil {
+		return 0, err
+	}
+	return s.repo.EliminarNotificaciones(usuarioID, ids)
+}
+
+func validarIDsAccion(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("debe indicar al menos una notificación")
+	}
+	if len(ids) > maxIDsPorAccion {
+		return fmt.Errorf("máximo %d notificaciones por operación", maxIDsPorAccion)
+	}
+	return nil
+}
+
+// El cursor codifica (created_at, id) de la última notificación devuelta
+func codificarCursor(fecha time.Time, id uuid.UUID) string {
+	raw := fecha.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodificarCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("cursor inválido")
+	}
+
+	partes := strings.SplitN(string(raw), "|", 2)
+	if len(partes) != 2 {
+		return time.Time{}, uuid.Nil, fmt.Errorf("cursor inválido")
+	}
+
+	fecha, err := time.Parse(time.RFC3339Nano, partes[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("cursor inválido")
+	}
+	id, err := uuid.Parse(partes[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("cursor inválido")
+	}
+
+	return fecha, id, nil
+}
+
+// ==================== RETENCIÓN ====================
+
+// Eliminar notificaciones leídas más antiguas que la edad indicada
+func (s *NotificationService) PurgarLeidasAntiguas(edad time.Duration) (int, error) {
+	eliminadas, err := s.repo.PurgarLeidasAntesDe(time.Now().Add(-edad))
+	if err != nil {
+		return 0, fmt.Errorf("error al purgar notificaciones: %w", err)
+	}
+	return eliminadas, nil
+}
+
+// IniciarRetencion purga periódicamente las notificaciones leídas antiguas
+func (s *NotificationService) IniciarRetencion(ctx context.Context, intervalo, edad time.Duration) {
+	if edad <= 0 {
+		log.Println("⚠️ Retención de notificaciones desactivada")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for {
+			if n, err := s.PurgarLeidasAntiguas(edad); err != nil {
+				log.Printf("❌ %v", err)
+			} else if n > 0 {
+				log.Printf("🧹 Notificaciones leídas purgadas: %d", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
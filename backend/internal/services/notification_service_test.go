@@ -0,0 +1,80 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"recetario-backend/internal/config"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// supabaseFalso responde los dispositivos del usuario y registra los tokens desactivados
+type supabaseFalso struct {
+	mu           sync.Mutex
+	desactivados []string
+}
+
+func (f *supabaseFalso) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/usuario_devices") {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Write([]byte(`[{"fcm_token":"vivo"},{"fcm_token":"muerto"},{"fcm_token":"payload"}]`))
+	case http.MethodPatch:
+		f.mu.Lock()
+		f.desactivados = append(f.desactivados, strings.TrimPrefix(r.URL.Query().Get("fcm_token"), "eq."))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestEnviarPushDesactivaTokensInvalidos(t *testing.T) {
+	supabase := &supabaseFalso{}
+	srv := httptest.NewServer(supabase)
+	defer srv.Close()
+
+	anterior := config.AppConfig
+	config.AppConfig = &config.Config{SupabaseURL: srv.URL}
+	defer func() { config.AppConfig = anterior }()
+
+	fake := NewFakeMessagingClient()
+	fake.MarcarTokenInvalido("muerto")
+	fake.ErroresPorToken["payload"] = &ErrorFCM{Codigo: CodigoFCMInvalidArgument, Mensaje: "data demasiado grande"}
+	firebase := NewFirebaseServiceWithClient(fake)
+
+	servicio := NewNotificationService(
+		repository.NewNotificationRepository(repository.NewSupabaseClient(), nil),
+		firebase, nil, nil, nil, nil, nil,
+	)
+
+	if !servicio.enviarPush(uuid.New(), "Hola", "Mensaje", nil) {
+		t.Fatal("se esperaba que al menos un dispositivo recibiera el push")
+	}
+
+	sort.Strings(supabase.desactivados)
+	if len(supabase.desactivados) != 1 || supabase.desactivados[0] != "muerto" {
+		t.Fatalf("tokens desactivados = %v, se esperaba solo [muerto]", supabase.desactivados)
+	}
+
+	m := servicio.ObtenerMetricasPush()
+	if m == nil || m.Enviados != 3 || m.Exitosos != 1 || m.TokensInvalidos != 1 {
+		t.Fatalf("métricas inesperadas: %+v", m)
+	}
+}
+
+func TestObtenerMetricasPushSinFirebase(t *testing.T) {
+	servicio := NewNotificationService(nil, nil, nil, nil, nil, nil, nil)
+	if m := servicio.ObtenerMetricasPush(); m != nil {
+		t.Fatalf("sin Firebase no debería haber métricas, se obtuvo %+v", m)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Tamaño A4 en puntos
+const (
+	anchoPaginaPDF = 595.28
+	altoPaginaPDF  = 841.89
+)
+
+// documentoPDF arma un PDF de texto simple con las fuentes estándar
+// Helvetica y Helvetica-Bold (no se incrustan). El texto se codifica en
+// WinAnsi, suficiente para el español.
+type documentoPDF struct {
+	paginas []*bytes.Buffer
+	actual  *bytes.Buffer
+	pie     string // se agrega al pie de cada página junto al número
+}
+
+func nuevoDocumentoPDF() *documentoPDF {
+	d := &documentoPDF{}
+	d.NuevaPagina()
+	return d
+}
+
+// NuevaPagina agrega una página en blanco y la deja como actual
+func (d *documentoPDF) NuevaPagina() {
+	d.actual = &bytes.Buffer{}
+	d.paginas = append(d.paginas, d.actual)
+}
+
+// Texto escribe en (x, y) desde la esquina inferior izquierda de la página
+func (d *documentoPDF) Texto(x, y, tamano float64, negrita bool, texto string) {
+	fuente := "F1"
+	if negrita {
+		fuente = "F2"
+	}
+	fmt.Fprintf(d.actual, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", fuente, tamano, x, y, textoPDF(texto))
+}
+
+// Color cambia el color de relleno (texto y rectángulos); componentes de 0 a 1
+func (d *documentoPDF) Color(r, g, b float64) {
+	fmt.Fprintf(d.actual, "%.3f %.3f %.3f rg\n", r, g, b)
+}
+
+// Rectangulo dibuja un rectángulo relleno con el color actual
+func (d *documentoPDF) Rectangulo(x, y, ancho, alto float64) {
+	fmt.Fprintf(d.actual, "%.2f %.2f %.2f %.2f re f\n", x, y, ancho, alto)
+}
+
+// Linea dibuja una línea fina gris
+func (d *documentoPDF) Linea(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.actual, "0.7 G 0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// Bytes serializa el documento con numeración de páginas
+func (d *documentoPDF) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := []int{}
+	objeto := func(contenido string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), contenido)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catálogo, 2: árbol de páginas, 3-4: fuentes, luego página + contenido
+	total := len(d.paginas)
+	kids := make([]string, total)
+	for i := range d.paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	objeto("<< /Type /Catalog /Pages 2 0 R >>")
+	objeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), total))
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, pagina := range d.paginas {
+		contenido := pagina.String()
+		pie := fmt.Sprintf("Página %d de %d", i+1, total)
+		if d.pie != "" {
+			pie = d.pie + " - " + pie
+		}
+		contenido += fmt.Sprintf("0 g BT /F1 8.0 Tf 50.00 30.00 Td (%s) Tj ET\n", textoPDF(pie))
+
+		objeto(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			anchoPaginaPDF, altoPaginaPDF, 6+i*2))
+		objeto(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(contenido), contenido))
+	}
+
+	inicioXref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, inicioXref)
+
+	return out.Bytes()
+}
+
+// Caracteres de WinAnsi fuera de Latin-1
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// textoPDF codifica el texto en WinAnsi y escapa los caracteres especiales
+func textoPDF(texto string) string {
+	var b strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b.WriteByte(byte(r))
+		case winAnsiExtra[r] != 0:
+			b.WriteByte(winAnsiExtra[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// recortarTexto limita el texto a n caracteres para que quepa en una columna
+func recortarTexto(texto string, n int) string {
+	runas := []rune(texto)
+	if len(runas) <= n {
+		return texto
+	}
+	return string(runas[:n-1]) + "…"
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"recetario-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Nota mínima por defecto de un prerrequisito (la aprobatoria vigesimal)
+const notaMinimaPorDefecto = 10.5
+
+// ErrMotivoOmisionRequerido: omitir prerrequisitos exige dejar constancia del motivo
+var ErrMotivoOmisionRequerido = errors.New("indica el motivo para omitir los prerrequisitos")
+
+// PrerrequisitosPendientesError se devuelve cuando el estudiante no cumple
+// algún prerrequisito del curso y no hubo autorización para omitirlos
+type PrerrequisitosPendientesError struct {
+	Pendientes []models.RequisitoPendiente
+}
+
+func (e *PrerrequisitosPendientesError) Error() string {
+	codigos := make([]string, len(e.Pendientes))
+	for i, p := range e.Pendientes {
+		codigos[i] = p.RequisitoCodigo
+	}
+	return "el estudiante no cumple los prerrequisitos: " + strings.Join(codigos, ", ")
+}
+
+// ==================== DEFINICIÓN ====================
+
+// ListarPrerrequisitos devuelve los prerrequisitos del curso (por su código)
+func (s *MatriculaService) ListarPrerrequisitos(cursoID string) ([]models.Prerrequisito, error) {
+	curso, err := s.obtenerCursoMatricula(cursoID)
+	if err != nil {
+		return nil, err
+	}
+	if curso.Codigo == "" {
+		return []models.Prerrequisito{}, nil
+	}
+	return s.prerrequisitoRepo.GetByCursoCodigo(context.Background(), curso.Codigo)
+}
+
+// AgregarPrerrequisito define que el curso requiere otro curso aprobado.
+// Aplica a todas las ediciones del curso porque se guarda por código.
+func (s *MatriculaService) AgregarPrerrequisito(cursoID string, req *models.CrearPrerrequisitoRequest) (*models.Prerrequisito, error) {
+	curso, err := s.obtenerCursoMatricula(cursoID)
+	if err != nil {
+		return nil, err
+	}
+	if curso.Codigo == "" {
+		return nil, fmt.Errorf("asigna un código al curso antes de definir prerrequisitos")
+	}
+
+	requisito := normalizarCodigoCurso(req.RequisitoCodigo)
+	if requisito == "" {
+		return nil, fmt.Errorf("el código del curso requisito es obligatorio")
+	}
+	if requisito == curso.Codigo {
+		return nil, fmt.Errorf("un curso no puede ser prerrequisito de sí mismo")
+	}
+
+	notaMinima := notaMinimaPorDefecto
+	if req.NotaMinima != nil {
+		if *req.NotaMinima < 0 {
+			return nil, fmt.Errorf("la nota mínima no puede ser negativa")
+		}
+		notaMinima = *req.NotaMinima
+	}
+
+	// Evitar ciclos directos (A requiere B y B requiere A)
+	inversos, err := s.prerrequisitoRepo.GetByCursoCodigo(context.Background(), requisito)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range inversos {
+		if p.RequisitoCodigo == curso.Codigo {
+			return nil, fmt.Errorf("%s ya es prerrequisito de %s", curso.Codigo, requisito)
+		}
+	}
+
+	return s.prerrequisitoRepo.Create(context.Background(), &models.Prerrequisito{
+		CursoCodigo:     curso.Codigo,
+		RequisitoCodigo: requisito,
+		NotaMinima:      notaMinima,
+	})
+}
+
+// EliminarPrerrequisito quita un prerrequisito
+func (s *MatriculaService) EliminarPrerrequisito(id uuid.UUID) error {
+	return s.prerrequisitoRepo.Delete(context.Background(), id)
+}
+
+// ==================== VERIFICACIÓN ====================
+
+// validarPrerrequisitos rechaza la matrícula si faltan prerrequisitos,
+// salvo que un administrador haya autorizado la omisión con un motivo
+func (s *MatriculaService) validarPrerrequisitos(req *models.CrearMatriculaRequest, curso *models.Curso) error {
+	if req.OmitirPrerrequisitos {
+		if req.MotivoOmision == nil || strings.TrimSpace(*req.MotivoOmision) == "" {
+			return ErrMotivoOmisionRequerido
+		}
+		if req.OmitidoPor == "" {
+			return ErrSinPermiso
+		}
+		log.Printf("⚠️ Prerrequisitos omitidos para %s en %s por %s: %s",
+			req.EstudianteID, curso.ID, req.OmitidoPor, *req.MotivoOmision)
+		return nil
+	}
+
+	evaluador, err := s.cargarPrerrequisitos(curso, req.EstudianteID)
+	if err != nil {
+		return err
+	}
+	if pendientes := evaluador.pendientes(req.EstudianteID); len(pendientes) > 0 {
+		return &PrerrequisitosPendientesError{Pendientes: pendientes}
+	}
+	return nil
+}
+
+// evaluadorPrerrequisitos tiene los requisitos del curso y la mejor nota de
+// cada estudiante en cada curso requisito
+type evaluadorPrerrequisitos struct {
+	requisitos []models.Prerrequisito
+	mejores    map[string]map[string]float64 // estudiante -> código -> nota
+}
+
+// pendientes devuelve lo que le falta al estudiante; nil si cumple o el curso no tiene requisitos
+func (e *evaluadorPrerrequisitos) pendientes(estudianteID string) []models.RequisitoPendiente {
+	if e == nil {
+		return nil
+	}
+	return evaluarRequisitos(e.requisitos, e.mejores[estudianteID])
+}
+
+// cargarPrerrequisitos consulta los requisitos del curso y las notas previas
+// (de un estudiante o, con estudianteID vacío, de todos). Devuelve nil si el
+// curso no tiene código o no tiene prerrequisitos.
+func (s *MatriculaService) cargarPrerrequisitos(curso *models.Curso, estudianteID string) (*evaluadorPrerrequisitos, error) {
+	if curso.Codigo == "" || s.prerrequisitoRepo == nil {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	requisitos, err := s.prerrequisitoRepo.GetByCursoCodigo(ctx, curso.Codigo)
+	if err != nil {
+		return nil, err
+	}
+	if len(requisitos) == 0 {
+		return nil, nil
+	}
+
+	codigos := make([]string, len(requisitos))
+	for i, r := range requisitos {
+		codigos[i] = r.RequisitoCodigo
+	}
+	notas, err := s.prerrequisitoRepo.GetNotasPorCodigo(ctx, codigos, estudianteID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mejor nota por requisito, sin contar el ciclo del propio curso
+	mejores := make(map[string]map[string]float64)
+	for _, n := range notas {
+		if n.NotaFinal == nil || n.CicloID == curso.CicloID {
+			continue
+		}
+		if mejores[n.EstudianteID] == nil {
+			mejores[n.EstudianteID] = make(map[string]float64)
+		}
+		codigo := normalizarCodigoCurso(n.Curso.Codigo)
+		if actual, ok := mejores[n.EstudianteID][codigo]; !ok || *n.NotaFinal > actual {
+			mejores[n.EstudianteID][codigo] = *n.NotaFinal
+		}
+	}
+
+	return &evaluadorPrerrequisitos{requisitos: requisitos, mejores: mejores}, nil
+}
+
+// evaluarRequisitos compara las mejores notas del estudiante con cada requisito
+func evaluarRequisitos(requisitos []models.Prerrequisito, mejores map[string]float64) []models.RequisitoPendiente {
+	var faltan []models.RequisitoPendiente
+	for _, r := range requisitos {
+		nota, ok := mejores[r.RequisitoCodigo]
+		if ok && nota >= r.NotaMinima {
+			continue
+		}
+		pendiente := models.RequisitoPendiente{
+			RequisitoCodigo: r.RequisitoCodigo,
+			NotaMinima:      r.NotaMinima,
+		}
+		if ok {
+			pendiente.MejorNota = &nota
+		}
+		faltan = append(faltan, pendiente)
+	}
+	return faltan
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Umbrales con los que un estudiante se marca como rezagado
+const (
+	umbralMaterialesRezago  = 50.0 // % mínimo de materiales vistos
+	umbralPuntualidadRezago = 50.0 // % mínimo de entregas a tiempo
+	maxPendientesVencidas   = 2    // tareas vencidas sin entregar
+	minEntregasPuntualidad  = 2    // entregas necesarias para juzgar la puntualidad
+)
+
+// ProgresoService calcula el avance de cada estudiante en un curso a partir
+// de los materiales vistos y las entregas
+type ProgresoService struct {
+	materialRepo  *repository.MaterialRepository
+	tareaRepo     *repository.TareaRepository
+	entregaRepo   *repository.EntregaRepository
+	matriculaRepo repository.MatriculaRepository
+	cursoRepo     repository.CursoRepository
+}
+
+func NewProgresoService(
+	materialRepo *repository.MaterialRepository,
+	tareaRepo *repository.TareaRepository,
+	entregaRepo *repository.EntregaRepository,
+	matriculaRepo repository.MatriculaRepository,
+	cursoRepo repository.CursoRepository,
+) *ProgresoService {
+	return &ProgresoService{
+		materialRepo:  materialRepo,
+		tareaRepo:     tareaRepo,
+		entregaRepo:   entregaRepo,
+		matriculaRepo: matriculaRepo,
+		cursoRepo:     cursoRepo,
+	}
+}
+
+// ObtenerProgresoCurso devuelve la matriz de avance para el docente.
+// Con soloRezagados se filtran los estudiantes que se están quedando atrás.
+func (s *ProgresoService) ObtenerProgresoCurso(ctx context.Context, cursoID, usuarioID, rol string, soloRezagados bool) (*models.ProgresoCurso, error) {
+	if _, err := validarDocenteOAdmin(s.cursoRepo, cursoID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	progreso, err := s.armarProgreso(ctx, cursoID, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if soloRezagados {
+		rezagados := make([]models.ProgresoEstudiante, 0, progreso.Rezagados)
+		for _, p := range progreso.Estudiantes {
+			if p.Rezagado {
+				rezagados = append(rezagados, p)
+			}
+		}
+		progreso.Estudiantes = rezagados
+	}
+
+	return progreso, nil
+}
+
+// ObtenerMiProgreso devuelve el resumen del estudiante con sus tareas pendientes
+func (s *ProgresoService) ObtenerMiProgreso(ctx context.Context, cursoID, estudianteID string) (*models.ProgresoEstudiante, error) {
+	progreso, err := s.armarProgreso(ctx, cursoID, estudianteID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(progreso.Estudiantes) == 0 {
+		return nil, ErrSinAccesoCurso
+	}
+
+	return &progreso.Estudiantes[0], nil
+}
+
+// armarProgreso carga materiales, vistas, tareas, matrículas y entregas del
+// curso. Con estudianteID solo se calcula la fila de ese estudiante; fuera de
+// la vista docente las notas sin publicar no cuentan como calificadas.
+func (s *ProgresoService) armarProgreso(ctx context.Context, cursoID, estudianteID string, vistaDocente bool) (*models.ProgresoCurso, error) {
+	cursoUUID, err := uuid.Parse(cursoID)
+	if err != nil {
+		return nil, ErrCursoNoEncontrado
+	}
+	if _, err := obtenerCurso(s.cursoRepo, cursoID); err != nil {
+		return nil, err
+	}
+
+	materiales, err := s.materialRepo.GetByCursoID(ctx, cursoID)
+	if err != nil {
+		return nil, err
+	}
+	materialIDs := make([]uuid.UUID, 0, len(materiales))
+	for _, m := range materiales {
+		if m.Activo {
+			materialIDs = append(materialIDs, m.ID)
+		}
+	}
+	vistos, err := s.materialRepo.GetVistosPorMateriales(ctx, materialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tareas, err := s.tareaRepo.GetByCursoID(ctx, cursoUUID)
+	if err != nil {
+		return nil, err
+	}
+	tareaIDs := make([]uuid.UUID, 0, len(tareas))
+	for _, t := range tareas {
+		tareaIDs = append(tareaIDs, t.ID)
+	}
+	entregas, err := s.entregaRepo.GetByTareaIDs(ctx, tareaIDs)
+	if err != nil {
+		return nil, err
+	}
+	ocultarNoPublicadas(tareas, entregas, vistaDocente)
+
+	respBody, err := s.matriculaRepo.GetMatriculasByCurso(cursoID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener matrículas: %w", err)
+	}
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return nil, fmt.Errorf("error al parsear matrículas")
+	}
+	activas := make([]models.Matricula, 0, len(matriculas))
+	for _, m := range matriculas {
+		if m.Estado != "activo" {
+			continue
+		}
+		if estudianteID != "" && m.EstudianteID != estudianteID {
+			continue
+		}
+		activas = append(activas, m)
+	}
+
+	progreso := CalcularProgreso(cursoID, materiales, vistos, tareas, activas, entregas, vistaDocente, time.Now())
+	if vistaDocente {
+		for i := range progreso.Estudiantes {
+			progreso.Estudiantes[i].Pendientes = nil
+		}
+	} else {
+		// El estudiante no ve las vistas de sus compañeros
+		progreso.Materiales = nil
+		progreso.PromedioMateriales = nil
+		progreso.PromedioEntregas = nil
+	}
+
+	return progreso, nil
+}
+
+// CalcularProgreso arma la matriz de avance. Es puro: no accede a la base de datos.
+// Fuera de la vista docente solo cuentan como calificadas las tareas que ya
+// publicaron sus notas.
+func CalcularProgreso(cursoID string, materiales []models.Material, vistos []models.MaterialVisto, tareas []models.Tarea, matriculas []models.Matricula, entregas []models.Entrega, vistaDocente bool, ahora time.Time) *models.ProgresoCurso {
+	activos := make([]models.Material, 0, len(materiales))
+	for _, m := range materiales {
+		if m.Activo {
+			activos = append(activos, m)
+		}
+	}
+
+	asignadas := make([]models.Tarea, 0, len(tareas))
+	for _, t := range tareas {
+		if t.Activo && !t.FechaPublicacion.After(ahora) {
+			asignadas = append(asignadas, t)
+		}
+	}
+	sort.SliceStable(asignadas, func(i, j int) bool { return asignadas[i].FechaLimite.Before(asignadas[j].FechaLimite) })
+
+	enCurso := make(map[uuid.UUID]bool, len(matriculas))
+	for _, m := range matriculas {
+		if id, err := uuid.Parse(m.EstudianteID); err == nil {
+			enCurso[id] = true
+		}
+	}
+
+	// Vistas por estudiante y por material (solo de estudiantes del curso)
+	vistosPor := make(map[uuid.UUID]map[uuid.UUID]bool)
+	vistasMaterial := make(map[uuid.UUID]int)
+	for _, v := range vistos {
+		if !enCurso[v.EstudianteID] {
+			continue
+		}
+		if vistosPor[v.EstudianteID] == nil {
+			vistosPor[v.EstudianteID] = make(map[uuid.UUID]bool)
+		}
+		if !vistosPor[v.EstudianteID][v.MaterialID] {
+			vistosPor[v.EstudianteID][v.MaterialID] = true
+			vistasMaterial[v.MaterialID]++
+		}
+	}
+
+	entregaDe := make(map[uuid.UUID]map[uuid.UUID]*models.Entrega)
+	for i := range entregas {
+		e := &entregas[i]
+		if entregaDe[e.EstudianteID] == nil {
+			entregaDe[e.EstudianteID] = make(map[uuid.UUID]*models.Entrega)
+		}
+		entregaDe[e.EstudianteID][e.TareaID] = e
+	}
+
+	progreso := &models.ProgresoCurso{
+		CursoID:         cursoID,
+		MaterialesTotal: len(activos),
+		TareasAsignadas: len(asignadas),
+		Estudiantes:     make([]models.ProgresoEstudiante, 0, len(matriculas)),
+		Materiales:      make([]models.Material, 0, len(activos)),
+	}
+
+	var sumaMateriales, sumaEntregas float64
+	var conMateriales, conEntregas int
+	for i := range matriculas {
+		m := &matriculas[i]
+		p := models.ProgresoEstudiante{
+			MatriculaID:     m.ID,
+			EstudianteID:    m.EstudianteID,
+			MaterialesTotal: len(activos),
+			TareasAsignadas: len(asignadas),
+			Alertas:         []string{},
+		}
+		p.Codigo, p.Nombre = datosEstudiante(m)
+		estudianteID, _ := uuid.Parse(m.EstudianteID)
+
+		for _, mat := range activos {
+			if vistosPor[estudianteID][mat.ID] {
+				p.MaterialesVistos++
+			}
+		}
+
+		for _, t := range asignadas {
+			vencida := t.FechaLimite.Before(ahora)
+			if vencida {
+				p.TareasVencidas++
+			}
+
+			e := entregaDe[estudianteID][t.ID]
+			if e == nil {
+				if vencida {
+					p.PendientesVencidas++
+				}
+				p.Pendientes = append(p.Pendientes, models.TareaPendiente{
+					TareaID:     t.ID,
+					Titulo:      t.Titulo,
+					FechaLimite: t.FechaLimite,
+					Vencida:     vencida,
+				})
+				continue
+			}
+
+			p.TareasEntregadas++
+			if !e.EntregaTardia {
+				p.EntregasATiempo++
+			}
+			if e.Calificacion != nil && (vistaDocente || calificacionesPublicadas(&t)) {
+				p.TareasCalificadas++
+			}
+		}
+
+		if p.MaterialesTotal > 0 {
+			p.PorcentajeMateriales = redondear2(float64(p.MaterialesVistos) * 100 / float64(p.MaterialesTotal))
+			sumaMateriales += *p.PorcentajeMateriales
+			conMateriales++
+		}
+		if p.TareasAsignadas > 0 {
+			p.PorcentajeEntregas = redondear2(float64(p.TareasEntregadas) * 100 / float64(p.TareasAsignadas))
+			sumaEntregas += *p.PorcentajeEntregas
+			conEntregas++
+		}
+		if p.TareasEntregadas > 0 {
+			p.PorcentajePuntualidad = redondear2(float64(p.EntregasATiempo) * 100 / float64(p.TareasEntregadas))
+		}
+
+		marcarRezago(&p)
+		if p.Rezagado {
+			progreso.Rezagados++
+		}
+		progreso.Estudiantes = append(progreso.Estudiantes, p)
+	}
+
+	if conMateriales > 0 {
+		progreso.PromedioMateriales = redondear2(sumaMateriales / float64(conMateriales))
+	}
+	if conEntregas > 0 {
+		progreso.PromedioEntregas = redondear2(sumaEntregas / float64(conEntregas))
+	}
+
+	for _, mat := range activos {
+		mat.CantidadVistos = vistasMaterial[mat.ID]
+		mat.TotalEstudiantes = len(matriculas)
+		progreso.Materiales = append(progreso.Materiales, mat)
+	}
+
+	// Los rezagados primero para que el docente los vea de inmediato
+	sort.SliceStable(progreso.Estudiantes, func(i, j int) bool {
+		a, b := progreso.Estudiantes[i], progreso.Estudiantes[j]
+		if a.Rezagado != b.Rezagado {
+			return a.Rezagado
+		}
+		return a.Nombre < b.Nombre
+	})
+
+	return progreso
+}
+
+// marcarRezago agrega una alerta por cada criterio que el estudiante no cumple
+func marcarRezago(p *models.ProgresoEstudiante) {
+	if p.PendientesVencidas >= maxPendientesVencidas {
+		p.Alertas = append(p.Alertas, fmt.Sprintf("%d tareas vencidas sin entregar", p.PendientesVencidas))
+	}
+	if p.PorcentajeMateriales != nil && *p.PorcentajeMateriales < umbralMaterialesRezago {
+		p.Alertas = append(p.Alertas, fmt.Sprintf("vio %d de %d materiales", p.MaterialesVistos, p.MaterialesTotal))
+	}
+	if p.TareasEntregadas >= minEntregasPuntualidad && p.PorcentajePuntualidad != nil &&
+		*p.PorcentajePuntualidad < umbralPuntualidadRezago {
+		p.Alertas = append(p.Alertas, fmt.Sprintf("%d de %d entregas fuera de plazo",
+			p.TareasEntregadas-p.EntregasATiempo, p.TareasEntregadas))
+	}
+	p.Rezagado = len(p.Alertas) > 0
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSolicitudNoEncontrada = errors.New("solicitud de recalificación no encontrada")
+	ErrSolicitudResuelta     = errors.New("la solicitud de recalificación ya fue resuelta")
+	ErrSolicitudDuplicada    = errors.New("ya existe una solicitud de recalificación pendiente para esta entrega")
+)
+
+const maxJustificacionRecalificacion = 2000
+
+// esSolicitudDuplicada detecta el índice de una sola solicitud pendiente por entrega
+func esSolicitudDuplicada(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "23505") || strings.Contains(err.Error(), "duplicate key"))
+}
+
+// RecalificacionService expone el historial de notas y los reclamos de los estudiantes
+type RecalificacionService struct {
+	recalificacionRepo  *repository.RecalificacionRepository
+	entregaRepo         *repository.EntregaRepository
+	tareaRepo           *repository.TareaRepository
+	cursoRepo           repository.CursoRepository
+	tareaService        *TareaService
+	notificationService *NotificationService
+}
+
+func NewRecalificacionService(
+	recalificacionRepo *repository.RecalificacionRepository,
+	entregaRepo *repository.EntregaRepository,
+	tareaRepo *repository.TareaRepository,
+	cursoRepo repository.CursoRepository,
+	tareaService *TareaService,
+	notificationService *NotificationService,
+) *RecalificacionService {
+	return &RecalificacionService{
+		recalificacionRepo:  recalificacionRepo,
+		entregaRepo:         entregaRepo,
+		tareaRepo:           tareaRepo,
+		cursoRepo:           cursoRepo,
+		tareaService:        tareaService,
+		notificationService: notificationService,
+	}
+}
+
+// entregaConTarea carga la entrega y valida que el usuario pueda verla:
+// el estudiante dueño (con notas publicadas) o el docente del curso
+func (s *RecalificacionService) entregaConTarea(ctx context.Context, entregaID uuid.UUID, usuarioID, rol string) (*models.Entrega, *models.Tarea, error) {
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("entrega no encontrada: %w", err)
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+
+	if rol == "estudiante" {
+		if entrega.EstudianteID.String() != usuarioID {
+			return nil, nil, fmt.Errorf("%w: la entrega no te pertenece", ErrSinPermiso)
+		}
+		if !calificacionesPublicadas(tarea) {
+			return nil, nil, fmt.Errorf("%w: las calificaciones de esta tarea aún no se publican", ErrSinPermiso)
+		}
+		return entrega, tarea, nil
+	}
+
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, nil, err
+	}
+	return entrega, tarea, nil
+}
+
+// ==================== HISTORIAL ====================
+
+// ObtenerHistorial devuelve los cambios de calificación de la entrega
+func (s *RecalificacionService) ObtenerHistorial(ctx context.Context, entregaID uuid.UUID, usuarioID, rol string) ([]models.CambioCalificacion, error) {
+	if _, _, err := s.entregaConTarea(ctx, entregaID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return s.entregaRepo.GetHistorial(ctx, entregaID)
+}
+
+// ==================== SOLICITUDES ====================
+
+// Solicitar abre un reclamo del estudiante sobre su nota publicada
+func (s *RecalificacionService) Solicitar(ctx context.Context, entregaID uuid.UUID, estudianteID string, req *models.SolicitarRecalificacionRequest) (*models.SolicitudRecalificacion, error) {
+	entrega, tarea, err := s.entregaConTarea(ctx, entregaID, estudianteID, "estudiante")
+	if err != nil {
+		return nil, err
+	}
+	if entrega.Calificacion == nil {
+		return nil, fmt.Errorf("la entrega aún no tiene calificación")
+	}
+
+	justificacion := strings.TrimSpace(req.Justificacion)
+	if justificacion == "" {
+		return nil, fmt.Errorf("la justificación es obligatoria")
+	}
+	if utf8.RuneCountInString(justificacion) > maxJustificacionRecalificacion {
+		return nil, fmt.Errorf("la justificación no puede superar %d caracteres", maxJustificacionRecalificacion)
+	}
+
+	previas, err := s.recalificacionRepo.GetByEntregaID(ctx, entregaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range previas {
+		if p.Estado == models.RecalificacionPendiente {
+			return nil, ErrSolicitudDuplicada
+		}
+	}
+
+	solicitud, err := s.recalificacionRepo.Create(ctx, &models.SolicitudRecalificacion{
+		EntregaID:            entregaID,
+		EstudianteID:         entrega.EstudianteID,
+		Justificacion:        justificacion,
+		CalificacionAnterior: entrega.Calificacion,
+	})
+	if err != nil {
+		// Dos pedidos simultáneos pasan la verificación de arriba; el índice
+		// de solicitud pendiente única rechaza al segundo
+		if esSolicitudDuplicada(err) {
+			return nil, ErrSolicitudDuplicada
+		}
+		return nil, err
+	}
+
+	go s.avisarDocente(tarea, solicitud)
+	return solicitud, nil
+}
+
+// ListarSolicitudes devuelve los reclamos de la entrega
+func (s *RecalificacionService) ListarSolicitudes(ctx context.Context, entregaID uuid.UUID, usuarioID, rol string) ([]models.SolicitudRecalificacion, error) {
+	if _, _, err := s.entregaConTarea(ctx, entregaID, usuarioID, rol); err != nil {
+		return nil, err
+	}
+	return s.recalificacionRepo.GetByEntregaID(ctx, entregaID)
+}
+
+// Resolver acepta o rechaza una solicitud pendiente. Al aceptar se aplica la
+// nueva nota (queda en el historial con origen "recalificacion").
+func (s *RecalificacionService) Resolver(ctx context.Context, entregaID, solicitudID uuid.UUID, usuarioID, rol string, req *models.ResolverRecalificacionRequest) (*models.SolicitudRecalificacion, error) {
+	if rol == "estudiante" {
+		return nil, ErrSinPermiso
+	}
+	entrega, tarea, err := s.entregaConTarea(ctx, entregaID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+
+	solicitud, err := s.recalificacionRepo.GetByID(ctx, solicitudID)
+	if err != nil || solicitud.EntregaID != entregaID {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	if solicitud.Estado != models.RecalificacionPendiente {
+		return nil, ErrSolicitudResuelta
+	}
+
+	respuesta := strings.TrimSpace(req.Respuesta)
+	resueltaPor, err := uuid.Parse(usuarioID)
+	if err != nil {
+		return nil, fmt.Errorf("usuario inválido")
+	}
+
+	if !req.Aceptar {
+		if respuesta == "" {
+			return nil, fmt.Errorf("indica el motivo del rechazo")
+		}
+		if err := s.cerrar(ctx, solicitud, models.RecalificacionRechazada, nil, respuesta, resueltaPor); err != nil {
+			return nil, err
+		}
+		go s.avisarEstudiante(tarea, solicitud)
+		return solicitud, nil
+	}
+
+	calificar, err := s.nuevaCalificacion(ctx, entrega, tarea, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Primero se reclama la solicitud para que dos docentes no la apliquen a la vez
+	if err := s.cerrar(ctx, solicitud, models.RecalificacionAceptada, &calificar.Calificacion, respuesta, resueltaPor); err != nil {
+		return nil, err
+	}
+	if err := s.tareaService.registrarCalificacion(ctx, entregaID, resueltaPor, calificar, models.OrigenRecalificacion); err != nil {
+		if errReabrir := s.recalificacionRepo.Reabrir(ctx, solicitudID); errReabrir != nil {
+			log.Printf("❌ No se pudo reabrir la solicitud %s: %v", solicitudID, errReabrir)
+		}
+		return nil, err
+	}
+
+	go s.avisarEstudiante(tarea, solicitud)
+	return solicitud, nil
+}
+
+// nuevaCalificacion arma la nota aceptada: por rúbrica o un valor directo
+// entre 0 y el puntaje máximo. Se conserva el comentario del docente.
+func (s *RecalificacionService) nuevaCalificacion(ctx context.Context, entrega *models.Entrega, tarea *models.Tarea, req *models.ResolverRecalificacionRequest) (*models.CalificarEntregaRequest, error) {
+	calificar := &models.CalificarEntregaRequest{Rubrica: req.Rubrica}
+	if entrega.ComentarioDocente != nil {
+		calificar.ComentarioDocente = *entrega.ComentarioDocente
+	}
+
+	switch {
+	case len(req.Rubrica) > 0:
+		_, total, err := s.tareaService.evaluarRubrica(ctx, entrega.ID, req.Rubrica)
+		if err != nil {
+			return nil, err
+		}
+		calificar.Calificacion = total
+	case req.Calificacion != nil:
+		if *req.Calificacion < 0 || *req.Calificacion > tarea.PuntajeMaximo {
+			return nil, fmt.Errorf("la calificación debe estar entre 0 y %.2f", tarea.PuntajeMaximo)
+		}
+		calificar.Calificacion = *req.Calificacion
+	default:
+		return nil, fmt.Errorf("indica la nueva calificación o los niveles de la rúbrica")
+	}
+
+	return calificar, nil
+}
+
+func (s *RecalificacionService) cerrar(ctx context.Context, solicitud *models.SolicitudRecalificacion, estado string, calificacion *float64, respuesta string, resueltaPor uuid.UUID) error {
+	ok, err := s.recalificacionRepo.Resolver(ctx, solicitud.ID, estado, calificacion, respuesta, resueltaPor)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSolicitudResuelta
+	}
+
+	solicitud.Estado = estado
+	solicitud.CalificacionNueva = calificacion
+	solicitud.Respuesta = &respuesta
+	solicitud.ResueltaPor = &resueltaPor
+	return nil
+}
+
+func (s *RecalificacionService) avisarDocente(tarea *models.Tarea, solicitud *models.SolicitudRecalificacion) {
+	if s.notificationService == nil {
+		return
+	}
+	curso, err := obtenerCurso(s.cursoRepo, tarea.CursoID.String())
+	if err != nil {
+		log.Printf("⚠️ No se pudo avisar la solicitud de recalificación %s: %v", solicitud.ID, err)
+		return
+	}
+	docenteID, err := uuid.Parse(curso.DocenteID)
+	if err != nil {
+		return
+	}
+	if err := s.notificationService.NotificarSolicitudRecalificacion(docenteID, tarea, curso.Nombre, solicitud); err != nil {
+		log.Printf("❌ Error notificando solicitud de recalificación: %v", err)
+	}
+}
+
+func (s *RecalificacionService) avisarEstudiante(tarea *models.Tarea, solicitud *models.SolicitudRecalificacion) {
+	if s.notificationService == nil {
+		return
+	}
+	if err := s.notificationService.NotificarResolucionRecalificacion(tarea, solicitud); err != nil {
+		log.Printf("❌ Error notificando resolución de recalificación: %v", err)
+	}
+}
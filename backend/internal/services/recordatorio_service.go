@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Anticipación con la que se recuerda una fecha límite
+const anticipacionRecordatorio = 24 * time.Hour
+
+// RecordatorioService avisa a los estudiantes que aún no entregan
+// una tarea próxima a vencer
+type RecordatorioService struct {
+	tareaRepo           *repository.TareaRepository
+	entregaRepo         *repository.EntregaRepository
+	matriculaRepo       repository.MatriculaRepository
+	notificationRepo    *repository.NotificationRepository
+	notificationService *NotificationService
+}
+
+func NewRecordatorioService(
+	tareaRepo *repository.TareaRepository,
+	entregaRepo *repository.EntregaRepository,
+	matriculaRepo repository.MatriculaRepository,
+	notificationRepo *repository.NotificationRepository,
+	notificationService *NotificationService,
+) *RecordatorioService {
+	return &RecordatorioService{
+		tareaRepo:           tareaRepo,
+		entregaRepo:         entregaRepo,
+		matriculaRepo:       matriculaRepo,
+		notificationRepo:    notificationRepo,
+		notificationService: notificationService,
+	}
+}
+
+// Iniciar ejecuta la revisión periódicamente hasta que se cancele el contexto
+func (s *RecordatorioService) Iniciar(ctx context.Context, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		s.EnviarRecordatorios(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.EnviarRecordatorios(ctx)
+			}
+		}
+	}()
+}
+
+// EnviarRecordatorios revisa las tareas que vencen en las próximas horas
+func (s *RecordatorioService) EnviarRecordatorios(ctx context.Context) int {
+	ahora := time.Now()
+	tareas, err := s.tareaRepo.GetProximasAVencer(ctx, ahora, ahora.Add(anticipacionRecordatorio))
+	if err != nil {
+		log.Printf("❌ Error buscando tareas por vencer: %v", err)
+		return 0
+	}
+
+	enviados := 0
+	for i := range tareas {
+		enviados += s.recordarTarea(ctx, &tareas[i])
+	}
+
+	if enviados > 0 {
+		log.Printf("⏰ Recordatorios de entrega enviados: %d", enviados)
+	}
+	return enviados
+}
+
+func (s *RecordatorioService) recordarTarea(ctx context.Context, tarea *models.Tarea) int {
+	respBody, err := s.matriculaRepo.GetMatriculasByCurso(tarea.CursoID.String())
+	if err != nil {
+		log.Printf("❌ Error obteniendo matrículas del curso %s: %v", tarea.CursoID, err)
+		return 0
+	}
+
+	var matriculas []models.Matricula
+	if err := json.Unmarshal(respBody, &matriculas); err != nil {
+		return 0
+	}
+
+	entregas, err := s.entregaRepo.GetByTareaID(ctx, tarea.ID)
+	if err != nil {
+		log.Printf("❌ Error obteniendo entregas de la tarea %s: %v", tarea.ID, err)
+		return 0
+	}
+
+	entregaron := make(map[uuid.UUID]bool, len(entregas))
+	for _, e := range entregas {
+		entregaron[e.EstudianteID] = true
+	}
+
+	curso := s.notificationService.nombreCurso(tarea.CursoID.String())
+
+	enviados := 0
+	for _, m := range matriculas {
+		if m.Estado != "activo" {
+			continue
+		}
+
+		estudianteID, err := uuid.Parse(m.EstudianteID)
+		if err != nil || entregaron[estudianteID] {
+			continue
+		}
+
+		// Solo un recordatorio por tarea y estudiante
+		nuevo, err := s.notificationRepo.RegistrarRecordatorio(tarea.ID, estudianteID)
+		if err != nil {
+			log.Printf("❌ Error registrando recordatorio: %v", err)
+			continue
+		}
+		if !nuevo {
+			continue
+		}
+
+		if err := s.notificationService.NotificarRecordatorioEntrega(estudianteID, tarea, curso); err != nil {
+			log.Printf("❌ Error enviando recordatorio a %s: %v", estudianteID, err)
+			continue
+		}
+		enviados++
+	}
+
+	return enviados
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrRubricaNoEncontrada se devuelve si la plantilla no existe o fue eliminada
+	ErrRubricaNoEncontrada = errors.New("rúbrica no encontrada")
+	// ErrEvaluacionInvalida agrupa los errores al calificar con rúbrica
+	ErrEvaluacionInvalida = errors.New("evaluación de rúbrica inválida")
+)
+
+func errEvaluacion(formato string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrEvaluacionInvalida, fmt.Sprintf(formato, args...))
+}
+
+type RubricaService struct {
+	rubricaRepo *repository.RubricaRepository
+	tareaRepo   *repository.TareaRepository
+	cursoRepo   repository.CursoRepository
+}
+
+func NewRubricaService(
+	rubricaRepo *repository.RubricaRepository,
+	tareaRepo *repository.TareaRepository,
+	cursoRepo repository.CursoRepository,
+) *RubricaService {
+	return &RubricaService{
+		rubricaRepo: rubricaRepo,
+		tareaRepo:   tareaRepo,
+		cursoRepo:   cursoRepo,
+	}
+}
+
+// ==================== PLANTILLAS ====================
+
+// CrearRubrica guarda una plantilla nueva del docente
+func (s *RubricaService) CrearRubrica(ctx context.Context, usuarioID string, req *models.RubricaRequest) (*models.Rubrica, error) {
+	criterios, err := normalizarCriterios(req.Criterios)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Nombre) == "" {
+		return nil, fmt.Errorf("el nombre de la rúbrica es obligatorio")
+	}
+
+	data := map[string]interface{}{
+		"nombre":      strings.TrimSpace(req.Nombre),
+		"descripcion": req.Descripcion,
+		"creado_por":  usuarioID,
+		"criterios":   criterios,
+		"activo":      true,
+	}
+
+	rubrica, err := s.rubricaRepo.Create(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	rubrica.PuntajeTotal = PuntajeMaximoRubrica(rubrica.Criterios)
+	return rubrica, nil
+}
+
+// ListarRubricas devuelve las plantillas activas
+func (s *RubricaService) ListarRubricas(ctx context.Context) ([]models.Rubrica, error) {
+	rubricas, err := s.rubricaRepo.ListActivas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rubricas {
+		rubricas[i].PuntajeTotal = PuntajeMaximoRubrica(rubricas[i].Criterios)
+	}
+	return rubricas, nil
+}
+
+// ObtenerRubrica devuelve una plantilla activa
+func (s *RubricaService) ObtenerRubrica(ctx context.Context, rubricaID uuid.UUID) (*models.Rubrica, error) {
+	rubrica, err := s.rubricaRepo.GetByID(ctx, rubricaID)
+	if err != nil || !rubrica.Activo {
+		return nil, ErrRubricaNoEncontrada
+	}
+	rubrica.PuntajeTotal = PuntajeMaximoRubrica(rubrica.Criterios)
+	return rubrica, nil
+}
+
+// ActualizarRubrica edita la plantilla; solo su autor o un administrador.
+// Las tareas que ya la usan conservan su copia.
+func (s *RubricaService) ActualizarRubrica(ctx context.Context, rubricaID uuid.UUID, usuarioID, rol string, req *models.RubricaRequest) error {
+	if _, err := s.rubricaEditable(ctx, rubricaID, usuarioID, rol); err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	if strings.TrimSpace(req.Nombre) != "" {
+		data["nombre"] = strings.TrimSpace(req.Nombre)
+	}
+	if req.Descripcion != nil {
+		data["descripcion"] = *req.Descripcion
+	}
+	if req.Criterios != nil {
+		criterios, err := normalizarCriterios(req.Criterios)
+		if err != nil {
+			return err
+		}
+		data["criterios"] = criterios
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no hay datos para actualizar")
+	}
+
+	return s.rubricaRepo.Update(ctx, rubricaID, data)
+}
+
+// EliminarRubrica desactiva la plantilla (las tareas conservan su copia)
+func (s *RubricaService) EliminarRubrica(ctx context.Context, rubricaID uuid.UUID, usuarioID, rol string) error {
+	if _, err := s.rubricaEditable(ctx, rubricaID, usuarioID, rol); err != nil {
+		return err
+	}
+	return s.rubricaRepo.Update(ctx, rubricaID, map[string]interface{}{"activo": false})
+}
+
+func (s *RubricaService) rubricaEditable(ctx context.Context, rubricaID uuid.UUID, usuarioID, rol string) (*models.Rubrica, error) {
+	rubrica, err := s.ObtenerRubrica(ctx, rubricaID)
+	if err != nil {
+		return nil, err
+	}
+	if rol != "administrador" && (rubrica.CreadoPor == nil || rubrica.CreadoPor.String() != usuarioID) {
+		return nil, fmt.Errorf("%w: solo el autor puede modificar la rúbrica", ErrSinPermiso)
+	}
+	return rubrica, nil
+}
+
+// normalizarCriterios valida la estructura y asigna IDs a criterios y niveles
+func normalizarCriterios(criterios []models.CriterioRubrica) ([]models.CriterioRubrica, error) {
+	if len(criterios) == 0 {
+		return nil, fmt.Errorf("la rúbrica debe tener al menos un criterio")
+	}
+
+	resultado := make([]models.CriterioRubrica, 0, len(criterios))
+	for i, c := range criterios {
+		c.Nombre = strings.TrimSpace(c.Nombre)
+		if c.Nombre == "" {
+			return nil, fmt.Errorf("el criterio %d no tiene nombre", i+1)
+		}
+		if len(c.Niveles) < 2 {
+			return nil, fmt.Errorf("el criterio '%s' debe tener al menos dos niveles", c.Nombre)
+		}
+		if c.ID == "" {
+			c.ID = uuid.New().String()
+		}
+
+		niveles := make([]models.NivelRubrica, 0, len(c.Niveles))
+		for _, n := range c.Niveles {
+			n.Nombre = strings.TrimSpace(n.Nombre)
+			if n.Nombre == "" {
+				return nil, fmt.Errorf("hay un nivel sin nombre en el criterio '%s'", c.Nombre)
+			}
+			if n.Puntaje < 0 {
+				return nil, fmt.Errorf("el nivel '%s' de '%s' no puede tener puntaje negativo", n.Nombre, c.Nombre)
+			}
+			if n.ID == "" {
+				n.ID = uuid.New().String()
+			}
+			niveles = append(niveles, n)
+		}
+		c.Niveles = niveles
+		resultado = append(resultado, c)
+	}
+
+	if PuntajeMaximoRubrica(resultado) <= 0 {
+		return nil, fmt.Errorf("el puntaje máximo de la rúbrica debe ser mayor a cero")
+	}
+
+	return resultado, nil
+}
+
+// PuntajeMaximoRubrica suma el nivel más alto de cada criterio
+func PuntajeMaximoRubrica(criterios []models.CriterioRubrica) float64 {
+	total := 0.0
+	for _, c := range criterios {
+		total += maximoCriterio(&c)
+	}
+	return total
+}
+
+func maximoCriterio(c *models.CriterioRubrica) float64 {
+	maximo := 0.0
+	for _, n := range c.Niveles {
+		maximo = math.Max(maximo, n.Puntaje)
+	}
+	return maximo
+}
+
+// ==================== RÚBRICA DE TAREA ====================
+
+// ObtenerRubricaDeTarea devuelve la rúbrica con la que se califica la tarea (nil si no tiene)
+func (s *RubricaService) ObtenerRubricaDeTarea(ctx context.Context, tareaID uuid.UUID) (*models.RubricaTarea, error) {
+	rubrica, err := s.rubricaRepo.GetDeTarea(ctx, tareaID)
+	if err != nil || rubrica == nil {
+		return rubrica, err
+	}
+	rubrica.PuntajeTotal = PuntajeMaximoRubrica(rubrica.Criterios)
+	return rubrica, nil
+}
+
+// AsignarRubrica copia la plantilla a la tarea; el puntaje máximo de la
+// rúbrica debe coincidir con el de la tarea
+func (s *RubricaService) AsignarRubrica(ctx context.Context, tareaID, rubricaID uuid.UUID, usuarioID, rol string) (*models.RubricaTarea, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada")
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+
+	plantilla, err := s.ObtenerRubrica(ctx, rubricaID)
+	if err != nil {
+		return nil, err
+	}
+	if math.Abs(plantilla.PuntajeTotal-tarea.PuntajeMaximo) > 0.001 {
+		return nil, fmt.Errorf("la rúbrica suma %.2f puntos y la tarea tiene puntaje máximo %.2f",
+			plantilla.PuntajeTotal, tarea.PuntajeMaximo)
+	}
+
+	asignada := &models.RubricaTarea{
+		TareaID:      tareaID,
+		RubricaID:    &plantilla.ID,
+		Nombre:       plantilla.Nombre,
+		Criterios:    plantilla.Criterios,
+		PuntajeTotal: plantilla.PuntajeTotal,
+	}
+	if err := s.rubricaRepo.AsignarATarea(ctx, asignada); err != nil {
+		return nil, err
+	}
+
+	return asignada, nil
+}
+
+// QuitarRubrica deja la tarea con calificación numérica simple
+func (s *RubricaService) QuitarRubrica(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) error {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return fmt.Errorf("tarea no encontrada")
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return err
+	}
+	return s.rubricaRepo.QuitarDeTarea(ctx, tareaID)
+}
+
+// Evaluar convierte los niveles elegidos en el detalle por criterio y el total.
+// Todos los criterios deben evaluarse y el total no puede superar el puntaje máximo.
+func (s *RubricaService) Evaluar(rubrica *models.RubricaTarea, puntajeMaximo float64, elegidos []models.EvaluarCriterioRequest) ([]models.CriterioEvaluado, float64, error) {
+	porCriterio := make(map[string]models.EvaluarCriterioRequest, len(elegidos))
+	for _, e := range elegidos {
+		if _, repetido := porCriterio[e.CriterioID]; repetido {
+			return nil, 0, errEvaluacion("el criterio %s se evaluó más de una vez", e.CriterioID)
+		}
+		porCriterio[e.CriterioID] = e
+	}
+
+	detalle := make([]models.CriterioEvaluado, 0, len(rubrica.Criterios))
+	total := 0.0
+	for i := range rubrica.Criterios {
+		c := &rubrica.Criterios[i]
+		elegido, ok := porCriterio[c.ID]
+		if !ok {
+			return nil, 0, errEvaluacion("falta evaluar el criterio '%s'", c.Nombre)
+		}
+		delete(porCriterio, c.ID)
+
+		var nivel *models.NivelRubrica
+		for j := range c.Niveles {
+			if c.Niveles[j].ID == elegido.NivelID {
+				nivel = &c.Niveles[j]
+				break
+			}
+		}
+		if nivel == nil {
+			return nil, 0, errEvaluacion("nivel inválido para el criterio '%s'", c.Nombre)
+		}
+
+		detalle = append(detalle, models.CriterioEvaluado{
+			CriterioID:    c.ID,
+			Criterio:      c.Nombre,
+			NivelID:       nivel.ID,
+			Nivel:         nivel.Nombre,
+			Puntaje:       nivel.Puntaje,
+			PuntajeMaximo: maximoCriterio(c),
+			Comentario:    strings.TrimSpace(elegido.Comentario),
+		})
+		total += nivel.Puntaje
+	}
+
+	if len(porCriterio) > 0 {
+		return nil, 0, errEvaluacion("se enviaron criterios que no pertenecen a la rúbrica de la tarea")
+	}
+	if total > puntajeMaximo+0.001 {
+		return nil, 0, errEvaluacion("el total de la rúbrica (%.2f) supera el puntaje máximo de la tarea (%.2f)", total, puntajeMaximo)
+	}
+
+	return detalle, math.Round(total*100) / 100, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -125,6 +126,63 @@ func (s *StorageService) DeleteFile(fileURL string) error {
 	return nil
 }
 
+// CopyFile duplica un archivo del bucket dentro de folder y devuelve la URL
+// pública de la copia. Falla si la URL no pertenece al bucket.
+func (s *StorageService) CopyFile(fileURL, folder string) (string, error) {
+	if !s.IsStoredFile(fileURL) {
+		return "", fmt.Errorf("el archivo no está en el bucket %s", s.bucket)
+	}
+	sourcePath, err := s.extractPathFromURL(fileURL)
+	if err != nil {
+		return "", fmt.Errorf("error al extraer path: %w", err)
+	}
+
+	// Mismo esquema de nombres que UploadFile, con nanosegundos para que
+	// varias copias en el mismo segundo no se pisen
+	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), filepath.Ext(sourcePath))
+	destPath := fmt.Sprintf("%s/%s", folder, filename)
+
+	payload, err := json.Marshal(map[string]string{
+		"bucketId":       s.bucket,
+		"sourceKey":      sourcePath,
+		"destinationKey": destPath,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creando request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", s.storageURL+"/object/copy", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("error creando request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error copiando archivo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("error copiando archivo: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	publicURL := fmt.Sprintf("%s/object/public/%s/%s", s.storageURL, s.bucket, destPath)
+	fmt.Printf("✅ Archivo copiado: %s -> %s\n", sourcePath, destPath)
+
+	return publicURL, nil
+}
+
+// IsStoredFile indica si la URL apunta a un archivo de nuestro bucket
+// (los enlaces externos no se pueden copiar ni eliminar)
+func (s *StorageService) IsStoredFile(fileURL string) bool {
+	return strings.HasPrefix(fileURL, fmt.Sprintf("%s/object/public/%s/", s.storageURL, s.bucket))
+}
+
 // ✅ NUEVO: extractPathFromURL extrae el path relativo desde una URL completa
 // Ejemplo: https://xxx.supabase.co/storage/v1/object/public/archivos/entregas/abc/file.pdf
 // Resultado: entregas/abc/file.pdf
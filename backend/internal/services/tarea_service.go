@@ -3,7 +3,12 @@ package services
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"recetario-backend/internal/models"
 	"recetario-backend/internal/repository"
@@ -12,18 +17,37 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// ErrCalificacionesYaPublicadas se devuelve al publicar una tarea ya publicada
+var ErrCalificacionesYaPublicadas = errors.New("las calificaciones de esta tarea ya fueron publicadas")
+
 type TareaService struct {
-	tareaRepo   *repository.TareaRepository
-	entregaRepo *repository.EntregaRepository
+	tareaRepo           *repository.TareaRepository
+	entregaRepo         *repository.EntregaRepository
+	cursoRepo           repository.CursoRepository
+	notificationService *NotificationService
+
+	calificacionesService *CalificacionesService
+	rubricaService        *RubricaService
+	temaService           *TemaService
 }
 
 func NewTareaService(
 	tareaRepo *repository.TareaRepository,
 	entregaRepo *repository.EntregaRepository,
+	cursoRepo repository.CursoRepository,
+	notificationService *NotificationService,
+	calificacionesService *CalificacionesService,
+	rubricaService *RubricaService,
+	temaService *TemaService,
 ) *TareaService {
 	return &TareaService{
-		tareaRepo:   tareaRepo,
-		entregaRepo: entregaRepo,
+		tareaRepo:             tareaRepo,
+		entregaRepo:           entregaRepo,
+		cursoRepo:             cursoRepo,
+		notificationService:   notificationService,
+		calificacionesService: calificacionesService,
+		rubricaService:        rubricaService,
+		temaService:           temaService,
 	}
 }
 
@@ -34,14 +58,23 @@ func (s *TareaService) CrearTarea(ctx context.Context, req *models.CreateTareaRe
 
 // Obtener entregas de una tarea (para docente)
 func (s *TareaService) ObtenerEntregasDeTarea(ctx context.Context, tareaID uuid.UUID) ([]models.Entrega, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+
 	entregas, err := s.entregaRepo.GetByTareaID(ctx, tareaID)
 	if err != nil {
 		return nil, fmt.Errorf("error obteniendo entregas: %w", err)
 	}
+	anonimizarEntregas(tarea, entregas)
+	if err := adjuntarVersionAlCierre(ctx, s.entregaRepo, tarea, entregas); err != nil {
+		return nil, err
+	}
 
 	// Para cada entrega, cargar archivos
 	for i := range entregas {
-		archivos, err := s.entregaRepo.GetArchivosByEntregaID(ctx, entregas[i].ID)
+		archivos, err := archivosDeEntrega(ctx, s.entregaRepo, &entregas[i])
 		if err != nil {
 			return nil, fmt.Errorf("error obteniendo archivos: %w", err)
 		}
@@ -51,9 +84,276 @@ func (s *TareaService) ObtenerEntregasDeTarea(ctx context.Context, tareaID uuid.
 	return entregas, nil
 }
 
-// Calificar entrega
-func (s *TareaService) CalificarEntrega(ctx context.Context, entregaID uuid.UUID, req *models.CalificarEntregaRequest) error {
-	return s.entregaRepo.Calificar(ctx, entregaID, req.Calificacion, req.ComentarioDocente)
+// Calificar entrega (con rúbrica, la calificación es la suma de los niveles elegidos)
+func (s *TareaService) CalificarEntrega(ctx context.Context, entregaID, usuarioID uuid.UUID, req *models.CalificarEntregaRequest) error {
+	return s.registrarCalificacion(ctx, entregaID, usuarioID, req, models.OrigenCalificacion)
+}
+
+// registrarCalificacion guarda la nota con su autor y origen. Solo la
+// calificación directa avisa al estudiante; la recalificación notifica
+// su propio resultado. Si la tarea tiene rúbrica, la nota sale de sus
+// criterios; si no, debe estar entre 0 y el puntaje máximo. Lo guardado es
+// la nota menos la penalización por retraso.
+func (s *TareaService) registrarCalificacion(ctx context.Context, entregaID, usuarioID uuid.UUID, req *models.CalificarEntregaRequest, origen string) error {
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return fmt.Errorf("entrega no encontrada: %w", err)
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return fmt.Errorf("tarea no encontrada: %w", err)
+	}
+
+	var detalle []models.CriterioEvaluado
+	if len(req.Rubrica) > 0 {
+		evaluacion, total, err := s.evaluarRubrica(ctx, entregaID, req.Rubrica)
+		if err != nil {
+			return err
+		}
+		detalle = evaluacion
+		req.Calificacion = total
+	} else {
+		if s.rubricaService != nil {
+			rubrica, err := s.rubricaService.ObtenerRubricaDeTarea(ctx, tarea.ID)
+			if err != nil {
+				return err
+			}
+			if rubrica != nil {
+				return errEvaluacion("la tarea tiene rúbrica: califica eligiendo un nivel por criterio")
+			}
+		}
+		if req.Calificacion < 0 || req.Calificacion > tarea.PuntajeMaximo {
+			return errEvaluacion("la calificación debe estar entre 0 y %.2f", tarea.PuntajeMaximo)
+		}
+	}
+
+	return s.guardarCalificacion(ctx, entrega, &models.NuevaCalificacion{
+		Calificacion:  notaConPenalizacion(req.Calificacion, entrega),
+		Comentario:    req.ComentarioDocente,
+		Rubrica:       detalle,
+		CalificadoPor: &usuarioID,
+		Origen:        origen,
+	})
+}
+
+// guardarCalificacion persiste una nota ya validada, repartiéndola al grupo
+// si la entrega es grupal
+func (s *TareaService) guardarCalificacion(ctx context.Context, entrega *models.Entrega, nueva *models.NuevaCalificacion) error {
+	if entrega.GrupoID != nil {
+		return s.calificarGrupo(ctx, entrega, nueva)
+	}
+
+	if err := s.entregaRepo.GuardarCalificacion(ctx, entrega.ID, nueva); err != nil {
+		if esCicloCerrado(err) {
+			return ErrCicloCerrado
+		}
+		return err
+	}
+
+	go s.despuesDeCalificar(entrega.ID, nueva.Calificacion, nueva.Comentario, nueva.Origen == models.OrigenCalificacion)
+	return nil
+}
+
+// calificarGrupo reparte la nota del grupo a la entrega de cada integrante,
+// sumando el ajuste individual que tenga cada uno. Todas las entregas del
+// grupo se actualizan en una sola transacción (calificar_grupo).
+func (s *TareaService) calificarGrupo(ctx context.Context, entrega *models.Entrega, nueva *models.NuevaCalificacion) error {
+	integrantes, err := s.entregaRepo.CalificarGrupo(ctx, entrega.TareaID, *entrega.GrupoID, nueva)
+	if err != nil {
+		if esCicloCerrado(err) {
+			return ErrCicloCerrado
+		}
+		return err
+	}
+
+	for _, integrante := range integrantes {
+		if integrante.Calificacion == nil {
+			continue
+		}
+		go s.despuesDeCalificar(integrante.ID, *integrante.Calificacion, nueva.Comentario, nueva.Origen == models.OrigenCalificacion)
+	}
+	return nil
+}
+
+// AjustarCalificacionIndividual fija los puntos que un integrante gana o
+// pierde respecto de la nota del grupo. Si el grupo ya está calificado, la
+// nota del integrante se recalcula de inmediato.
+func (s *TareaService) AjustarCalificacionIndividual(ctx context.Context, entregaID, usuarioID uuid.UUID, rol string, req *models.AjusteIndividualRequest) (*models.Entrega, error) {
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return nil, fmt.Errorf("entrega no encontrada: %w", err)
+	}
+	if entrega.GrupoID == nil {
+		return nil, errEvaluacion("solo las entregas grupales admiten ajuste individual")
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID.String(), rol); err != nil {
+		return nil, err
+	}
+
+	var motivo *string
+	if texto := strings.TrimSpace(req.Motivo); texto != "" {
+		motivo = &texto
+	}
+	if req.Ajuste != 0 && motivo == nil {
+		return nil, errEvaluacion("indica el motivo del ajuste")
+	}
+	if req.Ajuste < -tarea.PuntajeMaximo || req.Ajuste > tarea.PuntajeMaximo {
+		return nil, errEvaluacion("el ajuste debe estar entre %.2f y %.2f", -tarea.PuntajeMaximo, tarea.PuntajeMaximo)
+	}
+
+	// El ajuste y la nota recalculada se guardan juntos; con el ciclo
+	// cerrado no se guarda ninguno de los dos
+	ajustada, err := s.entregaRepo.AjustarCalificacionIndividual(ctx, entregaID, req.Ajuste, motivo, usuarioID)
+	if err != nil {
+		if esCicloCerrado(err) {
+			return nil, ErrCicloCerrado
+		}
+		return nil, err
+	}
+
+	if ajustada.CalificacionGrupal != nil && ajustada.Calificacion != nil {
+		comentario := ""
+		if ajustada.ComentarioDocente != nil {
+			comentario = *ajustada.ComentarioDocente
+		}
+		go s.despuesDeCalificar(entregaID, *ajustada.Calificacion, comentario, true)
+	}
+
+	return s.entregaRepo.GetByID(ctx, entregaID)
+}
+
+// notaConPenalizacion descuenta la penalización por retraso de la entrega.
+// La usan todos los caminos que registran una nota para que el resultado
+// no dependa de si se calificó a mano, por planilla o por cuestionario.
+func notaConPenalizacion(calificacion float64, entrega *models.Entrega) float64 {
+	return *redondear2(math.Max(0, calificacion-entrega.PenalizacionAplicada))
+}
+
+func (s *TareaService) evaluarRubrica(ctx context.Context, entregaID uuid.UUID, elegidos []models.EvaluarCriterioRequest) ([]models.CriterioEvaluado, float64, error) {
+	if s.rubricaService == nil {
+		return nil, 0, fmt.Errorf("calificación por rúbrica no disponible")
+	}
+
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("entrega no encontrada: %w", err)
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+
+	rubrica, err := s.rubricaService.ObtenerRubricaDeTarea(ctx, tarea.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rubrica == nil {
+		return nil, 0, errEvaluacion("la tarea no tiene una rúbrica asignada")
+	}
+
+	return s.rubricaService.Evaluar(rubrica, tarea.PuntajeMaximo, elegidos)
+}
+
+// Avisar al estudiante (push o email) que su entrega fue calificada
+// y recalcular su nota final en el curso. Si la tarea aún no publica sus
+// notas no se hace nada: el aviso y el recálculo ocurren al publicar.
+func (s *TareaService) despuesDeCalificar(entregaID uuid.UUID, calificacion float64, comentario string, notificar bool) {
+	if s.notificationService == nil && s.calificacionesService == nil {
+		return
+	}
+
+	ctx := context.Background()
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		log.Printf("⚠️ No se pudo notificar calificación de %s: %v", entregaID, err)
+		return
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		log.Printf("⚠️ No se pudo notificar calificación de %s: %v", entregaID, err)
+		return
+	}
+	if !calificacionesPublicadas(tarea) {
+		return
+	}
+
+	s.calificacionesService.RecalcularEnSegundoPlano(tarea.CursoID.String(), entrega.EstudianteID.String())
+
+	if s.notificationService == nil || !notificar {
+		return
+	}
+	if err := s.notificationService.NotificarCalificacion(entrega.EstudianteID, tarea, entregaID, calificacion, comentario); err != nil {
+		log.Printf("❌ Error notificando calificación: %v", err)
+	}
+}
+
+// PublicarCalificaciones hace visibles a la vez todas las notas de la tarea
+// y avisa a cada estudiante calificado. Las que se califiquen después se
+// muestran y notifican de inmediato.
+func (s *TareaService) PublicarCalificaciones(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) (*models.PublicacionCalificaciones, error) {
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID, rol); err != nil {
+		return nil, err
+	}
+	if calificacionesPublicadas(tarea) {
+		return nil, ErrCalificacionesYaPublicadas
+	}
+
+	entregas, err := s.entregaRepo.GetByTareaID(ctx, tareaID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo entregas: %w", err)
+	}
+
+	ahora := time.Now()
+	publicada, err := s.tareaRepo.PublicarCalificaciones(ctx, tareaID, ahora)
+	if err != nil {
+		return nil, err
+	}
+	if !publicada {
+		return nil, ErrCalificacionesYaPublicadas
+	}
+	tarea.CalificacionesPublicadasAt = &ahora
+
+	resumen := &models.PublicacionCalificaciones{TareaID: tareaID, PublicadasAt: ahora}
+	calificadas := make([]models.Entrega, 0, len(entregas))
+	for _, e := range entregas {
+		if e.Calificacion == nil {
+			resumen.SinCalificar++
+			continue
+		}
+		calificadas = append(calificadas, e)
+	}
+	resumen.Calificadas = len(calificadas)
+
+	s.calificacionesService.RecalcularEnSegundoPlano(tarea.CursoID.String(), "")
+	go s.notificarPublicacion(tarea, calificadas)
+
+	log.Printf("📋 Calificaciones publicadas: tarea %s (%d calificadas, %d sin calificar)",
+		tareaID, resumen.Calificadas, resumen.SinCalificar)
+	return resumen, nil
+}
+
+func (s *TareaService) notificarPublicacion(tarea *models.Tarea, entregas []models.Entrega) {
+	if s.notificationService == nil {
+		return
+	}
+
+	for _, e := range entregas {
+		comentario := ""
+		if e.ComentarioDocente != nil {
+			comentario = *e.ComentarioDocente
+		}
+		if err := s.notificationService.NotificarCalificacion(e.EstudianteID, tarea, e.ID, *e.Calificacion, comentario); err != nil {
+			log.Printf("❌ Error notificando calificación de %s: %v", e.ID, err)
+		}
+	}
 }
 
 // ========================================
@@ -70,34 +370,58 @@ func (s *TareaService) GetTareasByTemaID(ctx context.Context, temaID uuid.UUID)
 }
 
 // Obtener tarea por ID
-func (s *TareaService) GetTareaByID(ctx context.Context, tareaID uuid.UUID) (*models.Tarea, error) {
+func (s *TareaService) GetTareaByID(ctx context.Context, tareaID uuid.UUID, usuarioID, rol string) (*models.Tarea, error) {
 	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
 	if err != nil {
 		return nil, fmt.Errorf("tarea no encontrada: %w", err)
 	}
+
+	// El estudiante no ve tareas de temas que aún no desbloquea
+	if rol == "estudiante" {
+		estudianteID, err := uuid.Parse(usuarioID)
+		if err != nil {
+			return nil, ErrSinPermiso
+		}
+		if err := tareaBloqueada(ctx, s.temaService, tarea, estudianteID); err != nil {
+			return nil, err
+		}
+	}
 	return tarea, nil
 }
 
 // Actualizar tarea
 func (s *TareaService) UpdateTarea(ctx context.Context, tareaID uuid.UUID, req *models.CreateTareaRequest) error {
 	// Verificar que la tarea existe
-	_, err := s.tareaRepo.GetByID(ctx, tareaID)
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
 	if err != nil {
 		return fmt.Errorf("tarea no encontrada: %w", err)
 	}
 
+	// Pasar de individual a grupal (o al revés) rompería las entregas ya hechas
+	if req.EntregaGrupal != tarea.EntregaGrupal {
+		entregas, err := s.entregaRepo.GetByTareaID(ctx, tareaID)
+		if err != nil {
+			return fmt.Errorf("error obteniendo entregas: %w", err)
+		}
+		if len(entregas) > 0 {
+			return fmt.Errorf("la tarea ya tiene entregas: no se puede cambiar si es grupal")
+		}
+	}
+
 	// Actualizar
 	if err := s.tareaRepo.Update(ctx, tareaID, req); err != nil {
 		return fmt.Errorf("error actualizando tarea: %w", err)
 	}
 
+	// Puntaje, tipo o fecha límite pueden cambiar las notas finales
+	s.calificacionesService.RecalcularEnSegundoPlano(tarea.CursoID.String(), "")
 	return nil
 }
 
 // Eliminar tarea
 func (s *TareaService) DeleteTarea(ctx context.Context, tareaID uuid.UUID) error {
 	// Verificar que la tarea existe
-	_, err := s.tareaRepo.GetByID(ctx, tareaID)
+	tarea, err := s.tareaRepo.GetByID(ctx, tareaID)
 	if err != nil {
 		return fmt.Errorf("tarea no encontrada: %w", err)
 	}
@@ -107,6 +431,7 @@ func (s *TareaService) DeleteTarea(ctx context.Context, tareaID uuid.UUID) error
 		return fmt.Errorf("error eliminando tarea: %w", err)
 	}
 
+	s.calificacionesService.RecalcularEnSegundoPlano(tarea.CursoID.String(), "")
 	return nil
 }
 
@@ -135,6 +460,7 @@ func (s *TareaService) ExportarEntregasExcel(ctx context.Context, tareaID uuid.U
 	}
 
 	nombreTarea := tarea.Titulo
+	anonimizarEntregas(tarea, entregas)
 
 	// Crear un nuevo archivo Excel
 	f := excelize.NewFile()
@@ -212,6 +538,9 @@ func (s *TareaService) ExportarEntregasExcel(ctx context.Context, tareaID uuid.U
 
 		nombreEstudiante := "-"
 		emailEstudiante := "-"
+		if entrega.Alias != "" {
+			nombreEstudiante = entrega.Alias
+		}
 		if entrega.Estudiante != nil {
 			nombreEstudiante = entrega.Estudiante.Usuario.NombreCompleto // ✅ CORRECTO
 			emailEstudiante = entrega.Estudiante.Usuario.Email           // ✅ CORRECTO
@@ -11,25 +11,38 @@ import (
 )
 
 type TemaService struct {
-	temaRepo    *repository.TemaRepository
-	tareaRepo   *repository.TareaRepository
-	entregaRepo *repository.EntregaRepository
+	temaRepo            *repository.TemaRepository
+	tareaRepo           *repository.TareaRepository
+	entregaRepo         *repository.EntregaRepository
+	materialRepo        *repository.MaterialRepository
+	cursoRepo           repository.CursoRepository
+	matriculaRepo       repository.MatriculaRepository
+	notificationService *NotificationService
 }
 
 func NewTemaService(
 	temaRepo *repository.TemaRepository,
 	tareaRepo *repository.TareaRepository,
 	entregaRepo *repository.EntregaRepository,
+	materialRepo *repository.MaterialRepository,
+	cursoRepo repository.CursoRepository,
+	matriculaRepo repository.MatriculaRepository,
+	notificationService *NotificationService,
 ) *TemaService {
 	return &TemaService{
-		temaRepo:    temaRepo,
-		tareaRepo:   tareaRepo,
-		entregaRepo: entregaRepo,
+		temaRepo:            temaRepo,
+		tareaRepo:           tareaRepo,
+		entregaRepo:         entregaRepo,
+		materialRepo:        materialRepo,
+		cursoRepo:           cursoRepo,
+		matriculaRepo:       matriculaRepo,
+		notificationService: notificationService,
 	}
 }
 
-// Obtener temas de un curso CON estadísticas de tareas Y mi_entrega para estudiantes
-func (s *TemaService) GetTemasByCursoID(cursoID string, userID interface{}) ([]models.Tema, error) {
+// Obtener temas de un curso CON estadísticas de tareas Y mi_entrega para estudiantes.
+// A los estudiantes los temas bloqueados se les devuelven sin materiales ni tareas.
+func (s *TemaService) GetTemasByCursoID(cursoID string, userID interface{}, rol string) ([]models.Tema, error) {
 	ctx := context.Background()
 	cursoUUID, err := uuid.Parse(cursoID)
 	if err != nil {
@@ -59,8 +72,23 @@ func (s *TemaService) GetTemasByCursoID(cursoID string, userID interface{}) ([]m
 		}
 	}
 
+	if rol == "estudiante" && estudianteID != nil {
+		curso, err := obtenerCurso(s.cursoRepo, cursoID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.aplicarDesbloqueo(ctx, curso, temas, *estudianteID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Para cada tema, cargar sus tareas con estadísticas Y mi_entrega
 	for i := range temas {
+		if temas[i].Bloqueado {
+			ocultarContenido(&temas[i])
+			continue
+		}
+
 		// Obtener tareas del tema
 		tareas, err := s.tareaRepo.GetByTemaID(ctx, temas[i].ID)
 		if err != nil {
@@ -82,8 +110,11 @@ func (s *TemaService) GetTemasByCursoID(cursoID string, userID interface{}) ([]m
 			if estudianteID != nil {
 				miEntrega, err := s.entregaRepo.GetMiEntrega(ctx, tareas[j].ID, *estudianteID)
 				if err == nil && miEntrega != nil {
+					if !calificacionesPublicadas(&tareas[j]) {
+						ocultarCalificacion(miEntrega)
+					}
 					// Cargar archivos de la entrega
-					archivos, err := s.entregaRepo.GetArchivosByEntregaID(ctx, miEntrega.ID)
+					archivos, err := archivosDeEntrega(ctx, s.entregaRepo, miEntrega)
 					if err == nil {
 						miEntrega.Archivos = archivos
 					}
@@ -124,6 +155,11 @@ func (s *TemaService) CrearTema(data map[string]interface{}) (interface{}, error
 
 // Actualizar tema
 func (s *TemaService) ActualizarTema(temaID string, data map[string]interface{}) error {
+	// Con una nueva fecha el aviso de desbloqueo vuelve a quedar pendiente
+	if _, ok := data["fecha_desbloqueo"]; ok {
+		data["desbloqueo_notificado_at"] = nil
+	}
+
 	if err := s.temaRepo.UpdateTema(temaID, data); err != nil {
 		return fmt.Errorf("error al actualizar tema: %w", err)
 	}
@@ -138,8 +174,9 @@ func (s *TemaService) EliminarTema(temaID string) error {
 	return nil
 }
 
-// Obtener tema por ID con materiales y tareas CON estadísticas
-func (s *TemaService) GetTemaByID(temaID string) (models.Tema, error) {
+// Obtener tema por ID con materiales y tareas CON estadísticas.
+// Si el tema sigue bloqueado para el estudiante solo se devuelven sus datos generales.
+func (s *TemaService) GetTemaByID(temaID, usuarioID, rol string) (models.Tema, error) {
 	ctx := context.Background()
 
 	temaUUID, err := uuid.Parse(temaID)
@@ -160,11 +197,27 @@ func (s *TemaService) GetTemaByID(temaID string) (models.Tema, error) {
 	}
 
 	if len(temas) == 0 {
-		return models.Tema{}, fmt.Errorf("tema no encontrado")
+		return models.Tema{}, ErrTemaNoEncontrado
 	}
 
 	tema := temas[0]
 
+	if rol == "estudiante" {
+		estudianteID, err := uuid.Parse(usuarioID)
+		if err != nil {
+			return models.Tema{}, ErrSinPermiso
+		}
+		bloqueado, motivo, err := s.estadoTemaParaEstudiante(ctx, tema.CursoID.String(), tema.ID, estudianteID)
+		if err != nil {
+			return models.Tema{}, err
+		}
+		if bloqueado {
+			tema.Bloqueado, tema.MotivoBloqueo = true, motivo
+			ocultarContenido(&tema)
+			return tema, nil
+		}
+	}
+
 	// Obtener tareas con estadísticas
 	tareas, err := s.tareaRepo.GetByTemaID(ctx, temaUUID)
 	if err == nil {
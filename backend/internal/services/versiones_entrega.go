@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"recetario-backend/internal/models"
+	"recetario-backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Errores de la política de reenvío y de las versiones
+var (
+	ErrEntregaCerrada      = errors.New("el plazo de entrega terminó")
+	ErrReenvioNoPermitido  = errors.New("esta tarea no admite reenvíos: la entrega ya fue enviada")
+	ErrReenviosAgotados    = errors.New("ya usaste todos los reenvíos permitidos para esta tarea")
+	ErrVersionNoEncontrada = errors.New("versión de la entrega no encontrada")
+)
+
+// ventanaEnvio agrupa en un mismo envío los guardados seguidos, como crear
+// la entrega y subir sus archivos uno por uno. Un guardado hecho más tarde
+// que esto desde la última versión es un reenvío.
+const ventanaEnvio = 10 * time.Minute
+
+// ==================== CAMBIOS DEL ESTUDIANTE ====================
+
+// ValidarCambio comprueba, antes de subir un archivo, que el estudiante pueda
+// modificar la entrega ahora
+func (s *EntregaService) ValidarCambio(ctx context.Context, entregaID, estudianteID uuid.UUID) error {
+	if err := s.ValidarPropietario(ctx, entregaID, estudianteID); err != nil {
+		return err
+	}
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return err
+	}
+	_, err = s.prepararCambio(ctx, entrega, time.Now())
+	return err
+}
+
+// prepararCambio aplica la política de la tarea a un nuevo guardado y
+// devuelve el envío al que pertenece
+func (s *EntregaService) prepararCambio(ctx context.Context, entrega *models.Entrega, ahora time.Time) (int, error) {
+	if entrega.Calificacion != nil {
+		return 0, fmt.Errorf("no se puede modificar una entrega ya calificada")
+	}
+
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return 0, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+	if ahora.After(cierreEntregas(tarea)) {
+		return 0, fmt.Errorf("%w: la entrega ya no se puede modificar", ErrEntregaCerrada)
+	}
+
+	ultima, err := s.entregaRepo.GetUltimaVersion(ctx, entrega.ID)
+	if err != nil {
+		return 0, err
+	}
+	envio := siguienteEnvio(ultima, ahora)
+	if envio > 1 {
+		if !tarea.PermiteReenvio {
+			return 0, ErrReenvioNoPermitido
+		}
+		if tarea.MaxReenvios != nil && envio-1 > *tarea.MaxReenvios {
+			return 0, ErrReenviosAgotados
+		}
+	}
+
+	return envio, nil
+}
+
+// registrarVersion guarda la foto actual de la entrega (en las grupales, en
+// la fila de cada integrante). Si falla, la entrega ya está guardada: solo
+// se deja constancia en el log.
+func (s *EntregaService) registrarVersion(ctx context.Context, entrega *models.Entrega, envio int, motivo string, autor uuid.UUID) {
+	var filas []models.Entrega
+	if entrega.GrupoID != nil {
+		grupo, err := s.entregaRepo.GetByTareaAndGrupo(ctx, entrega.TareaID, *entrega.GrupoID)
+		if err != nil {
+			log.Printf("⚠️ No se pudo versionar la entrega %s: %v", entrega.ID, err)
+			return
+		}
+		filas = grupo
+	} else {
+		actual, err := s.entregaRepo.GetByID(ctx, entrega.ID)
+		if err != nil {
+			log.Printf("⚠️ No se pudo versionar la entrega %s: %v", entrega.ID, err)
+			return
+		}
+		filas = []models.Entrega{*actual}
+	}
+	if len(filas) == 0 {
+		return
+	}
+
+	archivos, err := archivosDeEntrega(ctx, s.entregaRepo, &filas[0])
+	if err != nil {
+		log.Printf("⚠️ No se pudo versionar la entrega %s: %v", entrega.ID, err)
+		return
+	}
+	if archivos == nil {
+		archivos = []models.ArchivoEntrega{}
+	}
+
+	versiones := make([]models.VersionEntrega, 0, len(filas))
+	for _, fila := range filas {
+		versiones = append(versiones, models.VersionEntrega{
+			EntregaID:   fila.ID,
+			Envio:       envio,
+			Motivo:      motivo,
+			Titulo:      fila.Titulo,
+			Descripcion: fila.Descripcion,
+			Archivos:    archivos,
+			CreadoPor:   &autor,
+		})
+	}
+
+	if err := s.entregaRepo.CrearVersiones(ctx, versiones); err != nil {
+		log.Printf("⚠️ No se pudo versionar la entrega %s: %v", entrega.ID, err)
+		return
+	}
+	log.Printf("🗂️ Versión guardada para la entrega %s (envío %d, %s)", entrega.ID, envio, motivo)
+}
+
+// ArchivosAlmacenados son todos los archivos que la entrega tiene o tuvo en
+// alguna versión, para limpiarlos del Storage al eliminarla
+func (s *EntregaService) ArchivosAlmacenados(ctx context.Context, entregaID uuid.UUID) ([]models.ArchivoEntrega, error) {
+	archivos, err := s.ObtenerArchivosPorEntregaID(ctx, entregaID)
+	if err != nil {
+		return nil, err
+	}
+	versiones, err := s.entregaRepo.GetVersiones(ctx, entregaID)
+	if err != nil {
+		return nil, err
+	}
+
+	vistos := make(map[string]bool, len(archivos))
+	for _, a := range archivos {
+		vistos[a.URLArchivo] = true
+	}
+	for _, v := range versiones {
+		for _, a := range v.Archivos {
+			if !vistos[a.URLArchivo] {
+				vistos[a.URLArchivo] = true
+				archivos = append(archivos, a)
+			}
+		}
+	}
+	return archivos, nil
+}
+
+// ==================== CONSULTA DE VERSIONES ====================
+
+// ListarVersiones devuelve el historial de la entrega al estudiante (o a su
+// grupo) y al docente del curso. En calificación anónima se oculta el autor.
+func (s *EntregaService) ListarVersiones(ctx context.Context, entregaID, usuarioID uuid.UUID, rol string) (*models.HistorialVersiones, error) {
+	entrega, err := s.entregaRepo.GetByID(ctx, entregaID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVersionNoEncontrada, err)
+	}
+	tarea, err := s.tareaRepo.GetByID(ctx, entrega.TareaID)
+	if err != nil {
+		return nil, fmt.Errorf("tarea no encontrada: %w", err)
+	}
+
+	if rol == "estudiante" {
+		if err := s.ValidarPropietario(ctx, entregaID, usuarioID); err != nil {
+			return nil, err
+		}
+	} else if _, err := validarDocenteOAdmin(s.cursoRepo, tarea.CursoID.String(), usuarioID.String(), rol); err != nil {
+		return nil, err
+	}
+
+	versiones, err := s.entregaRepo.GetVersiones(ctx, entregaID)
+	if err != nil {
+		return nil, err
+	}
+	alCierre := marcarVersiones(tarea, versiones)
+	if rol != "estudiante" && tarea.CalificacionAnonima && !calificacionesPublicadas(tarea) {
+		for i := range versiones {
+			versiones[i].CreadoPor = nil
+		}
+	}
+
+	historial := &models.HistorialVersiones{
+		EntregaID:      entregaID,
+		Versiones:      versiones,
+		PermiteReenvio: tarea.PermiteReenvio,
+	}
+	if alCierre != nil {
+		historial.VersionAlCierre = &alCierre.Numero
+	}
+	if len(versiones) > 0 {
+		historial.ReenviosUsados = versiones[len(versiones)-1].Envio - 1
+	}
+	if tarea.MaxReenvios != nil {
+		restantes := *tarea.MaxReenvios - historial.ReenviosUsados
+		if restantes < 0 || !tarea.PermiteReenvio {
+			restantes = 0
+		}
+		historial.ReenviosRestantes = &restantes
+	}
+
+	return historial, nil
+}
+
+// CompararVersiones muestra qué cambió entre dos versiones de la entrega.
+// Sin números compara la última con la anterior; desde=0 compara contra una
+// entrega vacía.
+func (s *EntregaService) CompararVersiones(ctx context.Context, entregaID, usuarioID uuid.UUID, rol string, desde, hasta *int) (*models.DiferenciaVersiones, error) {
+	historial, err := s.ListarVersiones(ctx, entregaID, usuarioID, rol)
+	if err != nil {
+		return nil, err
+	}
+	if len(historial.Versiones) == 0 {
+		return nil, ErrVersionNoEncontrada
+	}
+
+	numeroHasta := historial.Versiones[len(historial.Versiones)-1].Numero
+	if hasta != nil {
+		numeroHasta = *hasta
+	}
+	numeroDesde := numeroHasta - 1
+	if desde != nil {
+		numeroDesde = *desde
+	}
+
+	b := buscarVersion(historial.Versiones, numeroHasta)
+	if b == nil {
+		return nil, fmt.Errorf("%w: no existe la versión %d", ErrVersionNoEncontrada, numeroHasta)
+	}
+	a := &models.VersionEntrega{Numero: 0}
+	if numeroDesde > 0 {
+		if a = buscarVersion(historial.Versiones, numeroDesde); a == nil {
+			return nil, fmt.Errorf("%w: no existe la versión %d", ErrVersionNoEncontrada, numeroDesde)
+		}
+	}
+
+	return DiferenciarVersiones(a, b), nil
+}
+
+// adjuntarVersionAlCierre agrega a cada entrega la versión vigente a la fecha
+// límite, que es la que el docente debe revisar
+func adjuntarVersionAlCierre(ctx context.Context, entregaRepo *repository.EntregaRepository, tarea *models.Tarea, entregas []models.Entrega) error {
+	ids := make([]uuid.UUID, 0, len(entregas))
+	for _, e := range entregas {
+		ids = append(ids, e.ID)
+	}
+	versiones, err := entregaRepo.GetVersionesByEntregaIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error obteniendo versiones: %w", err)
+	}
+
+	porEntrega := make(map[uuid.UUID][]models.VersionEntrega)
+	for _, v := range versiones {
+		porEntrega[v.EntregaID] = append(porEntrega[v.EntregaID], v)
+	}
+
+	anonima := tarea.CalificacionAnonima && !calificacionesPublicadas(tarea)
+	for i := range entregas {
+		propias := porEntrega[entregas[i].ID]
+		if alCierre := marcarVersiones(tarea, propias); alCierre != nil {
+			version := *alCierre
+			if anonima {
+				version.CreadoPor = nil
+			}
+			entregas[i].VersionAlCierre = &version
+		}
+	}
+	return nil
+}
+
+// ==================== CÁLCULO ====================
+
+// siguienteEnvio numera el envío de un nuevo guardado: sigue en el mismo si
+// la última versión es reciente; si no, empieza un reenvío
+func siguienteEnvio(ultima *models.VersionEntrega, ahora time.Time) int {
+	if ultima == nil {
+		return 1
+	}
+	if ahora.Sub(ultima.CreatedAt) <= ventanaEnvio {
+		return ultima.Envio
+	}
+	return ultima.Envio + 1
+}
+
+// marcarVersiones señala las versiones tardías y la vigente a la fecha
+// límite: la última guardada hasta ese momento o, si el estudiante entregó
+// tarde, la primera. Las versiones deben venir en orden.
+func marcarVersiones(tarea *models.Tarea, versiones []models.VersionEntrega) *models.VersionEntrega {
+	if len(versiones) == 0 {
+		return nil
+	}
+
+	alCierre := 0
+	for i := range versiones {
+		versiones[i].Tardia = versiones[i].CreatedAt.After(tarea.FechaLimite)
+		if !versiones[i].Tardia {
+			alCierre = i
+		}
+	}
+	versiones[alCierre].AlCierre = true
+	return &versiones[alCierre]
+}
+
+func buscarVersion(versiones []models.VersionEntrega, numero int) *models.VersionEntrega {
+	for i := range versiones {
+		if versiones[i].Numero == numero {
+			return &versiones[i]
+		}
+	}
+	return nil
+}
+
+// DiferenciarVersiones compara dos versiones: título, descripción línea por
+// línea y archivos agregados o quitados
+func DiferenciarVersiones(a, b *models.VersionEntrega) *models.DiferenciaVersiones {
+	dif := &models.DiferenciaVersiones{
+		Desde:              a.Numero,
+		Hasta:              b.Numero,
+		ArchivosAgregados:  []models.ArchivoEntrega{},
+		ArchivosEliminados: []models.ArchivoEntrega{},
+		ArchivosSinCambios: []models.ArchivoEntrega{},
+	}
+
+	if a.Titulo != b.Titulo {
+		dif.Titulo = &models.CambioTexto{Antes: a.Titulo, Despues: b.Titulo}
+	}
+
+	dif.Descripcion = diferenciaLineas(lineas(a.Descripcion), lineas(b.Descripcion))
+	for _, l := range dif.Descripcion {
+		if l.Tipo != "igual" {
+			dif.DescripcionCambio = true
+			break
+		}
+	}
+
+	antes := make(map[uuid.UUID]bool, len(a.Archivos))
+	for _, archivo := range a.Archivos {
+		antes[archivo.ID] = true
+	}
+	despues := make(map[uuid.UUID]bool, len(b.Archivos))
+	for _, archivo := range b.Archivos {
+		despues[archivo.ID] = true
+		if antes[archivo.ID] {
+			dif.ArchivosSinCambios = append(dif.ArchivosSinCambios, archivo)
+		} else {
+			dif.ArchivosAgregados = append(dif.ArchivosAgregados, archivo)
+		}
+	}
+	for _, archivo := range a.Archivos {
+		if !despues[archivo.ID] {
+			dif.ArchivosEliminados = append(dif.ArchivosEliminados, archivo)
+		}
+	}
+
+	return dif
+}
+
+func lineas(texto *string) []string {
+	if texto == nil || *texto == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(*texto, "\r\n", "\n"), "\n")
+}
+
+// diferenciaLineas arma el diff por la subsecuencia común más larga
+func diferenciaLineas(antes, despues []string) []models.LineaDiferencia {
+	n, m := len(antes), len(despues)
+	comun := make([][]int, n+1)
+	for i := range comun {
+		comun[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if antes[i] == despues[j] {
+				comun[i][j] = comun[i+1][j+1] + 1
+			} else if comun[i+1][j] >= comun[i][j+1] {
+				comun[i][j] = comun[i+1][j]
+			} else {
+				comun[i][j] = comun[i][j+1]
+			}
+		}
+	}
+
+	resultado := make([]models.LineaDiferencia, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case antes[i] == despues[j]:
+			resultado = append(resultado, models.LineaDiferencia{Tipo: "igual", Texto: antes[i]})
+			i++
+			j++
+		case comun[i+1][j] >= comun[i][j+1]:
+			resultado = append(resultado, models.LineaDiferencia{Tipo: "eliminada", Texto: antes[i]})
+			i++
+		default:
+			resultado = append(resultado, models.LineaDiferencia{Tipo: "agregada", Texto: despues[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		resultado = append(resultado, models.LineaDiferencia{Tipo: "eliminada", Texto: antes[i]})
+	}
+	for ; j < m; j++ {
+		resultado = append(resultado, models.LineaDiferencia{Tipo: "agregada", Texto: despues[j]})
+	}
+	return resultado
+}
@@ -0,0 +1,8 @@
+-- Recordatorios de entrega ya enviados (evita duplicados entre ejecuciones)
+CREATE TABLE IF NOT EXISTS recordatorios_entrega (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tarea_id      UUID NOT NULL REFERENCES tareas(id) ON DELETE CASCADE,
+    estudiante_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    enviado_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tarea_id, estudiante_id)
+);
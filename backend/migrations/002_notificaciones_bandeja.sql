@@ -0,0 +1,12 @@
+-- Bandeja de notificaciones: archivado y paginación por cursor
+ALTER TABLE notificaciones
+    ADD COLUMN IF NOT EXISTS archivada BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Listado paginado (usuario, más recientes primero)
+CREATE INDEX IF NOT EXISTS idx_notificaciones_usuario_cursor
+    ON notificaciones (usuario_id, archivada, created_at DESC, id DESC);
+
+-- Limpieza de notificaciones leídas antiguas
+CREATE INDEX IF NOT EXISTS idx_notificaciones_leidas_created
+    ON notificaciones (created_at)
+    WHERE leida = TRUE;
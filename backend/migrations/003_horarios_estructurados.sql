@@ -0,0 +1,9 @@
+-- Horario estructurado de cursos: lista de sesiones semanales
+-- [{"dia_semana": 1, "hora_inicio": "08:00", "hora_fin": "10:00", "aula": "Aula 201"}]
+-- Los textos antiguos de "horario" se convierten con: go run ./cmd/migrar-horarios
+ALTER TABLE cursos
+    ADD COLUMN IF NOT EXISTS sesiones JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Búsqueda de cruces por ciclo
+CREATE INDEX IF NOT EXISTS idx_cursos_ciclo_docente
+    ON cursos (ciclo_id, docente_id);
@@ -0,0 +1,6 @@
+-- Tokens de los enlaces de calendario (.ics) por usuario
+CREATE TABLE IF NOT EXISTS calendario_tokens (
+    usuario_id UUID PRIMARY KEY REFERENCES usuarios(id) ON DELETE CASCADE,
+    token      TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
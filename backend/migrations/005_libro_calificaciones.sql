@@ -0,0 +1,18 @@
+-- Esquema de calificación por curso para calcular matriculas.nota_final
+CREATE TABLE IF NOT EXISTS esquemas_calificacion (
+    curso_id         UUID PRIMARY KEY REFERENCES cursos(id) ON DELETE CASCADE,
+    modo             TEXT NOT NULL DEFAULT 'tipo' CHECK (modo IN ('tipo', 'tarea')),
+    -- modo 'tipo':  {"practica": 30, "evaluacion": 50, "proyecto": 20}
+    -- modo 'tarea': {"<tarea_id>": 25, ...}
+    pesos            JSONB NOT NULL DEFAULT '{}'::jsonb,
+    -- Cantidad de notas más bajas que se descartan por tipo: {"practica": 1}
+    eliminar_menores JSONB NOT NULL DEFAULT '{}'::jsonb,
+    faltantes        TEXT NOT NULL DEFAULT 'cero' CHECK (faltantes IN ('cero', 'excluir')),
+    escala           NUMERIC NOT NULL DEFAULT 20,
+    nota_aprobatoria NUMERIC NOT NULL DEFAULT 10.5,
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Momento del último cálculo automático de la nota final
+ALTER TABLE matriculas
+    ADD COLUMN IF NOT EXISTS nota_calculada_at TIMESTAMPTZ;
@@ -0,0 +1,26 @@
+-- Plantillas de rúbrica reutilizables (criterios con niveles de desempeño)
+-- criterios: [{"id", "nombre", "descripcion", "niveles": [{"id", "nombre", "descripcion", "puntaje"}]}]
+CREATE TABLE IF NOT EXISTS rubricas (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    nombre      TEXT NOT NULL,
+    descripcion TEXT,
+    creado_por  UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    criterios   JSONB NOT NULL DEFAULT '[]'::jsonb,
+    activo      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Copia de la rúbrica asignada a una tarea: editar la plantilla no cambia
+-- las tareas ya configuradas ni las entregas calificadas
+CREATE TABLE IF NOT EXISTS tarea_rubricas (
+    tarea_id    UUID PRIMARY KEY REFERENCES tareas(id) ON DELETE CASCADE,
+    rubrica_id  UUID REFERENCES rubricas(id) ON DELETE SET NULL,
+    nombre      TEXT NOT NULL,
+    criterios   JSONB NOT NULL,
+    asignada_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Nivel obtenido por criterio al calificar
+ALTER TABLE entregas
+    ADD COLUMN IF NOT EXISTS rubrica_evaluacion JSONB;
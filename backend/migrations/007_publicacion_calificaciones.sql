@@ -0,0 +1,10 @@
+-- Publicación de calificaciones por tarea y calificación anónima.
+-- Las notas quedan ocultas para el estudiante hasta calificaciones_publicadas_at.
+ALTER TABLE tareas
+    ADD COLUMN IF NOT EXISTS calificaciones_publicadas_at TIMESTAMPTZ DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS calificacion_anonima BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- El DEFAULT solo sirve para que las tareas existentes (cuyas notas ya eran
+-- visibles) queden publicadas; las nuevas nacen sin publicar
+ALTER TABLE tareas
+    ALTER COLUMN calificaciones_publicadas_at DROP DEFAULT;
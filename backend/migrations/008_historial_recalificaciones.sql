@@ -0,0 +1,75 @@
+-- Autor y origen de la última calificación de cada entrega
+ALTER TABLE entregas
+    ADD COLUMN IF NOT EXISTS calificado_por UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS origen_calificacion TEXT;
+
+-- Historial inmutable de cambios de calificación. Lo llena un trigger sobre
+-- entregas, así ningún camino de escritura puede saltárselo.
+CREATE TABLE IF NOT EXISTS historial_calificaciones (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entrega_id            UUID NOT NULL REFERENCES entregas(id) ON DELETE CASCADE,
+    cambiado_por          UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    origen                TEXT NOT NULL DEFAULT 'calificacion',
+    calificacion_anterior NUMERIC,
+    calificacion_nueva    NUMERIC,
+    comentario_anterior   TEXT,
+    comentario_nuevo      TEXT,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_historial_calificaciones_entrega
+    ON historial_calificaciones (entrega_id, created_at);
+
+CREATE OR REPLACE FUNCTION registrar_cambio_calificacion() RETURNS trigger AS $$
+BEGIN
+    IF NEW.calificacion IS DISTINCT FROM OLD.calificacion
+       OR NEW.comentario_docente IS DISTINCT FROM OLD.comentario_docente THEN
+        INSERT INTO historial_calificaciones (
+            entrega_id, cambiado_por, origen,
+            calificacion_anterior, calificacion_nueva,
+            comentario_anterior, comentario_nuevo
+        ) VALUES (
+            NEW.id, NEW.calificado_por, COALESCE(NEW.origen_calificacion, 'calificacion'),
+            OLD.calificacion, NEW.calificacion,
+            OLD.comentario_docente, NEW.comentario_docente
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS entregas_historial_calificacion ON entregas;
+CREATE TRIGGER entregas_historial_calificacion
+    AFTER UPDATE OF calificacion, comentario_docente ON entregas
+    FOR EACH ROW EXECUTE FUNCTION registrar_cambio_calificacion();
+
+CREATE OR REPLACE FUNCTION historial_calificaciones_inmutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'el historial de calificaciones no se puede modificar';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS historial_calificaciones_sin_cambios ON historial_calificaciones;
+CREATE TRIGGER historial_calificaciones_sin_cambios
+    BEFORE UPDATE ON historial_calificaciones
+    FOR EACH ROW EXECUTE FUNCTION historial_calificaciones_inmutable();
+
+-- Solicitudes de recalificación abiertas por el estudiante
+CREATE TABLE IF NOT EXISTS solicitudes_recalificacion (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entrega_id            UUID NOT NULL REFERENCES entregas(id) ON DELETE CASCADE,
+    estudiante_id         UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    justificacion         TEXT NOT NULL,
+    estado                TEXT NOT NULL DEFAULT 'pendiente'
+                          CHECK (estado IN ('pendiente', 'aceptada', 'rechazada')),
+    calificacion_anterior NUMERIC,
+    calificacion_nueva    NUMERIC,
+    respuesta             TEXT,
+    resuelta_por          UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resuelta_at           TIMESTAMPTZ
+);
+
+-- Una sola solicitud pendiente por entrega
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recalificacion_pendiente
+    ON solicitudes_recalificacion (entrega_id) WHERE estado = 'pendiente';
@@ -0,0 +1,40 @@
+-- Aplica en una sola transacción las notas importadas desde una planilla.
+-- p_filas: [{"entrega_id", "calificacion", "comentario", "calificacion_esperada"}]
+-- Si alguna entrega no pertenece a la tarea o su nota cambió desde la vista
+-- previa, se revierte todo el lote. El historial lo registra el trigger de entregas.
+CREATE OR REPLACE FUNCTION importar_calificaciones(p_tarea_id UUID, p_calificado_por UUID, p_filas JSONB)
+RETURNS INTEGER AS $$
+DECLARE
+    fila   JSONB;
+    actual entregas%ROWTYPE;
+    total  INTEGER := 0;
+BEGIN
+    FOR fila IN SELECT * FROM jsonb_array_elements(p_filas) LOOP
+        SELECT * INTO actual
+          FROM entregas
+         WHERE id = (fila->>'entrega_id')::uuid
+           AND tarea_id = p_tarea_id
+           FOR UPDATE;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'la entrega % no pertenece a la tarea', fila->>'entrega_id';
+        END IF;
+        IF actual.calificacion IS DISTINCT FROM (fila->>'calificacion_esperada')::numeric THEN
+            RAISE EXCEPTION 'la calificación de la entrega % cambió desde la vista previa', fila->>'entrega_id';
+        END IF;
+
+        UPDATE entregas
+           SET calificacion        = (fila->>'calificacion')::numeric,
+               comentario_docente  = fila->>'comentario',
+               estado              = 'evaluada',
+               rubrica_evaluacion  = NULL,
+               calificado_por      = p_calificado_por,
+               origen_calificacion = 'importacion'
+         WHERE id = actual.id;
+
+        total := total + 1;
+    END LOOP;
+
+    RETURN total;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,137 @@
+-- Capacidad real de los cursos y lista de espera FIFO.
+-- Solo las matrículas en estado 'activo' ocupan cupo. capacidad NULL = sin límite.
+ALTER TABLE cursos
+    ADD COLUMN IF NOT EXISTS capacidad INTEGER CHECK (capacidad IS NULL OR capacidad > 0);
+
+CREATE TABLE IF NOT EXISTS lista_espera (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    curso_id      UUID NOT NULL REFERENCES cursos(id) ON DELETE CASCADE,
+    ciclo_id      UUID NOT NULL REFERENCES ciclos(id) ON DELETE CASCADE,
+    estudiante_id UUID NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    observaciones TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (curso_id, ciclo_id, estudiante_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lista_espera_orden
+    ON lista_espera (curso_id, ciclo_id, created_at);
+
+-- Cupo libre del curso en el ciclo. Bloquea la fila del curso para que las
+-- matrículas concurrentes se serialicen; debe llamarse dentro de la transacción.
+CREATE OR REPLACE FUNCTION cupo_disponible(p_curso_id UUID, p_ciclo_id UUID)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_capacidad INTEGER;
+    v_activos   INTEGER;
+BEGIN
+    SELECT capacidad INTO v_capacidad FROM cursos WHERE id = p_curso_id FOR UPDATE;
+    IF v_capacidad IS NULL THEN
+        RETURN TRUE;
+    END IF;
+
+    SELECT COUNT(*) INTO v_activos
+      FROM matriculas
+     WHERE curso_id = p_curso_id
+       AND ciclo_id = p_ciclo_id
+       AND estado = 'activo';
+
+    RETURN v_activos < v_capacidad;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Ninguna ruta (API, importación, edición directa) puede pasar de la capacidad
+CREATE OR REPLACE FUNCTION verificar_cupo_matricula()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.estado <> 'activo' THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.estado = 'activo'
+       AND OLD.curso_id = NEW.curso_id AND OLD.ciclo_id = NEW.ciclo_id THEN
+        RETURN NEW;
+    END IF;
+
+    IF NOT cupo_disponible(NEW.curso_id, NEW.ciclo_id) THEN
+        RAISE EXCEPTION 'curso_lleno: el curso alcanzó su capacidad';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_verificar_cupo_matricula ON matriculas;
+CREATE TRIGGER trg_verificar_cupo_matricula
+    BEFORE INSERT OR UPDATE OF estado, curso_id, ciclo_id ON matriculas
+    FOR EACH ROW EXECUTE FUNCTION verificar_cupo_matricula();
+
+-- Matricula si hay cupo; si no, agrega al estudiante al final de la lista de espera.
+-- Devuelve {"matricula": {...}} o {"lista_espera": {...}, "posicion": n}.
+CREATE OR REPLACE FUNCTION matricular_con_cupo(
+    p_estudiante_id UUID,
+    p_curso_id      UUID,
+    p_ciclo_id      UUID,
+    p_estado        TEXT,
+    p_observaciones TEXT
+) RETURNS JSONB AS $$
+DECLARE
+    v_matricula matriculas%ROWTYPE;
+    v_espera    lista_espera%ROWTYPE;
+    v_posicion  INTEGER;
+BEGIN
+    IF COALESCE(p_estado, 'activo') <> 'activo' OR cupo_disponible(p_curso_id, p_ciclo_id) THEN
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula)
+        VALUES (p_estudiante_id, p_curso_id, p_ciclo_id, COALESCE(p_estado, 'activo'), p_observaciones, NOW())
+        RETURNING * INTO v_matricula;
+
+        RETURN jsonb_build_object('matricula', to_jsonb(v_matricula));
+    END IF;
+
+    INSERT INTO lista_espera (curso_id, ciclo_id, estudiante_id, observaciones)
+    VALUES (p_curso_id, p_ciclo_id, p_estudiante_id, p_observaciones)
+    ON CONFLICT (curso_id, ciclo_id, estudiante_id) DO NOTHING;
+
+    SELECT * INTO v_espera
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND estudiante_id = p_estudiante_id;
+
+    SELECT COUNT(*) INTO v_posicion
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND created_at <= v_espera.created_at;
+
+    RETURN jsonb_build_object('lista_espera', to_jsonb(v_espera), 'posicion', v_posicion);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Matricula a los primeros de la lista de espera mientras haya cupo.
+-- Quien ya tenga una matrícula en el curso sale de la lista sin ser promovido.
+CREATE OR REPLACE FUNCTION promover_lista_espera(p_curso_id UUID, p_ciclo_id UUID)
+RETURNS SETOF matriculas AS $$
+DECLARE
+    v_espera    lista_espera%ROWTYPE;
+    v_matricula matriculas%ROWTYPE;
+BEGIN
+    WHILE cupo_disponible(p_curso_id, p_ciclo_id) LOOP
+        SELECT * INTO v_espera
+          FROM lista_espera
+         WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id
+         ORDER BY created_at, id
+         LIMIT 1
+           FOR UPDATE;
+        EXIT WHEN NOT FOUND;
+
+        DELETE FROM lista_espera WHERE id = v_espera.id;
+
+        IF EXISTS (SELECT 1 FROM matriculas
+                    WHERE estudiante_id = v_espera.estudiante_id
+                      AND curso_id = p_curso_id AND ciclo_id = p_ciclo_id) THEN
+            CONTINUE;
+        END IF;
+
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula)
+        VALUES (v_espera.estudiante_id, p_curso_id, p_ciclo_id, 'activo',
+                COALESCE(v_espera.observaciones, 'Promovido desde la lista de espera'), NOW())
+        RETURNING * INTO v_matricula;
+
+        RETURN NEXT v_matricula;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
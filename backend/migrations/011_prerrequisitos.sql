@@ -0,0 +1,105 @@
+-- Código estable del curso: identifica la misma asignatura en distintos ciclos
+-- (por ejemplo "PAST-101" para Pastelería I en 2024-I y 2024-II)
+ALTER TABLE cursos
+    ADD COLUMN IF NOT EXISTS codigo TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_cursos_codigo ON cursos (codigo);
+
+-- curso_codigo requiere haber aprobado requisito_codigo con al menos nota_minima
+CREATE TABLE IF NOT EXISTS prerrequisitos (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    curso_codigo     TEXT NOT NULL,
+    requisito_codigo TEXT NOT NULL,
+    nota_minima      NUMERIC NOT NULL DEFAULT 10.5 CHECK (nota_minima >= 0),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (curso_codigo, requisito_codigo),
+    CHECK (curso_codigo <> requisito_codigo)
+);
+
+-- Matrícula aceptada sin cumplir prerrequisitos: quién lo autorizó y por qué
+ALTER TABLE matriculas
+    ADD COLUMN IF NOT EXISTS requisitos_omitidos_por UUID REFERENCES usuarios(id),
+    ADD COLUMN IF NOT EXISTS motivo_omision TEXT;
+
+ALTER TABLE lista_espera
+    ADD COLUMN IF NOT EXISTS requisitos_omitidos_por UUID REFERENCES usuarios(id),
+    ADD COLUMN IF NOT EXISTS motivo_omision TEXT;
+
+-- matricular_con_cupo ahora también guarda la autorización de omisión
+DROP FUNCTION IF EXISTS matricular_con_cupo(UUID, UUID, UUID, TEXT, TEXT);
+
+CREATE OR REPLACE FUNCTION matricular_con_cupo(
+    p_estudiante_id UUID,
+    p_curso_id      UUID,
+    p_ciclo_id      UUID,
+    p_estado        TEXT,
+    p_observaciones TEXT,
+    p_omitido_por   UUID DEFAULT NULL,
+    p_motivo        TEXT DEFAULT NULL
+) RETURNS JSONB AS $$
+DECLARE
+    v_matricula matriculas%ROWTYPE;
+    v_espera    lista_espera%ROWTYPE;
+    v_posicion  INTEGER;
+BEGIN
+    IF COALESCE(p_estado, 'activo') <> 'activo' OR cupo_disponible(p_curso_id, p_ciclo_id) THEN
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula,
+                                requisitos_omitidos_por, motivo_omision)
+        VALUES (p_estudiante_id, p_curso_id, p_ciclo_id, COALESCE(p_estado, 'activo'), p_observaciones, NOW(),
+                p_omitido_por, p_motivo)
+        RETURNING * INTO v_matricula;
+
+        RETURN jsonb_build_object('matricula', to_jsonb(v_matricula));
+    END IF;
+
+    INSERT INTO lista_espera (curso_id, ciclo_id, estudiante_id, observaciones, requisitos_omitidos_por, motivo_omision)
+    VALUES (p_curso_id, p_ciclo_id, p_estudiante_id, p_observaciones, p_omitido_por, p_motivo)
+    ON CONFLICT (curso_id, ciclo_id, estudiante_id) DO NOTHING;
+
+    SELECT * INTO v_espera
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND estudiante_id = p_estudiante_id;
+
+    SELECT COUNT(*) INTO v_posicion
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND created_at <= v_espera.created_at;
+
+    RETURN jsonb_build_object('lista_espera', to_jsonb(v_espera), 'posicion', v_posicion);
+END;
+$$ LANGUAGE plpgsql;
+
+-- La promoción conserva la autorización registrada al entrar a la lista
+CREATE OR REPLACE FUNCTION promover_lista_espera(p_curso_id UUID, p_ciclo_id UUID)
+RETURNS SETOF matriculas AS $$
+DECLARE
+    v_espera    lista_espera%ROWTYPE;
+    v_matricula matriculas%ROWTYPE;
+BEGIN
+    WHILE cupo_disponible(p_curso_id, p_ciclo_id) LOOP
+        SELECT * INTO v_espera
+          FROM lista_espera
+         WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id
+         ORDER BY created_at, id
+         LIMIT 1
+           FOR UPDATE;
+        EXIT WHEN NOT FOUND;
+
+        DELETE FROM lista_espera WHERE id = v_espera.id;
+
+        IF EXISTS (SELECT 1 FROM matriculas
+                    WHERE estudiante_id = v_espera.estudiante_id
+                      AND curso_id = p_curso_id AND ciclo_id = p_ciclo_id) THEN
+            CONTINUE;
+        END IF;
+
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula,
+                                requisitos_omitidos_por, motivo_omision)
+        VALUES (v_espera.estudiante_id, p_curso_id, p_ciclo_id, 'activo',
+                COALESCE(v_espera.observaciones, 'Promovido desde la lista de espera'), NOW(),
+                v_espera.requisitos_omitidos_por, v_espera.motivo_omision)
+        RETURNING * INTO v_matricula;
+
+        RETURN NEXT v_matricula;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,132 @@
+-- Cierre de ciclo: al cerrarlo sus notas quedan bloqueadas y sus cursos archivados
+ALTER TABLE ciclos
+    ADD COLUMN IF NOT EXISTS cerrado_at TIMESTAMPTZ;
+
+ALTER TABLE cursos
+    ADD COLUMN IF NOT EXISTS archivado_at TIMESTAMPTZ;
+
+-- Ninguna ruta (calificación, importación, recalificación, recálculo) puede
+-- cambiar notas de un ciclo cerrado
+CREATE OR REPLACE FUNCTION bloquear_notas_ciclo_cerrado()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_cerrado BOOLEAN;
+BEGIN
+    IF TG_TABLE_NAME = 'entregas' THEN
+        IF NEW.calificacion IS NOT DISTINCT FROM OLD.calificacion
+           AND NEW.rubrica_evaluacion IS NOT DISTINCT FROM OLD.rubrica_evaluacion THEN
+            RETURN NEW;
+        END IF;
+
+        SELECT ci.cerrado_at IS NOT NULL INTO v_cerrado
+          FROM tareas t
+          JOIN cursos c  ON c.id = t.curso_id
+          JOIN ciclos ci ON ci.id = c.ciclo_id
+         WHERE t.id = NEW.tarea_id;
+    ELSE
+        IF NEW.nota_final IS NOT DISTINCT FROM OLD.nota_final
+           AND NEW.estado IS NOT DISTINCT FROM OLD.estado THEN
+            RETURN NEW;
+        END IF;
+
+        SELECT cerrado_at IS NOT NULL INTO v_cerrado
+          FROM ciclos
+         WHERE id = OLD.ciclo_id;
+    END IF;
+
+    IF v_cerrado THEN
+        RAISE EXCEPTION 'ciclo_cerrado: las notas del ciclo están cerradas';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_bloquear_notas_entregas ON entregas;
+CREATE TRIGGER trg_bloquear_notas_entregas
+    BEFORE UPDATE OF calificacion, rubrica_evaluacion ON entregas
+    FOR EACH ROW EXECUTE FUNCTION bloquear_notas_ciclo_cerrado();
+
+DROP TRIGGER IF EXISTS trg_bloquear_notas_matriculas ON matriculas;
+CREATE TRIGGER trg_bloquear_notas_matriculas
+    BEFORE UPDATE OF nota_final, estado ON matriculas
+    FOR EACH ROW EXECUTE FUNCTION bloquear_notas_ciclo_cerrado();
+
+-- Aplica en una sola transacción el cierre calculado en la vista previa.
+-- p_matriculas:  [{"id", "estado", "nota_esperada"}]
+-- p_promociones: [{"usuario_id", "ciclo_anterior", "ciclo_nuevo"}]
+-- Si una matrícula o un estudiante cambió desde la vista previa se revierte todo.
+-- Devuelve la cantidad de filas afectadas por cada paso.
+CREATE OR REPLACE FUNCTION cerrar_ciclo(
+    p_ciclo_id           UUID,
+    p_ciclo_siguiente_id UUID,
+    p_matriculas         JSONB,
+    p_promociones        JSONB
+) RETURNS JSONB AS $$
+DECLARE
+    v_ciclo       ciclos%ROWTYPE;
+    fila          JSONB;
+    v_finalizadas INTEGER := 0;
+    v_promovidos  INTEGER := 0;
+    v_archivados  INTEGER;
+    v_espera      INTEGER;
+BEGIN
+    SELECT * INTO v_ciclo FROM ciclos WHERE id = p_ciclo_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'ciclo no encontrado';
+    END IF;
+    IF v_ciclo.cerrado_at IS NOT NULL THEN
+        RAISE EXCEPTION 'ciclo_cerrado: el ciclo ya fue cerrado';
+    END IF;
+
+    -- 1. Estado final de las matrículas (antes de bloquear las notas)
+    FOR fila IN SELECT * FROM jsonb_array_elements(p_matriculas) LOOP
+        UPDATE matriculas
+           SET estado = fila->>'estado'
+         WHERE id = (fila->>'id')::uuid
+           AND ciclo_id = p_ciclo_id
+           AND estado = 'activo'
+           AND nota_final IS NOT DISTINCT FROM (fila->>'nota_esperada')::numeric;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'la matrícula % cambió desde la vista previa', fila->>'id';
+        END IF;
+        v_finalizadas := v_finalizadas + 1;
+    END LOOP;
+
+    -- 2. Bloqueo de notas
+    UPDATE ciclos SET cerrado_at = NOW() WHERE id = p_ciclo_id;
+
+    -- 3. Promoción de estudiantes
+    FOR fila IN SELECT * FROM jsonb_array_elements(p_promociones) LOOP
+        UPDATE estudiantes
+           SET ciclo_actual = (fila->>'ciclo_nuevo')::integer
+         WHERE usuario_id = (fila->>'usuario_id')::uuid
+           AND ciclo_actual = (fila->>'ciclo_anterior')::integer;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'el estudiante % cambió de ciclo desde la vista previa', fila->>'usuario_id';
+        END IF;
+        v_promovidos := v_promovidos + 1;
+    END LOOP;
+
+    -- 4. Archivo de cursos; la lista de espera del ciclo ya no aplica
+    UPDATE cursos
+       SET activo = false, archivado_at = NOW()
+     WHERE ciclo_id = p_ciclo_id AND archivado_at IS NULL;
+    GET DIAGNOSTICS v_archivados = ROW_COUNT;
+
+    DELETE FROM lista_espera WHERE ciclo_id = p_ciclo_id;
+    GET DIAGNOSTICS v_espera = ROW_COUNT;
+
+    -- 5. Activación del ciclo siguiente
+    UPDATE ciclos SET activo = (id = p_ciclo_siguiente_id)
+     WHERE activo OR id = p_ciclo_siguiente_id;
+
+    RETURN jsonb_build_object(
+        'matriculas_finalizadas', v_finalizadas,
+        'estudiantes_promovidos', v_promovidos,
+        'cursos_archivados',      v_archivados,
+        'lista_espera_eliminada', v_espera
+    );
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,32 @@
+-- Sesiones de clase de un curso: generadas desde el horario o creadas a mano
+CREATE TABLE IF NOT EXISTS sesiones_clase (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    curso_id    UUID NOT NULL REFERENCES cursos(id) ON DELETE CASCADE,
+    fecha       DATE NOT NULL,
+    hora_inicio TEXT NOT NULL,            -- "HH:MM"
+    hora_fin    TEXT,
+    aula        TEXT,
+    tema        TEXT,
+    origen      TEXT NOT NULL DEFAULT 'manual' CHECK (origen IN ('horario', 'manual')),
+    created_by  UUID REFERENCES usuarios(id),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Generar dos veces desde el horario no duplica sesiones
+    UNIQUE (curso_id, fecha, hora_inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sesiones_clase_curso ON sesiones_clase (curso_id, fecha);
+
+-- Marca de asistencia de una matrícula en una sesión
+CREATE TABLE IF NOT EXISTS asistencias (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sesion_id         UUID NOT NULL REFERENCES sesiones_clase(id) ON DELETE CASCADE,
+    matricula_id      UUID NOT NULL REFERENCES matriculas(id) ON DELETE CASCADE,
+    estado            TEXT NOT NULL CHECK (estado IN ('presente', 'tarde', 'ausente', 'justificado')),
+    observacion       TEXT,
+    justificacion_url TEXT,
+    registrado_por    UUID REFERENCES usuarios(id),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (sesion_id, matricula_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_asistencias_matricula ON asistencias (matricula_id);
@@ -0,0 +1,21 @@
+-- Desbloqueo de temas: por fecha (temas.fecha_desbloqueo) y, si el docente lo
+-- activa, en orden: un tema se abre cuando se vieron los materiales
+-- obligatorios del anterior.
+ALTER TABLE cursos
+    ADD COLUMN IF NOT EXISTS desbloqueo_secuencial BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE materiales
+    ADD COLUMN IF NOT EXISTS obligatorio BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Aviso a los estudiantes cuando llega la fecha de desbloqueo.
+-- desbloqueo_notificado_at evita avisar dos veces.
+ALTER TABLE temas
+    ADD COLUMN IF NOT EXISTS notificar_desbloqueo BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS desbloqueo_notificado_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_temas_desbloqueo_pendiente
+    ON temas (fecha_desbloqueo)
+    WHERE notificar_desbloqueo AND desbloqueo_notificado_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_material_visto_estudiante
+    ON material_visto (estudiante_id, material_id);
@@ -0,0 +1,55 @@
+-- Banco de preguntas de un curso
+-- opciones:             [{"id", "texto", "correcta"}]          (opcion_multiple)
+-- respuestas_aceptadas: ["texto", ...]                         (respuesta_corta)
+-- pares:                [{"id", "izquierda", "derecha"}]       (emparejamiento)
+CREATE TABLE IF NOT EXISTS preguntas (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    curso_id             UUID NOT NULL REFERENCES cursos(id) ON DELETE CASCADE,
+    tipo                 TEXT NOT NULL CHECK (tipo IN ('opcion_multiple', 'verdadero_falso', 'respuesta_corta', 'emparejamiento')),
+    enunciado            TEXT NOT NULL,
+    opciones             JSONB,
+    respuesta_verdadera  BOOLEAN,
+    respuestas_aceptadas JSONB,
+    pares                JSONB,
+    puntaje              NUMERIC NOT NULL DEFAULT 1 CHECK (puntaje > 0),
+    creado_por           UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    activo               BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_preguntas_curso ON preguntas (curso_id) WHERE activo;
+
+-- Cuestionario de una tarea: copia de las preguntas elegidas, así editar el
+-- banco no cambia un cuestionario ya rendido
+CREATE TABLE IF NOT EXISTS tarea_cuestionarios (
+    tarea_id              UUID PRIMARY KEY REFERENCES tareas(id) ON DELETE CASCADE,
+    tiempo_limite_minutos INTEGER NOT NULL DEFAULT 0 CHECK (tiempo_limite_minutos >= 0), -- 0 = sin límite
+    orden_aleatorio       BOOLEAN NOT NULL DEFAULT FALSE,
+    preguntas             JSONB NOT NULL,
+    configurado_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Intento de un estudiante. El servidor fija iniciado_at y vence_at; las
+-- respuestas guardadas después de vence_at no se toman en cuenta.
+CREATE TABLE IF NOT EXISTS intentos_cuestionario (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tarea_id         UUID NOT NULL REFERENCES tareas(id) ON DELETE CASCADE,
+    estudiante_id    UUID NOT NULL REFERENCES estudiantes(usuario_id) ON DELETE CASCADE,
+    entrega_id       UUID REFERENCES entregas(id) ON DELETE SET NULL,
+    orden            JSONB NOT NULL DEFAULT '[]'::jsonb,
+    respuestas       JSONB NOT NULL DEFAULT '[]'::jsonb,
+    resultado        JSONB,
+    puntaje_obtenido NUMERIC,
+    iniciado_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    vence_at         TIMESTAMPTZ NOT NULL,
+    enviado_at       TIMESTAMPTZ,
+    revisado_por     UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    revisado_at      TIMESTAMPTZ,
+    -- Un solo intento por estudiante
+    UNIQUE (tarea_id, estudiante_id)
+);
+
+-- Intentos vencidos que el proceso periódico debe cerrar
+CREATE INDEX IF NOT EXISTS idx_intentos_cuestionario_abiertos
+    ON intentos_cuestionario (vence_at) WHERE enviado_at IS NULL;
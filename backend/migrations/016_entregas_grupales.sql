@@ -0,0 +1,73 @@
+-- Grupos de trabajo de un curso. Los define el docente o, con
+-- autoinscripcion, los propios estudiantes. max_integrantes NULL = sin límite.
+CREATE TABLE IF NOT EXISTS grupos (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    curso_id        UUID NOT NULL REFERENCES cursos(id) ON DELETE CASCADE,
+    nombre          TEXT NOT NULL,
+    max_integrantes INTEGER CHECK (max_integrantes IS NULL OR max_integrantes > 0),
+    autoinscripcion BOOLEAN NOT NULL DEFAULT FALSE,
+    creado_por      UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (curso_id, nombre)
+);
+
+-- Un estudiante pertenece a un solo grupo por curso
+CREATE TABLE IF NOT EXISTS grupo_integrantes (
+    grupo_id      UUID NOT NULL REFERENCES grupos(id) ON DELETE CASCADE,
+    curso_id      UUID NOT NULL REFERENCES cursos(id) ON DELETE CASCADE,
+    estudiante_id UUID NOT NULL REFERENCES estudiantes(usuario_id) ON DELETE CASCADE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (grupo_id, estudiante_id),
+    UNIQUE (curso_id, estudiante_id)
+);
+
+-- Ninguna ruta puede pasar del máximo de integrantes. Bloquea la fila del
+-- grupo para que las inscripciones concurrentes se serialicen.
+CREATE OR REPLACE FUNCTION verificar_cupo_grupo()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_curso_id UUID;
+    v_maximo   INTEGER;
+    v_actuales INTEGER;
+BEGIN
+    SELECT curso_id, max_integrantes INTO v_curso_id, v_maximo
+      FROM grupos WHERE id = NEW.grupo_id FOR UPDATE;
+
+    IF v_curso_id IS DISTINCT FROM NEW.curso_id THEN
+        RAISE EXCEPTION 'grupo_invalido: el grupo no pertenece al curso';
+    END IF;
+    IF v_maximo IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT COUNT(*) INTO v_actuales
+      FROM grupo_integrantes
+     WHERE grupo_id = NEW.grupo_id;
+
+    IF v_actuales >= v_maximo THEN
+        RAISE EXCEPTION 'grupo_lleno: el grupo alcanzó su máximo de integrantes';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_verificar_cupo_grupo ON grupo_integrantes;
+CREATE TRIGGER trg_verificar_cupo_grupo
+    BEFORE INSERT ON grupo_integrantes
+    FOR EACH ROW EXECUTE FUNCTION verificar_cupo_grupo();
+
+-- Tareas que se entregan en grupo
+ALTER TABLE tareas
+    ADD COLUMN IF NOT EXISTS entrega_grupal BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Una entrega grupal es una fila por integrante con el mismo grupo_id, así el
+-- libro de calificaciones, el progreso y los recordatorios la ven como propia.
+-- calificacion_grupal es la nota del grupo; calificacion = grupal + ajuste.
+ALTER TABLE entregas
+    ADD COLUMN IF NOT EXISTS grupo_id            UUID REFERENCES grupos(id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS calificacion_grupal NUMERIC,
+    ADD COLUMN IF NOT EXISTS ajuste_individual   NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS motivo_ajuste       TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_entregas_grupo
+    ON entregas (tarea_id, grupo_id) WHERE grupo_id IS NOT NULL;
@@ -0,0 +1,71 @@
+-- Política de reenvío por tarea. max_reenvios NULL = sin límite.
+ALTER TABLE tareas
+    ADD COLUMN IF NOT EXISTS permite_reenvio BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS max_reenvios    INTEGER CHECK (max_reenvios IS NULL OR max_reenvios >= 0);
+
+-- Cada vez que el estudiante guarda su entrega queda una versión inmutable con
+-- el título, la descripción y la lista de archivos de ese momento.
+-- envio agrupa los guardados seguidos (crear la entrega y subir sus archivos);
+-- cada envío después del primero es un reenvío.
+-- archivos: [{"id", "entrega_id", "nombre_archivo", "url_archivo", "tipo_archivo", "tamano_mb", "uploaded_at"}]
+CREATE TABLE IF NOT EXISTS versiones_entrega (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entrega_id  UUID NOT NULL REFERENCES entregas(id) ON DELETE CASCADE,
+    numero      INTEGER NOT NULL,
+    envio       INTEGER NOT NULL DEFAULT 1 CHECK (envio > 0),
+    motivo      TEXT NOT NULL CHECK (motivo IN ('entrega', 'edicion', 'archivo_agregado', 'archivo_eliminado')),
+    titulo      TEXT NOT NULL,
+    descripcion TEXT,
+    archivos    JSONB NOT NULL DEFAULT '[]'::jsonb,
+    creado_por  UUID REFERENCES usuarios(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (entrega_id, numero)
+);
+
+-- Numera las versiones de cada entrega. Bloquea la fila de la entrega para
+-- que dos guardados simultáneos no tomen el mismo número.
+CREATE OR REPLACE FUNCTION numerar_version_entrega()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1 FROM entregas WHERE id = NEW.entrega_id FOR UPDATE;
+
+    SELECT COALESCE(MAX(numero), 0) + 1 INTO NEW.numero
+      FROM versiones_entrega
+     WHERE entrega_id = NEW.entrega_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_numerar_version_entrega ON versiones_entrega;
+CREATE TRIGGER trg_numerar_version_entrega
+    BEFORE INSERT ON versiones_entrega
+    FOR EACH ROW EXECUTE FUNCTION numerar_version_entrega();
+
+-- Una versión no se modifica nunca; solo desaparece junto con su entrega
+CREATE OR REPLACE FUNCTION bloquear_cambio_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'version_inmutable: las versiones de una entrega no se pueden modificar';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_bloquear_cambio_version ON versiones_entrega;
+CREATE TRIGGER trg_bloquear_cambio_version
+    BEFORE UPDATE ON versiones_entrega
+    FOR EACH ROW EXECUTE FUNCTION bloquear_cambio_version();
+
+-- Las entregas existentes arrancan con su estado actual como versión 1 (en
+-- las grupales, con los archivos de todo el grupo)
+INSERT INTO versiones_entrega (entrega_id, numero, envio, motivo, titulo, descripcion, archivos, creado_por, created_at)
+SELECT e.id, 1, 1, 'entrega', e.titulo, e.descripcion,
+       COALESCE((SELECT jsonb_agg(to_jsonb(a) ORDER BY a.uploaded_at)
+                   FROM archivos_entrega a
+                   JOIN entregas g ON g.id = a.entrega_id
+                  WHERE g.id = e.id
+                     OR (g.tarea_id = e.tarea_id AND g.grupo_id = e.grupo_id)), '[]'::jsonb),
+       e.estudiante_id, e.fecha_entrega
+  FROM entregas e
+ WHERE NOT EXISTS (SELECT 1 FROM versiones_entrega v WHERE v.entrega_id = e.id);
+
+CREATE INDEX IF NOT EXISTS idx_versiones_entrega_fecha
+    ON versiones_entrega (entrega_id, created_at);
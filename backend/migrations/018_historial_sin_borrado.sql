@@ -0,0 +1,15 @@
+-- El historial de calificaciones tampoco se borra. Solo se va junto con su
+-- entrega: en el borrado en cascada la entrega ya no existe cuando llega el DELETE.
+CREATE OR REPLACE FUNCTION historial_calificaciones_inmutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM entregas WHERE id = OLD.entrega_id) THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'el historial de calificaciones no se puede modificar';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS historial_calificaciones_sin_cambios ON historial_calificaciones;
+CREATE TRIGGER historial_calificaciones_sin_cambios
+    BEFORE UPDATE OR DELETE ON historial_calificaciones
+    FOR EACH ROW EXECUTE FUNCTION historial_calificaciones_inmutable();
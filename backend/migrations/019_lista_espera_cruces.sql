@@ -0,0 +1,64 @@
+-- cruce_horario_estudiante replica la validación de cruces de registrarMatricula:
+-- el curso choca con otro curso activo en el que el estudiante tiene matrícula
+-- activa en el mismo ciclo (mismo día y rangos de hora solapados). Solo mira
+-- las sesiones estructuradas; los horarios en texto se migran antes.
+CREATE OR REPLACE FUNCTION cruce_horario_estudiante(p_estudiante_id UUID, p_curso_id UUID, p_ciclo_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1
+          FROM cursos propio
+         CROSS JOIN jsonb_array_elements(propio.sesiones) p
+          JOIN matriculas m ON m.estudiante_id = p_estudiante_id
+                           AND m.ciclo_id = p_ciclo_id
+                           AND m.estado = 'activo'
+                           AND m.curso_id <> p_curso_id
+          JOIN cursos otro ON otro.id = m.curso_id AND otro.activo
+         CROSS JOIN jsonb_array_elements(otro.sesiones) o
+         WHERE propio.id = p_curso_id
+           AND (p->>'dia_semana') = (o->>'dia_semana')
+           AND (p->>'hora_inicio') < (o->>'hora_fin')
+           AND (o->>'hora_inicio') < (p->>'hora_fin')
+    );
+$$ LANGUAGE sql STABLE;
+
+-- La promoción conserva la autorización registrada al entrar a la lista.
+-- Quien tiene un cruce de horario sigue esperando y el cupo pasa al siguiente.
+CREATE OR REPLACE FUNCTION promover_lista_espera(p_curso_id UUID, p_ciclo_id UUID)
+RETURNS SETOF matriculas AS $$
+DECLARE
+    v_espera    lista_espera%ROWTYPE;
+    v_matricula matriculas%ROWTYPE;
+BEGIN
+    FOR v_espera IN
+        SELECT *
+          FROM lista_espera
+         WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id
+         ORDER BY created_at, id
+           FOR UPDATE
+    LOOP
+        EXIT WHEN NOT cupo_disponible(p_curso_id, p_ciclo_id);
+
+        IF EXISTS (SELECT 1 FROM matriculas
+                    WHERE estudiante_id = v_espera.estudiante_id
+                      AND curso_id = p_curso_id AND ciclo_id = p_ciclo_id) THEN
+            DELETE FROM lista_espera WHERE id = v_espera.id;
+            CONTINUE;
+        END IF;
+
+        IF cruce_horario_estudiante(v_espera.estudiante_id, p_curso_id, p_ciclo_id) THEN
+            CONTINUE;
+        END IF;
+
+        DELETE FROM lista_espera WHERE id = v_espera.id;
+
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula,
+                                requisitos_omitidos_por, motivo_omision)
+        VALUES (v_espera.estudiante_id, p_curso_id, p_ciclo_id, 'activo',
+                COALESCE(v_espera.observaciones, 'Promovido desde la lista de espera'), NOW(),
+                v_espera.requisitos_omitidos_por, v_espera.motivo_omision)
+        RETURNING * INTO v_matricula;
+
+        RETURN NEXT v_matricula;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
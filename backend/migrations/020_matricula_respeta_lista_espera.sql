@@ -0,0 +1,73 @@
+-- La matrícula directa respeta el orden de la lista de espera: antes de usar un
+-- cupo libre se promueve, en la misma transacción, a quienes ya esperaban. Solo
+-- si después queda cupo (los que siguen en la lista tienen cruce de horario) se
+-- matricula al nuevo estudiante; si no, entra al final de la lista.
+-- Devuelve además {"promovidos": [...]} con las matrículas creadas por la promoción.
+CREATE OR REPLACE FUNCTION matricular_con_cupo(
+    p_estudiante_id UUID,
+    p_curso_id      UUID,
+    p_ciclo_id      UUID,
+    p_estado        TEXT,
+    p_observaciones TEXT,
+    p_omitido_por   UUID DEFAULT NULL,
+    p_motivo        TEXT DEFAULT NULL
+) RETURNS JSONB AS $$
+DECLARE
+    v_matricula  matriculas%ROWTYPE;
+    v_propia     matriculas%ROWTYPE;
+    v_espera     lista_espera%ROWTYPE;
+    v_posicion   INTEGER;
+    v_promovidos JSONB := '[]'::jsonb;
+BEGIN
+    IF COALESCE(p_estado, 'activo') <> 'activo' THEN
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula,
+                                requisitos_omitidos_por, motivo_omision)
+        VALUES (p_estudiante_id, p_curso_id, p_ciclo_id, p_estado, p_observaciones, NOW(),
+                p_omitido_por, p_motivo)
+        RETURNING * INTO v_matricula;
+
+        RETURN jsonb_build_object('matricula', to_jsonb(v_matricula));
+    END IF;
+
+    FOR v_matricula IN SELECT * FROM promover_lista_espera(p_curso_id, p_ciclo_id) LOOP
+        IF v_matricula.estudiante_id = p_estudiante_id THEN
+            v_propia := v_matricula;
+        ELSE
+            v_promovidos := v_promovidos || jsonb_build_array(to_jsonb(v_matricula));
+        END IF;
+    END LOOP;
+
+    -- El estudiante ya estaba en la lista y le tocó uno de los cupos
+    IF v_propia.id IS NOT NULL THEN
+        RETURN jsonb_build_object('matricula', to_jsonb(v_propia), 'promovidos', v_promovidos);
+    END IF;
+
+    IF cupo_disponible(p_curso_id, p_ciclo_id) THEN
+        INSERT INTO matriculas (estudiante_id, curso_id, ciclo_id, estado, observaciones, fecha_matricula,
+                                requisitos_omitidos_por, motivo_omision)
+        VALUES (p_estudiante_id, p_curso_id, p_ciclo_id, 'activo', p_observaciones, NOW(),
+                p_omitido_por, p_motivo)
+        RETURNING * INTO v_matricula;
+
+        DELETE FROM lista_espera
+         WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND estudiante_id = p_estudiante_id;
+
+        RETURN jsonb_build_object('matricula', to_jsonb(v_matricula), 'promovidos', v_promovidos);
+    END IF;
+
+    INSERT INTO lista_espera (curso_id, ciclo_id, estudiante_id, observaciones, requisitos_omitidos_por, motivo_omision)
+    VALUES (p_curso_id, p_ciclo_id, p_estudiante_id, p_observaciones, p_omitido_por, p_motivo)
+    ON CONFLICT (curso_id, ciclo_id, estudiante_id) DO NOTHING;
+
+    SELECT * INTO v_espera
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND estudiante_id = p_estudiante_id;
+
+    SELECT COUNT(*) INTO v_posicion
+      FROM lista_espera
+     WHERE curso_id = p_curso_id AND ciclo_id = p_ciclo_id AND created_at <= v_espera.created_at;
+
+    RETURN jsonb_build_object('lista_espera', to_jsonb(v_espera), 'posicion', v_posicion,
+                              'promovidos', v_promovidos);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,71 @@
+-- Aplica la nota del grupo a la entrega de cada integrante en una sola
+-- transacción: si alguna fila falla (por ejemplo, el ciclo está cerrado) no
+-- queda ningún integrante con la nota nueva.
+-- calificacion = nota del grupo + ajuste individual, dentro de 0..puntaje_maximo
+CREATE OR REPLACE FUNCTION calificar_grupo(p_tarea_id UUID, p_grupo_id UUID, p_calificacion_grupal NUMERIC,
+                                           p_comentario TEXT, p_rubrica JSONB, p_calificado_por UUID, p_origen TEXT)
+RETURNS SETOF entregas AS $$
+DECLARE
+    v_maximo NUMERIC;
+BEGIN
+    SELECT puntaje_maximo INTO v_maximo FROM tareas WHERE id = p_tarea_id;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'la tarea % no existe', p_tarea_id;
+    END IF;
+
+    RETURN QUERY
+    UPDATE entregas
+       SET calificacion_grupal = p_calificacion_grupal,
+           calificacion        = ROUND(LEAST(GREATEST(p_calificacion_grupal + ajuste_individual, 0), v_maximo), 2),
+           comentario_docente  = p_comentario,
+           estado              = 'evaluada',
+           rubrica_evaluacion  = p_rubrica,
+           calificado_por      = p_calificado_por,
+           origen_calificacion = p_origen
+     WHERE tarea_id = p_tarea_id
+       AND grupo_id = p_grupo_id
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Las entregas grupales no se importan desde la planilla: su nota se reparte
+-- con calificar_grupo.
+CREATE OR REPLACE FUNCTION importar_calificaciones(p_tarea_id UUID, p_calificado_por UUID, p_filas JSONB)
+RETURNS INTEGER AS $$
+DECLARE
+    fila   JSONB;
+    actual entregas%ROWTYPE;
+    total  INTEGER := 0;
+BEGIN
+    FOR fila IN SELECT * FROM jsonb_array_elements(p_filas) LOOP
+        SELECT * INTO actual
+          FROM entregas
+         WHERE id = (fila->>'entrega_id')::uuid
+           AND tarea_id = p_tarea_id
+           FOR UPDATE;
+
+        IF NOT FOUND THEN
+            RAISE EXCEPTION 'la entrega % no pertenece a la tarea', fila->>'entrega_id';
+        END IF;
+        IF actual.grupo_id IS NOT NULL THEN
+            RAISE EXCEPTION 'la entrega % es grupal y no se puede importar', fila->>'entrega_id';
+        END IF;
+        IF actual.calificacion IS DISTINCT FROM (fila->>'calificacion_esperada')::numeric THEN
+            RAISE EXCEPTION 'la calificación de la entrega % cambió desde la vista previa', fila->>'entrega_id';
+        END IF;
+
+        UPDATE entregas
+           SET calificacion        = (fila->>'calificacion')::numeric,
+               comentario_docente  = fila->>'comentario',
+               estado              = 'evaluada',
+               rubrica_evaluacion  = NULL,
+               calificado_por      = p_calificado_por,
+               origen_calificacion = 'importacion'
+         WHERE id = actual.id;
+
+        total := total + 1;
+    END LOOP;
+
+    RETURN total;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,53 @@
+-- Guarda el ajuste individual de un integrante y recalcula su nota en una sola
+-- transacción. El ajuste se rechaza antes de guardarse si el ciclo está
+-- cerrado: el trigger de notas solo mira calificacion y no cubriría el caso
+-- de un grupo todavía sin nota.
+-- calificacion = nota del grupo + ajuste individual, dentro de 0..puntaje_maximo
+CREATE OR REPLACE FUNCTION ajustar_calificacion_individual(p_entrega_id UUID, p_ajuste NUMERIC, p_motivo TEXT,
+                                                           p_calificado_por UUID)
+RETURNS SETOF entregas AS $$
+DECLARE
+    v_entrega entregas%ROWTYPE;
+    v_maximo  NUMERIC;
+    v_cerrado BOOLEAN;
+BEGIN
+    SELECT * INTO v_entrega FROM entregas WHERE id = p_entrega_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'la entrega % no existe', p_entrega_id;
+    END IF;
+    IF v_entrega.grupo_id IS NULL THEN
+        RAISE EXCEPTION 'la entrega % no es grupal', p_entrega_id;
+    END IF;
+
+    SELECT t.puntaje_maximo, ci.cerrado_at IS NOT NULL INTO v_maximo, v_cerrado
+      FROM tareas t
+      JOIN cursos c  ON c.id = t.curso_id
+      JOIN ciclos ci ON ci.id = c.ciclo_id
+     WHERE t.id = v_entrega.tarea_id;
+
+    IF v_cerrado THEN
+        RAISE EXCEPTION 'ciclo_cerrado: las notas del ciclo están cerradas';
+    END IF;
+
+    IF v_entrega.calificacion_grupal IS NULL THEN
+        RETURN QUERY
+        UPDATE entregas
+           SET ajuste_individual = p_ajuste,
+               motivo_ajuste     = p_motivo
+         WHERE id = p_entrega_id
+        RETURNING *;
+        RETURN;
+    END IF;
+
+    RETURN QUERY
+    UPDATE entregas
+       SET ajuste_individual   = p_ajuste,
+           motivo_ajuste       = p_motivo,
+           calificacion        = ROUND(LEAST(GREATEST(calificacion_grupal + p_ajuste, 0), v_maximo), 2),
+           estado              = 'evaluada',
+           calificado_por      = p_calificado_por,
+           origen_calificacion = 'calificacion'
+     WHERE id = p_entrega_id
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql;